@@ -1,8 +1,10 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"regexp"
 	"slices"
@@ -78,6 +80,18 @@ type TorrentTracker struct {
 
 type TorrentTrackers []TorrentTracker
 
+type TorrentPeer struct {
+	Address       string  // "ip:port"
+	Ip            string  // IPv4 or IPv6 address
+	Port          int64   // Peer listening port
+	Client        string  // Peer client, e.g. "qBittorrent/4.6.0"
+	Progress      float64 // Peer's download progress (percentage/100)
+	DownloadSpeed int64   // Download speed from peer (bytes/s)
+	UploadSpeed   int64   // Upload speed to peer (bytes/s)
+	Flags         string  // Client specific connection flags, e.g. "D U E I"
+	Country       string  // Optional country code of peer, e.g. "US"
+}
+
 type TorrentOption struct {
 	Name               string // if not empty, set name of torrent in client to this value
 	Category           string
@@ -108,6 +122,10 @@ type Client interface {
 	GetTorrents(stateFilter string, category string, showAll bool) ([]*Torrent, error)
 	GetTorrentsByContentPath(contentPath string) ([]*Torrent, error)
 	AddTorrent(torrentContent []byte, option *TorrentOption, meta map[string]int64) error
+	// add torrent by url: magnet link (or "bc://bt/" url), or http(s) url of .torrent file.
+	// The torrent metadata is resolved (downloaded) by client itself;
+	// option & meta are applied to the torrent as soon as it is added, which is before metadata is resolved.
+	AddTorrentUrl(torrentUrl string, option *TorrentOption, meta map[string]int64) error
 	ModifyTorrent(infoHash string, option *TorrentOption, meta map[string]int64) error
 	DeleteTorrents(infoHashes []string, deleteFiles bool) error
 	PauseTorrents(infoHashes []string) error
@@ -147,10 +165,17 @@ type Client interface {
 	EditTorrentTracker(infoHash string, oldTracker string, newTracker string, replaceHost bool) error
 	AddTorrentTrackers(infoHash string, trackers []string, oldTracker string, removeExisting bool) error
 	RemoveTorrentTrackers(infoHash string, trackers []string) error
+	GetTorrentPeers(infoHash string) ([]*TorrentPeer, error)
+	// Ban peers. peers: "ip:port" or "ip" list. Clients that do not support port ban the whole ip.
+	BanPeers(peers []string) error
 	// QB only, priority: 0	Do not download; 1	Normal priority; 6	High priority; 7	Maximal priority
 	SetFilePriority(infoHash string, fileIndexes []int64, priority int64) error
 	Cached() bool
 	Close()
+	// Return a client instance which shares all underlying data (e.g. cache) with current one,
+	// and binds all its network requests to ctx, so they are aborted when ctx is cancelled.
+	// Instances of the same client could be used concurrently.
+	WithContext(ctx context.Context) Client
 }
 
 type RegInfo struct {
@@ -252,9 +277,10 @@ func ClientExists(name string) bool {
 	return clientConfig != nil
 }
 
-func CreateClient(name string) (Client, error) {
+// Create (or get the cached) client instance of name, with all its network requests bound to ctx.
+func CreateClient(ctx context.Context, name string) (Client, error) {
 	if clients[name] != nil {
-		return clients[name], nil
+		return clients[name].WithContext(ctx), nil
 	}
 	clientConfig := config.GetClientConfig(name)
 	if clientConfig == nil {
@@ -265,10 +291,11 @@ func CreateClient(name string) (Client, error) {
 		return nil, fmt.Errorf("unsupported client type %s", clientConfig.Type)
 	}
 	clientInstance, err := regInfo.Creator(name, clientConfig, config.Get())
-	if err == nil {
-		clients[name] = clientInstance
+	if err != nil {
+		return nil, err
 	}
-	return clientInstance, err
+	clients[name] = clientInstance
+	return clientInstance.WithContext(ctx), nil
 }
 
 func GenerateNameWithMeta(name string, meta map[string]int64) string {
@@ -508,6 +535,37 @@ func PrintTorrentFiles(files []*TorrentContentFile, showRaw bool) {
 	}
 }
 
+func PrintTorrentPeers(output io.Writer, infoHash string, peers []*TorrentPeer, showRaw bool) {
+	for _, peer := range peers {
+		fmt.Fprintf(output, "%-40s  %-40s  ", infoHash, peer.Address)
+		util.PrintStringInWidth(output, peer.Client, 24, true)
+		if showRaw {
+			fmt.Fprintf(output, "  %-4d%%  %-8d  %-8d  %-8s  %s\n", int(peer.Progress*100),
+				peer.DownloadSpeed, peer.UploadSpeed, peer.Flags, peer.Country)
+		} else {
+			fmt.Fprintf(output, "  %-4d%%  %-8s  %-8s  %-8s  %s\n", int(peer.Progress*100),
+				util.BytesSize(float64(peer.DownloadSpeed)), util.BytesSize(float64(peer.UploadSpeed)),
+				peer.Flags, peer.Country)
+		}
+	}
+}
+
+func PrintTorrentPeersHeader(output io.Writer) {
+	fmt.Fprintf(output, "%-40s  %-40s  %-24s  %-5s  %-8s  %-8s  %-8s  %s\n",
+		"InfoHash", "Address", "Client", "Prog.", "↓S(/s)", "↑S(/s)", "Flags", "Country")
+}
+
+// Return true if peer's client contains any one of the keywords (case-insensitive).
+func (peer *TorrentPeer) MatchClient(keywords []string) bool {
+	peerClient := strings.ToLower(peer.Client)
+	for _, keyword := range keywords {
+		if strings.Contains(peerClient, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
 func (torrent *Torrent) Print() {
 	ctimeStr := "-"
 	if torrent.Ctime > 0 {
@@ -840,6 +898,26 @@ func IsValidInfoHash(infoHash string) bool {
 	return infoHashV1Regex.MatchString(infoHash) || infoHashV2Regex.MatchString(infoHash)
 }
 
+// Generate a magnet link of torrent. infoHash is v1 (40 hex chars) or v2 (64 hex chars) info-hash.
+// name & trackers are optional.
+func GenerateMagnetUrl(infoHash string, name string, trackers []string) string {
+	infoHash = strings.ToLower(infoHash)
+	magnetUrl := ""
+	if infoHashV2Regex.MatchString(infoHash) {
+		// multihash: 0x12 (sha2-256), 0x20 (32 bytes length)
+		magnetUrl = "magnet:?xt=urn:btmh:1220" + infoHash
+	} else {
+		magnetUrl = "magnet:?xt=urn:btih:" + infoHash
+	}
+	if name != "" {
+		magnetUrl += "&dn=" + url.QueryEscape(name)
+	}
+	for _, tracker := range trackers {
+		magnetUrl += "&tr=" + url.QueryEscape(tracker)
+	}
+	return magnetUrl
+}
+
 func IsValidStateFilter(stateFilter string) bool {
 	if strings.HasPrefix(stateFilter, "_") {
 		if slices.Contains(STATE_FILTERS, stateFilter) {
@@ -0,0 +1,244 @@
+package deluge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/util"
+)
+
+type rpcRequest struct {
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+	Id     int64  `json:"id"`
+}
+
+type rpcError struct {
+	Message string `json:"message"`
+	Code    int64  `json:"code"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+	Id     int64           `json:"id"`
+}
+
+// keys of torrent status fields that are fetched during sync
+var torrentStatusKeys = []string{
+	"hash", "name", "state", "label", "download_location", "save_path", "tracker", "tracker_host", "is_finished",
+	"total_wanted", "total_size", "total_done", "all_time_download", "total_uploaded", "download_payload_rate",
+	"upload_payload_rate", "max_download_speed", "max_upload_speed", "time_added", "completed_time",
+	"time_since_transfer", "total_seeds", "total_peers", "ratio",
+}
+
+type apiUpdateUi struct {
+	Connected bool                       `json:"connected"`
+	Torrents  map[string]*apiTorrentInfo `json:"torrents"`
+	Stats     *apiStats                  `json:"stats"`
+}
+
+type apiStats struct {
+	Upload_rate   float64 `json:"upload_rate"`   // Global upload rate (bytes/s)
+	Download_rate float64 `json:"download_rate"` // Global download rate (bytes/s)
+	Max_upload    float64 `json:"max_upload"`    // Global upload limit (KiB/s). -1 if unlimited
+	Max_download  float64 `json:"max_download"`  // Global download limit (KiB/s). -1 if unlimited
+	Free_space    int64   `json:"free_space"`    // Free space of default download location (bytes). -1 if unknown
+}
+
+type apiTorrentInfo struct {
+	Hash                  string  `json:"hash"`
+	Name                  string  `json:"name"`
+	State                 string  `json:"state"` // Downloading|Seeding|Paused|Checking|Queued|Error|Allocating|Moving
+	Label                 string  `json:"label"` // Label plugin
+	Download_location     string  `json:"download_location"`
+	Save_path             string  `json:"save_path"` // deluge 1.x
+	Tracker               string  `json:"tracker"`   // current tracker url
+	Tracker_host          string  `json:"tracker_host"`
+	Is_finished           bool    `json:"is_finished"`
+	Total_wanted          int64   `json:"total_wanted"` // size of selected files
+	Total_size            int64   `json:"total_size"`   // size of all files
+	Total_done            int64   `json:"total_done"`
+	All_time_download     int64   `json:"all_time_download"`
+	Total_uploaded        int64   `json:"total_uploaded"`
+	Download_payload_rate int64   `json:"download_payload_rate"`
+	Upload_payload_rate   int64   `json:"upload_payload_rate"`
+	Max_download_speed    float64 `json:"max_download_speed"` // KiB/s. -1 if unlimited
+	Max_upload_speed      float64 `json:"max_upload_speed"`   // KiB/s. -1 if unlimited
+	Time_added            float64 `json:"time_added"`
+	Completed_time        int64   `json:"completed_time"`      // deluge 2.0+. 0 if not completed
+	Time_since_transfer   int64   `json:"time_since_transfer"` // deluge 2.0+. -1 if never transferred
+	Total_seeds           int64   `json:"total_seeds"`         // seeders in the swarm
+	Total_peers           int64   `json:"total_peers"`         // peers in the swarm
+	Ratio                 float64 `json:"ratio"`
+}
+
+type apiTorrentFile struct {
+	Index  int64  `json:"index"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Offset int64  `json:"offset"`
+}
+
+type apiTorrentFiles struct {
+	Files           []apiTorrentFile `json:"files"`
+	File_progress   []float64        `json:"file_progress"`
+	File_priorities []int64          `json:"file_priorities"` // 0: skip; 1: low; 4: normal; 7: high
+}
+
+type apiTorrentPeers struct {
+	Peers []apiTorrentPeer `json:"peers"`
+}
+
+type apiTorrentPeer struct {
+	Ip         string  `json:"ip"` // "ip:port"
+	Client     string  `json:"client"`
+	Country    string  `json:"country"`
+	Down_speed int64   `json:"down_speed"`
+	Up_speed   int64   `json:"up_speed"`
+	Progress   float64 `json:"progress"`
+	Seed       int64   `json:"seed"`
+}
+
+func (peer *apiTorrentPeer) ToPeer() *client.TorrentPeer {
+	torrentPeer := &client.TorrentPeer{
+		Address:       peer.Ip,
+		Ip:            peer.Ip,
+		Client:        peer.Client,
+		Progress:      peer.Progress,
+		DownloadSpeed: peer.Down_speed,
+		UploadSpeed:   peer.Up_speed,
+		Country:       strings.TrimSpace(peer.Country),
+	}
+	if host, port, err := net.SplitHostPort(peer.Ip); err == nil {
+		torrentPeer.Ip = host
+		torrentPeer.Port = util.ParseInt(port)
+	}
+	if peer.Seed != 0 {
+		torrentPeer.Flags = "S"
+	}
+	return torrentPeer
+}
+
+type apiTorrentTracker struct {
+	Url  string `json:"url"`
+	Tier int64  `json:"tier"`
+}
+
+type apiTorrentTrackers struct {
+	Tracker        string              `json:"tracker"`
+	Tracker_status string              `json:"tracker_status"` // e.g. "Announce OK", "Error: ..."
+	Trackers       []apiTorrentTracker `json:"trackers"`
+}
+
+// Label plugin label options. Only the fields used by ptool are defined.
+type apiLabelOptions struct {
+	Apply_move_completed bool   `json:"apply_move_completed"`
+	Move_completed       bool   `json:"move_completed"`
+	Move_completed_path  string `json:"move_completed_path"`
+}
+
+func (dltorrent *apiTorrentInfo) ToTorrentState() string {
+	state := ""
+	switch dltorrent.State {
+	case "Seeding":
+		state = "seeding"
+	case "Downloading", "Allocating":
+		state = "downloading"
+	case "Queued":
+		if dltorrent.Is_finished {
+			state = "seeding"
+		} else {
+			state = "downloading"
+		}
+	case "Paused":
+		if dltorrent.Is_finished {
+			state = "completed"
+		} else {
+			state = "paused"
+		}
+	case "Checking", "Moving":
+		state = "checking"
+	case "Error":
+		state = "error"
+	default:
+		state = "unknown"
+	}
+	return state
+}
+
+func (dltorrent *apiTorrentInfo) SavePath() string {
+	if dltorrent.Download_location != "" {
+		return dltorrent.Download_location
+	}
+	return dltorrent.Save_path
+}
+
+func (dltorrent *apiTorrentInfo) ContentPath() string {
+	savePath := dltorrent.SavePath()
+	sep := "/"
+	if strings.Contains(savePath, `\`) {
+		sep = `\`
+	}
+	return strings.TrimSuffix(savePath, sep) + sep + dltorrent.Name
+}
+
+func (dltorrent *apiTorrentInfo) ToTorrent() *client.Torrent {
+	activityTime := int64(0)
+	if dltorrent.Time_since_transfer >= 0 {
+		activityTime = util.Now() - dltorrent.Time_since_transfer
+	}
+	torrent := &client.Torrent{
+		InfoHash:           dltorrent.Hash,
+		Name:               dltorrent.Name,
+		TrackerDomain:      util.ParseUrlHostname(dltorrent.Tracker),
+		TrackerBaseDomain:  util.GetUrlDomain(dltorrent.Tracker),
+		Tracker:            dltorrent.Tracker,
+		State:              dltorrent.ToTorrentState(),
+		LowLevelState:      dltorrent.State,
+		Atime:              int64(dltorrent.Time_added),
+		Ctime:              dltorrent.Completed_time,
+		ActivityTime:       activityTime,
+		Downloaded:         dltorrent.All_time_download,
+		DownloadSpeed:      dltorrent.Download_payload_rate,
+		DownloadSpeedLimit: speedLimitToBytes(dltorrent.Max_download_speed),
+		Uploaded:           dltorrent.Total_uploaded,
+		UploadSpeed:        dltorrent.Upload_payload_rate,
+		UploadedSpeedLimit: speedLimitToBytes(dltorrent.Max_upload_speed),
+		Category:           dltorrent.Label,
+		SavePath:           dltorrent.SavePath(),
+		ContentPath:        dltorrent.ContentPath(),
+		Tags:               []string{},
+		Seeders:            dltorrent.Total_seeds,
+		Leechers:           dltorrent.Total_peers,
+		Size:               dltorrent.Total_wanted,
+		SizeCompleted:      dltorrent.Total_done,
+		SizeTotal:          dltorrent.Total_size,
+		Ratio:              dltorrent.Ratio,
+		Meta:               map[string]int64{},
+	}
+	return torrent
+}
+
+// deluge speed limit (KiB/s, -1 == unlimited) => bytes/s (-1 == unlimited)
+func speedLimitToBytes(limit float64) int64 {
+	if limit < 0 {
+		return -1
+	}
+	return int64(limit * 1024)
+}
+
+// speed limit (bytes/s) => deluge speed limit (KiB/s). <0 means unlimited.
+func speedLimitFromBytes(limit int64) float64 {
+	if limit < 0 {
+		return -1
+	}
+	return float64(limit) / 1024
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code=%d)", e.Message, e.Code)
+}
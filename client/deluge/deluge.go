@@ -0,0 +1,1241 @@
+package deluge
+
+// Deluge Web JSON-RPC API: https://deluge.readthedocs.io/en/latest/reference/webapi.html
+// RPC methods: https://deluge.readthedocs.io/en/latest/reference/api.html
+// Categories & tags are implemented using Label plugin, which must be enabled in Deluge. See label.go.
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+)
+
+type Client struct {
+	*clientData
+	ctx context.Context // see client.Client.WithContext
+}
+
+type clientData struct {
+	Name                      string
+	ClientConfig              *config.ClientConfigStruct
+	Config                    *config.ConfigStruct
+	HttpClient                *http.Client
+	Logined                   bool
+	rpcUrl                    string
+	rpcId                     int64
+	data                      *apiUpdateUi
+	datatime                  int64
+	unfinishedSize            int64
+	unfinishedDownloadingSize int64
+	contentPathTorrents       map[string][]*apiTorrentInfo
+}
+
+func (dlclient *Client) WithContext(ctx context.Context) client.Client {
+	return &Client{clientData: dlclient.clientData, ctx: ctx}
+}
+
+// Call a deluge web JSON-RPC method. If result is not nil, the returned result will be unmarshaled into it.
+func (dlclient *Client) call(method string, result any, params ...any) error {
+	id := atomic.AddInt64(&dlclient.rpcId, 1)
+	if params == nil {
+		params = []any{}
+	}
+	req := &rpcRequest{
+		Method: method,
+		Params: params,
+		Id:     id,
+	}
+	res := &rpcResponse{}
+	err := util.PostAndFetchJsonContext(dlclient.ctx, dlclient.rpcUrl, req, res, nil, dlclient.HttpClient)
+	if err != nil {
+		return fmt.Errorf("%s error: %w", method, err)
+	}
+	if res.Error != nil {
+		return fmt.Errorf("%s error: %w", method, res.Error)
+	}
+	if result != nil && len(res.Result) > 0 {
+		if err = json.Unmarshal(res.Result, result); err != nil {
+			return fmt.Errorf("%s error: invalid result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+func (dlclient *Client) login() error {
+	if dlclient.Logined {
+		return nil
+	}
+	password := dlclient.ClientConfig.Password
+	// use deluge web default
+	if password == "" {
+		password = "deluge"
+	}
+	ok := false
+	if err := dlclient.call("auth.login", &ok, password); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("incorrect password")
+	}
+	connected := false
+	if err := dlclient.call("web.connected", &connected); err != nil {
+		return err
+	}
+	if !connected {
+		// each host: [id, host, port, status / username]
+		var hosts [][]any
+		if err := dlclient.call("web.get_hosts", &hosts); err != nil {
+			return err
+		}
+		if len(hosts) == 0 || len(hosts[0]) == 0 {
+			return fmt.Errorf("no daemon host configured in deluge web")
+		}
+		if err := dlclient.call("web.connect", nil, hosts[0][0]); err != nil {
+			return err
+		}
+	}
+	dlclient.Logined = true
+	return nil
+}
+
+func (dlclient *Client) toTorrent(dltorrent *apiTorrentInfo) *client.Torrent {
+	torrent := dltorrent.ToTorrent()
+	tl := parseLabel(dltorrent.Label)
+	torrent.Category = tl.Category
+	torrent.Tags = util.CopySlice(tl.Tags)
+	torrent.Meta = tl.meta()
+	return torrent
+}
+
+// Return labels of client. label => parsed label.
+func (dlclient *Client) getLabels() (map[string]*torrentLabel, error) {
+	err := dlclient.login()
+	if err != nil {
+		return nil, fmt.Errorf("login error: %w", err)
+	}
+	var labels []string
+	if err = dlclient.call("label.get_labels", &labels); err != nil {
+		return nil, err
+	}
+	labelsMap := map[string]*torrentLabel{}
+	for _, label := range labels {
+		labelsMap[label] = parseLabel(label)
+	}
+	return labelsMap, nil
+}
+
+// Set labels of torrents. infoHash => label. The labels are created if not exist, a new label inherits the
+// "move completed" options of it's category label. Derived labels no longer used by any torrent are removed.
+func (dlclient *Client) setTorrentLabels(torrentLabels map[string]*torrentLabel) error {
+	labels, err := dlclient.getLabels()
+	if err != nil {
+		return err
+	}
+	assigned := map[string]bool{}
+	for infoHash, tl := range torrentLabels {
+		label := tl.String()
+		if label != "" && labels[label] == nil {
+			if err = dlclient.call("label.add", nil, label); err != nil {
+				return err
+			}
+			labels[label] = tl
+			categoryLabel := (&torrentLabel{Category: tl.Category}).String()
+			if tl.Category != "" && len(tl.Tags) > 0 && labels[categoryLabel] != nil {
+				var options *apiLabelOptions
+				if err = dlclient.call("label.get_options", &options, categoryLabel); err != nil {
+					return err
+				}
+				if options != nil && options.Move_completed_path != "" {
+					if err = dlclient.call("label.set_options", nil, label, options); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if err = dlclient.call("label.set_torrent", nil, infoHash, label); err != nil {
+			return err
+		}
+		assigned[label] = true
+		if dlclient.Cached() && dlclient.data.Torrents[infoHash] != nil {
+			dlclient.data.Torrents[infoHash].Label = label
+		}
+	}
+	return dlclient.pruneLabels(labels, func(label string, tl *torrentLabel) bool {
+		return !assigned[label] && tl.derived()
+	})
+}
+
+// Remove labels that match filter and are not used by any torrent.
+func (dlclient *Client) pruneLabels(labels map[string]*torrentLabel,
+	filter func(label string, tl *torrentLabel) bool) error {
+	if err := dlclient.sync(); err != nil {
+		return err
+	}
+	used := map[string]bool{}
+	for _, dltorrent := range dlclient.data.Torrents {
+		used[dltorrent.Label] = true
+	}
+	for label, tl := range labels {
+		if label == "" || used[label] || !filter(label, tl) {
+			continue
+		}
+		if err := dlclient.call("label.remove", nil, label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update labels of existing torrents.
+func (dlclient *Client) updateLabels(infoHashes []string, update func(tl *torrentLabel)) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	if err := dlclient.sync(); err != nil {
+		return err
+	}
+	torrentLabels := map[string]*torrentLabel{}
+	for _, infoHash := range infoHashes {
+		dltorrent := dlclient.data.Torrents[infoHash]
+		if dltorrent == nil {
+			continue
+		}
+		tl := parseLabel(dltorrent.Label)
+		update(tl)
+		tl.Tags = util.UniqueSlice(tl.Tags)
+		if tl.String() != dltorrent.Label {
+			torrentLabels[infoHash] = tl
+		}
+	}
+	if len(torrentLabels) == 0 {
+		return nil
+	}
+	return dlclient.setTorrentLabels(torrentLabels)
+}
+
+func (dlclient *Client) Cached() bool {
+	return dlclient.datatime > 0
+}
+
+func (dlclient *Client) sync() error {
+	if dlclient.datatime > 0 {
+		return nil
+	}
+	err := dlclient.login()
+	if err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	var data *apiUpdateUi
+	err = dlclient.call("web.update_ui", &data, torrentStatusKeys, map[string]any{})
+	if err != nil {
+		return err
+	}
+	if data == nil || data.Stats == nil {
+		return fmt.Errorf("web.update_ui error: invalid result")
+	}
+	dlclient.data = data
+	dlclient.datatime = util.Now()
+	dlclient.buildDerivative()
+	return nil
+}
+
+func (dlclient *Client) buildDerivative() {
+	unfinishedSize := int64(0)
+	unfinishedDownloadingSize := int64(0)
+	contentPathTorrents := map[string][]*apiTorrentInfo{}
+	for hash, torrent := range dlclient.data.Torrents {
+		torrent.Hash = hash
+		usize := torrent.Total_wanted - torrent.Total_done
+		unfinishedSize += usize
+		if torrent.State != "Paused" {
+			unfinishedDownloadingSize += usize
+		}
+		contentPath := torrent.ContentPath()
+		contentPathTorrents[contentPath] = append(contentPathTorrents[contentPath], torrent)
+	}
+	dlclient.unfinishedSize = unfinishedSize
+	dlclient.unfinishedDownloadingSize = unfinishedDownloadingSize
+	dlclient.contentPathTorrents = contentPathTorrents
+}
+
+func (dlclient *Client) getAllInfoHashes() ([]string, error) {
+	if err := dlclient.sync(); err != nil {
+		return nil, err
+	}
+	infoHashes := []string{}
+	for infoHash := range dlclient.data.Torrents {
+		infoHashes = append(infoHashes, infoHash)
+	}
+	return infoHashes, nil
+}
+
+// Call a core method which accepts a list of torrent ids as it's first param.
+func (dlclient *Client) callTorrents(method string, infoHashes []string, params ...any) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	err := dlclient.login()
+	if err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	return dlclient.call(method, nil, append([]any{infoHashes}, params...)...)
+}
+
+func (dlclient *Client) getTorrentStatus(infoHash string, keys []string, v any) error {
+	err := dlclient.login()
+	if err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	var result map[string]any
+	if err = dlclient.call("core.get_torrent_status", &result, infoHash, keys); err != nil {
+		return err
+	}
+	// deluge returns an empty dict for non-existent torrent
+	if len(result) == 0 {
+		return fmt.Errorf("torrent %s not found", infoHash)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (dlclient *Client) GetName() string {
+	return dlclient.Name
+}
+
+func (dlclient *Client) GetClientConfig() *config.ClientConfigStruct {
+	return dlclient.ClientConfig
+}
+
+// Deluge does not provide an API to export .torrent file.
+// It's read from the "state" dir of deluge, which must be accessible locally.
+func (dlclient *Client) ExportTorrentFile(infoHash string) ([]byte, error) {
+	if dlclient.ClientConfig.DelugeStateDir == "" {
+		return nil, fmt.Errorf("delugeStateDir of client is not configured")
+	}
+	return os.ReadFile(filepath.Join(dlclient.ClientConfig.DelugeStateDir, infoHash+".torrent"))
+}
+
+func (dlclient *Client) GetTorrent(infoHash string) (*client.Torrent, error) {
+	if err := dlclient.sync(); err != nil {
+		return nil, err
+	}
+	dltorrent := dlclient.data.Torrents[infoHash]
+	if dltorrent == nil {
+		return nil, nil
+	}
+	return dlclient.toTorrent(dltorrent), nil
+}
+
+func (dlclient *Client) GetTorrents(stateFilter string, category string, showAll bool) ([]*client.Torrent, error) {
+	if err := dlclient.sync(); err != nil {
+		return nil, err
+	}
+	torrents := []*client.Torrent{}
+	for _, dltorrent := range dlclient.data.Torrents {
+		if category != "" {
+			torrentCategory := parseLabel(dltorrent.Label).Category
+			if category == constants.NONE {
+				if torrentCategory != "" {
+					continue
+				}
+			} else if !strings.EqualFold(category, torrentCategory) {
+				continue
+			}
+		}
+		if !showAll && dltorrent.Download_payload_rate < 1024 && dltorrent.Upload_payload_rate < 1024 {
+			continue
+		}
+		torrent := dlclient.toTorrent(dltorrent)
+		if !torrent.MatchStateFilter(stateFilter) {
+			continue
+		}
+		torrents = append(torrents, torrent)
+	}
+	return torrents, nil
+}
+
+func (dlclient *Client) GetTorrentsByContentPath(contentPath string) ([]*client.Torrent, error) {
+	if err := dlclient.sync(); err != nil {
+		return nil, err
+	}
+	var torrents []*client.Torrent
+	for _, t := range dlclient.contentPathTorrents[contentPath] {
+		torrents = append(torrents, dlclient.toTorrent(t))
+	}
+	return torrents, nil
+}
+
+// option.Name is not supported, as Deluge can not rename a torrent without renaming it's files.
+func (dlclient *Client) AddTorrent(torrentContent []byte, option *client.TorrentOption, meta map[string]int64) error {
+	err := dlclient.login()
+	if err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	if option == nil {
+		option = &client.TorrentOption{}
+	}
+	options := map[string]any{
+		"add_paused": option.Pause,
+	}
+	if option.SavePath != "" {
+		options["download_location"] = option.SavePath
+	}
+	if option.UploadSpeedLimit > 0 {
+		options["max_upload_speed"] = speedLimitFromBytes(option.UploadSpeedLimit)
+	}
+	if option.DownloadSpeedLimit > 0 {
+		options["max_download_speed"] = speedLimitFromBytes(option.DownloadSpeedLimit)
+	}
+	if option.SkipChecking {
+		options["seed_mode"] = true
+	}
+	if option.SequentialDownload {
+		options["sequential_download"] = true
+	}
+	if option.RatioLimit > 0 {
+		options["stop_at_ratio"] = true
+		options["stop_ratio"] = option.RatioLimit
+	}
+	infoHash := ""
+	content := string(torrentContent)
+	if util.IsPureTorrentUrl(content) {
+		err = dlclient.call("core.add_torrent_magnet", &infoHash, content, options)
+	} else if util.IsUrl(content) {
+		err = dlclient.call("core.add_torrent_url", &infoHash, content, options)
+	} else {
+		err = dlclient.call("core.add_torrent_file", &infoHash, "file.torrent",
+			base64.StdEncoding.EncodeToString(torrentContent), options)
+	}
+	if err != nil {
+		return fmt.Errorf("add torrent error: %w", err)
+	}
+	if infoHash == "" {
+		return fmt.Errorf("add torrent error: torrent already exists or is invalid")
+	}
+	tl := &torrentLabel{Tags: util.UniqueSlice(option.Tags)}
+	if option.Category != "" && option.Category != constants.NONE {
+		tl.Category = option.Category
+		if err = dlclient.MakeCategory(tl.Category, constants.NONE); err != nil {
+			return err
+		}
+	}
+	tl.setMeta(meta)
+	if tl.String() == "" {
+		return nil
+	}
+	return dlclient.setTorrentLabels(map[string]*torrentLabel{infoHash: tl})
+}
+
+func (dlclient *Client) AddTorrentUrl(torrentUrl string, option *client.TorrentOption,
+	meta map[string]int64) error {
+	if !util.IsTorrentUrl(torrentUrl) {
+		return fmt.Errorf("invalid torrent url: %s", torrentUrl)
+	}
+	return dlclient.AddTorrent([]byte(torrentUrl), option, meta)
+}
+
+func (dlclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOption, meta map[string]int64) error {
+	if option == nil {
+		option = &client.TorrentOption{}
+	}
+	err := dlclient.sync()
+	if err != nil {
+		return err
+	}
+	dltorrent, ok := dlclient.data.Torrents[infoHash]
+	if !ok {
+		return fmt.Errorf("torrent not exists")
+	}
+
+	if option.Category != "" && option.Category != constants.NONE {
+		if err := dlclient.MakeCategory(option.Category, constants.NONE); err != nil {
+			return err
+		}
+	}
+	if option.Category != "" || len(option.Tags) > 0 || len(option.RemoveTags) > 0 || len(meta) > 0 {
+		err := dlclient.updateLabels([]string{infoHash}, func(tl *torrentLabel) {
+			if option.Category == constants.NONE {
+				tl.Category = ""
+			} else if option.Category != "" {
+				tl.Category = option.Category
+			}
+			tl.Tags = util.Filter(tl.Tags, func(tag string) bool {
+				return !slices.Contains(option.RemoveTags, tag)
+			})
+			tl.Tags = append(tl.Tags, option.Tags...)
+			if len(meta) > 0 {
+				tl.setMeta(meta)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	options := map[string]any{}
+	if option.DownloadSpeedLimit != 0 &&
+		option.DownloadSpeedLimit != speedLimitToBytes(dltorrent.Max_download_speed) {
+		options["max_download_speed"] = speedLimitFromBytes(option.DownloadSpeedLimit)
+	}
+	if option.UploadSpeedLimit != 0 && option.UploadSpeedLimit != speedLimitToBytes(dltorrent.Max_upload_speed) {
+		options["max_upload_speed"] = speedLimitFromBytes(option.UploadSpeedLimit)
+	}
+	if option.SequentialDownload {
+		options["sequential_download"] = true
+	}
+	if len(options) > 0 {
+		if err := dlclient.callTorrents("core.set_torrent_options", []string{infoHash}, options); err != nil {
+			return err
+		}
+	}
+
+	if option.RatioLimit != 0 || option.SeedingTimeLimit != 0 {
+		err := dlclient.SetTorrentsShareLimits([]string{infoHash}, option.RatioLimit, option.SeedingTimeLimit)
+		if err != nil {
+			return err
+		}
+	}
+
+	if option.SavePath != "" && option.SavePath != dltorrent.SavePath() {
+		if err := dlclient.SetTorrentsSavePath([]string{infoHash}, option.SavePath); err != nil {
+			return err
+		}
+	}
+
+	if option.Pause {
+		if dltorrent.State != "Paused" {
+			dlclient.PauseTorrents([]string{infoHash})
+		}
+	} else if option.Resume {
+		if dltorrent.State == "Paused" || dltorrent.State == "Error" {
+			dlclient.ResumeTorrents([]string{infoHash})
+		}
+	}
+	return nil
+}
+
+func (dlclient *Client) DeleteTorrents(infoHashes []string, deleteFiles bool) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	err := dlclient.login()
+	if err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	for _, infoHash := range infoHashes {
+		if err = dlclient.call("core.remove_torrent", nil, infoHash, deleteFiles); err != nil {
+			return err
+		}
+		if dlclient.Cached() {
+			delete(dlclient.data.Torrents, infoHash)
+		}
+	}
+	if dlclient.Cached() {
+		dlclient.buildDerivative()
+	}
+	labels, err := dlclient.getLabels()
+	if err != nil {
+		return err
+	}
+	return dlclient.pruneLabels(labels, func(label string, tl *torrentLabel) bool {
+		return tl.derived()
+	})
+}
+
+func (dlclient *Client) PauseTorrents(infoHashes []string) error {
+	return dlclient.callTorrents("core.pause_torrent", infoHashes)
+}
+
+func (dlclient *Client) ResumeTorrents(infoHashes []string) error {
+	return dlclient.callTorrents("core.resume_torrent", infoHashes)
+}
+
+func (dlclient *Client) RecheckTorrents(infoHashes []string) error {
+	return dlclient.callTorrents("core.force_recheck", infoHashes)
+}
+
+func (dlclient *Client) ReannounceTorrents(infoHashes []string) error {
+	return dlclient.callTorrents("core.force_reannounce", infoHashes)
+}
+
+func (dlclient *Client) AddTagsToTorrents(infoHashes []string, tags []string) error {
+	if len(infoHashes) == 0 || len(tags) == 0 {
+		return nil
+	}
+	return dlclient.updateLabels(infoHashes, func(tl *torrentLabel) {
+		tl.Tags = append(tl.Tags, tags...)
+	})
+}
+
+func (dlclient *Client) RemoveTagsFromTorrents(infoHashes []string, tags []string) error {
+	if len(infoHashes) == 0 || len(tags) == 0 {
+		return nil
+	}
+	return dlclient.updateLabels(infoHashes, func(tl *torrentLabel) {
+		tl.Tags = util.Filter(tl.Tags, func(tag string) bool {
+			return !slices.Contains(tags, tag)
+		})
+	})
+}
+
+func (dlclient *Client) SetTorrentsSavePath(infoHashes []string, savePath string) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	savePath = strings.TrimSpace(savePath)
+	if savePath == "" {
+		return fmt.Errorf("savePath is empty")
+	}
+	return dlclient.callTorrents("core.move_storage", infoHashes, savePath)
+}
+
+func (dlclient *Client) PauseAllTorrents() error {
+	infoHashes, err := dlclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return dlclient.PauseTorrents(infoHashes)
+}
+
+func (dlclient *Client) ResumeAllTorrents() error {
+	infoHashes, err := dlclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return dlclient.ResumeTorrents(infoHashes)
+}
+
+func (dlclient *Client) RecheckAllTorrents() error {
+	infoHashes, err := dlclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return dlclient.RecheckTorrents(infoHashes)
+}
+
+func (dlclient *Client) ReannounceAllTorrents() error {
+	infoHashes, err := dlclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return dlclient.ReannounceTorrents(infoHashes)
+}
+
+func (dlclient *Client) AddTagsToAllTorrents(tags []string) error {
+	infoHashes, err := dlclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return dlclient.AddTagsToTorrents(infoHashes, tags)
+}
+
+func (dlclient *Client) RemoveTagsFromAllTorrents(tags []string) error {
+	infoHashes, err := dlclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return dlclient.RemoveTagsFromTorrents(infoHashes, tags)
+}
+
+func (dlclient *Client) SetAllTorrentsSavePath(savePath string) error {
+	infoHashes, err := dlclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return dlclient.SetTorrentsSavePath(infoHashes, savePath)
+}
+
+// Return tags of all labels, excluding meta tags.
+func (dlclient *Client) GetTags() ([]string, error) {
+	labels, err := dlclient.getLabels()
+	if err != nil {
+		return nil, err
+	}
+	tags := []string{}
+	for _, tl := range labels {
+		for _, tag := range tl.Tags {
+			if !client.IsSubstituteTag(tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	tags = util.UniqueSlice(tags)
+	slices.Sort(tags)
+	return tags, nil
+}
+
+// Create a single tag label for each tag.
+func (dlclient *Client) CreateTags(tags ...string) error {
+	labels, err := dlclient.getLabels()
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		label := (&torrentLabel{Tags: []string{tag}}).String()
+		if labels[label] != nil {
+			continue
+		}
+		if err = dlclient.call("label.add", nil, label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove tags from all torrents, then remove all labels which have any of the tags.
+func (dlclient *Client) DeleteTags(tags ...string) error {
+	if err := dlclient.RemoveTagsFromAllTorrents(tags); err != nil {
+		return err
+	}
+	labels, err := dlclient.getLabels()
+	if err != nil {
+		return err
+	}
+	return dlclient.pruneLabels(labels, func(label string, tl *torrentLabel) bool {
+		return tl.derived() || slices.ContainsFunc(tl.Tags, func(tag string) bool {
+			return slices.Contains(tags, tag)
+		})
+	})
+}
+
+// savePath is applied to the category label's "move completed" path.
+func (dlclient *Client) MakeCategory(category string, savePath string) error {
+	labels, err := dlclient.getLabels()
+	if err != nil {
+		return err
+	}
+	label := (&torrentLabel{Category: category}).String()
+	if labels[label] == nil {
+		if err = dlclient.call("label.add", nil, label); err != nil {
+			return err
+		}
+	}
+	if savePath != constants.NONE {
+		options := &apiLabelOptions{
+			Apply_move_completed: savePath != "",
+			Move_completed:       savePath != "",
+			Move_completed_path:  savePath,
+		}
+		return dlclient.call("label.set_options", nil, label, options)
+	}
+	return nil
+}
+
+// Unset category of torrents of the categories, then remove the category labels.
+func (dlclient *Client) DeleteCategories(categories []string) error {
+	if err := dlclient.sync(); err != nil {
+		return err
+	}
+	var infoHashes []string
+	for infoHash, dltorrent := range dlclient.data.Torrents {
+		if slices.Contains(categories, parseLabel(dltorrent.Label).Category) {
+			infoHashes = append(infoHashes, infoHash)
+		}
+	}
+	err := dlclient.updateLabels(infoHashes, func(tl *torrentLabel) {
+		tl.Category = ""
+	})
+	if err != nil {
+		return err
+	}
+	labels, err := dlclient.getLabels()
+	if err != nil {
+		return err
+	}
+	return dlclient.pruneLabels(labels, func(label string, tl *torrentLabel) bool {
+		return tl.derived() || slices.Contains(categories, tl.Category)
+	})
+}
+
+func (dlclient *Client) GetCategories() ([]*client.TorrentCategory, error) {
+	labels, err := dlclient.getLabels()
+	if err != nil {
+		return nil, err
+	}
+	categories := map[string]*client.TorrentCategory{}
+	for label, tl := range labels {
+		if tl.Category == "" {
+			continue
+		}
+		if categories[tl.Category] == nil {
+			categories[tl.Category] = &client.TorrentCategory{Name: tl.Category}
+		}
+		if len(tl.Tags) > 0 {
+			continue
+		}
+		var options *apiLabelOptions
+		if err = dlclient.call("label.get_options", &options, label); err != nil {
+			return nil, err
+		}
+		if options != nil && options.Apply_move_completed && options.Move_completed {
+			categories[tl.Category].SavePath = options.Move_completed_path
+		}
+	}
+	cats := []*client.TorrentCategory{}
+	for _, cat := range categories {
+		cats = append(cats, cat)
+	}
+	slices.SortFunc(cats, func(a, b *client.TorrentCategory) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return cats, nil
+}
+
+func (dlclient *Client) SetTorrentsCatetory(infoHashes []string, category string) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	if category == constants.NONE {
+		category = ""
+	}
+	if category != "" {
+		if err := dlclient.MakeCategory(category, constants.NONE); err != nil {
+			return err
+		}
+	}
+	return dlclient.updateLabels(infoHashes, func(tl *torrentLabel) {
+		tl.Category = category
+	})
+}
+
+func (dlclient *Client) SetAllTorrentsCatetory(category string) error {
+	infoHashes, err := dlclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return dlclient.SetTorrentsCatetory(infoHashes, category)
+}
+
+// Deluge does not support seeding time limit.
+func (dlclient *Client) SetTorrentsShareLimits(infoHashes []string, ratioLimit float64, seedingTimeLimit int64) error {
+	if seedingTimeLimit > 0 {
+		return fmt.Errorf("seeding time limit is not supported by deluge")
+	}
+	options := map[string]any{
+		"stop_at_ratio": ratioLimit > 0,
+	}
+	if ratioLimit > 0 {
+		options["stop_ratio"] = ratioLimit
+	}
+	return dlclient.callTorrents("core.set_torrent_options", infoHashes, options)
+}
+
+func (dlclient *Client) SetAllTorrentsShareLimits(ratioLimit float64, seedingTimeLimit int64) error {
+	infoHashes, err := dlclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return dlclient.SetTorrentsShareLimits(infoHashes, ratioLimit, seedingTimeLimit)
+}
+
+func (dlclient *Client) TorrentRootPathExists(rootFolder string) bool {
+	if rootFolder == "" {
+		return false
+	}
+	if err := dlclient.sync(); err != nil {
+		return false
+	}
+	for _, torrent := range dlclient.data.Torrents {
+		if torrent.Name == rootFolder {
+			return true
+		}
+	}
+	return false
+}
+
+func (dlclient *Client) GetTorrentPeers(infoHash string) ([]*client.TorrentPeer, error) {
+	var dlpeers *apiTorrentPeers
+	err := dlclient.getTorrentStatus(infoHash, []string{"peers"}, &dlpeers)
+	if err != nil {
+		return nil, err
+	}
+	peers := []*client.TorrentPeer{}
+	for _, dlpeer := range dlpeers.Peers {
+		peers = append(peers, dlpeer.ToPeer())
+	}
+	return peers, nil
+}
+
+// Deluge core does not provide an API to ban peers (the Blocklist plugin only supports blocklist urls).
+func (dlclient *Client) BanPeers(peers []string) error {
+	return fmt.Errorf("banning peers is not supported by deluge")
+}
+
+func (dlclient *Client) GetTorrentContents(infoHash string) ([]*client.TorrentContentFile, error) {
+	var dlfiles *apiTorrentFiles
+	err := dlclient.getTorrentStatus(infoHash, []string{"files", "file_progress", "file_priorities"}, &dlfiles)
+	if err != nil {
+		return nil, err
+	}
+	files := []*client.TorrentContentFile{}
+	for i, dlfile := range dlfiles.Files {
+		progress := float64(0)
+		if i < len(dlfiles.File_progress) {
+			progress = dlfiles.File_progress[i]
+		}
+		ignored := false
+		if i < len(dlfiles.File_priorities) {
+			ignored = dlfiles.File_priorities[i] == 0
+		}
+		files = append(files, &client.TorrentContentFile{
+			Index:    dlfile.Index,
+			Path:     strings.ReplaceAll(dlfile.Path, `\`, "/"),
+			Size:     dlfile.Size,
+			Ignored:  ignored,
+			Complete: progress >= 1,
+			Progress: progress,
+		})
+	}
+	return files, nil
+}
+
+func (dlclient *Client) PurgeCache() {
+	dlclient.data = nil
+	dlclient.datatime = 0
+	dlclient.unfinishedSize = 0
+	dlclient.unfinishedDownloadingSize = 0
+	dlclient.contentPathTorrents = nil
+}
+
+func (dlclient *Client) GetStatus() (*client.Status, error) {
+	if err := dlclient.sync(); err != nil {
+		return nil, err
+	}
+	stats := dlclient.data.Stats
+	status := &client.Status{
+		DownloadSpeed:             int64(stats.Download_rate),
+		UploadSpeed:               int64(stats.Upload_rate),
+		FreeSpaceOnDisk:           stats.Free_space,
+		UnfinishedSize:            dlclient.unfinishedSize,
+		UnfinishedDownloadingSize: dlclient.unfinishedDownloadingSize,
+	}
+	if stats.Max_download > 0 {
+		status.DownloadSpeedLimit = speedLimitToBytes(stats.Max_download)
+	}
+	if stats.Max_upload > 0 {
+		status.UploadSpeedLimit = speedLimitToBytes(stats.Max_upload)
+	}
+	tags, err := dlclient.GetTags()
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(tags, config.NOADD_TAG) {
+		status.NoAdd = true
+	}
+	if slices.Contains(tags, config.NODEL_TAG) {
+		status.NoDel = true
+	}
+	return status, nil
+}
+
+func (dlclient *Client) getConfigValue(key string) (value any, err error) {
+	err = dlclient.login()
+	if err != nil {
+		return nil, fmt.Errorf("login error: %w", err)
+	}
+	err = dlclient.call("core.get_config_value", &value, key)
+	return
+}
+
+func (dlclient *Client) setConfig(key string, value any) error {
+	err := dlclient.login()
+	if err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	return dlclient.call("core.set_config", nil, map[string]any{key: value})
+}
+
+func (dlclient *Client) GetConfig(variable string) (string, error) {
+	if strings.HasPrefix(variable, "de_") && len(variable) > 3 {
+		value, err := dlclient.getConfigValue(variable[3:])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(value), nil
+	}
+	switch variable {
+	case "global_download_speed_limit", "global_upload_speed_limit":
+		key := "max_download_speed"
+		if variable == "global_upload_speed_limit" {
+			key = "max_upload_speed"
+		}
+		value, err := dlclient.getConfigValue(key)
+		if err != nil {
+			return "", err
+		}
+		limit, _ := value.(float64)
+		if limit <= 0 {
+			return "0", nil
+		}
+		return fmt.Sprint(speedLimitToBytes(limit)), nil
+	case "free_disk_space":
+		status, err := dlclient.GetStatus()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(status.FreeSpaceOnDisk), nil
+	case "global_download_speed":
+		status, err := dlclient.GetStatus()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(status.DownloadSpeed), nil
+	case "global_upload_speed":
+		status, err := dlclient.GetStatus()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(status.UploadSpeed), nil
+	case "save_path":
+		value, err := dlclient.getConfigValue("download_location")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(value), nil
+	default:
+		return "", nil
+	}
+}
+
+func (dlclient *Client) SetConfig(variable string, value string) error {
+	if strings.HasPrefix(variable, "de_") && len(variable) > 3 {
+		v, _ := util.String2Any(value)
+		return dlclient.setConfig(variable[3:], v)
+	}
+	switch variable {
+	case "global_download_speed_limit", "global_upload_speed_limit":
+		key := "max_download_speed"
+		if variable == "global_upload_speed_limit" {
+			key = "max_upload_speed"
+		}
+		limit := util.ParseInt(value)
+		if limit <= 0 {
+			limit = -1
+		}
+		return dlclient.setConfig(key, speedLimitFromBytes(limit))
+	case "free_disk_space", "global_download_speed", "global_upload_speed":
+		return fmt.Errorf("%s is read-only", variable)
+	case "save_path":
+		return dlclient.setConfig("download_location", value)
+	default:
+		return nil
+	}
+}
+
+func (dlclient *Client) getTorrentTrackers(infoHash string) (*apiTorrentTrackers, error) {
+	var dltrackers *apiTorrentTrackers
+	err := dlclient.getTorrentStatus(infoHash, []string{"tracker", "tracker_status", "trackers"}, &dltrackers)
+	if err != nil {
+		return nil, err
+	}
+	return dltrackers, nil
+}
+
+func (dlclient *Client) setTorrentTrackers(infoHash string, trackers []apiTorrentTracker) error {
+	err := dlclient.login()
+	if err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	return dlclient.call("core.set_torrent_trackers", nil, infoHash, trackers)
+}
+
+// Deluge only reports the status of current tracker.
+func (dlclient *Client) GetTorrentTrackers(infoHash string) (client.TorrentTrackers, error) {
+	dltrackers, err := dlclient.getTorrentTrackers(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	trackers := client.TorrentTrackers{}
+	for _, dltracker := range dltrackers.Trackers {
+		status := "notcontacted"
+		msg := ""
+		if dltracker.Url == dltrackers.Tracker {
+			trackerStatus := dltrackers.Tracker_status
+			switch {
+			case strings.HasPrefix(trackerStatus, "Error"):
+				status = "error"
+				msg = strings.TrimSpace(strings.TrimPrefix(trackerStatus, "Error:"))
+			case strings.HasPrefix(trackerStatus, "Warning"):
+				status = "working"
+				msg = strings.TrimSpace(strings.TrimPrefix(trackerStatus, "Warning:"))
+			case strings.HasPrefix(trackerStatus, "Announce OK"):
+				status = "working"
+			case trackerStatus != "":
+				status = "updating"
+			}
+		}
+		trackers = append(trackers, client.TorrentTracker{
+			Url:    dltracker.Url,
+			Msg:    msg,
+			Status: status,
+		})
+	}
+	return trackers, nil
+}
+
+func (dlclient *Client) EditTorrentTracker(infoHash string, oldTracker string,
+	newTracker string, replaceHost bool) error {
+	dltrackers, err := dlclient.getTorrentTrackers(infoHash)
+	if err != nil {
+		return err
+	}
+	trackers := dltrackers.Trackers
+	directNewUrlMode := util.IsUrl(newTracker)
+	index := slices.IndexFunc(trackers, func(t apiTorrentTracker) bool {
+		if replaceHost {
+			return util.MatchUrlWithHostOrUrl(t.Url, oldTracker)
+		}
+		return t.Url == oldTracker
+	})
+	if index == -1 {
+		return fmt.Errorf("torrent %s old tracker does NOT exist", infoHash)
+	}
+	newTrackerUrl := newTracker
+	if replaceHost && !directNewUrlMode {
+		urlObj, err := url.Parse(trackers[index].Url)
+		if err != nil {
+			return err
+		}
+		urlObj.Host = newTracker
+		newTrackerUrl = urlObj.String()
+	}
+	if trackers[index].Url == newTrackerUrl {
+		return nil
+	}
+	log.Debugf("Replace torrent %s tracker %s => %s", infoHash, trackers[index].Url, newTrackerUrl)
+	trackers[index].Url = newTrackerUrl
+	return dlclient.setTorrentTrackers(infoHash, trackers)
+}
+
+// trackers - new trackers full URLs; oldTracker - existing tracker host or URL
+func (dlclient *Client) AddTorrentTrackers(infoHash string, trackers []string,
+	oldTracker string, removeExisting bool) error {
+	dltrackers, err := dlclient.getTorrentTrackers(infoHash)
+	if err != nil {
+		return err
+	}
+	existingTrackers := dltrackers.Trackers
+	if oldTracker != "" && !slices.ContainsFunc(existingTrackers, func(t apiTorrentTracker) bool {
+		return util.MatchUrlWithHostOrUrl(t.Url, oldTracker)
+	}) {
+		return nil
+	}
+	newTrackers := []apiTorrentTracker{}
+	tier := int64(0)
+	if !removeExisting {
+		newTrackers = existingTrackers
+		for _, t := range existingTrackers {
+			tier = max(tier, t.Tier+1)
+		}
+		trackers = util.Filter(trackers, func(tracker string) bool {
+			return !slices.ContainsFunc(existingTrackers, func(t apiTorrentTracker) bool {
+				return t.Url == tracker
+			})
+		})
+		if len(trackers) == 0 {
+			return nil
+		}
+	}
+	for i, tracker := range trackers {
+		newTrackers = append(newTrackers, apiTorrentTracker{
+			Url:  tracker,
+			Tier: tier + int64(i),
+		})
+	}
+	return dlclient.setTorrentTrackers(infoHash, newTrackers)
+}
+
+func (dlclient *Client) RemoveTorrentTrackers(infoHash string, trackers []string) error {
+	dltrackers, err := dlclient.getTorrentTrackers(infoHash)
+	if err != nil {
+		return err
+	}
+	newTrackers := util.Filter(dltrackers.Trackers, func(t apiTorrentTracker) bool {
+		return !slices.Contains(trackers, t.Url)
+	})
+	if len(newTrackers) == len(dltrackers.Trackers) {
+		return nil
+	}
+	return dlclient.setTorrentTrackers(infoHash, newTrackers)
+}
+
+// priority uses qb values, which is converted to deluge values: 0 (skip), 4 (normal), 6, 7 (high).
+func (dlclient *Client) SetFilePriority(infoHash string, fileIndexes []int64, priority int64) error {
+	if len(fileIndexes) == 0 {
+		return fmt.Errorf("must provide at least fileIndex")
+	}
+	if priority == 1 {
+		priority = 4
+	}
+	var dlfiles *apiTorrentFiles
+	err := dlclient.getTorrentStatus(infoHash, []string{"file_priorities"}, &dlfiles)
+	if err != nil {
+		return err
+	}
+	priorities := dlfiles.File_priorities
+	for _, index := range fileIndexes {
+		if index < 0 || index >= int64(len(priorities)) {
+			return fmt.Errorf("invalid file index %d", index)
+		}
+		priorities[index] = priority
+	}
+	return dlclient.callTorrents("core.set_torrent_options", []string{infoHash}, map[string]any{
+		"file_priorities": priorities,
+	})
+}
+
+func (dlclient *Client) Close() {
+	dlclient.PurgeCache()
+	if dlclient.Logined {
+		dlclient.Logined = false
+		dlclient.call("auth.delete_session", nil)
+	}
+}
+
+func NewClient(name string, clientConfig *config.ClientConfigStruct, config *config.ConfigStruct) (
+	client.Client, error) {
+	httpClient, err := client.NewHttpClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	client := &Client{
+		clientData: &clientData{
+			Name:         name,
+			ClientConfig: clientConfig,
+			Config:       config,
+			HttpClient:   httpClient,
+			rpcUrl:       strings.TrimSuffix(clientConfig.Url, "/") + "/json",
+		},
+		ctx: context.Background(),
+	}
+	return client, nil
+}
+
+func init() {
+	client.Register(&client.RegInfo{
+		Name:    "deluge",
+		Creator: NewClient,
+	})
+}
+
+var (
+	_ client.Client = (*Client)(nil)
+)
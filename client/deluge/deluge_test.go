@@ -0,0 +1,177 @@
+package deluge_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/client/deluge"
+	"github.com/sagan/ptool/config"
+)
+
+const testInfoHash = "0123456789abcdef0123456789abcdef01234567"
+
+// a minimal stand-in of deluge web JSON-RPC endpoint
+type fakeDeluge struct {
+	labels        []string
+	torrentLabels map[string]string
+	calls         []string
+}
+
+func (fd *fakeDeluge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+		Id     int64             `json:"id"`
+	}
+	if r.URL.Path != "/json" || json.NewDecoder(r.Body).Decode(&req) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fd.calls = append(fd.calls, req.Method)
+	var result any
+	var rpcErr any
+	switch req.Method {
+	case "auth.login":
+		var password string
+		json.Unmarshal(req.Params[0], &password)
+		result = password == "deluge"
+	case "web.connected":
+		result = true
+	case "web.update_ui":
+		result = map[string]any{
+			"connected": true,
+			"stats": map[string]any{
+				"upload_rate":   2048.0,
+				"download_rate": 1024.0,
+				"max_upload":    -1.0,
+				"max_download":  100.0,
+				"free_space":    int64(1 << 30),
+			},
+			"torrents": map[string]any{
+				testInfoHash: map[string]any{
+					"name":                  "Foo.2024",
+					"state":                 "Downloading",
+					"label":                 fd.torrentLabels[testInfoHash],
+					"download_location":     "/downloads",
+					"tracker":               "https://tracker.example.com/announce",
+					"total_wanted":          1000,
+					"total_size":            2000,
+					"total_done":            400,
+					"download_payload_rate": 4096,
+					"max_download_speed":    -1.0,
+					"max_upload_speed":      10.0,
+					"time_added":            1700000000.5,
+					"time_since_transfer":   -1,
+				},
+			},
+		}
+	case "label.get_labels":
+		result = fd.labels
+	case "label.add":
+		var label string
+		json.Unmarshal(req.Params[0], &label)
+		fd.labels = append(fd.labels, label)
+	case "label.remove":
+		var label string
+		json.Unmarshal(req.Params[0], &label)
+		fd.labels = slices.DeleteFunc(fd.labels, func(l string) bool { return l == label })
+	case "label.get_options":
+		result = map[string]any{}
+	case "label.set_options":
+	case "label.set_torrent":
+		var infoHash, label string
+		json.Unmarshal(req.Params[0], &infoHash)
+		json.Unmarshal(req.Params[1], &label)
+		if label != "" && !slices.Contains(fd.labels, label) {
+			rpcErr = map[string]any{"message": "Unknown Label", "code": 4}
+		} else {
+			fd.torrentLabels[infoHash] = label
+		}
+	case "core.add_torrent_magnet":
+		result = testInfoHash
+	default:
+		rpcErr = map[string]any{"message": "Unknown method", "code": 2}
+	}
+	json.NewEncoder(w).Encode(map[string]any{"id": req.Id, "result": result, "error": rpcErr})
+}
+
+func TestDelugeClient(t *testing.T) {
+	config.ConfigDir = t.TempDir()
+	// "tv.shows" is a label created by user
+	fd := &fakeDeluge{labels: []string{"tv.shows"}, torrentLabels: map[string]string{}}
+	server := httptest.NewServer(fd)
+	defer server.Close()
+	clientInstance, err := deluge.NewClient("de", &config.ClientConfigStruct{
+		Type: "deluge",
+		Url:  server.URL + "/",
+	}, &config.ConfigStruct{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	err = clientInstance.AddTorrent([]byte("magnet:?xt=urn:btih:"+testInfoHash), &client.TorrentOption{
+		Category: "_brush",
+		Tags:     []string{"site:foo"},
+	}, map[string]int64{"dcet": 1})
+	if err != nil {
+		t.Fatalf("AddTorrent: %v", err)
+	}
+	// category, tags & meta are all stored in label
+	if want := "_5f_brush._site_3a_foo._meta_2e_dcet_3a_1"; fd.torrentLabels[testInfoHash] != want {
+		t.Errorf("torrent label = %q, want %q", fd.torrentLabels[testInfoHash], want)
+	}
+
+	torrents, err := clientInstance.GetTorrents("", "_brush", true)
+	if err != nil {
+		t.Fatalf("GetTorrents: %v", err)
+	}
+	if len(torrents) != 1 {
+		t.Fatalf("GetTorrents returned %d torrents, want 1", len(torrents))
+	}
+	torrent := torrents[0]
+	if torrent.InfoHash != testInfoHash || torrent.State != "downloading" ||
+		torrent.ContentPath != "/downloads/Foo.2024" || torrent.Size != 1000 || torrent.SizeTotal != 2000 ||
+		torrent.DownloadSpeedLimit != -1 || torrent.UploadedSpeedLimit != 10*1024 ||
+		torrent.TrackerDomain != "tracker.example.com" {
+		t.Errorf("unexpected torrent: %+v", torrent)
+	}
+	if torrent.GetSiteFromTag() != "foo" || torrent.Meta["dcet"] != 1 {
+		t.Errorf("torrent tags / meta not restored: %v / %v", torrent.Tags, torrent.Meta)
+	}
+
+	status, err := clientInstance.GetStatus()
+	if err != nil {
+		t.Fatalf("GetStatus: %v", err)
+	}
+	if status.DownloadSpeedLimit != 100*1024 || status.UploadSpeedLimit != 0 || status.FreeSpaceOnDisk != 1<<30 ||
+		status.UnfinishedSize != 600 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if err = clientInstance.RemoveTagsFromTorrents([]string{testInfoHash}, []string{"site:foo"}); err != nil {
+		t.Fatalf("RemoveTagsFromTorrents: %v", err)
+	}
+	clientInstance.PurgeCache()
+	torrent, err = clientInstance.GetTorrent(testInfoHash)
+	if err != nil || torrent == nil {
+		t.Fatalf("GetTorrent: %v", err)
+	}
+	if torrent.GetSiteFromTag() != "" || torrent.Category != "_brush" || torrent.Meta["dcet"] != 1 {
+		t.Errorf("unexpected torrent tags / meta after removing tag: %v / %v", torrent.Tags, torrent.Meta)
+	}
+	// the no longer used label is removed, the user created one is kept
+	if want := []string{"tv.shows", "_brush", "_5f_brush._meta_2e_dcet_3a_1"}; !slices.Equal(fd.labels, want) {
+		t.Errorf("labels = %v, want %v", fd.labels, want)
+	}
+
+	if err = clientInstance.CreateTags("_noadd"); err != nil {
+		t.Fatalf("CreateTags: %v", err)
+	}
+	if status, err = clientInstance.GetStatus(); err != nil || !status.NoAdd {
+		t.Errorf("client is not in NoAdd status after creating tag: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package deluge
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/util"
+)
+
+// Deluge Label plugin allows only one label per torrent, and a label may only contain "[a-z0-9_\-.]" chars.
+// ptool stores the category and tags (including "meta.<name>:<value>" meta tags) of a torrent all in its label,
+// in "<category>[._<tag>]..." format. Each part is escaped: chars other than [a-z0-9-] are encoded as
+// "_" + 2 hex digits of the byte + "_", e.g. "_brush" => "_5f_brush". A label of only category is the category
+// as is if it's a valid label. A single tag label ("._<tag>") is created for each created tag. A label is only
+// treated as ptool encoded if it decodes and encodes back to itself, any other label created in Deluge
+// (e.g. "tv.shows") is a plain category.
+type torrentLabel struct {
+	Category string
+	Tags     []string
+}
+
+func parseLabel(label string) *torrentLabel {
+	if tl := decodeLabel(label); tl != nil && tl.String() == label {
+		return tl
+	}
+	return &torrentLabel{Category: label}
+}
+
+// Decode a ptool encoded label. Return nil if it's malformed.
+func decodeLabel(label string) *torrentLabel {
+	tl := &torrentLabel{}
+	if label == "" {
+		return tl
+	}
+	parts := strings.Split(label, ".")
+	var ok bool
+	if tl.Category, ok = unescapeLabelPart(parts[0]); !ok {
+		return nil
+	}
+	for _, part := range parts[1:] {
+		tag, ok := strings.CutPrefix(part, "_")
+		if !ok {
+			return nil
+		}
+		if tag, ok = unescapeLabelPart(tag); !ok || tag == "" {
+			return nil
+		}
+		tl.Tags = append(tl.Tags, tag)
+	}
+	return tl
+}
+
+// Return the label. A plain category that is a valid Deluge label and is not ptool encoded is used as is,
+// so that labels created in Deluge are kept.
+func (tl *torrentLabel) String() string {
+	if len(tl.Tags) == 0 && tl.Category != "" && escapeLabelPart(tl.Category) != tl.Category &&
+		isValidLabel(tl.Category) {
+		if decoded := decodeLabel(tl.Category); decoded == nil || decoded.String() != tl.Category {
+			return tl.Category
+		}
+	}
+	return tl.encode()
+}
+
+func (tl *torrentLabel) encode() string {
+	label := escapeLabelPart(tl.Category)
+	for _, tag := range tl.Tags {
+		label += "._" + escapeLabelPart(tag)
+	}
+	return label
+}
+
+// Whether the label is maintained by ptool and could be removed when no longer used by any torrent.
+// Plain category labels and single tag labels are kept.
+func (tl *torrentLabel) derived() bool {
+	if tl.Category != "" {
+		return len(tl.Tags) > 0
+	}
+	return len(tl.Tags) > 1 || len(tl.Tags) == 1 && strings.HasPrefix(tl.Tags[0], "meta.")
+}
+
+func (tl *torrentLabel) meta() map[string]int64 {
+	torrent := &client.Torrent{Tags: tl.Tags}
+	return torrent.GetMetadataFromTags()
+}
+
+// Set meta of label, replacing all existing meta tags.
+func (tl *torrentLabel) setMeta(meta map[string]int64) {
+	tl.Tags = util.Filter(tl.Tags, func(tag string) bool {
+		return !strings.HasPrefix(tag, "meta.")
+	})
+	names := []string{}
+	for name := range meta {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		tl.Tags = append(tl.Tags, client.GenerateTorrentTagFromMetadata(name, meta[name]))
+	}
+}
+
+func escapeLabelPart(str string) string {
+	var sb strings.Builder
+	for _, b := range []byte(str) {
+		if b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '-' {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "_%02x_", b)
+		}
+	}
+	return sb.String()
+}
+
+// Return false if str is not a valid escaped part.
+func unescapeLabelPart(str string) (string, bool) {
+	var bytes []byte
+	for i := 0; i < len(str); i++ {
+		if str[i] != '_' {
+			bytes = append(bytes, str[i])
+			continue
+		}
+		if i+3 >= len(str) || str[i+3] != '_' {
+			return "", false
+		}
+		b, err := strconv.ParseUint(str[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", false
+		}
+		bytes = append(bytes, byte(b))
+		i += 3
+	}
+	return string(bytes), true
+}
+
+func isValidLabel(label string) bool {
+	for _, b := range []byte(label) {
+		if !(b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '-' || b == '_' || b == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package deluge
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseLabel(t *testing.T) {
+	cases := []struct {
+		label    string
+		category string
+		tags     []string
+		derived  bool
+	}{
+		{"", "", nil, false},
+		{"movies", "movies", nil, false},
+		{"_brush", "_brush", nil, false},
+		{"_5f_brush", "_5f_brush", nil, false},
+		{"_5f_brush._site_3a_foo", "_brush", []string{"site:foo"}, true},
+		{"._meta_2e_dcet_3a_1", "", []string{"meta.dcet:1"}, true},
+		{"._mytag", "", []string{"mytag"}, false},
+		// labels created by user are plain categories
+		{"tv.shows", "tv.shows", nil, false},
+		{"x_2fy", "x_2fy", nil, false},
+		{"a_b.c_d", "a_b.c_d", nil, false},
+	}
+	for _, c := range cases {
+		tl := parseLabel(c.label)
+		if tl.Category != c.category || !slices.Equal(tl.Tags, c.tags) || tl.derived() != c.derived {
+			t.Errorf("parseLabel(%q) = %+v (derived %t), want %q %v (derived %t)",
+				c.label, tl, tl.derived(), c.category, c.tags, c.derived)
+		}
+		if label := tl.String(); label != c.label {
+			t.Errorf("parseLabel(%q).String() = %q", c.label, label)
+		}
+	}
+}
+
+func TestLabelString(t *testing.T) {
+	cases := []struct {
+		tl    *torrentLabel
+		label string
+	}{
+		{&torrentLabel{Category: "tv.shows"}, "tv.shows"},
+		{&torrentLabel{Category: "tv.shows", Tags: []string{"hd"}}, "tv_2e_shows._hd"},
+		{&torrentLabel{Category: "x/y"}, "x_2f_y"},
+		// a category that looks like an encoded label is escaped
+		{&torrentLabel{Category: "x_2f_y"}, "x_5f_2f_5f_y"},
+	}
+	for _, c := range cases {
+		label := c.tl.String()
+		if label != c.label {
+			t.Errorf("%+v.String() = %q, want %q", c.tl, label, c.label)
+		}
+		if tl := parseLabel(label); tl.Category != c.tl.Category || !slices.Equal(tl.Tags, c.tl.Tags) {
+			t.Errorf("parseLabel(%q) = %+v, want %+v", label, tl, c.tl)
+		}
+	}
+}
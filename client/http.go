@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+)
+
+// Add extra headers & basic auth to every request, and apply the network timeout.
+type httpRoundTripper struct {
+	transport         http.RoundTripper
+	clientConfig      *config.ClientConfigStruct
+	headers           [][]string
+	basicAuthUsername string
+	basicAuthPassword string
+}
+
+func (rt *httpRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper should not modify the original request
+	req = req.Clone(req.Context())
+	for _, header := range rt.headers {
+		if len(header) == 2 {
+			req.Header.Set(header[0], header[1])
+		}
+	}
+	// The Authorization header set by client itself (e.g. Transmission RPC auth) takes precedence.
+	if rt.basicAuthUsername != "" && req.Header.Get("Authorization") == "" {
+		req.SetBasicAuth(rt.basicAuthUsername, rt.basicAuthPassword)
+	}
+	// The timeout is determined at the time of each request, so the current --timeout flag always applies,
+	// even if the client instance is cached and reused (e.g. in shell).
+	timeout := GetTimeout(rt.clientConfig)
+	if timeout <= 0 {
+		return rt.transport.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	res, err := rt.transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = &contextBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// Return the network timeout of accessing BT client, which is determined by --timeout flag or client config.
+// 0 means use the default timeout of BT client backend.
+func GetTimeout(clientConfig *config.ClientConfigStruct) time.Duration {
+	timeout := util.FirstNonZeroIntegerArg(config.Timeout, clientConfig.Timeout)
+	if timeout < 0 {
+		timeout = constants.INFINITE_TIMEOUT
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// Create a http client (with cookie jar) to access BT client.
+// It honors the proxy, httpHeaders, basic auth, TLS & timeout settings of client config.
+// The timeout applies per request. Use http.NewRequestWithContext to bind a request to a context.
+func NewHttpClient(clientConfig *config.ClientConfigStruct) (*http.Client, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	switch clientConfig.Proxy {
+	case "", constants.ENV_PROXY:
+		transport.Proxy = http.ProxyFromEnvironment
+	case constants.NONE:
+		transport.Proxy = nil
+	default:
+		proxyUrl, err := url.Parse(clientConfig.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy %s: %w", clientConfig.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: clientConfig.Insecure || config.Insecure,
+	}
+	if clientConfig.TlsCaCert != "" {
+		contents, err := os.ReadFile(clientConfig.TlsCaCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tlsCaCert: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(contents) {
+			return nil, fmt.Errorf("invalid tlsCaCert %s: no certificate found", clientConfig.TlsCaCert)
+		}
+	}
+	if clientConfig.TlsClientCert != "" || clientConfig.TlsClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(clientConfig.TlsClientCert, clientConfig.TlsClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls client cert: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+	httpClient := &http.Client{
+		Jar: jar,
+		Transport: &httpRoundTripper{
+			transport:         transport,
+			clientConfig:      clientConfig,
+			headers:           clientConfig.HttpHeaders,
+			basicAuthUsername: clientConfig.BasicAuthUsername,
+			basicAuthPassword: clientConfig.BasicAuthPassword,
+		},
+	}
+	return httpClient, nil
+}
+
+// A response body which releases the request context when closed.
+type contextBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *contextBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,869 @@
+package mock
+
+// A in-memory mock BitTorrent client, which is used to rehearse workflows (brush, dynamicseeding, tidyup...) offline.
+// Torrents, tags, categories and status of client are kept in memory and persisted to a local state file
+// between invocations. The downloading / uploading of torrents is simulated over (real) time.
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/torrent/metainfo"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+	"github.com/sagan/ptool/util/torrentutil"
+)
+
+type Client struct {
+	Name          string
+	ClientConfig  *config.ClientConfigStruct
+	Config        *config.ConfigStruct
+	state         *state
+	downloadSpeed int64
+	uploadSpeed   int64
+	diskSpace     int64
+}
+
+func (mclient *Client) Cached() bool {
+	return mclient.state != nil
+}
+
+// Load state and simulate it to now.
+func (mclient *Client) sync() error {
+	if mclient.Cached() {
+		return nil
+	}
+	s, err := loadState(mclient.Name, mclient.ClientConfig.MockSnapshot)
+	if err != nil {
+		return err
+	}
+	s.simulate(time.Now().Unix(), mclient.downloadSpeed, mclient.uploadSpeed, mclient.diskSpace)
+	mclient.state = s
+	return s.save()
+}
+
+// Apply update to state and persist it.
+func (mclient *Client) update(update func(s *state) error) error {
+	if err := mclient.sync(); err != nil {
+		return err
+	}
+	if err := update(mclient.state); err != nil {
+		return err
+	}
+	return mclient.state.save()
+}
+
+// Apply update to the specified torrents. Non-existent torrents are ignored.
+func (mclient *Client) updateTorrents(infoHashes []string, update func(mt *mockTorrent)) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	return mclient.update(func(s *state) error {
+		for _, infoHash := range infoHashes {
+			if mt := s.Torrents[infoHash]; mt != nil {
+				update(mt)
+				mt.updateState()
+			}
+		}
+		return nil
+	})
+}
+
+func (mclient *Client) getAllInfoHashes() ([]string, error) {
+	if err := mclient.sync(); err != nil {
+		return nil, err
+	}
+	infoHashes := []string{}
+	for infoHash := range mclient.state.Torrents {
+		infoHashes = append(infoHashes, infoHash)
+	}
+	return infoHashes, nil
+}
+
+func (mclient *Client) toTorrent(mt *mockTorrent) *client.Torrent {
+	torrent := mt.Torrent
+	torrent.Tags = util.CopySlice(mt.Tags)
+	torrent.Meta = util.CopyMap(mt.Meta, false)
+	return &torrent
+}
+
+func (mclient *Client) GetName() string {
+	return mclient.Name
+}
+
+func (mclient *Client) GetClientConfig() *config.ClientConfigStruct {
+	return mclient.ClientConfig
+}
+
+func (mclient *Client) ExportTorrentFile(infoHash string) ([]byte, error) {
+	if err := mclient.sync(); err != nil {
+		return nil, err
+	}
+	mt := mclient.state.Torrents[infoHash]
+	if mt == nil {
+		return nil, fmt.Errorf("torrent not exists")
+	}
+	if len(mt.Content) == 0 {
+		return nil, fmt.Errorf("torrent file is not available (added by url or from snapshot)")
+	}
+	return mt.Content, nil
+}
+
+func (mclient *Client) GetTorrent(infoHash string) (*client.Torrent, error) {
+	if err := mclient.sync(); err != nil {
+		return nil, err
+	}
+	mt := mclient.state.Torrents[infoHash]
+	if mt == nil {
+		return nil, nil
+	}
+	return mclient.toTorrent(mt), nil
+}
+
+func (mclient *Client) GetTorrents(stateFilter string, category string, showAll bool) ([]*client.Torrent, error) {
+	if err := mclient.sync(); err != nil {
+		return nil, err
+	}
+	torrents := []*client.Torrent{}
+	for _, mt := range mclient.state.Torrents {
+		if category != "" {
+			if category == constants.NONE {
+				if mt.Category != "" {
+					continue
+				}
+			} else if category != mt.Category {
+				continue
+			}
+		}
+		if !showAll && mt.DownloadSpeed < ACTIVE_SPEED && mt.UploadSpeed < ACTIVE_SPEED {
+			continue
+		}
+		torrent := mclient.toTorrent(mt)
+		if !torrent.MatchStateFilter(stateFilter) {
+			continue
+		}
+		torrents = append(torrents, torrent)
+	}
+	return torrents, nil
+}
+
+func (mclient *Client) GetTorrentsByContentPath(contentPath string) ([]*client.Torrent, error) {
+	if err := mclient.sync(); err != nil {
+		return nil, err
+	}
+	var torrents []*client.Torrent
+	for _, mt := range mclient.state.Torrents {
+		if mt.ContentPath == contentPath {
+			torrents = append(torrents, mclient.toTorrent(mt))
+		}
+	}
+	return torrents, nil
+}
+
+func (mclient *Client) addTorrent(mt *mockTorrent, option *client.TorrentOption, meta map[string]int64) error {
+	if option == nil {
+		option = &client.TorrentOption{}
+	}
+	return mclient.update(func(s *state) error {
+		if s.Torrents[mt.InfoHash] != nil {
+			return fmt.Errorf("torrent %s already exists", mt.InfoHash)
+		}
+		now := time.Now().Unix()
+		if option.Name != "" {
+			mt.Name = option.Name
+		}
+		mt.SavePath = s.SavePath
+		if option.Category != "" && option.Category != constants.NONE {
+			mt.Category = option.Category
+			if _, ok := s.Categories[mt.Category]; !ok {
+				s.Categories[mt.Category] = ""
+			}
+			if s.Categories[mt.Category] != "" {
+				mt.SavePath = s.Categories[mt.Category]
+			}
+		}
+		if option.SavePath != "" {
+			mt.SavePath = option.SavePath
+		}
+		mt.ContentPath = filepath.Join(mt.SavePath, mt.Name)
+		mt.Tags = util.UniqueSlice(option.Tags)
+		s.Tags = util.UniqueSlice(append(s.Tags, mt.Tags...))
+		mt.Meta = util.CopyMap(meta, false)
+		mt.Atime = now
+		mt.ActivityTime = now
+		mt.DownloadSpeedLimit = option.DownloadSpeedLimit
+		mt.UploadedSpeedLimit = option.UploadSpeedLimit
+		mt.RatioLimit = option.RatioLimit
+		mt.SeedingTimeLimit = option.SeedingTimeLimit
+		mt.Paused = option.Pause
+		mt.MaxDownloadSpeed = speedOf(mt.InfoHash, mclient.downloadSpeed)
+		mt.MaxUploadSpeed = speedOf(mt.InfoHash+"up", mclient.uploadSpeed)
+		mt.Seeders = speedOf(mt.InfoHash, 20)
+		mt.Leechers = speedOf(mt.InfoHash+"up", 50)
+		if len(mt.Trackers) > 0 {
+			mt.Tracker = mt.Trackers[0].Url
+			mt.TrackerDomain = util.ParseUrlHostname(mt.Tracker)
+			mt.TrackerBaseDomain = util.GetUrlDomain(mt.Tracker)
+		}
+		if option.SkipChecking && mt.Size > 0 {
+			mt.SizeCompleted = mt.Size
+			mt.Ctime = now
+		}
+		mt.updateState()
+		s.Torrents[mt.InfoHash] = mt
+		return nil
+	})
+}
+
+func (mclient *Client) AddTorrent(torrentContent []byte, option *client.TorrentOption, meta map[string]int64) error {
+	tinfo, err := torrentutil.ParseTorrent(torrentContent)
+	if err != nil {
+		return fmt.Errorf("invalid torrent: %w", err)
+	}
+	mt := &mockTorrent{
+		Torrent: client.Torrent{
+			InfoHash:  tinfo.InfoHash,
+			Name:      tinfo.Info.Name,
+			Size:      tinfo.Size,
+			SizeTotal: tinfo.Size,
+		},
+		Content: torrentContent,
+	}
+	for i, file := range tinfo.Files {
+		path := file.Path
+		if tinfo.RootDir != "" {
+			path = tinfo.RootDir + "/" + path
+		}
+		mt.Files = append(mt.Files, &client.TorrentContentFile{
+			Index: int64(i),
+			Path:  path,
+			Size:  file.Size,
+		})
+	}
+	for _, tracker := range tinfo.Trackers {
+		mt.Trackers = append(mt.Trackers, client.TorrentTracker{Url: tracker, Status: "working"})
+	}
+	return mclient.addTorrent(mt, option, meta)
+}
+
+// Only magnet url is supported. The metadata of it will never be resolved, so the torrent stays downloading.
+func (mclient *Client) AddTorrentUrl(torrentUrl string, option *client.TorrentOption,
+	meta map[string]int64) error {
+	if !util.IsTorrentUrl(torrentUrl) {
+		return fmt.Errorf("invalid torrent url: %s", torrentUrl)
+	}
+	magnet, err := metainfo.ParseMagnetUri(torrentUrl)
+	if err != nil {
+		return fmt.Errorf("mock client only supports magnet url: %w", err)
+	}
+	mt := &mockTorrent{
+		Torrent: client.Torrent{
+			InfoHash: magnet.InfoHash.HexString(),
+			Name:     magnet.DisplayName,
+		},
+	}
+	if mt.Name == "" {
+		mt.Name = mt.InfoHash
+	}
+	for _, tracker := range magnet.Trackers {
+		mt.Trackers = append(mt.Trackers, client.TorrentTracker{Url: tracker, Status: "working"})
+	}
+	return mclient.addTorrent(mt, option, meta)
+}
+
+func (mclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOption, meta map[string]int64) error {
+	if option == nil {
+		option = &client.TorrentOption{}
+	}
+	return mclient.update(func(s *state) error {
+		mt := s.Torrents[infoHash]
+		if mt == nil {
+			return fmt.Errorf("torrent not exists")
+		}
+		if option.Name != "" {
+			mt.Name = option.Name
+		}
+		if option.Category != "" {
+			if option.Category == constants.NONE {
+				mt.Category = ""
+			} else {
+				mt.Category = option.Category
+				if _, ok := s.Categories[mt.Category]; !ok {
+					s.Categories[mt.Category] = ""
+				}
+			}
+		}
+		if len(option.Tags) > 0 || len(option.RemoveTags) > 0 {
+			mt.Tags = util.Filter(mt.Tags, func(tag string) bool {
+				return !slices.Contains(option.RemoveTags, tag)
+			})
+			mt.Tags = util.UniqueSlice(append(mt.Tags, option.Tags...))
+			s.Tags = util.UniqueSlice(append(s.Tags, option.Tags...))
+		}
+		if len(meta) > 0 {
+			mt.Meta = util.CopyMap(meta, false)
+		}
+		if option.DownloadSpeedLimit != 0 {
+			mt.DownloadSpeedLimit = option.DownloadSpeedLimit
+		}
+		if option.UploadSpeedLimit != 0 {
+			mt.UploadedSpeedLimit = option.UploadSpeedLimit
+		}
+		if option.RatioLimit != 0 {
+			mt.RatioLimit = option.RatioLimit
+		}
+		if option.SeedingTimeLimit != 0 {
+			mt.SeedingTimeLimit = option.SeedingTimeLimit
+		}
+		if option.SavePath != "" {
+			mt.SavePath = option.SavePath
+		}
+		mt.ContentPath = filepath.Join(mt.SavePath, mt.Name)
+		if option.Pause {
+			mt.Paused = true
+		} else if option.Resume {
+			mt.Paused = false
+		}
+		mt.updateState()
+		return nil
+	})
+}
+
+func (mclient *Client) DeleteTorrents(infoHashes []string, deleteFiles bool) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	return mclient.update(func(s *state) error {
+		for _, infoHash := range infoHashes {
+			delete(s.Torrents, infoHash)
+		}
+		return nil
+	})
+}
+
+func (mclient *Client) PauseTorrents(infoHashes []string) error {
+	return mclient.updateTorrents(infoHashes, func(mt *mockTorrent) {
+		mt.Paused = true
+		mt.DownloadSpeed = 0
+		mt.UploadSpeed = 0
+	})
+}
+
+func (mclient *Client) ResumeTorrents(infoHashes []string) error {
+	return mclient.updateTorrents(infoHashes, func(mt *mockTorrent) {
+		mt.Paused = false
+	})
+}
+
+// Mock torrent data is always "correct", so recheck does nothing.
+func (mclient *Client) RecheckTorrents(infoHashes []string) error {
+	return mclient.updateTorrents(infoHashes, func(mt *mockTorrent) {})
+}
+
+func (mclient *Client) ReannounceTorrents(infoHashes []string) error {
+	return mclient.updateTorrents(infoHashes, func(mt *mockTorrent) {})
+}
+
+func (mclient *Client) AddTagsToTorrents(infoHashes []string, tags []string) error {
+	if len(infoHashes) == 0 || len(tags) == 0 {
+		return nil
+	}
+	if err := mclient.CreateTags(tags...); err != nil {
+		return err
+	}
+	return mclient.updateTorrents(infoHashes, func(mt *mockTorrent) {
+		mt.Tags = util.UniqueSlice(append(mt.Tags, tags...))
+	})
+}
+
+func (mclient *Client) RemoveTagsFromTorrents(infoHashes []string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	return mclient.updateTorrents(infoHashes, func(mt *mockTorrent) {
+		mt.Tags = util.Filter(mt.Tags, func(tag string) bool {
+			return !slices.Contains(tags, tag)
+		})
+	})
+}
+
+func (mclient *Client) SetTorrentsSavePath(infoHashes []string, savePath string) error {
+	savePath = strings.TrimSpace(savePath)
+	if savePath == "" {
+		return fmt.Errorf("savePath is empty")
+	}
+	return mclient.updateTorrents(infoHashes, func(mt *mockTorrent) {
+		mt.SavePath = savePath
+		mt.ContentPath = filepath.Join(mt.SavePath, mt.Name)
+	})
+}
+
+func (mclient *Client) PauseAllTorrents() error {
+	infoHashes, err := mclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return mclient.PauseTorrents(infoHashes)
+}
+
+func (mclient *Client) ResumeAllTorrents() error {
+	infoHashes, err := mclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return mclient.ResumeTorrents(infoHashes)
+}
+
+func (mclient *Client) RecheckAllTorrents() error {
+	infoHashes, err := mclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return mclient.RecheckTorrents(infoHashes)
+}
+
+func (mclient *Client) ReannounceAllTorrents() error {
+	infoHashes, err := mclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return mclient.ReannounceTorrents(infoHashes)
+}
+
+func (mclient *Client) AddTagsToAllTorrents(tags []string) error {
+	infoHashes, err := mclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return mclient.AddTagsToTorrents(infoHashes, tags)
+}
+
+func (mclient *Client) RemoveTagsFromAllTorrents(tags []string) error {
+	infoHashes, err := mclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return mclient.RemoveTagsFromTorrents(infoHashes, tags)
+}
+
+func (mclient *Client) SetAllTorrentsSavePath(savePath string) error {
+	infoHashes, err := mclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return mclient.SetTorrentsSavePath(infoHashes, savePath)
+}
+
+func (mclient *Client) GetTags() ([]string, error) {
+	if err := mclient.sync(); err != nil {
+		return nil, err
+	}
+	return util.CopySlice(mclient.state.Tags), nil
+}
+
+func (mclient *Client) CreateTags(tags ...string) error {
+	return mclient.update(func(s *state) error {
+		s.Tags = util.UniqueSlice(append(s.Tags, tags...))
+		return nil
+	})
+}
+
+func (mclient *Client) DeleteTags(tags ...string) error {
+	return mclient.update(func(s *state) error {
+		notDeleted := func(tag string) bool {
+			return !slices.Contains(tags, tag)
+		}
+		s.Tags = util.Filter(s.Tags, notDeleted)
+		for _, mt := range s.Torrents {
+			mt.Tags = util.Filter(mt.Tags, notDeleted)
+		}
+		return nil
+	})
+}
+
+func (mclient *Client) MakeCategory(category string, savePath string) error {
+	return mclient.update(func(s *state) error {
+		if savePath == constants.NONE {
+			if _, ok := s.Categories[category]; ok {
+				return nil
+			}
+			savePath = ""
+		}
+		s.Categories[category] = savePath
+		return nil
+	})
+}
+
+func (mclient *Client) DeleteCategories(categories []string) error {
+	return mclient.update(func(s *state) error {
+		for _, category := range categories {
+			delete(s.Categories, category)
+		}
+		for _, mt := range s.Torrents {
+			if slices.Contains(categories, mt.Category) {
+				mt.Category = ""
+			}
+		}
+		return nil
+	})
+}
+
+func (mclient *Client) GetCategories() ([]*client.TorrentCategory, error) {
+	if err := mclient.sync(); err != nil {
+		return nil, err
+	}
+	cats := []*client.TorrentCategory{}
+	for name, savePath := range mclient.state.Categories {
+		cats = append(cats, &client.TorrentCategory{Name: name, SavePath: savePath})
+	}
+	slices.SortFunc(cats, func(a, b *client.TorrentCategory) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return cats, nil
+}
+
+func (mclient *Client) SetTorrentsCatetory(infoHashes []string, category string) error {
+	if category != "" && category != constants.NONE {
+		if err := mclient.MakeCategory(category, constants.NONE); err != nil {
+			return err
+		}
+	}
+	return mclient.updateTorrents(infoHashes, func(mt *mockTorrent) {
+		if category == constants.NONE {
+			mt.Category = ""
+		} else {
+			mt.Category = category
+		}
+	})
+}
+
+func (mclient *Client) SetAllTorrentsCatetory(category string) error {
+	infoHashes, err := mclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return mclient.SetTorrentsCatetory(infoHashes, category)
+}
+
+func (mclient *Client) SetTorrentsShareLimits(infoHashes []string, ratioLimit float64, seedingTimeLimit int64) error {
+	return mclient.updateTorrents(infoHashes, func(mt *mockTorrent) {
+		mt.RatioLimit = ratioLimit
+		mt.SeedingTimeLimit = seedingTimeLimit
+	})
+}
+
+func (mclient *Client) SetAllTorrentsShareLimits(ratioLimit float64, seedingTimeLimit int64) error {
+	infoHashes, err := mclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return mclient.SetTorrentsShareLimits(infoHashes, ratioLimit, seedingTimeLimit)
+}
+
+func (mclient *Client) TorrentRootPathExists(rootFolder string) bool {
+	if rootFolder == "" {
+		return false
+	}
+	if err := mclient.sync(); err != nil {
+		return false
+	}
+	for _, mt := range mclient.state.Torrents {
+		if mt.Name == rootFolder {
+			return true
+		}
+	}
+	return false
+}
+
+func (mclient *Client) GetTorrentContents(infoHash string) ([]*client.TorrentContentFile, error) {
+	if err := mclient.sync(); err != nil {
+		return nil, err
+	}
+	mt := mclient.state.Torrents[infoHash]
+	if mt == nil {
+		return nil, fmt.Errorf("torrent not exists")
+	}
+	files := []*client.TorrentContentFile{}
+	for _, file := range mt.Files {
+		f := *file
+		files = append(files, &f)
+	}
+	return files, nil
+}
+
+func (mclient *Client) PurgeCache() {
+	mclient.state = nil
+}
+
+func (mclient *Client) GetStatus() (*client.Status, error) {
+	if err := mclient.sync(); err != nil {
+		return nil, err
+	}
+	s := mclient.state
+	status := &client.Status{
+		FreeSpaceOnDisk:    max(mclient.diskSpace-s.usedSpace(), 0),
+		DownloadSpeedLimit: s.DownloadSpeedLimit,
+		UploadSpeedLimit:   s.UploadSpeedLimit,
+		NoAdd:              slices.Contains(s.Tags, config.NOADD_TAG),
+		NoDel:              slices.Contains(s.Tags, config.NODEL_TAG),
+	}
+	for _, mt := range s.Torrents {
+		status.DownloadSpeed += mt.DownloadSpeed
+		status.UploadSpeed += mt.UploadSpeed
+		status.UnfinishedSize += mt.Size - mt.SizeCompleted
+		if !mt.Paused {
+			status.UnfinishedDownloadingSize += mt.Size - mt.SizeCompleted
+		}
+	}
+	return status, nil
+}
+
+func (mclient *Client) GetConfig(variable string) (string, error) {
+	switch variable {
+	case "global_download_speed_limit", "global_upload_speed_limit",
+		"free_disk_space", "global_download_speed", "global_upload_speed":
+		status, err := mclient.GetStatus()
+		if err != nil {
+			return "", err
+		}
+		switch variable {
+		case "global_download_speed_limit":
+			return fmt.Sprint(status.DownloadSpeedLimit), nil
+		case "global_upload_speed_limit":
+			return fmt.Sprint(status.UploadSpeedLimit), nil
+		case "free_disk_space":
+			return fmt.Sprint(status.FreeSpaceOnDisk), nil
+		case "global_download_speed":
+			return fmt.Sprint(status.DownloadSpeed), nil
+		default:
+			return fmt.Sprint(status.UploadSpeed), nil
+		}
+	case "save_path":
+		if err := mclient.sync(); err != nil {
+			return "", err
+		}
+		return mclient.state.SavePath, nil
+	default:
+		return "", nil
+	}
+}
+
+func (mclient *Client) SetConfig(variable string, value string) error {
+	switch variable {
+	case "global_download_speed_limit":
+		return mclient.update(func(s *state) error {
+			s.DownloadSpeedLimit = max(util.ParseInt(value), 0)
+			return nil
+		})
+	case "global_upload_speed_limit":
+		return mclient.update(func(s *state) error {
+			s.UploadSpeedLimit = max(util.ParseInt(value), 0)
+			return nil
+		})
+	case "free_disk_space", "global_download_speed", "global_upload_speed":
+		return fmt.Errorf("%s is read-only", variable)
+	case "save_path":
+		return mclient.update(func(s *state) error {
+			s.SavePath = value
+			return nil
+		})
+	default:
+		return nil
+	}
+}
+
+func (mclient *Client) updateTrackers(infoHash string, update func(mt *mockTorrent) error) error {
+	return mclient.update(func(s *state) error {
+		mt := s.Torrents[infoHash]
+		if mt == nil {
+			return fmt.Errorf("torrent not exists")
+		}
+		if err := update(mt); err != nil {
+			return err
+		}
+		mt.Tracker = ""
+		mt.TrackerDomain = ""
+		mt.TrackerBaseDomain = ""
+		if len(mt.Trackers) > 0 {
+			mt.Tracker = mt.Trackers[0].Url
+			mt.TrackerDomain = util.ParseUrlHostname(mt.Tracker)
+			mt.TrackerBaseDomain = util.GetUrlDomain(mt.Tracker)
+		}
+		return nil
+	})
+}
+
+func (mclient *Client) GetTorrentTrackers(infoHash string) (client.TorrentTrackers, error) {
+	if err := mclient.sync(); err != nil {
+		return nil, err
+	}
+	mt := mclient.state.Torrents[infoHash]
+	if mt == nil {
+		return nil, fmt.Errorf("torrent not exists")
+	}
+	return slices.Clone(mt.Trackers), nil
+}
+
+func (mclient *Client) EditTorrentTracker(infoHash string, oldTracker string,
+	newTracker string, replaceHost bool) error {
+	return mclient.updateTrackers(infoHash, func(mt *mockTorrent) error {
+		index := slices.IndexFunc(mt.Trackers, func(t client.TorrentTracker) bool {
+			if replaceHost {
+				return util.MatchUrlWithHostOrUrl(t.Url, oldTracker)
+			}
+			return t.Url == oldTracker
+		})
+		if index == -1 {
+			return fmt.Errorf("torrent %s old tracker does NOT exist", infoHash)
+		}
+		newTrackerUrl := newTracker
+		if replaceHost && !util.IsUrl(newTracker) {
+			urlObj, err := url.Parse(mt.Trackers[index].Url)
+			if err != nil {
+				return err
+			}
+			urlObj.Host = newTracker
+			newTrackerUrl = urlObj.String()
+		}
+		log.Debugf("Replace torrent %s tracker %s => %s", infoHash, mt.Trackers[index].Url, newTrackerUrl)
+		mt.Trackers[index].Url = newTrackerUrl
+		return nil
+	})
+}
+
+// trackers - new trackers full URLs; oldTracker - existing tracker host or URL
+func (mclient *Client) AddTorrentTrackers(infoHash string, trackers []string,
+	oldTracker string, removeExisting bool) error {
+	return mclient.updateTrackers(infoHash, func(mt *mockTorrent) error {
+		if oldTracker != "" && !slices.ContainsFunc(mt.Trackers, func(t client.TorrentTracker) bool {
+			return util.MatchUrlWithHostOrUrl(t.Url, oldTracker)
+		}) {
+			return nil
+		}
+		if removeExisting {
+			mt.Trackers = nil
+		}
+		for _, tracker := range trackers {
+			if mt.Trackers.FindIndex(tracker) == -1 {
+				mt.Trackers = append(mt.Trackers, client.TorrentTracker{Url: tracker, Status: "working"})
+			}
+		}
+		return nil
+	})
+}
+
+func (mclient *Client) RemoveTorrentTrackers(infoHash string, trackers []string) error {
+	return mclient.updateTrackers(infoHash, func(mt *mockTorrent) error {
+		mt.Trackers = util.Filter(mt.Trackers, func(t client.TorrentTracker) bool {
+			return !slices.Contains(trackers, t.Url)
+		})
+		return nil
+	})
+}
+
+// Mock client does not have any peer.
+func (mclient *Client) GetTorrentPeers(infoHash string) ([]*client.TorrentPeer, error) {
+	if err := mclient.sync(); err != nil {
+		return nil, err
+	}
+	if mclient.state.Torrents[infoHash] == nil {
+		return nil, fmt.Errorf("torrent not exists")
+	}
+	return []*client.TorrentPeer{}, nil
+}
+
+func (mclient *Client) BanPeers(peers []string) error {
+	return mclient.update(func(s *state) error {
+		s.BannedPeers = util.UniqueSlice(append(s.BannedPeers, peers...))
+		return nil
+	})
+}
+
+// The size of torrent is updated to the sum of not ignored files.
+func (mclient *Client) SetFilePriority(infoHash string, fileIndexes []int64, priority int64) error {
+	if len(fileIndexes) == 0 {
+		return fmt.Errorf("must provide at least fileIndex")
+	}
+	return mclient.update(func(s *state) error {
+		mt := s.Torrents[infoHash]
+		if mt == nil {
+			return fmt.Errorf("torrent not exists")
+		}
+		for _, index := range fileIndexes {
+			if index < 0 || index >= int64(len(mt.Files)) {
+				return fmt.Errorf("invalid file index %d", index)
+			}
+			mt.Files[index].Ignored = priority == 0
+		}
+		size := int64(0)
+		for _, file := range mt.Files {
+			if !file.Ignored {
+				size += file.Size
+			}
+		}
+		mt.Size = size
+		mt.SizeCompleted = min(mt.SizeCompleted, size)
+		mt.updateState()
+		return nil
+	})
+}
+
+// Mock client does not do any network request, so the context is not used.
+func (mclient *Client) WithContext(ctx context.Context) client.Client {
+	return mclient
+}
+
+func (mclient *Client) Close() {
+	mclient.PurgeCache()
+}
+
+func NewClient(name string, clientConfig *config.ClientConfigStruct, config *config.ConfigStruct) (
+	client.Client, error) {
+	client := &Client{
+		Name:          name,
+		ClientConfig:  clientConfig,
+		Config:        config,
+		downloadSpeed: DEFAULT_DL_SPEED,
+		uploadSpeed:   DEFAULT_UP_SPEED,
+		diskSpace:     DEFAULT_DISK_SPACE,
+	}
+	for _, option := range []struct {
+		name  string
+		value string
+		field *int64
+	}{
+		{"mockDownloadSpeed", clientConfig.MockDownloadSpeed, &client.downloadSpeed},
+		{"mockUploadSpeed", clientConfig.MockUploadSpeed, &client.uploadSpeed},
+		{"mockDiskSpace", clientConfig.MockDiskSpace, &client.diskSpace},
+	} {
+		if option.value == "" {
+			continue
+		}
+		v, err := util.RAMInBytes(option.value)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid %s: %q", option.name, option.value)
+		}
+		*option.field = v
+	}
+	return client, nil
+}
+
+func init() {
+	client.Register(&client.RegInfo{
+		Name:    "mock",
+		Creator: NewClient,
+	})
+}
+
+var (
+	_ client.Client = (*Client)(nil)
+)
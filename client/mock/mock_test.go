@@ -0,0 +1,63 @@
+package mock
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sagan/ptool/config"
+)
+
+func TestSimulate(t *testing.T) {
+	config.ConfigDir = t.TempDir()
+	snapshot := filepath.Join(config.ConfigDir, "snapshot.json")
+	os.WriteFile(snapshot, []byte(`[
+		{"InfoHash":"h1","Name":"t1","State":"downloading","Size":1048576000,"SizeCompleted":0,"Tags":["a"]},
+		{"InfoHash":"h2","Name":"t2","State":"paused","Size":100,"SizeCompleted":50,"Category":"foo"}]`), 0600)
+	s, err := loadState("local", snapshot)
+	if err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	if len(s.Torrents) != 2 || !slices.Equal(s.Tags, []string{"a"}) || len(s.Categories) != 1 {
+		t.Fatalf("snapshot not loaded: %+v", s)
+	}
+	s.Torrents["h1"].RatioLimit = 0.5
+	// 1MiB/s: t1 completes in 1000s, then it's stopped after uploaded 500MiB.
+	s.simulate(s.Time+86400, 1024*1024, 1024*1024, DEFAULT_DISK_SPACE)
+	t1 := s.Torrents["h1"]
+	if t1.SizeCompleted != t1.Size || t1.Ctime <= 0 || t1.State != "completed" || t1.Ratio < 0.5 {
+		t.Errorf("unexpected t1: %+v", t1.Torrent)
+	}
+	if t2 := s.Torrents["h2"]; t2.SizeCompleted != 50 || t2.State != "paused" {
+		t.Errorf("paused torrent should not progress: %+v", t2.Torrent)
+	}
+}
+
+func TestPersistState(t *testing.T) {
+	config.ConfigDir = t.TempDir()
+	clientConfig := &config.ClientConfigStruct{MockDiskSpace: "1GiB"}
+	clientInstance, err := NewClient("local", clientConfig, &config.ConfigStruct{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	magnet := "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=test"
+	if err = clientInstance.AddTorrentUrl(magnet, nil, nil); err != nil {
+		t.Fatalf("AddTorrentUrl: %v", err)
+	}
+	if err = clientInstance.MakeCategory("movie", "/movie"); err != nil {
+		t.Fatalf("MakeCategory: %v", err)
+	}
+	clientInstance.Close()
+	clientInstance, _ = NewClient("local", clientConfig, &config.ConfigStruct{})
+	torrent, err := clientInstance.GetTorrent("0123456789abcdef0123456789abcdef01234567")
+	if err != nil || torrent == nil || torrent.Name != "test" {
+		t.Errorf("torrent not persisted: %v, %v", torrent, err)
+	}
+	if categories, _ := clientInstance.GetCategories(); len(categories) != 1 || categories[0].SavePath != "/movie" {
+		t.Errorf("category not persisted: %v", categories)
+	}
+	if status, _ := clientInstance.GetStatus(); status == nil || status.FreeSpaceOnDisk != 1024*1024*1024 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
@@ -0,0 +1,265 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"time"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/util"
+)
+
+const (
+	SIMULATION_STEP      = 60       // seconds of each simulation step
+	SIMULATION_MAX_STEPS = 10000    // if offline for a long time, enlarge the step
+	UPLOAD_HALF_LIFE     = 6 * 3600 // upload speed of a torrent halves every this seconds since added
+	ACTIVE_SPEED         = 1024     // torrents of lower speed are considered inactive
+	DEFAULT_SAVE_PATH    = "/downloads"
+	DEFAULT_DISK_SPACE   = int64(1024 * 1024 * 1024 * 1024)
+	DEFAULT_DL_SPEED     = int64(10 * 1024 * 1024)
+	DEFAULT_UP_SPEED     = int64(5 * 1024 * 1024)
+)
+
+type mockTorrent struct {
+	client.Torrent
+	Paused           bool                         `json:"paused"`
+	RatioLimit       float64                      `json:"ratio_limit"`
+	SeedingTimeLimit int64                        `json:"seeding_time_limit"`
+	MaxDownloadSpeed int64                        `json:"max_download_speed"` // simulated peak speed of torrent
+	MaxUploadSpeed   int64                        `json:"max_upload_speed"`
+	Files            []*client.TorrentContentFile `json:"files"`
+	Trackers         client.TorrentTrackers       `json:"trackers"`
+	Content          []byte                       `json:"content"` // .torrent file contents, if available
+}
+
+// The state of mock client is persisted in a local store file in config dir.
+type state struct {
+	filename           string
+	Time               int64                   `json:"time"` // timestamp the state was last simulated to
+	Torrents           map[string]*mockTorrent `json:"torrents"`
+	Tags               []string                `json:"tags"`
+	Categories         map[string]string       `json:"categories"` // category name => save path
+	BannedPeers        []string                `json:"banned_peers"`
+	SavePath           string                  `json:"save_path"`
+	DownloadSpeedLimit int64                   `json:"download_speed_limit"` // <= 0 means no limit
+	UploadSpeedLimit   int64                   `json:"upload_speed_limit"`
+}
+
+// Load state of mock client from file. If the file does not exist, initialize it from snapshot (if configured).
+func loadState(clientName string, snapshot string) (*state, error) {
+	s := &state{
+		filename:   client.StoreFilename(clientName, "mock"),
+		Time:       time.Now().Unix(),
+		Torrents:   map[string]*mockTorrent{},
+		Categories: map[string]string{},
+		SavePath:   DEFAULT_SAVE_PATH,
+	}
+	exists, err := client.LoadStore(s.filename, s)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if snapshot != "" {
+			if err = s.loadSnapshot(snapshot); err != nil {
+				return nil, fmt.Errorf("failed to load snapshot: %w", err)
+			}
+		}
+		return s, nil
+	}
+	if s.Torrents == nil {
+		s.Torrents = map[string]*mockTorrent{}
+	}
+	if s.Categories == nil {
+		s.Categories = map[string]string{}
+	}
+	return s, nil
+}
+
+// Snapshot is a json array of torrents, e.g. the output of "ptool show <client> --json" of a real client.
+func (s *state) loadSnapshot(snapshot string) error {
+	contents, err := os.ReadFile(snapshot)
+	if err != nil {
+		return err
+	}
+	var torrents []*client.Torrent
+	if err = json.Unmarshal(contents, &torrents); err != nil {
+		return err
+	}
+	for _, torrent := range torrents {
+		if torrent.InfoHash == "" {
+			continue
+		}
+		mt := &mockTorrent{
+			Torrent:          *torrent,
+			Paused:           torrent.State == "paused" || torrent.State == "completed",
+			MaxDownloadSpeed: max(torrent.DownloadSpeed, speedOf(torrent.InfoHash, DEFAULT_DL_SPEED)),
+			MaxUploadSpeed:   max(torrent.UploadSpeed, speedOf(torrent.InfoHash+"up", DEFAULT_UP_SPEED)),
+			Files: []*client.TorrentContentFile{{
+				Path: torrent.Name,
+				Size: torrent.Size,
+			}},
+		}
+		if mt.Atime <= 0 {
+			mt.Atime = s.Time
+		}
+		if torrent.Tracker != "" {
+			mt.Trackers = client.TorrentTrackers{{Url: torrent.Tracker, Status: "working"}}
+		}
+		if torrent.Category != "" {
+			if _, ok := s.Categories[torrent.Category]; !ok {
+				s.Categories[torrent.Category] = ""
+			}
+		}
+		s.Tags = util.UniqueSlice(append(s.Tags, torrent.Tags...))
+		mt.updateState()
+		s.Torrents[torrent.InfoHash] = mt
+	}
+	return nil
+}
+
+func (s *state) save() error {
+	return client.SaveStore(s.filename, s)
+}
+
+// Return a stable pseudo-random speed in [0.1, 1] * maxSpeed for key.
+func speedOf(key string, maxSpeed int64) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int64(float64(maxSpeed) * (0.1 + 0.9*float64(h.Sum32()%1000)/999))
+}
+
+func (mt *mockTorrent) isComplete() bool {
+	return mt.Size > 0 && mt.SizeCompleted >= mt.Size
+}
+
+func (mt *mockTorrent) updateState() {
+	switch {
+	case mt.Paused && mt.isComplete():
+		mt.State = "completed"
+	case mt.Paused:
+		mt.State = "paused"
+	case mt.isComplete():
+		mt.State = "seeding"
+	default:
+		mt.State = "downloading"
+	}
+	mt.LowLevelState = mt.State
+	if mt.Downloaded > 0 {
+		mt.Ratio = float64(mt.Uploaded) / float64(mt.Downloaded)
+	} else if mt.Size > 0 {
+		mt.Ratio = float64(mt.Uploaded) / float64(mt.Size)
+	}
+	if !mt.isComplete() {
+		mt.Ctime = -1
+	}
+	for _, file := range mt.Files {
+		if mt.Size > 0 {
+			file.Progress = float64(mt.SizeCompleted) / float64(mt.Size)
+		}
+		if file.Ignored {
+			file.Progress = 0
+		}
+		file.Complete = !file.Ignored && mt.isComplete()
+		if file.Complete {
+			file.Progress = 1
+		}
+	}
+}
+
+// Distribute the capacity among torrents according to their wanted speeds.
+func distribute(wanted map[string]int64, capacity int64) map[string]int64 {
+	sum := int64(0)
+	for _, speed := range wanted {
+		sum += speed
+	}
+	if capacity <= 0 || sum <= capacity {
+		return wanted
+	}
+	ratio := float64(capacity) / float64(sum)
+	for infoHash, speed := range wanted {
+		wanted[infoHash] = int64(float64(speed) * ratio)
+	}
+	return wanted
+}
+
+// Simulate downloading & uploading of torrents from s.Time to now.
+// downloadSpeed & uploadSpeed are the simulated bandwidth of network; diskSpace is the simulated disk size.
+func (s *state) simulate(now int64, downloadSpeed int64, uploadSpeed int64, diskSpace int64) {
+	if now <= s.Time {
+		return
+	}
+	step := max(int64(SIMULATION_STEP), (now-s.Time)/SIMULATION_MAX_STEPS+1)
+	downloadCapacity := downloadSpeed
+	if s.DownloadSpeedLimit > 0 {
+		downloadCapacity = min(downloadCapacity, s.DownloadSpeedLimit)
+	}
+	uploadCapacity := uploadSpeed
+	if s.UploadSpeedLimit > 0 {
+		uploadCapacity = min(uploadCapacity, s.UploadSpeedLimit)
+	}
+	for s.Time < now {
+		duration := min(step, now-s.Time)
+		freeSpace := diskSpace - s.usedSpace()
+		downloadSpeeds := map[string]int64{}
+		uploadSpeeds := map[string]int64{}
+		for infoHash, mt := range s.Torrents {
+			if mt.Paused {
+				continue
+			}
+			if !mt.isComplete() && mt.Size > 0 {
+				speed := mt.MaxDownloadSpeed
+				if mt.DownloadSpeedLimit > 0 {
+					speed = min(speed, mt.DownloadSpeedLimit)
+				}
+				downloadSpeeds[infoHash] = speed
+			}
+			if mt.SizeCompleted > 0 {
+				age := float64(s.Time - mt.Atime)
+				speed := int64(float64(mt.MaxUploadSpeed) * math.Pow(0.5, max(age, 0)/UPLOAD_HALF_LIFE))
+				if mt.UploadedSpeedLimit > 0 {
+					speed = min(speed, mt.UploadedSpeedLimit)
+				}
+				uploadSpeeds[infoHash] = speed
+			}
+		}
+		downloadSpeeds = distribute(downloadSpeeds, downloadCapacity)
+		uploadSpeeds = distribute(uploadSpeeds, uploadCapacity)
+		s.Time += duration
+		for infoHash, mt := range s.Torrents {
+			mt.DownloadSpeed = downloadSpeeds[infoHash]
+			mt.UploadSpeed = uploadSpeeds[infoHash]
+			downloaded := min(mt.DownloadSpeed*duration, mt.Size-mt.SizeCompleted, max(freeSpace, 0))
+			if downloaded > 0 {
+				freeSpace -= downloaded
+				mt.SizeCompleted += downloaded
+				mt.Downloaded += downloaded
+				if mt.isComplete() {
+					mt.Ctime = s.Time
+				}
+			}
+			mt.Uploaded += mt.UploadSpeed * duration
+			if downloaded > 0 || mt.UploadSpeed > 0 {
+				mt.ActivityTime = s.Time
+			}
+			mt.updateState()
+			if !mt.Paused && mt.isComplete() &&
+				(mt.RatioLimit > 0 && mt.Ratio >= mt.RatioLimit ||
+					mt.SeedingTimeLimit > 0 && s.Time-mt.Ctime >= mt.SeedingTimeLimit) {
+				mt.Paused = true
+				mt.DownloadSpeed = 0
+				mt.UploadSpeed = 0
+				mt.updateState()
+			}
+		}
+	}
+}
+
+func (s *state) usedSpace() (size int64) {
+	for _, mt := range s.Torrents {
+		size += mt.SizeCompleted
+	}
+	return size
+}
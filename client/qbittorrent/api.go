@@ -1,10 +1,26 @@
 package qbittorrent
 
 import (
+	"encoding/json"
+	"strings"
+
 	"github.com/sagan/ptool/client"
 	"github.com/sagan/ptool/util"
 )
 
+const (
+	// The assumed WebUI API version if it can not be detected
+	QB_LEGACY_API_VERSION = "2.0"
+	// qb 5.0+ WebUI API version. It renamed torrents/pause & torrents/resume to torrents/stop & torrents/start,
+	// the "paused" param of torrents/add to "stopped", and pausedUP & pausedDL torrent states to stoppedUP & stoppedDL.
+	QB_V5_API_VERSION = "2.11.0"
+)
+
+// Preferences renamed in qb 5.0+. legacy name => qb 5.0+ name
+var qbV5Preferences = map[string]string{
+	"start_paused_enabled": "add_stopped_enabled",
+}
+
 type apiTorrentTracker struct {
 	Url string `yaml:"url"` // Tracker url
 	// Tracker status.
@@ -40,6 +56,43 @@ type apiSyncMaindata struct {
 	Torrents     map[string]*apiTorrentInfo         `json:"torrents"`
 }
 
+// Response of api/v2/sync/maindata?rid=<rid>. If full_update is false, it only contains the changes since rid:
+// the changed fields of changed torrents / server_state, the added / changed categories & tags and the removed ones.
+type apiSyncMaindataUpdate struct {
+	Rid                int64                      `json:"rid"`
+	Full_update        bool                       `json:"full_update"`
+	Server_state       json.RawMessage            `json:"server_state"`
+	Tags               []string                   `json:"tags"`
+	Tags_removed       []string                   `json:"tags_removed"`
+	Categories         map[string]json.RawMessage `json:"categories"`
+	Categories_removed []string                   `json:"categories_removed"`
+	Torrents           map[string]json.RawMessage `json:"torrents"`
+	Torrents_removed   []string                   `json:"torrents_removed"`
+}
+
+// Response of api/v2/sync/torrentPeers
+type apiSyncTorrentPeers struct {
+	Rid         int64                      `json:"rid"`
+	Full_update bool                       `json:"full_update"`
+	Peers       map[string]*apiTorrentPeer `json:"peers"` // key: "ip:port"
+}
+
+type apiTorrentPeer struct {
+	Ip           string  `json:"ip"`
+	Port         int64   `json:"port"`
+	Client       string  `json:"client"`
+	Connection   string  `json:"connection"` // "BT" | "μTP" | "Web"
+	Country      string  `json:"country"`
+	Country_code string  `json:"country_code"`
+	Dl_speed     int64   `json:"dl_speed"`
+	Up_speed     int64   `json:"up_speed"`
+	Downloaded   int64   `json:"downloaded"`
+	Uploaded     int64   `json:"uploaded"`
+	Flags        string  `json:"flags"` // e.g. "D U K I E P"
+	Progress     float64 `json:"progress"`
+	Relevance    float64 `json:"relevance"`
+}
+
 type apiTransferInfo struct {
 	Free_space_on_disk int64  `json:"free_space_on_disk"`
 	Dl_info_speed      int64  `json:"dl_info_speed"`     //Global download rate (bytes/s)
@@ -140,6 +193,7 @@ type apiPreferences struct {
 	Locale                                 string         `json:"locale"`                                 // Currently selected language (e.g. en_GB for English)
 	Create_subfolder_enabled               bool           `json:"create_subfolder_enabled"`               // True if a subfolder should be created when adding a torrent
 	Start_paused_enabled                   bool           `json:"start_paused_enabled"`                   // True if torrents should be added in a Paused state
+	Add_stopped_enabled                    bool           `json:"add_stopped_enabled"`                    // qb 5.0+ replacement of start_paused_enabled
 	Auto_delete_mode                       int64          `json:"auto_delete_mode"`                       // TODO
 	Preallocate_all                        bool           `json:"preallocate_all"`                        // True if disk space should be pre-allocated for all files
 	Incomplete_files_ext                   bool           `json:"incomplete_files_ext"`                   // True if ".!qB" should be appended to incomplete files
@@ -285,8 +339,50 @@ type apiPreferences struct {
 	Utp_tcp_mixed_mode                     int64          `json:"utp_tcp_mixed_mode"`                     // μTP-TCP mixed mode algorithm (see list of possible values below)
 }
 
+// Return true if WebUI API version >= minVersion. Versions are in "major.minor.patch" format.
+func apiVersionAtLeast(version string, minVersion string) bool {
+	parts := strings.Split(version, ".")
+	minParts := strings.Split(minVersion, ".")
+	for i := range max(len(parts), len(minParts)) {
+		v, minV := int64(0), int64(0)
+		if i < len(parts) {
+			v = util.ParseInt(parts[i])
+		}
+		if i < len(minParts) {
+			minV = util.ParseInt(minParts[i])
+		}
+		if v != minV {
+			return v > minV
+		}
+	}
+	return true
+}
+
+func (peer *apiTorrentPeer) ToPeer(address string) *client.TorrentPeer {
+	return &client.TorrentPeer{
+		Address:       address,
+		Ip:            peer.Ip,
+		Port:          peer.Port,
+		Client:        peer.Client,
+		Progress:      peer.Progress,
+		DownloadSpeed: peer.Dl_speed,
+		UploadSpeed:   peer.Up_speed,
+		Flags:         peer.Flags,
+		Country:       strings.ToUpper(peer.Country_code),
+	}
+}
+
 func (qt *apiTorrentInfo) CanResume() bool {
-	return qt.State == "pausedUP" || qt.State == "pausedDL" || qt.State == "queuedUP" || qt.State == "queuedDL" || qt.State == "error"
+	switch qt.State {
+	case "pausedUP", "pausedDL", "stoppedUP", "stoppedDL", "queuedUP", "queuedDL", "error":
+		return true
+	}
+	return false
+}
+
+// Return true if torrent is paused (stopped) and incomplete. State is "pausedDL" (qb 4.x) or "stoppedDL" (qb 5.x).
+func (qt *apiTorrentInfo) IsStoppedDL() bool {
+	return qt.State == "pausedDL" || qt.State == "stoppedDL"
 }
 
 func (qt *apiTorrentInfo) CanPause() bool {
@@ -300,9 +396,9 @@ func (qbtorrent *apiTorrentInfo) ToTorrentState() string {
 		state = "seeding"
 	case "metaDL", "allocating", "stalledDL", "queuedDL", "forcedDL", "downloading":
 		state = "downloading"
-	case "pausedUP":
+	case "pausedUP", "stoppedUP":
 		state = "completed"
-	case "pausedDL":
+	case "pausedDL", "stoppedDL":
 		state = "paused"
 	case "checkingUP", "checkingDL", "checkingResumeData":
 		state = "checking"
@@ -4,12 +4,13 @@ package qbittorrent
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net"
 	"net/http"
-	"net/http/cookiejar"
 	"net/textproto"
 	"net/url"
 	"reflect"
@@ -26,13 +27,20 @@ import (
 )
 
 type Client struct {
+	*clientData
+	ctx context.Context // see client.Client.WithContext
+}
+
+type clientData struct {
 	Name                      string
 	ClientConfig              *config.ClientConfigStruct
 	Config                    *config.ConfigStruct
 	HttpClient                *http.Client
 	data                      *apiSyncMaindata
+	rid                       int64 // rid of last maindata response, 0 if data is empty
 	preferences               *apiPreferences
 	Logined                   bool
+	apiVersion                string // WebUI API version, e.g. "2.11.2". Detected at login
 	datatime                  int64
 	unfinishedSize            int64
 	unfinishedDownloadingSize int64
@@ -76,8 +84,26 @@ func (qbclient *Client) SetTorrentsShareLimits(infoHashes []string, ratioLimit f
 	return qbclient.apiPost("api/v2/torrents/setShareLimits", data)
 }
 
+func (qbclient *Client) WithContext(ctx context.Context) client.Client {
+	return &Client{clientData: qbclient.clientData, ctx: ctx}
+}
+
+// Do a request to qb Web API, bound to the context of client instance.
+func (qbclient *Client) request(method string, apiPath string, contentType string,
+	body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(qbclient.ctx, method, qbclient.ClientConfig.Url+apiPath, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return qbclient.HttpClient.Do(req)
+}
+
 func (qbclient *Client) apiPost(apiUrl string, data url.Values) error {
-	resp, err := qbclient.HttpClient.PostForm(qbclient.ClientConfig.Url+apiUrl, data)
+	resp, err := qbclient.request(http.MethodPost, apiUrl, "application/x-www-form-urlencoded",
+		strings.NewReader(data.Encode()))
 	if err != nil {
 		return err
 	}
@@ -96,7 +122,7 @@ func (qbclient *Client) apiPost(apiUrl string, data url.Values) error {
 }
 
 func (qbclient *Client) apiRequest(apiPath string, v any) error {
-	resp, err := qbclient.HttpClient.Get(qbclient.ClientConfig.Url + apiPath)
+	resp, err := qbclient.request(http.MethodGet, apiPath, "", nil)
 	if err != nil {
 		return err
 	}
@@ -115,10 +141,47 @@ func (qbclient *Client) apiRequest(apiPath string, v any) error {
 	}
 }
 
+// qb 5.0+
+func (qbclient *Client) isApiV5() bool {
+	return apiVersionAtLeast(qbclient.apiVersion, QB_V5_API_VERSION)
+}
+
+// Get plain text response of a GET api.
+func (qbclient *Client) apiRequestText(apiPath string) (string, error) {
+	resp, err := qbclient.request(http.MethodGet, apiPath, "", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("apiRequest %s response %d status", apiPath, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// Login (if required) and detect WebUI API version.
 func (qbclient *Client) login() error {
-	if qbclient.Logined || qbclient.ClientConfig.QbittorrentNoLogin {
-		return nil
+	if !qbclient.Logined && !qbclient.ClientConfig.QbittorrentNoLogin {
+		if err := qbclient.auth(); err != nil {
+			return err
+		}
+	}
+	if qbclient.apiVersion == "" {
+		version, err := qbclient.apiRequestText("api/v2/app/webapiVersion")
+		if err != nil || version == "" {
+			log.Debugf("Failed to get qb webapi version (%v), assume it's qb 4.x", err)
+			version = QB_LEGACY_API_VERSION
+		}
+		qbclient.apiVersion = version
 	}
+	return nil
+}
+
+func (qbclient *Client) auth() error {
 	username := qbclient.ClientConfig.Username
 	password := qbclient.ClientConfig.Password
 	// use qb default
@@ -176,7 +239,11 @@ func (qbclient *Client) AddTorrent(torrentContent []byte, option *client.Torrent
 			mp.WriteField("category", option.Category)
 		}
 		mp.WriteField("tags", strings.Join(option.Tags, ",")) // qb 4.3.2+ new
-		mp.WriteField("paused", fmt.Sprint(option.Pause))
+		if qbclient.isApiV5() {
+			mp.WriteField("stopped", fmt.Sprint(option.Pause))
+		} else {
+			mp.WriteField("paused", fmt.Sprint(option.Pause))
+		}
 		mp.WriteField("upLimit", fmt.Sprint(option.UploadSpeedLimit))
 		mp.WriteField("dlLimit", fmt.Sprint(option.DownloadSpeedLimit))
 		if option.SavePath != "" {
@@ -201,8 +268,7 @@ func (qbclient *Client) AddTorrent(torrentContent []byte, option *client.Torrent
 		}
 	}
 	mp.Close()
-	resp, err := qbclient.HttpClient.Post(qbclient.ClientConfig.Url+"api/v2/torrents/add",
-		mp.FormDataContentType(), body)
+	resp, err := qbclient.request(http.MethodPost, "api/v2/torrents/add", mp.FormDataContentType(), body)
 	if err != nil {
 		return fmt.Errorf("add torrent error: %w", err)
 	}
@@ -213,6 +279,58 @@ func (qbclient *Client) AddTorrent(torrentContent []byte, option *client.Torrent
 	return err
 }
 
+func (qbclient *Client) AddTorrentUrl(torrentUrl string, option *client.TorrentOption,
+	meta map[string]int64) error {
+	if !util.IsTorrentUrl(torrentUrl) {
+		return fmt.Errorf("invalid torrent url: %s", torrentUrl)
+	}
+	// qb applies all options (including rename) to magnet torrents when metadata is resolved.
+	return qbclient.AddTorrent([]byte(torrentUrl), option, meta)
+}
+
+func (qbclient *Client) GetTorrentPeers(infoHash string) ([]*client.TorrentPeer, error) {
+	err := qbclient.login()
+	if err != nil {
+		return nil, fmt.Errorf("login error: %w", err)
+	}
+	data := &apiSyncTorrentPeers{}
+	err = qbclient.apiRequest("api/v2/sync/torrentPeers?rid=0&hash="+infoHash, data)
+	if err != nil {
+		return nil, err
+	}
+	peers := []*client.TorrentPeer{}
+	for address, peer := range data.Peers {
+		peers = append(peers, peer.ToPeer(address))
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Address < peers[j].Address
+	})
+	return peers, nil
+}
+
+// qb 4.2+
+func (qbclient *Client) BanPeers(peers []string) error {
+	if len(peers) == 0 {
+		return nil
+	}
+	err := qbclient.login()
+	if err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	addresses := []string{}
+	for _, peer := range peers {
+		// qb requires "ip:port" format but actually only bans the ip
+		if _, _, err := net.SplitHostPort(peer); err != nil {
+			peer = net.JoinHostPort(peer, "0")
+		}
+		addresses = append(addresses, peer)
+	}
+	data := url.Values{
+		"peers": {strings.Join(addresses, "|")},
+	}
+	return qbclient.apiPost("api/v2/transfer/banPeers", data)
+}
+
 func (qbclient *Client) PauseTorrents(infoHashes []string) error {
 	if len(infoHashes) == 0 {
 		return nil
@@ -224,6 +342,9 @@ func (qbclient *Client) PauseTorrents(infoHashes []string) error {
 	data := url.Values{
 		"hashes": {strings.Join(infoHashes, "|")},
 	}
+	if qbclient.isApiV5() {
+		return qbclient.apiPost("api/v2/torrents/stop", data)
+	}
 	return qbclient.apiPost("api/v2/torrents/pause", data)
 }
 
@@ -238,6 +359,9 @@ func (qbclient *Client) ResumeTorrents(infoHashes []string) error {
 	data := url.Values{
 		"hashes": {strings.Join(infoHashes, "|")},
 	}
+	if qbclient.isApiV5() {
+		return qbclient.apiPost("api/v2/torrents/start", data)
+	}
 	return qbclient.apiPost("api/v2/torrents/resume", data)
 }
 
@@ -465,9 +589,11 @@ func (qbclient *Client) DeleteTorrents(infoHashes []string, deleteFiles bool) (e
 	err = qbclient.apiPost("api/v2/torrents/delete", data)
 	if err == nil && qbclient.Cached() {
 		for _, infoHash := range infoHashes {
-			delete(qbclient.data.Torrents, infoHash)
+			if torrent := qbclient.data.Torrents[infoHash]; torrent != nil {
+				qbclient.removeDerivative(torrent)
+				delete(qbclient.data.Torrents, infoHash)
+			}
 		}
-		qbclient.buildDerivative()
 	}
 	return
 }
@@ -605,12 +731,20 @@ func (qbclient *Client) ModifyTorrent(infoHash string,
 	return nil
 }
 
+// Mark the cached data as stale. The data itself (and the rid) is kept,
+// so the next sync only fetches the changes since then.
 func (qbclient *Client) PurgeCache() {
-	qbclient.data = nil
 	qbclient.preferences = nil
+	qbclient.datatime = 0
+}
+
+// Drop all cached data. The next sync will do a full update.
+func (qbclient *Client) resetCache() {
+	qbclient.PurgeCache()
+	qbclient.data = nil
+	qbclient.rid = 0
 	qbclient.unfinishedSize = 0
 	qbclient.unfinishedDownloadingSize = 0
-	qbclient.datatime = 0
 	qbclient.contentPathTorrents = nil
 }
 
@@ -626,33 +760,127 @@ func (qbclient *Client) sync() error {
 	if err != nil {
 		return fmt.Errorf("login error: %w", err)
 	}
-	err = qbclient.apiRequest("api/v2/sync/maindata", &qbclient.data)
+	rid := int64(0)
+	if qbclient.data != nil {
+		rid = qbclient.rid
+	}
+	var update *apiSyncMaindataUpdate
+	err = qbclient.apiRequest("api/v2/sync/maindata?rid="+fmt.Sprint(rid), &update)
 	if err != nil {
 		return err
 	}
+	if update == nil {
+		return fmt.Errorf("empty maindata response")
+	}
+	if err = qbclient.applyMaindataUpdate(update); err != nil {
+		// cached data is in unknown state now, fetch all again next time.
+		qbclient.resetCache()
+		return fmt.Errorf("failed to apply maindata: %w", err)
+	}
 	qbclient.datatime = util.Now()
-	qbclient.buildDerivative()
 	return nil
 }
 
-func (qbclient *Client) buildDerivative() {
-	unfinishedSize := int64(0)
-	unfinishedDownloadingSize := int64(0)
-	contentPathTorrents := map[string][]*apiTorrentInfo{}
-	// make hash available in torrent itself as well as map key
-	for hash, torrent := range qbclient.data.Torrents {
+// Apply a (full or partial) maindata response to cached data.
+func (qbclient *Client) applyMaindataUpdate(update *apiSyncMaindataUpdate) error {
+	if update.Full_update || qbclient.data == nil {
+		qbclient.data = &apiSyncMaindata{
+			Server_state: &apiTransferInfo{},
+			Categories:   map[string]*client.TorrentCategory{},
+			Torrents:     map[string]*apiTorrentInfo{},
+		}
+		qbclient.buildDerivative()
+	}
+	data := qbclient.data
+	if len(update.Server_state) > 0 {
+		if err := json.Unmarshal(update.Server_state, data.Server_state); err != nil {
+			return fmt.Errorf("invalid server_state: %w", err)
+		}
+	}
+	for _, tag := range update.Tags {
+		if !slices.Contains(data.Tags, tag) {
+			data.Tags = append(data.Tags, tag)
+		}
+	}
+	if len(update.Tags_removed) > 0 {
+		data.Tags = util.Filter(data.Tags, func(tag string) bool {
+			return !slices.Contains(update.Tags_removed, tag)
+		})
+	}
+	for name, raw := range update.Categories {
+		category := data.Categories[name]
+		if category == nil {
+			category = &client.TorrentCategory{}
+			data.Categories[name] = category
+		}
+		if err := json.Unmarshal(raw, category); err != nil {
+			return fmt.Errorf("invalid category %s: %w", name, err)
+		}
+	}
+	for _, name := range update.Categories_removed {
+		delete(data.Categories, name)
+	}
+	for hash, raw := range update.Torrents {
+		torrent := data.Torrents[hash]
+		if torrent == nil {
+			torrent = &apiTorrentInfo{}
+			data.Torrents[hash] = torrent
+		} else {
+			qbclient.removeDerivative(torrent)
+		}
+		// only the changed fields are present in a partial update, others keep the old values.
+		if err := json.Unmarshal(raw, torrent); err != nil {
+			return fmt.Errorf("invalid torrent %s: %w", hash, err)
+		}
+		// make hash available in torrent itself as well as map key
 		torrent.Hash = hash
-		qbclient.data.Torrents[hash] = torrent
-		usize := torrent.Size - torrent.Completed
-		unfinishedSize += usize
-		if torrent.State != "pausedDL" {
-			unfinishedDownloadingSize += usize
+		qbclient.addDerivative(torrent)
+	}
+	for _, hash := range update.Torrents_removed {
+		if torrent := data.Torrents[hash]; torrent != nil {
+			qbclient.removeDerivative(torrent)
+			delete(data.Torrents, hash)
 		}
-		contentPathTorrents[torrent.Content_path] = append(contentPathTorrents[torrent.Content_path], torrent)
 	}
-	qbclient.unfinishedSize = unfinishedSize
-	qbclient.unfinishedDownloadingSize = unfinishedDownloadingSize
-	qbclient.contentPathTorrents = contentPathTorrents
+	qbclient.rid = update.Rid
+	return nil
+}
+
+func (qbclient *Client) buildDerivative() {
+	qbclient.unfinishedSize = 0
+	qbclient.unfinishedDownloadingSize = 0
+	qbclient.contentPathTorrents = map[string][]*apiTorrentInfo{}
+	for _, torrent := range qbclient.data.Torrents {
+		qbclient.addDerivative(torrent)
+	}
+}
+
+// Add torrent to the derivative data.
+func (qbclient *Client) addDerivative(torrent *apiTorrentInfo) {
+	usize := torrent.Size - torrent.Completed
+	qbclient.unfinishedSize += usize
+	if !torrent.IsStoppedDL() {
+		qbclient.unfinishedDownloadingSize += usize
+	}
+	qbclient.contentPathTorrents[torrent.Content_path] =
+		append(qbclient.contentPathTorrents[torrent.Content_path], torrent)
+}
+
+// Remove torrent from the derivative data. Must be called before torrent is changed.
+func (qbclient *Client) removeDerivative(torrent *apiTorrentInfo) {
+	usize := torrent.Size - torrent.Completed
+	qbclient.unfinishedSize -= usize
+	if !torrent.IsStoppedDL() {
+		qbclient.unfinishedDownloadingSize -= usize
+	}
+	torrents := util.Filter(qbclient.contentPathTorrents[torrent.Content_path], func(t *apiTorrentInfo) bool {
+		return t != torrent
+	})
+	if len(torrents) > 0 {
+		qbclient.contentPathTorrents[torrent.Content_path] = torrents
+	} else {
+		delete(qbclient.contentPathTorrents, torrent.Content_path)
+	}
 }
 
 func (qbclient *Client) TorrentRootPathExists(rootFolder string) bool {
@@ -685,9 +913,9 @@ func (qbclient *Client) GetStatus() (*client.Status, error) {
 	status.UnfinishedSize = qbclient.unfinishedSize
 	status.UnfinishedDownloadingSize = qbclient.unfinishedDownloadingSize
 	// @workaround
-	// qb 的 Web API 有 bug，有时 FreeSpaceOnDisk 返回 0，但实际硬盘剩余空间充足，原因尚不明确。
-	// 目前在 Windows QB 4.5.2 上发现此现象。
-	if status.FreeSpaceOnDisk == 0 {
+	// qb 4.x 的 Web API 有 bug，有时 FreeSpaceOnDisk 返回 0，但实际硬盘剩余空间充足，原因尚不明确。
+	// 目前在 Windows QB 4.5.2 上发现此现象。qb 5.x 返回的 0 视为真实值。
+	if !qbclient.isApiV5() && status.FreeSpaceOnDisk == 0 {
 		hasDownloadingTorrent := false
 		hasErrorTorrent := false
 		for _, qbtorrent := range qbclient.data.Torrents {
@@ -723,9 +951,30 @@ func (qbclient *Client) setPreferences(preferences map[string]any) error {
 	}
 	// setPreferences qb API expects a "raw" form data (without %XX escapes)
 	dataStr := "json=" + string(data)
-	_, err = qbclient.HttpClient.Post(qbclient.ClientConfig.Url+"api/v2/app/setPreferences",
+	resp, err := qbclient.request(http.MethodPost, "api/v2/app/setPreferences",
 		"application/x-www-form-urlencoded", strings.NewReader(dataStr))
-	return err
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Return the version-specific name of preference. Some preferences are renamed in qb 5.0+,
+// the name of either version is accepted.
+func (qbclient *Client) preferenceName(name string) string {
+	if qbclient.isApiV5() {
+		if v5name := qbV5Preferences[name]; v5name != "" {
+			return v5name
+		}
+		return name
+	}
+	for legacyName, v5name := range qbV5Preferences {
+		if v5name == name {
+			return legacyName
+		}
+	}
+	return name
 }
 
 func (qbclient *Client) getPreferences() (*apiPreferences, error) {
@@ -750,7 +999,8 @@ func (qbclient *Client) GetConfig(variable string) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		value := reflect.Indirect(reflect.ValueOf(preferences)).FieldByName(util.Capitalize(variable[3:])).Interface()
+		name := util.Capitalize(qbclient.preferenceName(variable[3:]))
+		value := reflect.Indirect(reflect.ValueOf(preferences)).FieldByName(name).Interface()
 		return fmt.Sprint(value), nil
 	}
 
@@ -799,7 +1049,7 @@ func (qbclient *Client) SetConfig(variable string, value string) error {
 	}
 	if strings.HasPrefix(variable, "qb_") && len(variable) > 3 {
 		data := map[string]any{}
-		data[variable[3:]], _ = util.String2Any(value)
+		data[qbclient.preferenceName(variable[3:])], _ = util.String2Any(value)
 		return qbclient.setPreferences(data)
 	}
 	switch variable {
@@ -833,7 +1083,7 @@ func (qbclient *Client) SetConfig(variable string, value string) error {
 // See https://github.com/qbittorrent/qBittorrent/issues/18746 for more info.
 func (qbclient *Client) ExportTorrentFile(infoHash string) ([]byte, error) {
 	apiUrl := qbclient.ClientConfig.Url + "api/v2/torrents/export?hash=" + infoHash
-	res, _, err := util.FetchUrl(apiUrl, qbclient.HttpClient, nil)
+	res, _, err := util.FetchUrlContext(qbclient.ctx, apiUrl, qbclient.HttpClient, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -889,7 +1139,7 @@ func (qbclient *Client) GetTorrentContents(infoHash string) ([]*client.TorrentCo
 	}
 	apiUrl := qbclient.ClientConfig.Url + "api/v2/torrents/files?hash=" + infoHash
 	var qbTorrentContents []apiTorrentContent
-	err = util.FetchJson(apiUrl, &qbTorrentContents, qbclient.HttpClient, nil)
+	err = util.FetchJsonContext(qbclient.ctx, apiUrl, &qbTorrentContents, qbclient.HttpClient, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -917,7 +1167,7 @@ func (qbclient *Client) GetTorrentTrackers(infoHash string) (client.TorrentTrack
 	}
 	apiUrl := qbclient.ClientConfig.Url + "api/v2/torrents/trackers?hash=" + infoHash
 	var qbTorrentTrackers []apiTorrentTracker
-	err = util.FetchJson(apiUrl, &qbTorrentTrackers, qbclient.HttpClient, nil)
+	err = util.FetchJsonContext(qbclient.ctx, apiUrl, &qbTorrentTrackers, qbclient.HttpClient, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1067,7 +1317,8 @@ func (qbclient *Client) SetFilePriority(infoHash string, fileIndexes []int64, pr
 }
 
 func (qbclient *Client) Close() {
-	qbclient.PurgeCache()
+	qbclient.resetCache()
+	qbclient.apiVersion = ""
 	if qbclient.Logined && !qbclient.ClientConfig.QbittorrentNoLogout {
 		qbclient.Logined = false
 		qbclient.apiPost("api/v2/auth/logout", nil)
@@ -1076,17 +1327,18 @@ func (qbclient *Client) Close() {
 
 func NewClient(name string, clientConfig *config.ClientConfigStruct, config *config.ConfigStruct) (
 	client.Client, error) {
-	jar, err := cookiejar.New(nil)
+	httpClient, err := client.NewHttpClient(clientConfig)
 	if err != nil {
 		return nil, err
 	}
 	client := &Client{
-		Name:         name,
-		ClientConfig: clientConfig,
-		Config:       config,
-		HttpClient: &http.Client{
-			Jar: jar,
+		clientData: &clientData{
+			Name:         name,
+			ClientConfig: clientConfig,
+			Config:       config,
+			HttpClient:   httpClient,
 		},
+		ctx: context.Background(),
 	}
 	return client, nil
 }
@@ -0,0 +1,134 @@
+package qbittorrent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sagan/ptool/config"
+)
+
+func TestApplyMaindataUpdate(t *testing.T) {
+	qbclient := &Client{clientData: &clientData{}}
+	responses := []string{
+		`{"rid":1,"full_update":true,"server_state":{"dl_info_speed":100,"free_space_on_disk":1000},
+			"tags":["a","b"],"categories":{"foo":{"name":"foo","savePath":"/foo"}},
+			"torrents":{
+				"h1":{"name":"t1","size":100,"completed":40,"state":"downloading","content_path":"/data/t1"},
+				"h2":{"name":"t2","size":50,"completed":50,"state":"uploading","content_path":"/data/t2"}}}`,
+		`{"rid":2,"server_state":{"dl_info_speed":200},"tags":["c"],"tags_removed":["a"],
+			"categories":{"bar":{"name":"bar","savePath":"/bar"}},"categories_removed":["foo"],
+			"torrents":{"h1":{"completed":70,"content_path":"/data/t2"},
+				"h3":{"name":"t3","size":10,"completed":0,"state":"pausedDL","content_path":"/data/t3"}},
+			"torrents_removed":["h2"]}`,
+	}
+	for _, response := range responses {
+		update := &apiSyncMaindataUpdate{}
+		if err := json.Unmarshal([]byte(response), update); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		if err := qbclient.applyMaindataUpdate(update); err != nil {
+			t.Fatalf("applyMaindataUpdate: %v", err)
+		}
+	}
+	data := qbclient.data
+	if qbclient.rid != 2 || data.Server_state.Dl_info_speed != 200 || data.Server_state.Free_space_on_disk != 1000 {
+		t.Errorf("unexpected rid / server_state: %d / %+v", qbclient.rid, data.Server_state)
+	}
+	if !slices.Equal(data.Tags, []string{"b", "c"}) {
+		t.Errorf("unexpected tags: %v", data.Tags)
+	}
+	if len(data.Categories) != 1 || data.Categories["bar"] == nil || data.Categories["bar"].SavePath != "/bar" {
+		t.Errorf("unexpected categories: %v", data.Categories)
+	}
+	if len(data.Torrents) != 2 || data.Torrents["h2"] != nil {
+		t.Fatalf("unexpected torrents: %v", data.Torrents)
+	}
+	if torrent := data.Torrents["h1"]; torrent.Hash != "h1" || torrent.Name != "t1" || torrent.Completed != 70 ||
+		torrent.State != "downloading" {
+		t.Errorf("torrent not merged: %+v", torrent)
+	}
+	if qbclient.unfinishedSize != 40 || qbclient.unfinishedDownloadingSize != 30 {
+		t.Errorf("unexpected unfinished size: %d / %d", qbclient.unfinishedSize, qbclient.unfinishedDownloadingSize)
+	}
+	if len(qbclient.contentPathTorrents) != 2 || len(qbclient.contentPathTorrents["/data/t2"]) != 1 ||
+		qbclient.contentPathTorrents["/data/t2"][0].Hash != "h1" {
+		t.Errorf("unexpected contentPathTorrents: %v", qbclient.contentPathTorrents)
+	}
+}
+
+func TestApiVersionRouting(t *testing.T) {
+	for _, version := range []string{"2.8.19", "2.11.2"} {
+		paths := []string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			switch r.URL.Path {
+			case "/api/v2/auth/login":
+				w.Write([]byte("Ok."))
+			case "/api/v2/app/webapiVersion":
+				w.Write([]byte(version))
+			}
+		}))
+		clientInstance, _ := NewClient("qb", &config.ClientConfigStruct{Url: server.URL + "/"}, &config.ConfigStruct{})
+		if err := clientInstance.PauseTorrents([]string{"h1"}); err != nil {
+			t.Errorf("PauseTorrents: %v", err)
+		}
+		if err := clientInstance.ResumeTorrents([]string{"h1"}); err != nil {
+			t.Errorf("ResumeTorrents: %v", err)
+		}
+		server.Close()
+		want := []string{"/api/v2/auth/login", "/api/v2/app/webapiVersion",
+			"/api/v2/torrents/pause", "/api/v2/torrents/resume"}
+		if version == "2.11.2" {
+			want[2], want[3] = "/api/v2/torrents/stop", "/api/v2/torrents/start"
+		}
+		if !slices.Equal(paths, want) {
+			t.Errorf("webapi %s: requested paths %v, want %v", version, paths, want)
+		}
+	}
+	stopped := &apiTorrentInfo{State: "stoppedDL"}
+	if stopped.ToTorrentState() != "paused" || !stopped.CanResume() || !stopped.IsStoppedDL() {
+		t.Errorf("qb 5 stoppedDL state not recognized")
+	}
+}
+
+func TestCancelRequest(t *testing.T) {
+	blocked := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-blocked:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(blocked)
+	ctx, cancel := context.WithCancel(context.Background())
+	clientInstance, _ := NewClient("qb", &config.ClientConfigStruct{Url: server.URL + "/", QbittorrentNoLogin: true},
+		&config.ConfigStruct{})
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := clientInstance.WithContext(ctx).GetStatus()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetStatus error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request not cancelled in time: %v", elapsed)
+	}
+
+	// timeout set after the client instance is created still applies
+	config.Timeout = 1
+	defer func() { config.Timeout = 0 }()
+	start = time.Now()
+	_, err = clientInstance.WithContext(context.Background()).GetStatus()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetStatus error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request not timeout in time: %v", elapsed)
+	}
+}
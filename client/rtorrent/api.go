@@ -0,0 +1,343 @@
+package rtorrent
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/util"
+)
+
+// rTorrent does not support tags nor custom torrent metadata.
+// ptool stores tags (and meta as "meta.name:value" tags) in this custom field of torrent, comma-separated.
+const TAGS_FIELD = "ptool_tags"
+
+// The custom field that ruTorrent uses to store torrent added time.
+const ADDTIME_FIELD = "addtime"
+
+// d.* commands (fields) of torrent that are fetched during sync via d.multicall2.
+// The order must match the order of fields of rtTorrent parsed in parseTorrent.
+var torrentFields = []string{
+	"d.hash=",
+	"d.name=",
+	"d.state=",            // 0: stopped; 1: started
+	"d.is_active=",        // 0: paused (or stopped); 1: active
+	"d.complete=",         // 1 if all selected chunks are downloaded
+	"d.is_hash_checking=", //
+	"d.is_multi_file=",    //
+	"d.message=",          // latest tracker / error message
+	"d.custom1=",          // ruTorrent label, used as category
+	"d.custom=" + TAGS_FIELD,
+	"d.custom=" + ADDTIME_FIELD,
+	"d.directory=", // for multi-file torrent, it's the content (root folder) path
+	"d.size_bytes=",
+	"d.completed_bytes=",
+	"d.left_bytes=",
+	"d.down.rate=",
+	"d.up.rate=",
+	"d.down.total=",
+	"d.up.total=",
+	"d.ratio=", // ratio * 1000
+	"d.timestamp.started=",
+	"d.timestamp.finished=",
+	"d.free_diskspace=",
+}
+
+// t.* commands of tracker that are fetched via t.multicall.
+var trackerFields = []string{
+	"t.url=",
+	"t.is_enabled=",
+	"t.success_counter=",
+	"t.failed_counter=",
+	"t.scrape_complete=",
+	"t.scrape_incomplete=",
+}
+
+// f.* commands of file that are fetched via f.multicall.
+var fileFields = []string{
+	"f.path=",
+	"f.size_bytes=",
+	"f.completed_chunks=",
+	"f.size_chunks=",
+	"f.priority=", // 0: off; 1: normal; 2: high
+}
+
+// p.* commands of peer that are fetched via p.multicall.
+var peerFields = []string{
+	"p.id=",
+	"p.address=",
+	"p.port=",
+	"p.client_version=",
+	"p.completed_percent=",
+	"p.down_rate=",
+	"p.up_rate=",
+	"p.is_incoming=",
+	"p.is_encrypted=",
+	"p.is_snubbed=",
+}
+
+type rtTorrent struct {
+	Hash           string
+	Name           string
+	State          int64
+	IsActive       int64
+	Complete       int64
+	IsHashChecking int64
+	IsMultiFile    int64
+	Message        string
+	Label          string
+	Tags           string
+	Addtime        int64
+	Directory      string
+	SizeBytes      int64
+	CompletedBytes int64
+	LeftBytes      int64
+	DownRate       int64
+	UpRate         int64
+	DownTotal      int64
+	UpTotal        int64
+	Ratio          int64
+	Started        int64
+	Finished       int64
+	FreeDiskspace  int64
+	// Below fields are filled from first enabled tracker
+	Tracker  string
+	Seeders  int64
+	Leechers int64
+}
+
+type rtTracker struct {
+	Url              string
+	IsEnabled        bool
+	SuccessCounter   int64
+	FailedCounter    int64
+	ScrapeComplete   int64
+	ScrapeIncomplete int64
+}
+
+type rtFile struct {
+	Path            string
+	SizeBytes       int64
+	CompletedChunks int64
+	SizeChunks      int64
+	Priority        int64
+}
+
+type rtPeer struct {
+	Id               string
+	Address          string
+	Port             int64
+	ClientVersion    string
+	CompletedPercent int64
+	DownRate         int64
+	UpRate           int64
+	IsIncoming       bool
+	IsEncrypted      bool
+	IsSnubbed        bool
+}
+
+func parseTorrent(row []any) (*rtTorrent, error) {
+	if len(row) != len(torrentFields) {
+		return nil, fmt.Errorf("invalid torrent row: expect %d fields, got %d", len(torrentFields), len(row))
+	}
+	return &rtTorrent{
+		Hash:           strings.ToLower(toString(row[0])),
+		Name:           toString(row[1]),
+		State:          toInt(row[2]),
+		IsActive:       toInt(row[3]),
+		Complete:       toInt(row[4]),
+		IsHashChecking: toInt(row[5]),
+		IsMultiFile:    toInt(row[6]),
+		Message:        toString(row[7]),
+		Label:          decodeLabel(toString(row[8])),
+		Tags:           toString(row[9]),
+		Addtime:        toInt(row[10]),
+		Directory:      toString(row[11]),
+		SizeBytes:      toInt(row[12]),
+		CompletedBytes: toInt(row[13]),
+		LeftBytes:      toInt(row[14]),
+		DownRate:       toInt(row[15]),
+		UpRate:         toInt(row[16]),
+		DownTotal:      toInt(row[17]),
+		UpTotal:        toInt(row[18]),
+		Ratio:          toInt(row[19]),
+		Started:        toInt(row[20]),
+		Finished:       toInt(row[21]),
+		FreeDiskspace:  toInt(row[22]),
+	}, nil
+}
+
+func parseTracker(row []any) *rtTracker {
+	if len(row) != len(trackerFields) {
+		return nil
+	}
+	return &rtTracker{
+		Url:              toString(row[0]),
+		IsEnabled:        toInt(row[1]) == 1,
+		SuccessCounter:   toInt(row[2]),
+		FailedCounter:    toInt(row[3]),
+		ScrapeComplete:   toInt(row[4]),
+		ScrapeIncomplete: toInt(row[5]),
+	}
+}
+
+func parseFile(row []any) *rtFile {
+	if len(row) != len(fileFields) {
+		return nil
+	}
+	return &rtFile{
+		Path:            toString(row[0]),
+		SizeBytes:       toInt(row[1]),
+		CompletedChunks: toInt(row[2]),
+		SizeChunks:      toInt(row[3]),
+		Priority:        toInt(row[4]),
+	}
+}
+
+func parsePeer(row []any) *rtPeer {
+	if len(row) != len(peerFields) {
+		return nil
+	}
+	return &rtPeer{
+		Id:               toString(row[0]),
+		Address:          toString(row[1]),
+		Port:             toInt(row[2]),
+		ClientVersion:    toString(row[3]),
+		CompletedPercent: toInt(row[4]),
+		DownRate:         toInt(row[5]),
+		UpRate:           toInt(row[6]),
+		IsIncoming:       toInt(row[7]) == 1,
+		IsEncrypted:      toInt(row[8]) == 1,
+		IsSnubbed:        toInt(row[9]) == 1,
+	}
+}
+
+func (rtpeer *rtPeer) ToPeer() *client.TorrentPeer {
+	flags := []string{}
+	if rtpeer.IsIncoming {
+		flags = append(flags, "I")
+	}
+	if rtpeer.IsEncrypted {
+		flags = append(flags, "E")
+	}
+	if rtpeer.IsSnubbed {
+		flags = append(flags, "S")
+	}
+	return &client.TorrentPeer{
+		Address:       net.JoinHostPort(rtpeer.Address, fmt.Sprint(rtpeer.Port)),
+		Ip:            rtpeer.Address,
+		Port:          rtpeer.Port,
+		Client:        rtpeer.ClientVersion,
+		Progress:      float64(rtpeer.CompletedPercent) / 100,
+		DownloadSpeed: rtpeer.DownRate,
+		UploadSpeed:   rtpeer.UpRate,
+		Flags:         strings.Join(flags, " "),
+	}
+}
+
+// ruTorrent url-encodes the label (d.custom1).
+func decodeLabel(label string) string {
+	if strings.Contains(label, "%") {
+		if decoded, err := url.PathUnescape(label); err == nil {
+			return decoded
+		}
+	}
+	return label
+}
+
+func (rttorrent *rtTorrent) ToTorrentState() string {
+	switch {
+	case rttorrent.IsHashChecking == 1:
+		return "checking"
+	case rttorrent.State == 0 || rttorrent.IsActive == 0:
+		if rttorrent.Complete == 1 {
+			return "completed"
+		}
+		if rttorrent.State == 1 && rttorrent.Message != "" {
+			return "error"
+		}
+		return "paused"
+	case rttorrent.Complete == 1:
+		return "seeding"
+	default:
+		return "downloading"
+	}
+}
+
+func (rttorrent *rtTorrent) LowLevelState() string {
+	return fmt.Sprintf("state=%d,active=%d,complete=%d", rttorrent.State, rttorrent.IsActive, rttorrent.Complete)
+}
+
+func (rttorrent *rtTorrent) SavePath() string {
+	if rttorrent.IsMultiFile == 1 {
+		return path.Dir(rttorrent.Directory)
+	}
+	return rttorrent.Directory
+}
+
+func (rttorrent *rtTorrent) ContentPath() string {
+	if rttorrent.IsMultiFile == 1 {
+		return rttorrent.Directory
+	}
+	return strings.TrimSuffix(rttorrent.Directory, "/") + "/" + rttorrent.Name
+}
+
+func (rttorrent *rtTorrent) ToTorrent() *client.Torrent {
+	atime := rttorrent.Addtime
+	if atime <= 0 {
+		atime = rttorrent.Started
+	}
+	ctime := int64(0)
+	if rttorrent.Complete == 1 {
+		ctime = rttorrent.Finished
+	}
+	activityTime := int64(0)
+	if rttorrent.DownRate > 0 || rttorrent.UpRate > 0 {
+		activityTime = util.Now()
+	}
+	torrent := &client.Torrent{
+		InfoHash:           rttorrent.Hash,
+		Name:               rttorrent.Name,
+		TrackerDomain:      util.ParseUrlHostname(rttorrent.Tracker),
+		TrackerBaseDomain:  util.GetUrlDomain(rttorrent.Tracker),
+		Tracker:            rttorrent.Tracker,
+		State:              rttorrent.ToTorrentState(),
+		LowLevelState:      rttorrent.LowLevelState(),
+		Atime:              atime,
+		Ctime:              ctime,
+		ActivityTime:       activityTime,
+		Downloaded:         rttorrent.DownTotal,
+		DownloadSpeed:      rttorrent.DownRate,
+		DownloadSpeedLimit: -1,
+		Uploaded:           rttorrent.UpTotal,
+		UploadSpeed:        rttorrent.UpRate,
+		UploadedSpeedLimit: -1,
+		Category:           rttorrent.Label,
+		SavePath:           rttorrent.SavePath(),
+		ContentPath:        rttorrent.ContentPath(),
+		Tags:               util.SplitCsv(rttorrent.Tags),
+		Seeders:            rttorrent.Seeders,
+		Leechers:           rttorrent.Leechers,
+		Size:               rttorrent.CompletedBytes + rttorrent.LeftBytes,
+		SizeCompleted:      rttorrent.CompletedBytes,
+		SizeTotal:          rttorrent.SizeBytes,
+		Ratio:              float64(rttorrent.Ratio) / 1000,
+	}
+	torrent.Meta = torrent.GetMetadataFromTags()
+	torrent.RemoveSubstituteTags()
+	return torrent
+}
+
+// Return the tags field value of a torrent to store.
+func encodeTags(tags []string, meta map[string]int64) string {
+	tags = util.Filter(tags, func(tag string) bool {
+		return !client.IsSubstituteTag(tag)
+	})
+	for name, value := range meta {
+		tags = append(tags, client.GenerateTorrentTagFromMetadata(name, value))
+	}
+	return strings.Join(util.UniqueSlice(tags), ",")
+}
@@ -0,0 +1,1126 @@
+package rtorrent
+
+// rTorrent XML-RPC API: https://rtorrent-docs.readthedocs.io/en/latest/cmd-ref.html .
+// The client url could be a SCGI address (scgi://host:port or scgi:///path/to/socket),
+// or a XML-RPC over HTTP endpoint, e.g. the "/RPC2" mount point of ruTorrent web server.
+// Category is stored in d.custom1 (the same field used by ruTorrent as label);
+// tags & meta are stored in a custom field (d.custom=ptool_tags).
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/anacrolix/torrent/bencode"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+)
+
+type Client struct {
+	*clientData
+	ctx context.Context // see client.Client.WithContext
+}
+
+type clientData struct {
+	Name                      string
+	ClientConfig              *config.ClientConfigStruct
+	Config                    *config.ConfigStruct
+	transport                 transport
+	data                      map[string]*rtTorrent
+	datatime                  int64
+	downloadSpeed             int64
+	uploadSpeed               int64
+	downloadSpeedLimit        int64
+	uploadSpeedLimit          int64
+	freeSpace                 int64
+	unfinishedSize            int64
+	unfinishedDownloadingSize int64
+	contentPathTorrents       map[string][]*rtTorrent
+}
+
+func (rtclient *Client) WithContext(ctx context.Context) client.Client {
+	return &Client{clientData: rtclient.clientData, ctx: ctx}
+}
+
+// Call a rTorrent XML-RPC method.
+func (rtclient *Client) call(method string, params ...any) (any, error) {
+	body, err := encodeRequest(method, params)
+	if err != nil {
+		return nil, fmt.Errorf("%s error: %w", method, err)
+	}
+	res, err := rtclient.transport.roundTrip(rtclient.ctx, body)
+	if err != nil {
+		return nil, fmt.Errorf("%s error: %w", method, err)
+	}
+	result, err := decodeResponse(res)
+	if err != nil {
+		return nil, fmt.Errorf("%s error: %w", method, err)
+	}
+	return result, nil
+}
+
+// Call multiple methods in one request using system.multicall.
+// Each call is [method, params...]. Returns the result of each call.
+func (rtclient *Client) multicall(calls [][]any) ([]any, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	callStructs := []any{}
+	for _, call := range calls {
+		callStructs = append(callStructs, map[string]any{
+			"methodName": call[0],
+			"params":     call[1:],
+		})
+	}
+	result, err := rtclient.call("system.multicall", callStructs)
+	if err != nil {
+		return nil, err
+	}
+	results := toSlice(result)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("system.multicall error: expect %d results, got %d", len(calls), len(results))
+	}
+	for i, result := range results {
+		if fault, ok := result.(map[string]any); ok {
+			return nil, fmt.Errorf("%s error: %w", calls[i][0], &xmlrpcFault{
+				Code:   toInt(fault["faultCode"]),
+				String: toString(fault["faultString"]),
+			})
+		}
+		// a successful call result is wrapped in a single element array
+		if values := toSlice(result); len(values) > 0 {
+			results[i] = values[0]
+		} else {
+			results[i] = nil
+		}
+	}
+	return results, nil
+}
+
+// Call the same method for each torrent, with infoHash as it's first param.
+func (rtclient *Client) callTorrents(infoHashes []string, method string, params ...any) error {
+	calls := [][]any{}
+	for _, infoHash := range infoHashes {
+		calls = append(calls, append([]any{method, strings.ToUpper(infoHash)}, params...))
+	}
+	_, err := rtclient.multicall(calls)
+	return err
+}
+
+func (rtclient *Client) Cached() bool {
+	return rtclient.datatime > 0
+}
+
+func (rtclient *Client) sync() error {
+	if rtclient.datatime > 0 {
+		return nil
+	}
+	params := []any{"", "main"}
+	for _, field := range torrentFields {
+		params = append(params, field)
+	}
+	result, err := rtclient.call("d.multicall2", params...)
+	if err != nil {
+		return err
+	}
+	data := map[string]*rtTorrent{}
+	rttorrents := []*rtTorrent{}
+	for _, row := range toSlice(result) {
+		rttorrent, err := parseTorrent(toSlice(row))
+		if err != nil {
+			return fmt.Errorf("d.multicall2 error: %w", err)
+		}
+		data[rttorrent.Hash] = rttorrent
+		rttorrents = append(rttorrents, rttorrent)
+	}
+	calls := [][]any{
+		{"throttle.global_down.rate", ""},
+		{"throttle.global_up.rate", ""},
+		{"throttle.global_down.max_rate", ""},
+		{"throttle.global_up.max_rate", ""},
+	}
+	for _, rttorrent := range rttorrents {
+		call := []any{"t.multicall", strings.ToUpper(rttorrent.Hash), ""}
+		for _, field := range trackerFields {
+			call = append(call, field)
+		}
+		calls = append(calls, call)
+	}
+	results, err := rtclient.multicall(calls)
+	if err != nil {
+		return err
+	}
+	for i, rttorrent := range rttorrents {
+		for _, row := range toSlice(results[4+i]) {
+			tracker := parseTracker(toSlice(row))
+			// skip disabled trackers and "dht://" pseudo tracker
+			if tracker == nil || !tracker.IsEnabled ||
+				!util.IsUrl(tracker.Url) && !strings.HasPrefix(tracker.Url, "udp://") {
+				continue
+			}
+			if rttorrent.Tracker == "" {
+				rttorrent.Tracker = tracker.Url
+			}
+			rttorrent.Seeders = max(rttorrent.Seeders, tracker.ScrapeComplete)
+			rttorrent.Leechers = max(rttorrent.Leechers, tracker.ScrapeIncomplete)
+		}
+	}
+	rtclient.downloadSpeed = toInt(results[0])
+	rtclient.uploadSpeed = toInt(results[1])
+	rtclient.downloadSpeedLimit = toInt(results[2])
+	rtclient.uploadSpeedLimit = toInt(results[3])
+	rtclient.data = data
+	rtclient.datatime = util.Now()
+	rtclient.buildDerivative()
+	return nil
+}
+
+func (rtclient *Client) buildDerivative() {
+	unfinishedSize := int64(0)
+	unfinishedDownloadingSize := int64(0)
+	freeSpace := int64(-1)
+	contentPathTorrents := map[string][]*rtTorrent{}
+	for _, torrent := range rtclient.data {
+		unfinishedSize += torrent.LeftBytes
+		if torrent.State == 1 && torrent.IsActive == 1 {
+			unfinishedDownloadingSize += torrent.LeftBytes
+		}
+		// rTorrent only reports free disk space of the partition of each torrent
+		freeSpace = max(freeSpace, torrent.FreeDiskspace)
+		contentPath := torrent.ContentPath()
+		contentPathTorrents[contentPath] = append(contentPathTorrents[contentPath], torrent)
+	}
+	rtclient.unfinishedSize = unfinishedSize
+	rtclient.unfinishedDownloadingSize = unfinishedDownloadingSize
+	rtclient.freeSpace = freeSpace
+	rtclient.contentPathTorrents = contentPathTorrents
+}
+
+func (rtclient *Client) getAllInfoHashes() ([]string, error) {
+	if err := rtclient.sync(); err != nil {
+		return nil, err
+	}
+	infoHashes := []string{}
+	for infoHash := range rtclient.data {
+		infoHashes = append(infoHashes, infoHash)
+	}
+	return infoHashes, nil
+}
+
+// Return infoHashes of torrents that match the filter.
+func (rtclient *Client) findInfoHashes(filter func(rttorrent *rtTorrent) bool) ([]string, error) {
+	if err := rtclient.sync(); err != nil {
+		return nil, err
+	}
+	infoHashes := []string{}
+	for infoHash, rttorrent := range rtclient.data {
+		if filter(rttorrent) {
+			infoHashes = append(infoHashes, infoHash)
+		}
+	}
+	return infoHashes, nil
+}
+
+// Update tags & meta of torrents. update returns the new tags & meta of a torrent.
+func (rtclient *Client) updateTags(infoHashes []string,
+	update func(tags []string, meta map[string]int64) ([]string, map[string]int64)) error {
+	if err := rtclient.sync(); err != nil {
+		return err
+	}
+	calls := [][]any{}
+	for _, infoHash := range infoHashes {
+		rttorrent := rtclient.data[infoHash]
+		if rttorrent == nil {
+			continue
+		}
+		torrent := rttorrent.ToTorrent()
+		tags := encodeTags(update(torrent.Tags, torrent.Meta))
+		if tags == rttorrent.Tags {
+			continue
+		}
+		calls = append(calls, []any{"d.custom.set", strings.ToUpper(infoHash), TAGS_FIELD, tags})
+		rttorrent.Tags = tags
+	}
+	_, err := rtclient.multicall(calls)
+	return err
+}
+
+func (rtclient *Client) GetName() string {
+	return rtclient.Name
+}
+
+func (rtclient *Client) GetClientConfig() *config.ClientConfigStruct {
+	return rtclient.ClientConfig
+}
+
+// rTorrent does not provide an API to export .torrent file.
+// It's read from the "session" dir of rTorrent, which must be accessible locally.
+// If rtorrentSessionDir of client is not configured, the session.path of rTorrent is used.
+func (rtclient *Client) ExportTorrentFile(infoHash string) ([]byte, error) {
+	sessionDir := rtclient.ClientConfig.RtorrentSessionDir
+	if sessionDir == "" {
+		result, err := rtclient.call("session.path")
+		if err != nil {
+			return nil, err
+		}
+		if sessionDir = toString(result); sessionDir == "" {
+			return nil, fmt.Errorf("rtorrent session is not enabled")
+		}
+	}
+	contents, err := os.ReadFile(filepath.Join(sessionDir, strings.ToUpper(infoHash)+".torrent"))
+	if err != nil {
+		return nil, err
+	}
+	// Remove rTorrent own keys from the session torrent file
+	var data map[string]bencode.Bytes
+	if err = bencode.Unmarshal(contents, &data); err != nil {
+		return nil, fmt.Errorf("invalid session torrent file: %w", err)
+	}
+	delete(data, "libtorrent_resume")
+	delete(data, "rtorrent")
+	return bencode.Marshal(data)
+}
+
+func (rtclient *Client) GetTorrent(infoHash string) (*client.Torrent, error) {
+	if err := rtclient.sync(); err != nil {
+		return nil, err
+	}
+	rttorrent := rtclient.data[infoHash]
+	if rttorrent == nil {
+		return nil, nil
+	}
+	return rttorrent.ToTorrent(), nil
+}
+
+func (rtclient *Client) GetTorrents(stateFilter string, category string, showAll bool) ([]*client.Torrent, error) {
+	if err := rtclient.sync(); err != nil {
+		return nil, err
+	}
+	torrents := []*client.Torrent{}
+	for _, rttorrent := range rtclient.data {
+		if category != "" {
+			if category == constants.NONE {
+				if rttorrent.Label != "" {
+					continue
+				}
+			} else if category != rttorrent.Label {
+				continue
+			}
+		}
+		if !showAll && rttorrent.DownRate < 1024 && rttorrent.UpRate < 1024 {
+			continue
+		}
+		torrent := rttorrent.ToTorrent()
+		if !torrent.MatchStateFilter(stateFilter) {
+			continue
+		}
+		torrents = append(torrents, torrent)
+	}
+	return torrents, nil
+}
+
+func (rtclient *Client) GetTorrentsByContentPath(contentPath string) ([]*client.Torrent, error) {
+	if err := rtclient.sync(); err != nil {
+		return nil, err
+	}
+	var torrents []*client.Torrent
+	for _, t := range rtclient.contentPathTorrents[contentPath] {
+		torrents = append(torrents, t.ToTorrent())
+	}
+	return torrents, nil
+}
+
+// rTorrent does not support these options, which are ignored: Name, SkipChecking, per-torrent speed limits
+// (rTorrent uses throttle groups instead) and share limits.
+func (rtclient *Client) AddTorrent(torrentContent []byte, option *client.TorrentOption, meta map[string]int64) error {
+	if option == nil {
+		option = &client.TorrentOption{}
+	}
+	params := []any{""}
+	content := string(torrentContent)
+	isUrl := util.IsTorrentUrl(content)
+	if isUrl {
+		params = append(params, content)
+	} else {
+		params = append(params, torrentContent)
+	}
+	if option.SavePath != "" {
+		params = append(params, "d.directory.set="+quote(option.SavePath))
+	}
+	if option.Category != "" && option.Category != constants.NONE {
+		params = append(params, "d.custom1.set="+quote(encodeLabel(option.Category)))
+	}
+	if tags := encodeTags(option.Tags, meta); tags != "" {
+		params = append(params, "d.custom.set="+TAGS_FIELD+","+quote(tags))
+	}
+	params = append(params, fmt.Sprintf("d.custom.set=%s,%d", ADDTIME_FIELD, util.Now()))
+	method := ""
+	switch {
+	case isUrl && option.Pause:
+		method = "load.verbose"
+	case isUrl:
+		method = "load.start_verbose"
+	case option.Pause:
+		method = "load.raw_verbose"
+	default:
+		method = "load.raw_start_verbose"
+	}
+	if _, err := rtclient.call(method, params...); err != nil {
+		return fmt.Errorf("add torrent error: %w", err)
+	}
+	return nil
+}
+
+func (rtclient *Client) AddTorrentUrl(torrentUrl string, option *client.TorrentOption,
+	meta map[string]int64) error {
+	if !util.IsTorrentUrl(torrentUrl) {
+		return fmt.Errorf("invalid torrent url: %s", torrentUrl)
+	}
+	return rtclient.AddTorrent([]byte(torrentUrl), option, meta)
+}
+
+// Per-torrent speed limits & share limits are not supported and ignored.
+func (rtclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOption, meta map[string]int64) error {
+	if option == nil {
+		option = &client.TorrentOption{}
+	}
+	err := rtclient.sync()
+	if err != nil {
+		return err
+	}
+	rttorrent, ok := rtclient.data[infoHash]
+	if !ok {
+		return fmt.Errorf("torrent not exists")
+	}
+
+	if option.Category != "" {
+		category := option.Category
+		if category == constants.NONE {
+			category = ""
+		}
+		if category != rttorrent.Label {
+			if err := rtclient.SetTorrentsCatetory([]string{infoHash}, option.Category); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(option.Tags) > 0 || len(option.RemoveTags) > 0 || len(meta) > 0 {
+		err := rtclient.updateTags([]string{infoHash},
+			func(tags []string, torrentMeta map[string]int64) ([]string, map[string]int64) {
+				tags = util.Filter(tags, func(tag string) bool {
+					return !slices.Contains(option.RemoveTags, tag)
+				})
+				if len(meta) > 0 {
+					torrentMeta = meta
+				}
+				return append(tags, option.Tags...), torrentMeta
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	if option.SavePath != "" && option.SavePath != rttorrent.SavePath() {
+		if err := rtclient.SetTorrentsSavePath([]string{infoHash}, option.SavePath); err != nil {
+			return err
+		}
+	}
+
+	if option.Pause {
+		if rttorrent.State == 1 {
+			rtclient.PauseTorrents([]string{infoHash})
+		}
+	} else if option.Resume {
+		if rttorrent.State == 0 || rttorrent.IsActive == 0 {
+			rtclient.ResumeTorrents([]string{infoHash})
+		}
+	}
+	return nil
+}
+
+// rTorrent can not delete torrent files itself, they are removed by executing "rm" in rTorrent host.
+func (rtclient *Client) DeleteTorrents(infoHashes []string, deleteFiles bool) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	if err := rtclient.sync(); err != nil {
+		return err
+	}
+	calls := [][]any{}
+	for _, infoHash := range infoHashes {
+		rttorrent := rtclient.data[infoHash]
+		if rttorrent == nil {
+			continue
+		}
+		calls = append(calls, []any{"d.erase", strings.ToUpper(infoHash)})
+		if deleteFiles {
+			contentPath := path.Clean(rttorrent.ContentPath())
+			if !path.IsAbs(contentPath) || path.Dir(contentPath) == "/" {
+				log.Warnf("Refuse to delete files of torrent %s: invalid content path %q", infoHash, contentPath)
+				continue
+			}
+			calls = append(calls, []any{"execute.throw", "", "rm", "-rf", "--", contentPath})
+		}
+	}
+	if _, err := rtclient.multicall(calls); err != nil {
+		return err
+	}
+	for _, infoHash := range infoHashes {
+		delete(rtclient.data, infoHash)
+	}
+	rtclient.buildDerivative()
+	return nil
+}
+
+func (rtclient *Client) PauseTorrents(infoHashes []string) error {
+	return rtclient.callTorrents(infoHashes, "d.stop")
+}
+
+func (rtclient *Client) ResumeTorrents(infoHashes []string) error {
+	// d.resume is required to continue a torrent paused by d.pause (e.g. in ruTorrent)
+	if err := rtclient.callTorrents(infoHashes, "d.start"); err != nil {
+		return err
+	}
+	return rtclient.callTorrents(infoHashes, "d.resume")
+}
+
+func (rtclient *Client) RecheckTorrents(infoHashes []string) error {
+	return rtclient.callTorrents(infoHashes, "d.check_hash")
+}
+
+func (rtclient *Client) ReannounceTorrents(infoHashes []string) error {
+	return rtclient.callTorrents(infoHashes, "d.tracker_announce")
+}
+
+func (rtclient *Client) AddTagsToTorrents(infoHashes []string, tags []string) error {
+	if len(infoHashes) == 0 || len(tags) == 0 {
+		return nil
+	}
+	return rtclient.updateTags(infoHashes,
+		func(torrentTags []string, meta map[string]int64) ([]string, map[string]int64) {
+			return append(torrentTags, tags...), meta
+		})
+}
+
+func (rtclient *Client) RemoveTagsFromTorrents(infoHashes []string, tags []string) error {
+	if len(infoHashes) == 0 || len(tags) == 0 {
+		return nil
+	}
+	return rtclient.updateTags(infoHashes,
+		func(torrentTags []string, meta map[string]int64) ([]string, map[string]int64) {
+			return util.Filter(torrentTags, func(tag string) bool {
+				return !slices.Contains(tags, tag)
+			}), meta
+		})
+}
+
+// rTorrent can not move torrent files itself. The torrent is stopped,
+// it's files are moved by executing "mv" in rTorrent host, then it's directory is updated and restarted.
+func (rtclient *Client) SetTorrentsSavePath(infoHashes []string, savePath string) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	savePath = strings.TrimSuffix(strings.TrimSpace(savePath), "/")
+	if savePath == "" {
+		return fmt.Errorf("savePath is empty")
+	}
+	if err := rtclient.sync(); err != nil {
+		return err
+	}
+	if _, err := rtclient.call("execute.throw", "", "mkdir", "-p", "--", savePath); err != nil {
+		return err
+	}
+	for _, infoHash := range infoHashes {
+		rttorrent := rtclient.data[infoHash]
+		if rttorrent == nil || rttorrent.SavePath() == savePath {
+			continue
+		}
+		hash := strings.ToUpper(infoHash)
+		calls := [][]any{
+			{"d.stop", hash},
+			{"d.close", hash},
+			{"execute.throw", "", "mv", "--", rttorrent.ContentPath(), savePath + "/"},
+			{"d.directory.set", hash, savePath},
+		}
+		if rttorrent.State == 1 {
+			calls = append(calls, []any{"d.start", hash})
+		}
+		if _, err := rtclient.multicall(calls); err != nil {
+			return err
+		}
+	}
+	rtclient.PurgeCache()
+	return nil
+}
+
+func (rtclient *Client) PauseAllTorrents() error {
+	infoHashes, err := rtclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return rtclient.PauseTorrents(infoHashes)
+}
+
+func (rtclient *Client) ResumeAllTorrents() error {
+	infoHashes, err := rtclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return rtclient.ResumeTorrents(infoHashes)
+}
+
+func (rtclient *Client) RecheckAllTorrents() error {
+	infoHashes, err := rtclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return rtclient.RecheckTorrents(infoHashes)
+}
+
+func (rtclient *Client) ReannounceAllTorrents() error {
+	infoHashes, err := rtclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return rtclient.ReannounceTorrents(infoHashes)
+}
+
+func (rtclient *Client) AddTagsToAllTorrents(tags []string) error {
+	infoHashes, err := rtclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return rtclient.AddTagsToTorrents(infoHashes, tags)
+}
+
+func (rtclient *Client) RemoveTagsFromAllTorrents(tags []string) error {
+	infoHashes, err := rtclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return rtclient.RemoveTagsFromTorrents(infoHashes, tags)
+}
+
+func (rtclient *Client) SetAllTorrentsSavePath(savePath string) error {
+	infoHashes, err := rtclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return rtclient.SetTorrentsSavePath(infoHashes, savePath)
+}
+
+// rTorrent has no global tags list, return all tags of torrents.
+func (rtclient *Client) GetTags() ([]string, error) {
+	if err := rtclient.sync(); err != nil {
+		return nil, err
+	}
+	tags := []string{}
+	for _, rttorrent := range rtclient.data {
+		tags = append(tags, rttorrent.ToTorrent().Tags...)
+	}
+	tags = util.UniqueSlice(tags)
+	slices.Sort(tags)
+	return tags, nil
+}
+
+// Tags exist only with torrents in rTorrent, so it's a no-op.
+func (rtclient *Client) CreateTags(tags ...string) error {
+	return nil
+}
+
+func (rtclient *Client) DeleteTags(tags ...string) error {
+	return rtclient.RemoveTagsFromAllTorrents(tags)
+}
+
+// Categories exist only with torrents in rTorrent. Category save path is not supported.
+func (rtclient *Client) MakeCategory(category string, savePath string) error {
+	if savePath != "" && savePath != constants.NONE {
+		return fmt.Errorf("category save path is not supported by rtorrent")
+	}
+	return nil
+}
+
+// Remove categories from all torrents of them.
+func (rtclient *Client) DeleteCategories(categories []string) error {
+	infoHashes, err := rtclient.findInfoHashes(func(rttorrent *rtTorrent) bool {
+		return rttorrent.Label != "" && slices.Contains(categories, rttorrent.Label)
+	})
+	if err != nil {
+		return err
+	}
+	return rtclient.SetTorrentsCatetory(infoHashes, constants.NONE)
+}
+
+func (rtclient *Client) GetCategories() ([]*client.TorrentCategory, error) {
+	if err := rtclient.sync(); err != nil {
+		return nil, err
+	}
+	labels := []string{}
+	for _, rttorrent := range rtclient.data {
+		if rttorrent.Label != "" {
+			labels = append(labels, rttorrent.Label)
+		}
+	}
+	labels = util.UniqueSlice(labels)
+	slices.Sort(labels)
+	cats := []*client.TorrentCategory{}
+	for _, label := range labels {
+		cats = append(cats, &client.TorrentCategory{Name: label})
+	}
+	return cats, nil
+}
+
+func (rtclient *Client) SetTorrentsCatetory(infoHashes []string, category string) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	label := ""
+	if category != constants.NONE {
+		label = category
+	}
+	if err := rtclient.callTorrents(infoHashes, "d.custom1.set", encodeLabel(label)); err != nil {
+		return err
+	}
+	if rtclient.Cached() {
+		for _, infoHash := range infoHashes {
+			if rtclient.data[infoHash] != nil {
+				rtclient.data[infoHash].Label = label
+			}
+		}
+	}
+	return nil
+}
+
+func (rtclient *Client) SetAllTorrentsCatetory(category string) error {
+	infoHashes, err := rtclient.getAllInfoHashes()
+	if err != nil {
+		return err
+	}
+	return rtclient.SetTorrentsCatetory(infoHashes, category)
+}
+
+func (rtclient *Client) SetTorrentsShareLimits(infoHashes []string, ratioLimit float64, seedingTimeLimit int64) error {
+	return fmt.Errorf("share limits are not supported by rtorrent")
+}
+
+func (rtclient *Client) SetAllTorrentsShareLimits(ratioLimit float64, seedingTimeLimit int64) error {
+	return fmt.Errorf("share limits are not supported by rtorrent")
+}
+
+func (rtclient *Client) TorrentRootPathExists(rootFolder string) bool {
+	if rootFolder == "" {
+		return false
+	}
+	if err := rtclient.sync(); err != nil {
+		return false
+	}
+	for _, torrent := range rtclient.data {
+		if torrent.Name == rootFolder {
+			return true
+		}
+	}
+	return false
+}
+
+func (rtclient *Client) getPeers(infoHash string) ([]*rtPeer, error) {
+	params := []any{strings.ToUpper(infoHash), ""}
+	for _, field := range peerFields {
+		params = append(params, field)
+	}
+	result, err := rtclient.call("p.multicall", params...)
+	if err != nil {
+		return nil, err
+	}
+	peers := []*rtPeer{}
+	for _, row := range toSlice(result) {
+		rtpeer := parsePeer(toSlice(row))
+		if rtpeer == nil {
+			return nil, fmt.Errorf("p.multicall error: invalid peer row")
+		}
+		peers = append(peers, rtpeer)
+	}
+	return peers, nil
+}
+
+func (rtclient *Client) GetTorrentPeers(infoHash string) ([]*client.TorrentPeer, error) {
+	rtpeers, err := rtclient.getPeers(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	peers := []*client.TorrentPeer{}
+	for _, rtpeer := range rtpeers {
+		peers = append(peers, rtpeer.ToPeer())
+	}
+	return peers, nil
+}
+
+// rTorrent does not have a global ip ban list. The matched currently connected peers of all torrents
+// are marked as banned (p.banned.set) and disconnected, so they can not connect to the same torrent again.
+func (rtclient *Client) BanPeers(peers []string) error {
+	if len(peers) == 0 {
+		return nil
+	}
+	if err := rtclient.sync(); err != nil {
+		return err
+	}
+	calls := [][]any{}
+	for infoHash := range rtclient.data {
+		rtpeers, err := rtclient.getPeers(infoHash)
+		if err != nil {
+			return err
+		}
+		for _, rtpeer := range rtpeers {
+			address := net.JoinHostPort(rtpeer.Address, fmt.Sprint(rtpeer.Port))
+			if !slices.Contains(peers, address) && !slices.Contains(peers, rtpeer.Address) {
+				continue
+			}
+			target := strings.ToUpper(infoHash) + ":p" + rtpeer.Id
+			calls = append(calls, []any{"p.banned.set", target, int64(1)}, []any{"p.disconnect", target})
+		}
+	}
+	_, err := rtclient.multicall(calls)
+	return err
+}
+
+func (rtclient *Client) GetTorrentContents(infoHash string) ([]*client.TorrentContentFile, error) {
+	if err := rtclient.sync(); err != nil {
+		return nil, err
+	}
+	rttorrent := rtclient.data[infoHash]
+	if rttorrent == nil {
+		return nil, fmt.Errorf("torrent %s not found", infoHash)
+	}
+	// f.path is relative to the root folder of multi-file torrent
+	pathPrefix := ""
+	if rttorrent.IsMultiFile == 1 {
+		pathPrefix = rttorrent.Name + "/"
+	}
+	params := []any{strings.ToUpper(infoHash), ""}
+	for _, field := range fileFields {
+		params = append(params, field)
+	}
+	result, err := rtclient.call("f.multicall", params...)
+	if err != nil {
+		return nil, err
+	}
+	files := []*client.TorrentContentFile{}
+	for i, row := range toSlice(result) {
+		rtfile := parseFile(toSlice(row))
+		if rtfile == nil {
+			return nil, fmt.Errorf("f.multicall error: invalid file row")
+		}
+		progress := float64(1)
+		if rtfile.SizeChunks > 0 {
+			progress = float64(rtfile.CompletedChunks) / float64(rtfile.SizeChunks)
+		}
+		files = append(files, &client.TorrentContentFile{
+			Index:    int64(i),
+			Path:     pathPrefix + rtfile.Path,
+			Size:     rtfile.SizeBytes,
+			Ignored:  rtfile.Priority == 0,
+			Complete: progress >= 1,
+			Progress: progress,
+		})
+	}
+	return files, nil
+}
+
+func (rtclient *Client) PurgeCache() {
+	rtclient.data = nil
+	rtclient.datatime = 0
+	rtclient.unfinishedSize = 0
+	rtclient.unfinishedDownloadingSize = 0
+	rtclient.contentPathTorrents = nil
+}
+
+func (rtclient *Client) GetStatus() (*client.Status, error) {
+	if err := rtclient.sync(); err != nil {
+		return nil, err
+	}
+	return &client.Status{
+		DownloadSpeed:             rtclient.downloadSpeed,
+		UploadSpeed:               rtclient.uploadSpeed,
+		DownloadSpeedLimit:        rtclient.downloadSpeedLimit,
+		UploadSpeedLimit:          rtclient.uploadSpeedLimit,
+		FreeSpaceOnDisk:           rtclient.freeSpace,
+		UnfinishedSize:            rtclient.unfinishedSize,
+		UnfinishedDownloadingSize: rtclient.unfinishedDownloadingSize,
+	}, nil
+}
+
+func (rtclient *Client) GetConfig(variable string) (string, error) {
+	if strings.HasPrefix(variable, "rt_") && len(variable) > 3 {
+		value, err := rtclient.call(variable[3:], "")
+		if err != nil {
+			return "", err
+		}
+		return toString(value), nil
+	}
+	switch variable {
+	case "global_download_speed_limit", "global_upload_speed_limit":
+		status, err := rtclient.GetStatus()
+		if err != nil {
+			return "", err
+		}
+		if variable == "global_download_speed_limit" {
+			return fmt.Sprint(status.DownloadSpeedLimit), nil
+		}
+		return fmt.Sprint(status.UploadSpeedLimit), nil
+	case "free_disk_space":
+		status, err := rtclient.GetStatus()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(status.FreeSpaceOnDisk), nil
+	case "global_download_speed":
+		status, err := rtclient.GetStatus()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(status.DownloadSpeed), nil
+	case "global_upload_speed":
+		status, err := rtclient.GetStatus()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(status.UploadSpeed), nil
+	case "save_path":
+		value, err := rtclient.call("directory.default", "")
+		if err != nil {
+			return "", err
+		}
+		return toString(value), nil
+	default:
+		return "", nil
+	}
+}
+
+func (rtclient *Client) SetConfig(variable string, value string) error {
+	if strings.HasPrefix(variable, "rt_") && len(variable) > 3 {
+		v, _ := util.String2Any(value)
+		_, err := rtclient.call(variable[3:]+".set", "", v)
+		return err
+	}
+	switch variable {
+	case "global_download_speed_limit", "global_upload_speed_limit":
+		method := "throttle.global_down.max_rate.set"
+		if variable == "global_upload_speed_limit" {
+			method = "throttle.global_up.max_rate.set"
+		}
+		_, err := rtclient.call(method, "", max(util.ParseInt(value), 0))
+		return err
+	case "free_disk_space", "global_download_speed", "global_upload_speed":
+		return fmt.Errorf("%s is read-only", variable)
+	case "save_path":
+		_, err := rtclient.call("directory.default.set", "", value)
+		return err
+	default:
+		return nil
+	}
+}
+
+func (rtclient *Client) getTorrentTrackers(infoHash string) ([]*rtTracker, error) {
+	params := []any{strings.ToUpper(infoHash), ""}
+	for _, field := range trackerFields {
+		params = append(params, field)
+	}
+	result, err := rtclient.call("t.multicall", params...)
+	if err != nil {
+		return nil, err
+	}
+	trackers := []*rtTracker{}
+	for _, row := range toSlice(result) {
+		if tracker := parseTracker(toSlice(row)); tracker != nil {
+			trackers = append(trackers, tracker)
+		}
+	}
+	return trackers, nil
+}
+
+// rTorrent only reports the latest tracker message of torrent, which is used as msg of errored trackers.
+func (rtclient *Client) GetTorrentTrackers(infoHash string) (client.TorrentTrackers, error) {
+	rttrackers, err := rtclient.getTorrentTrackers(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	message := ""
+	if result, err := rtclient.call("d.message", strings.ToUpper(infoHash)); err == nil {
+		message = toString(result)
+	}
+	trackers := client.TorrentTrackers{}
+	for _, rttracker := range rttrackers {
+		status := "notcontacted"
+		msg := ""
+		switch {
+		case !rttracker.IsEnabled:
+			status = "disabled"
+		case rttracker.FailedCounter > 0 && rttracker.SuccessCounter == 0:
+			status = "error"
+			msg = message
+		case rttracker.SuccessCounter > 0:
+			status = "working"
+		}
+		trackers = append(trackers, client.TorrentTracker{
+			Url:    rttracker.Url,
+			Msg:    msg,
+			Status: status,
+		})
+	}
+	return trackers, nil
+}
+
+// rTorrent can not modify tracker url. The new tracker is added and the old one is disabled.
+func (rtclient *Client) EditTorrentTracker(infoHash string, oldTracker string,
+	newTracker string, replaceHost bool) error {
+	rttrackers, err := rtclient.getTorrentTrackers(infoHash)
+	if err != nil {
+		return err
+	}
+	directNewUrlMode := util.IsUrl(newTracker)
+	index := slices.IndexFunc(rttrackers, func(t *rtTracker) bool {
+		if replaceHost {
+			return util.MatchUrlWithHostOrUrl(t.Url, oldTracker)
+		}
+		return t.Url == oldTracker
+	})
+	if index == -1 {
+		return fmt.Errorf("torrent %s old tracker does NOT exist", infoHash)
+	}
+	newTrackerUrl := newTracker
+	if replaceHost && !directNewUrlMode {
+		urlObj, err := url.Parse(rttrackers[index].Url)
+		if err != nil {
+			return err
+		}
+		urlObj.Host = newTracker
+		newTrackerUrl = urlObj.String()
+	}
+	if rttrackers[index].Url == newTrackerUrl {
+		return nil
+	}
+	log.Debugf("Replace torrent %s tracker %s => %s", infoHash, rttrackers[index].Url, newTrackerUrl)
+	hash := strings.ToUpper(infoHash)
+	_, err = rtclient.multicall([][]any{
+		{"d.tracker.insert", hash, int64(0), newTrackerUrl},
+		{"t.is_enabled.set", fmt.Sprintf("%s:t%d", hash, index), 0},
+	})
+	return err
+}
+
+// trackers - new trackers full URLs; oldTracker - existing tracker host or URL.
+// rTorrent can not remove trackers, existing ones are disabled if removeExisting is true.
+func (rtclient *Client) AddTorrentTrackers(infoHash string, trackers []string,
+	oldTracker string, removeExisting bool) error {
+	rttrackers, err := rtclient.getTorrentTrackers(infoHash)
+	if err != nil {
+		return err
+	}
+	if oldTracker != "" && !slices.ContainsFunc(rttrackers, func(t *rtTracker) bool {
+		return util.MatchUrlWithHostOrUrl(t.Url, oldTracker)
+	}) {
+		return nil
+	}
+	hash := strings.ToUpper(infoHash)
+	calls := [][]any{}
+	for _, tracker := range trackers {
+		if !slices.ContainsFunc(rttrackers, func(t *rtTracker) bool { return t.Url == tracker }) {
+			calls = append(calls, []any{"d.tracker.insert", hash, int64(len(calls)), tracker})
+		}
+	}
+	for i, t := range rttrackers {
+		if removeExisting && t.IsEnabled && !slices.Contains(trackers, t.Url) {
+			calls = append(calls, []any{"t.is_enabled.set", fmt.Sprintf("%s:t%d", hash, i), 0})
+		}
+	}
+	_, err = rtclient.multicall(calls)
+	return err
+}
+
+// rTorrent can not remove trackers, they are disabled instead.
+func (rtclient *Client) RemoveTorrentTrackers(infoHash string, trackers []string) error {
+	rttrackers, err := rtclient.getTorrentTrackers(infoHash)
+	if err != nil {
+		return err
+	}
+	hash := strings.ToUpper(infoHash)
+	calls := [][]any{}
+	for i, t := range rttrackers {
+		if t.IsEnabled && slices.Contains(trackers, t.Url) {
+			calls = append(calls, []any{"t.is_enabled.set", fmt.Sprintf("%s:t%d", hash, i), 0})
+		}
+	}
+	_, err = rtclient.multicall(calls)
+	return err
+}
+
+// priority uses qb values, which is converted to rTorrent values: 0 (off), 1 (normal), 2 (high).
+func (rtclient *Client) SetFilePriority(infoHash string, fileIndexes []int64, priority int64) error {
+	if len(fileIndexes) == 0 {
+		return fmt.Errorf("must provide at least fileIndex")
+	}
+	if priority > 1 {
+		priority = 2
+	}
+	hash := strings.ToUpper(infoHash)
+	calls := [][]any{}
+	for _, index := range fileIndexes {
+		calls = append(calls, []any{"f.priority.set", fmt.Sprintf("%s:f%d", hash, index), priority})
+	}
+	calls = append(calls, []any{"d.update_priorities", hash})
+	_, err := rtclient.multicall(calls)
+	return err
+}
+
+func (rtclient *Client) Close() {
+	rtclient.PurgeCache()
+}
+
+// Quote a value in rTorrent command string.
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// Encode category to d.custom1 value, the same way as ruTorrent does.
+func encodeLabel(label string) string {
+	return url.PathEscape(label)
+}
+
+func NewClient(name string, clientConfig *config.ClientConfigStruct, config *config.ConfigStruct) (
+	client.Client, error) {
+	transport, err := newTransport(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	client := &Client{
+		clientData: &clientData{
+			Name:         name,
+			ClientConfig: clientConfig,
+			Config:       config,
+			transport:    transport,
+		},
+		ctx: context.Background(),
+	}
+	return client, nil
+}
+
+func init() {
+	client.Register(&client.RegInfo{
+		Name:    "rtorrent",
+		Creator: NewClient,
+	})
+}
+
+var (
+	_ client.Client = (*Client)(nil)
+)
@@ -0,0 +1,158 @@
+package rtorrent
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+)
+
+const testInfoHash = "0123456789abcdef0123456789abcdef01234567"
+
+// a minimal stand-in of rTorrent XML-RPC over HTTP endpoint
+type fakeRtorrent struct {
+	label string
+	tags  string
+	calls map[string][]any
+}
+
+func (fr *fakeRtorrent) handle(method string, params []any) any {
+	fr.calls[method] = params
+	switch method {
+	case "d.multicall2":
+		return []any{[]any{
+			"0123456789ABCDEF0123456789ABCDEF01234567", "Foo.2024", int64(1), int64(1), int64(0), int64(0),
+			int64(1), "", fr.label, fr.tags, "", "/downloads/Foo.2024", int64(2000), int64(400), int64(600),
+			int64(4096), int64(0), int64(400), int64(0), int64(0), int64(1700000000), int64(0), int64(1 << 30),
+		}}
+	case "system.multicall":
+		results := []any{}
+		for _, call := range params[0].([]any) {
+			call := call.(map[string]any)
+			results = append(results, []any{fr.handle(call["methodName"].(string), call["params"].([]any))})
+		}
+		return results
+	case "throttle.global_down.rate":
+		return int64(1024)
+	case "throttle.global_up.max_rate":
+		return int64(100 * 1024)
+	case "t.multicall":
+		return []any{
+			[]any{"dht://", int64(1), int64(0), int64(0), int64(0), int64(0)},
+			[]any{"https://tracker.example.com/announce", int64(1), int64(3), int64(0), int64(10), int64(5)},
+		}
+	case "d.custom.set":
+		fr.tags = params[2].(string)
+	case "d.custom1.set":
+		fr.label = params[1].(string)
+	}
+	return int64(0)
+}
+
+func (fr *fakeRtorrent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	root := &xmlNode{}
+	if r.URL.Path != "/RPC2" || xml.Unmarshal(body, root) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	method := ""
+	params := []any{}
+	for _, node := range root.Nodes {
+		switch node.XMLName.Local {
+		case "methodName":
+			method = node.Content
+		case "params":
+			for _, param := range node.Nodes {
+				value, err := decodeValue(&param.Nodes[0])
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				params = append(params, value)
+			}
+		}
+	}
+	buf := bytes.NewBufferString(`<?xml version="1.0"?><methodResponse><params><param>`)
+	encodeValue(buf, fr.handle(method, params))
+	buf.WriteString(`</param></params></methodResponse>`)
+	w.Write(buf.Bytes())
+}
+
+func TestRtorrentClient(t *testing.T) {
+	fr := &fakeRtorrent{calls: map[string][]any{}}
+	server := httptest.NewServer(fr)
+	defer server.Close()
+	clientInstance, err := NewClient("rt", &config.ClientConfigStruct{
+		Type: "rtorrent",
+		Url:  server.URL + "/RPC2",
+	}, &config.ConfigStruct{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	err = clientInstance.AddTorrent([]byte("d4:infod4:name3:fooee"), &client.TorrentOption{
+		Category: "_brush",
+		Tags:     []string{"site:foo"},
+		SavePath: `/downloads/a "b"`,
+	}, map[string]int64{"dcet": 1})
+	if err != nil {
+		t.Fatalf("AddTorrent: %v", err)
+	}
+	params := fr.calls["load.raw_start_verbose"]
+	if len(params) < 2 || string(params[1].([]byte)) != "d4:infod4:name3:fooee" ||
+		!slices.Contains(params, `d.directory.set="/downloads/a \"b\""`) ||
+		!slices.Contains(params, `d.custom1.set="_brush"`) ||
+		!slices.Contains(params, `d.custom.set=ptool_tags,"site:foo,meta.dcet:1"`) {
+		t.Errorf("unexpected load.raw_start_verbose params: %v", params)
+	}
+	fr.label = "_brush"
+	fr.tags = "site:foo,meta.dcet:1"
+
+	torrents, err := clientInstance.GetTorrents("", "_brush", true)
+	if err != nil {
+		t.Fatalf("GetTorrents: %v", err)
+	}
+	if len(torrents) != 1 {
+		t.Fatalf("GetTorrents returned %d torrents, want 1", len(torrents))
+	}
+	torrent := torrents[0]
+	if torrent.InfoHash != testInfoHash || torrent.State != "downloading" || torrent.SavePath != "/downloads" ||
+		torrent.ContentPath != "/downloads/Foo.2024" || torrent.Size != 1000 || torrent.SizeTotal != 2000 ||
+		torrent.TrackerDomain != "tracker.example.com" || torrent.Seeders != 10 || torrent.Leechers != 5 {
+		t.Errorf("unexpected torrent: %+v", torrent)
+	}
+	if !slices.Equal(torrent.Tags, []string{"site:foo"}) || torrent.Meta["dcet"] != 1 {
+		t.Errorf("torrent tags / meta not restored: %v / %v", torrent.Tags, torrent.Meta)
+	}
+
+	status, err := clientInstance.GetStatus()
+	if err != nil {
+		t.Fatalf("GetStatus: %v", err)
+	}
+	if status.DownloadSpeed != 1024 || status.UploadSpeedLimit != 100*1024 || status.FreeSpaceOnDisk != 1<<30 ||
+		status.UnfinishedSize != 600 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if err = clientInstance.RemoveTagsFromTorrents([]string{testInfoHash}, []string{"site:foo"}); err != nil {
+		t.Fatalf("RemoveTagsFromTorrents: %v", err)
+	}
+	if err = clientInstance.DeleteCategories([]string{"_brush"}); err != nil {
+		t.Fatalf("DeleteCategories: %v", err)
+	}
+	clientInstance.PurgeCache()
+	torrent, err = clientInstance.GetTorrent(testInfoHash)
+	if err != nil || torrent == nil {
+		t.Fatalf("GetTorrent: %v", err)
+	}
+	if len(torrent.Tags) != 0 || torrent.Meta["dcet"] != 1 || torrent.Category != "" {
+		t.Errorf("unexpected torrent after removing tag & category: %+v", torrent)
+	}
+}
@@ -0,0 +1,389 @@
+package rtorrent
+
+// A minimal XML-RPC client implementation, which supports only the subset of XML-RPC used by rTorrent.
+// Spec: http://xmlrpc.com/spec.md .
+// Decoded values are of Go types: string, int64, bool, float64, []byte (base64), []any (array),
+// map[string]any (struct) or nil.
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+)
+
+type xmlrpcFault struct {
+	Code   int64
+	String string
+}
+
+type xmlNode struct {
+	XMLName xml.Name
+	Content string    `xml:",chardata"`
+	Nodes   []xmlNode `xml:",any"`
+}
+
+// Transport sends a XML-RPC request body and returns the response body.
+type transport interface {
+	roundTrip(ctx context.Context, body []byte) ([]byte, error)
+}
+
+// XML-RPC over HTTP(S). E.g. rTorrent "/RPC2" endpoint mounted by web server (which is also used by ruTorrent).
+type httpTransport struct {
+	url        string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// XML-RPC over SCGI (TCP or unix domain socket), which is what rTorrent "network.scgi.open_port" /
+// "network.scgi.open_local" provides.
+type scgiTransport struct {
+	network      string // "tcp" | "unix"
+	address      string
+	clientConfig *config.ClientConfigStruct
+}
+
+func (f *xmlrpcFault) Error() string {
+	return fmt.Sprintf("%s (code=%d)", f.String, f.Code)
+}
+
+func (t *httpTransport) roundTrip(ctx context.Context, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/xml")
+	if t.username != "" || t.password != "" {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status=%d", res.StatusCode)
+	}
+	return io.ReadAll(res.Body)
+}
+
+func (t *scgiTransport) roundTrip(ctx context.Context, body []byte) (data []byte, err error) {
+	// determined per request, so the current --timeout flag always applies
+	timeout := client.GetTimeout(t.clientConfig)
+	if timeout == 0 {
+		timeout = time.Duration(config.DEFAULT_TIMEOUT) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+	}()
+	conn, err := (&net.Dialer{}).DialContext(ctx, t.network, t.address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	// abort the pending read / write immediately if ctx is cancelled
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+	// SCGI request: netstring of headers (CONTENT_LENGTH must be the first), followed by body.
+	headers := "CONTENT_LENGTH\x00" + fmt.Sprint(len(body)) + "\x00SCGI\x001\x00" +
+		"REQUEST_METHOD\x00POST\x00REQUEST_URI\x00/RPC2\x00"
+	request := bytes.NewBufferString(fmt.Sprintf("%d:%s,", len(headers), headers))
+	request.Write(body)
+	if _, err = conn.Write(request.Bytes()); err != nil {
+		return nil, err
+	}
+	// SCGI response is a CGI response: "Status: 200 OK\r\nContent-Type: text/xml\r\n\r\n<body>".
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("invalid scgi response: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if name, value, found := strings.Cut(line, ":"); found && strings.EqualFold(name, "Status") {
+			if status := strings.TrimSpace(value); !strings.HasPrefix(status, "200") {
+				return nil, fmt.Errorf("status=%s", status)
+			}
+		}
+	}
+	return io.ReadAll(reader)
+}
+
+// Parse client url. Supported formats:
+// scgi://127.0.0.1:5000 ; scgi:///path/to/rpc.socket (unix domain socket) ;
+// http(s)://user:pass@example.com/RPC2 .
+// The http options (proxy, headers, TLS...) of client config apply to http(s) url only.
+func newTransport(clientConfig *config.ClientConfigStruct) (transport, error) {
+	username := clientConfig.Username
+	password := clientConfig.Password
+	urlObj, err := url.Parse(clientConfig.Url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	switch urlObj.Scheme {
+	case "scgi":
+		if urlObj.Host != "" {
+			return &scgiTransport{network: "tcp", address: urlObj.Host, clientConfig: clientConfig}, nil
+		}
+		if urlObj.Path == "" {
+			return nil, fmt.Errorf("invalid scgi url: no host or socket path")
+		}
+		return &scgiTransport{network: "unix", address: urlObj.Path, clientConfig: clientConfig}, nil
+	case "http", "https":
+		if urlObj.User != nil {
+			if username == "" {
+				username = urlObj.User.Username()
+			}
+			if p, ok := urlObj.User.Password(); ok && password == "" {
+				password = p
+			}
+			urlObj.User = nil
+		}
+		httpClient, err := client.NewHttpClient(clientConfig)
+		if err != nil {
+			return nil, err
+		}
+		return &httpTransport{
+			url:        urlObj.String(),
+			username:   username,
+			password:   password,
+			httpClient: httpClient,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported url scheme %q", urlObj.Scheme)
+	}
+}
+
+func encodeRequest(method string, params []any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`<?xml version="1.0"?><methodCall><methodName>`)
+	xml.EscapeText(buf, []byte(method))
+	buf.WriteString(`</methodName><params>`)
+	for _, param := range params {
+		buf.WriteString("<param>")
+		if err := encodeValue(buf, param); err != nil {
+			return nil, err
+		}
+		buf.WriteString("</param>")
+	}
+	buf.WriteString(`</params></methodCall>`)
+	return buf.Bytes(), nil
+}
+
+func encodeValue(buf *bytes.Buffer, value any) error {
+	buf.WriteString("<value>")
+	switch v := value.(type) {
+	case string:
+		buf.WriteString("<string>")
+		xml.EscapeText(buf, []byte(v))
+		buf.WriteString("</string>")
+	case int:
+		encodeInt(buf, int64(v))
+	case int64:
+		encodeInt(buf, v)
+	case bool:
+		if v {
+			buf.WriteString("<boolean>1</boolean>")
+		} else {
+			buf.WriteString("<boolean>0</boolean>")
+		}
+	case float64:
+		buf.WriteString("<double>" + strconv.FormatFloat(v, 'f', -1, 64) + "</double>")
+	case []byte:
+		buf.WriteString("<base64>" + base64.StdEncoding.EncodeToString(v) + "</base64>")
+	case []string:
+		buf.WriteString("<array><data>")
+		for _, item := range v {
+			encodeValue(buf, item)
+		}
+		buf.WriteString("</data></array>")
+	case []any:
+		buf.WriteString("<array><data>")
+		for _, item := range v {
+			if err := encodeValue(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("</data></array>")
+	case map[string]any:
+		buf.WriteString("<struct>")
+		for name, item := range v {
+			buf.WriteString("<member><name>")
+			xml.EscapeText(buf, []byte(name))
+			buf.WriteString("</name>")
+			if err := encodeValue(buf, item); err != nil {
+				return err
+			}
+			buf.WriteString("</member>")
+		}
+		buf.WriteString("</struct>")
+	default:
+		return fmt.Errorf("unsupported xmlrpc value type %T", value)
+	}
+	buf.WriteString("</value>")
+	return nil
+}
+
+func encodeInt(buf *bytes.Buffer, v int64) {
+	if v >= math.MinInt32 && v <= math.MaxInt32 {
+		buf.WriteString("<i4>" + fmt.Sprint(v) + "</i4>")
+	} else {
+		buf.WriteString("<i8>" + fmt.Sprint(v) + "</i8>")
+	}
+}
+
+// Decode a methodResponse. If the response is a fault, a *xmlrpcFault error is returned.
+func decodeResponse(body []byte) (any, error) {
+	root := &xmlNode{}
+	if err := xml.Unmarshal(body, root); err != nil {
+		return nil, fmt.Errorf("invalid xmlrpc response: %w", err)
+	}
+	if root.XMLName.Local != "methodResponse" || len(root.Nodes) == 0 {
+		return nil, fmt.Errorf("invalid xmlrpc response: no methodResponse")
+	}
+	switch node := root.Nodes[0]; node.XMLName.Local {
+	case "fault":
+		if len(node.Nodes) == 0 {
+			return nil, fmt.Errorf("invalid xmlrpc response: empty fault")
+		}
+		value, err := decodeValue(&node.Nodes[0])
+		if err != nil {
+			return nil, err
+		}
+		fault := &xmlrpcFault{}
+		if v, ok := value.(map[string]any); ok {
+			fault.Code = toInt(v["faultCode"])
+			fault.String = toString(v["faultString"])
+		}
+		return nil, fault
+	case "params":
+		if len(node.Nodes) == 0 || len(node.Nodes[0].Nodes) == 0 {
+			return nil, nil
+		}
+		return decodeValue(&node.Nodes[0].Nodes[0])
+	default:
+		return nil, fmt.Errorf("invalid xmlrpc response: unexpected element %s", node.XMLName.Local)
+	}
+}
+
+// Decode a <value> node.
+func decodeValue(node *xmlNode) (any, error) {
+	if len(node.Nodes) == 0 {
+		// A value without type element is a string
+		return node.Content, nil
+	}
+	typeNode := &node.Nodes[0]
+	content := strings.TrimSpace(typeNode.Content)
+	switch typeNode.XMLName.Local {
+	case "string":
+		return typeNode.Content, nil
+	case "i4", "i8", "int":
+		return strconv.ParseInt(content, 10, 64)
+	case "boolean":
+		return content == "1", nil
+	case "double":
+		return strconv.ParseFloat(content, 64)
+	case "base64":
+		return base64.StdEncoding.DecodeString(content)
+	case "nil":
+		return nil, nil
+	case "array":
+		values := []any{}
+		for _, data := range typeNode.Nodes {
+			for i := range data.Nodes {
+				value, err := decodeValue(&data.Nodes[i])
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+		}
+		return values, nil
+	case "struct":
+		values := map[string]any{}
+		for _, member := range typeNode.Nodes {
+			name := ""
+			var value any
+			for i := range member.Nodes {
+				switch member.Nodes[i].XMLName.Local {
+				case "name":
+					name = member.Nodes[i].Content
+				case "value":
+					v, err := decodeValue(&member.Nodes[i])
+					if err != nil {
+						return nil, err
+					}
+					value = v
+				}
+			}
+			values[name] = value
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported xmlrpc value type %s", typeNode.XMLName.Local)
+	}
+}
+
+func toString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toInt(value any) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case string:
+		i, _ := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return i
+	default:
+		return 0
+	}
+}
+
+func toSlice(value any) []any {
+	if v, ok := value.([]any); ok {
+		return v
+	}
+	return nil
+}
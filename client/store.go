@@ -0,0 +1,52 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/natefinch/atomic"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+)
+
+// Some clients lack features that ptool requires (e.g. categories), which are simulated by ptool with
+// the data kept in a local json store file of client in config dir.
+const STORE_FILE = "client-%s-%s.json"
+
+// Return the store file path of client.
+func StoreFilename(clientName string, clientType string) string {
+	return filepath.Join(config.ConfigDir, fmt.Sprintf(STORE_FILE, clientName, clientType))
+}
+
+// Load the json store file into v. Return false if the file does not exist.
+func LoadStore(filename string, v any) (exists bool, err error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err = json.Unmarshal(contents, v); err != nil {
+		return true, fmt.Errorf("invalid store file %s: %w", filename, err)
+	}
+	return true, nil
+}
+
+// Save v to the json store file atomically.
+func SaveStore(filename string, v any) error {
+	contents, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filename), constants.PERM_DIR); err != nil {
+		return err
+	}
+	return atomic.WriteFile(filename, bytes.NewReader(contents))
+}
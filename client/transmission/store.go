@@ -0,0 +1,30 @@
+package transmission
+
+import (
+	"github.com/sagan/ptool/client"
+)
+
+// Transmission does not have categories nor a global tags list (labels exist only with torrents).
+// Categories are simulated by "category:<name>" torrent labels; ptool keeps the categories (with their save path)
+// and the created tags of Transmission client in a local store file in config dir.
+type store struct {
+	filename   string
+	Categories map[string]string `json:"categories"` // category name => save path
+	Tags       []string          `json:"tags"`       // created tags
+	BannedIps  []string          `json:"banned_ips"` // banned peer ips, written to blocklist file
+}
+
+func loadStore(clientName string) (*store, error) {
+	s := &store{filename: client.StoreFilename(clientName, "transmission")}
+	if _, err := client.LoadStore(s.filename, s); err != nil {
+		return nil, err
+	}
+	if s.Categories == nil {
+		s.Categories = map[string]string{}
+	}
+	return s, nil
+}
+
+func (s *store) save() error {
+	return client.SaveStore(s.filename, s)
+}
@@ -8,13 +8,17 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"slices"
 	"strings"
 
 	"github.com/ettle/strcase"
 	transmissionrpc "github.com/hekmon/transmissionrpc/v2"
+	"github.com/natefinch/atomic"
 	"github.com/sagan/ptool/client"
 	"github.com/sagan/ptool/config"
 	"github.com/sagan/ptool/constants"
@@ -23,6 +27,11 @@ import (
 )
 
 type Client struct {
+	*clientData
+	ctx context.Context // see client.Client.WithContext
+}
+
+type clientData struct {
 	Name                      string
 	ClientConfig              *config.ClientConfigStruct
 	Config                    *config.ConfigStruct
@@ -38,6 +47,11 @@ type Client struct {
 	unfinishedDownloadingSize int64
 	contentPathTorrents       map[string][]*transmissionrpc.Torrent
 	lastTorrent               *transmissionrpc.Torrent // a **really** simple cache with capacity of only one
+	store                     *store
+}
+
+func (trclient *Client) WithContext(ctx context.Context) client.Client {
+	return &Client{clientData: trclient.clientData, ctx: ctx}
 }
 
 func (trclient *Client) GetTorrentsByContentPath(contentPath string) ([]*client.Torrent, error) {
@@ -51,10 +65,104 @@ func (trclient *Client) GetTorrentsByContentPath(contentPath string) ([]*client.
 	return torrents, nil
 }
 
+// The default blocklist-url of Transmission, which is not a real blocklist
+const DEFAULT_BLOCKLIST_URL = "http://www.example.com/blocklist"
+
 var (
 	ErrNotImplemented = errors.New("not implemented yet")
 )
 
+func (trclient *Client) GetTorrentPeers(infoHash string) ([]*client.TorrentPeer, error) {
+	torrents, err := trclient.client.TorrentGetHashes(trclient.ctx, []string{"hashString", "peers"},
+		[]string{infoHash})
+	if err != nil {
+		return nil, err
+	}
+	if len(torrents) == 0 {
+		return nil, fmt.Errorf("torrent not found")
+	}
+	peers := []*client.TorrentPeer{}
+	for _, peer := range torrents[0].Peers {
+		peers = append(peers, &client.TorrentPeer{
+			Address:       net.JoinHostPort(peer.Address, fmt.Sprint(peer.Port)),
+			Ip:            peer.Address,
+			Port:          peer.Port,
+			Client:        peer.ClientName,
+			Progress:      peer.Progress,
+			DownloadSpeed: peer.RateToClient,
+			UploadSpeed:   peer.RateToPeer,
+			Flags:         peer.FlagStr,
+		})
+	}
+	return peers, nil
+}
+
+// Transmission can not ban a peer directly. Banned ips are kept in store and written to the
+// transmissionBlocklistFile (P2P plaintext format), which is then loaded by Transmission as it's blocklist
+// via a "file://" blocklist-url. To not lose the user's own blocklist, it refuses to work if another
+// blocklist-url (other than the Transmission default one) is already set.
+func (trclient *Client) BanPeers(peers []string) error {
+	if len(peers) == 0 {
+		return nil
+	}
+	blocklistFile := trclient.ClientConfig.TransmissionBlocklistFile
+	if blocklistFile == "" {
+		return fmt.Errorf("transmissionBlocklistFile of client is not configured")
+	}
+	blocklistFile, err := filepath.Abs(blocklistFile)
+	if err != nil {
+		return err
+	}
+	blocklistUrl := (&url.URL{Scheme: "file", Path: filepath.ToSlash(blocklistFile)}).String()
+	sessionArgs, err := trclient.client.SessionArgumentsGet(trclient.ctx, []string{"blocklist-url"})
+	if err != nil {
+		return fmt.Errorf("failed to get blocklist-url: %w", err)
+	}
+	if sessionArgs.BlocklistURL != nil && *sessionArgs.BlocklistURL != "" &&
+		*sessionArgs.BlocklistURL != blocklistUrl && *sessionArgs.BlocklistURL != DEFAULT_BLOCKLIST_URL {
+		return fmt.Errorf("client blocklist-url is already set to %s. Clear it in Transmission to allow ptool "+
+			"to use transmissionBlocklistFile as blocklist", *sessionArgs.BlocklistURL)
+	}
+	s, err := trclient.getStore()
+	if err != nil {
+		return err
+	}
+	for _, peer := range peers {
+		ip := peer
+		if host, _, err := net.SplitHostPort(peer); err == nil {
+			ip = host
+		}
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid peer %q", peer)
+		}
+		if !slices.Contains(s.BannedIps, ip) {
+			s.BannedIps = append(s.BannedIps, ip)
+		}
+	}
+	if err = s.save(); err != nil {
+		return fmt.Errorf("failed to save store: %w", err)
+	}
+	blocklist := &strings.Builder{}
+	for _, ip := range s.BannedIps {
+		fmt.Fprintf(blocklist, "ptool:%s-%s\n", ip, ip)
+	}
+	if err = atomic.WriteFile(blocklistFile, strings.NewReader(blocklist.String())); err != nil {
+		return fmt.Errorf("failed to write blocklist file: %w", err)
+	}
+	blocklistEnabled := true
+	err = trclient.client.SessionArgumentsSet(trclient.ctx, transmissionrpc.SessionArguments{
+		BlocklistURL:     &blocklistUrl,
+		BlocklistEnabled: &blocklistEnabled,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set blocklist: %w", err)
+	}
+	if _, err = trclient.client.BlocklistUpdate(trclient.ctx); err != nil {
+		return fmt.Errorf("failed to update blocklist: %w", err)
+	}
+	return nil
+}
+
 // SetAllTorrentsShareLimits implements client.Client.
 func (trclient *Client) SetAllTorrentsShareLimits(ratioLimit float64, seedingTimeLimit int64) error {
 	return ErrNotImplemented
@@ -75,7 +183,7 @@ func (trclient *Client) getTorrent(infoHash string, full bool) (*transmissionrpc
 		return trclient.lastTorrent, nil
 	}
 	transmissionbt := trclient.client
-	torrents, err := transmissionbt.TorrentGetAllForHashes(context.TODO(), []string{infoHash})
+	torrents, err := transmissionbt.TorrentGetAllForHashes(trclient.ctx, []string{infoHash})
 	if err != nil {
 		return nil, err
 	}
@@ -122,9 +230,9 @@ func (trclient *Client) Sync(full bool) (err error) {
 	now := util.Now()
 	var torrents []transmissionrpc.Torrent
 	if full {
-		torrents, err = transmissionbt.TorrentGetAll(context.TODO())
+		torrents, err = transmissionbt.TorrentGetAll(trclient.ctx)
 	} else {
-		torrents, err = transmissionbt.TorrentGet(context.TODO(), []string{
+		torrents, err = transmissionbt.TorrentGet(trclient.ctx, []string{
 			"activityDate", "addedDate", "doneDate", "downloadDir", "downloadedEver", "downloadLimit", "downloadLimited",
 			"hashString", "id", "labels", "name", "peersGettingFromUs", "peersSendingToUs", "percentDone", "rateDownload",
 			"rateUpload", "sizeWhenDone", "status", "trackers", "totalSize", "uploadedEver", "uploadLimit", "uploadLimited",
@@ -169,15 +277,15 @@ func (trclient *Client) syncMeta() error {
 	}
 	transmissionbt := trclient.client
 	now := util.Now()
-	sessionStats, err := transmissionbt.SessionStats(context.TODO())
+	sessionStats, err := transmissionbt.SessionStats(trclient.ctx)
 	if err != nil {
 		return err
 	}
-	sessionArgs, err := transmissionbt.SessionArgumentsGet(context.TODO(), nil)
+	sessionArgs, err := transmissionbt.SessionArgumentsGet(trclient.ctx, nil)
 	if err != nil {
 		return err
 	}
-	freeSpace, err := transmissionbt.FreeSpace(context.TODO(), *sessionArgs.DownloadDir)
+	freeSpace, err := transmissionbt.FreeSpace(trclient.ctx, *sessionArgs.DownloadDir)
 	if err != nil {
 		return err
 	}
@@ -188,8 +296,48 @@ func (trclient *Client) syncMeta() error {
 	return nil
 }
 
+func (trclient *Client) getStore() (*store, error) {
+	if trclient.store != nil {
+		return trclient.store, nil
+	}
+	s, err := loadStore(trclient.Name)
+	if err != nil {
+		return nil, err
+	}
+	trclient.store = s
+	return s, nil
+}
+
+// Transmission does not provide an API to export .torrent file. It's read from the "torrentFile" path of torrent.
+// For remote daemon, the "torrents" dir of transmission must be accessible locally at transmissionTorrentsDir.
 func (trclient *Client) ExportTorrentFile(infoHash string) ([]byte, error) {
-	return nil, fmt.Errorf("unsupported")
+	torrents, err := trclient.client.TorrentGetHashes(trclient.ctx,
+		[]string{"hashString", "torrentFile"}, []string{infoHash})
+	if err != nil {
+		return nil, err
+	}
+	if len(torrents) == 0 {
+		return nil, fmt.Errorf("torrent not found")
+	}
+	trtorrent := &torrents[0]
+	if trtorrent.TorrentFile == nil || *trtorrent.TorrentFile == "" {
+		return nil, fmt.Errorf("torrent file path of torrent is not available")
+	}
+	torrentFile := *trtorrent.TorrentFile
+	if trclient.ClientConfig.TransmissionTorrentsDir != "" {
+		// the torrentFile is a path of the daemon's host, which may be Windows or *nix
+		torrentFile = filepath.Join(trclient.ClientConfig.TransmissionTorrentsDir,
+			filepath.Base(strings.ReplaceAll(torrentFile, `\`, "/")))
+	}
+	contents, err := os.ReadFile(torrentFile)
+	if err != nil {
+		if trclient.ClientConfig.TransmissionTorrentsDir == "" {
+			return nil, fmt.Errorf("failed to read torrent file %s (set transmissionTorrentsDir of client "+
+				"if the daemon is remote): %w", torrentFile, err)
+		}
+		return nil, fmt.Errorf("failed to read torrent file %s: %w", torrentFile, err)
+	}
+	return contents, nil
 }
 
 func (trclient *Client) GetTorrent(infoHash string) (*client.Torrent, error) {
@@ -235,12 +383,22 @@ func (trclient *Client) AddTorrent(torrentContent []byte, option *client.Torrent
 	var downloadDir *string
 	if option.SavePath != "" {
 		downloadDir = &option.SavePath
+	} else if option.Category != "" && option.Category != constants.NONE {
+		// use the save path of category, like qBittorrent Automatic Torrent Management mode
+		s, err := trclient.getStore()
+		if err != nil {
+			return err
+		}
+		if savePath := s.Categories[option.Category]; savePath != "" {
+			downloadDir = &savePath
+		}
 	}
 	payload := transmissionrpc.TorrentAddPayload{
 		Paused:      &option.Pause,
 		DownloadDir: downloadDir,
 	}
-	if util.IsTorrentUrl(string(torrentContent)) {
+	isUrl := util.IsTorrentUrl(string(torrentContent))
+	if isUrl {
 		url := string(torrentContent)
 		payload.Filename = &url
 	} else {
@@ -248,15 +406,18 @@ func (trclient *Client) AddTorrent(torrentContent []byte, option *client.Torrent
 		payload.MetaInfo = &torrentContentB64
 	}
 	// returned torrent will only have HashString, ID and Name fields set up.
-	torrent, err := transmissionbt.TorrentAdd(context.TODO(), payload)
+	torrent, err := transmissionbt.TorrentAdd(trclient.ctx, payload)
 	if err != nil {
 		return err
 	}
 
 	name := option.Name
-	if name != "" {
+	if name != "" && isUrl {
+		// the root file / folder does not exist before metadata is resolved
+		log.Warnf("Can not rename torrent %s added by url, ignore the name option", *torrent.HashString)
+	} else if name != "" {
 		// it's not robust, and will actually rename the root file / folder name on disk
-		err := transmissionbt.TorrentRenamePathHash(context.TODO(), *torrent.HashString, *torrent.Name, name)
+		err := transmissionbt.TorrentRenamePathHash(trclient.ctx, *torrent.HashString, *torrent.Name, name)
 		log.Tracef("rename tr torrent name=%s err=%v", name, err)
 	}
 
@@ -287,7 +448,7 @@ func (trclient *Client) AddTorrent(torrentContent []byte, option *client.Torrent
 		downloadLimited = true
 	}
 	if len(labels) > 0 || uploadLimited || downloadLimited {
-		err := transmissionbt.TorrentSet(context.TODO(), transmissionrpc.TorrentSetPayload{
+		err := transmissionbt.TorrentSet(trclient.ctx, transmissionrpc.TorrentSetPayload{
 			IDs:             []int64{*torrent.ID},
 			Labels:          labels,
 			UploadLimited:   &uploadLimited,
@@ -301,6 +462,14 @@ func (trclient *Client) AddTorrent(torrentContent []byte, option *client.Torrent
 	return nil
 }
 
+func (trclient *Client) AddTorrentUrl(torrentUrl string, option *client.TorrentOption,
+	meta map[string]int64) error {
+	if !util.IsTorrentUrl(torrentUrl) {
+		return fmt.Errorf("invalid torrent url: %s", torrentUrl)
+	}
+	return trclient.AddTorrent([]byte(torrentUrl), option, meta)
+}
+
 func (trclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOption, meta map[string]int64) error {
 	transmissionbt := trclient.client
 	trtorrent, err := trclient.getTorrent(infoHash, false)
@@ -310,7 +479,7 @@ func (trclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOpt
 	torrent := tr2Torrent(trtorrent)
 
 	if option.Name != "" && option.Name != torrent.Name {
-		err := transmissionbt.TorrentRenamePathHash(context.TODO(), infoHash, *trtorrent.Name, option.Name)
+		err := transmissionbt.TorrentRenamePathHash(trclient.ctx, infoHash, *trtorrent.Name, option.Name)
 		if err != nil {
 			return err
 		}
@@ -320,11 +489,13 @@ func (trclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOpt
 		IDs: []int64{*trtorrent.ID},
 	}
 
-	if (option.Category != "" && option.Category != constants.NONE) ||
-		len(option.Tags) > 0 || len(option.RemoveTags) > 0 ||
+	if option.Category != "" || len(option.Tags) > 0 || len(option.RemoveTags) > 0 ||
 		len(meta) > 0 || len(torrent.Meta) > 0 {
 		labels := []string{}
-		if option.Category != "" && torrent.Category != option.Category {
+		categoryRemoved := false
+		if option.Category == constants.NONE {
+			categoryRemoved = torrent.Category != ""
+		} else if option.Category != "" && torrent.Category != option.Category {
 			categoryTag := client.GenerateTorrentTagFromCategory(option.Category)
 			labels = append(labels, categoryTag)
 		} else if torrent.Category != "" {
@@ -341,7 +512,7 @@ func (trclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOpt
 				labels = append(labels, client.GenerateTorrentTagFromMetadata(name, value))
 			}
 		}
-		if len(labels) > 0 || len(option.RemoveTags) > 0 {
+		if len(labels) > 0 || len(option.RemoveTags) > 0 || categoryRemoved {
 			for _, tag := range torrent.Tags {
 				if !slices.Contains(option.RemoveTags, tag) {
 					labels = append(labels, tag)
@@ -386,7 +557,7 @@ func (trclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOpt
 		payload.Location = &option.SavePath
 	}
 
-	transmissionbt.TorrentSet(context.TODO(), payload)
+	transmissionbt.TorrentSet(trclient.ctx, payload)
 
 	if option.Pause {
 		err = trclient.PauseTorrents([]string{infoHash})
@@ -402,7 +573,7 @@ func (trclient *Client) DeleteTorrents(infoHashes []string, deleteFiles bool) (e
 	if err := trclient.Sync(false); err != nil {
 		return err
 	}
-	err = transmissionbt.TorrentRemove(context.TODO(), transmissionrpc.TorrentRemovePayload{
+	err = transmissionbt.TorrentRemove(trclient.ctx, transmissionrpc.TorrentRemovePayload{
 		IDs:             trclient.getIds(infoHashes),
 		DeleteLocalData: deleteFiles,
 	})
@@ -416,19 +587,19 @@ func (trclient *Client) DeleteTorrents(infoHashes []string, deleteFiles bool) (e
 }
 
 func (trclient *Client) PauseTorrents(infoHashes []string) error {
-	return trclient.client.TorrentStopHashes(context.TODO(), infoHashes)
+	return trclient.client.TorrentStopHashes(trclient.ctx, infoHashes)
 }
 
 func (trclient *Client) ResumeTorrents(infoHashes []string) error {
-	return trclient.client.TorrentStartHashes(context.TODO(), infoHashes)
+	return trclient.client.TorrentStartHashes(trclient.ctx, infoHashes)
 }
 
 func (trclient *Client) RecheckTorrents(infoHashes []string) error {
-	return trclient.client.TorrentVerifyHashes(context.TODO(), infoHashes)
+	return trclient.client.TorrentVerifyHashes(trclient.ctx, infoHashes)
 }
 
 func (trclient *Client) ReannounceTorrents(infoHashes []string) error {
-	return trclient.client.TorrentReannounceHashes(context.TODO(), infoHashes)
+	return trclient.client.TorrentReannounceHashes(trclient.ctx, infoHashes)
 }
 
 func (trclient *Client) AddTagsToTorrents(infoHashes []string, tags []string) error {
@@ -470,7 +641,7 @@ func (trclient *Client) RemoveTagsFromTorrents(infoHashes []string, tags []strin
 func (trclient *Client) SetTorrentsSavePath(infoHashes []string, savePath string) error {
 	// it's a limit imposed by transmissionrpc library that can not batch update savePath
 	for _, infoHash := range infoHashes {
-		err := trclient.client.TorrentSetLocationHash(context.TODO(), infoHash, savePath, true)
+		err := trclient.client.TorrentSetLocationHash(trclient.ctx, infoHash, savePath, true)
 		if err != nil {
 			return err
 		}
@@ -479,19 +650,19 @@ func (trclient *Client) SetTorrentsSavePath(infoHashes []string, savePath string
 }
 
 func (trclient *Client) PauseAllTorrents() error {
-	return trclient.client.TorrentStopHashes(context.TODO(), nil)
+	return trclient.client.TorrentStopHashes(trclient.ctx, nil)
 }
 
 func (trclient *Client) ResumeAllTorrents() error {
-	return trclient.client.TorrentStartHashes(context.TODO(), nil)
+	return trclient.client.TorrentStartHashes(trclient.ctx, nil)
 }
 
 func (trclient *Client) RecheckAllTorrents() error {
-	return trclient.client.TorrentVerifyHashes(context.TODO(), nil)
+	return trclient.client.TorrentVerifyHashes(trclient.ctx, nil)
 }
 
 func (trclient *Client) ReannounceAllTorrents() error {
-	return trclient.client.TorrentReannounceHashes(context.TODO(), nil)
+	return trclient.client.TorrentReannounceHashes(trclient.ctx, nil)
 }
 
 func (trclient *Client) AddTagsToAllTorrents(tags []string) error {
@@ -519,8 +690,15 @@ func (trclient *Client) GetTags() ([]string, error) {
 	if err := trclient.Sync(false); err != nil {
 		return nil, err
 	}
-	tags := []string{}
+	s, err := trclient.getStore()
+	if err != nil {
+		return nil, err
+	}
+	tags := util.CopySlice(s.Tags)
 	tagsFlag := map[string]bool{}
+	for _, tag := range tags {
+		tagsFlag[tag] = true
+	}
 	for _, trtorrent := range trclient.torrents {
 		for _, label := range trtorrent.Labels {
 			if label != "" && !tagsFlag[label] && !client.IsSubstituteTag(label) {
@@ -532,28 +710,106 @@ func (trclient *Client) GetTags() ([]string, error) {
 	return tags, nil
 }
 
+// Tags are saved in local store, as labels exist only with torrents in Transmission.
 func (trclient *Client) CreateTags(tags ...string) error {
-	return fmt.Errorf("unsupported")
+	s, err := trclient.getStore()
+	if err != nil {
+		return err
+	}
+	tags = util.Filter(tags, func(tag string) bool {
+		return tag != "" && !client.IsSubstituteTag(tag)
+	})
+	s.Tags = util.UniqueSlice(append(s.Tags, tags...))
+	return s.save()
 }
 
 func (trclient *Client) DeleteTags(tags ...string) error {
+	s, err := trclient.getStore()
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(s.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+		s.Tags = util.Filter(s.Tags, func(tag string) bool {
+			return !slices.Contains(tags, tag)
+		})
+		if err = s.save(); err != nil {
+			return err
+		}
+	}
 	return trclient.RemoveTagsFromAllTorrents(tags)
 }
 
+// Categories (with save path) are saved in local store.
 func (trclient *Client) MakeCategory(category string, savePath string) error {
-	return fmt.Errorf("unsupported")
+	if category == "" || category == constants.NONE {
+		return fmt.Errorf("invalid category name %q", category)
+	}
+	s, err := trclient.getStore()
+	if err != nil {
+		return err
+	}
+	if currentSavePath, ok := s.Categories[category]; ok && (savePath == constants.NONE || savePath == currentSavePath) {
+		return nil
+	}
+	if savePath == constants.NONE {
+		savePath = ""
+	}
+	s.Categories[category] = savePath
+	return s.save()
 }
 
+// Delete categories from local store and remove them from torrents.
 func (trclient *Client) DeleteCategories(categories []string) error {
-	return fmt.Errorf("unsupported")
+	s, err := trclient.getStore()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, category := range categories {
+		if _, ok := s.Categories[category]; ok {
+			delete(s.Categories, category)
+			changed = true
+		}
+	}
+	if changed {
+		if err = s.save(); err != nil {
+			return err
+		}
+	}
+	if err := trclient.Sync(false); err != nil {
+		return err
+	}
+	for infoHash, trtorrent := range trclient.torrents {
+		if category := tr2Torrent(trtorrent).Category; category == "" || !slices.Contains(categories, category) {
+			continue
+		}
+		err := trclient.ModifyTorrent(infoHash, &client.TorrentOption{
+			Category: constants.NONE,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (trclient *Client) GetCategories() ([]*client.TorrentCategory, error) {
 	if err := trclient.Sync(false); err != nil {
 		return nil, err
 	}
+	s, err := trclient.getStore()
+	if err != nil {
+		return nil, err
+	}
 	cats := []*client.TorrentCategory{}
 	catsFlag := map[string]bool{}
+	for cat, savePath := range s.Categories {
+		cats = append(cats, &client.TorrentCategory{
+			Name:     cat,
+			SavePath: savePath,
+		})
+		catsFlag[cat] = true
+	}
 	for _, trtorrent := range trclient.torrents {
 		torrent := tr2Torrent(trtorrent)
 		cat := torrent.GetCategoryFromTag()
@@ -632,6 +888,7 @@ func (trclient *Client) PurgeCache() {
 	trclient.torrents = nil
 	trclient.lastTorrent = nil
 	trclient.contentPathTorrents = nil
+	trclient.store = nil
 }
 
 func (trclient *Client) GetStatus() (*client.Status, error) {
@@ -685,7 +942,7 @@ func (trclient *Client) SetConfig(variable string, value string) error {
 		} else {
 			return fmt.Errorf("invalid value type: %v", kind)
 		}
-		return transmissionbt.SessionArgumentsSet(context.TODO(), args)
+		return transmissionbt.SessionArgumentsSet(trclient.ctx, args)
 	}
 	switch variable {
 	case "global_download_speed_limit":
@@ -698,7 +955,7 @@ func (trclient *Client) SetConfig(variable string, value string) error {
 				limit = 1
 			}
 		}
-		return transmissionbt.SessionArgumentsSet(context.TODO(), transmissionrpc.SessionArguments{
+		return transmissionbt.SessionArgumentsSet(trclient.ctx, transmissionrpc.SessionArguments{
 			SpeedLimitDownEnabled: &limited,
 			SpeedLimitDown:        &limit,
 		})
@@ -712,14 +969,14 @@ func (trclient *Client) SetConfig(variable string, value string) error {
 				limit = 1
 			}
 		}
-		return transmissionbt.SessionArgumentsSet(context.TODO(), transmissionrpc.SessionArguments{
+		return transmissionbt.SessionArgumentsSet(trclient.ctx, transmissionrpc.SessionArguments{
 			SpeedLimitUpEnabled: &limited,
 			SpeedLimitUp:        &limit,
 		})
 	case "free_disk_space", "global_download_speed", "global_upload_speed":
 		return fmt.Errorf("%s is read-only", variable)
 	case "save_path":
-		return transmissionbt.SessionArgumentsSet(context.TODO(), transmissionrpc.SessionArguments{
+		return transmissionbt.SessionArgumentsSet(trclient.ctx, transmissionrpc.SessionArguments{
 			DownloadDir: &value,
 		})
 	default:
@@ -840,7 +1097,7 @@ func (trclient *Client) EditTorrentTracker(infoHash string, oldTracker string, n
 	// this is broken for now as transmission RPC expects trackerReplace to be
 	// a mixed types array of ids (integer) and urls(string)
 	// it's a problem of transmissionrpc library
-	return trclient.client.TorrentSet(context.TODO(), transmissionrpc.TorrentSetPayload{
+	return trclient.client.TorrentSet(trclient.ctx, transmissionrpc.TorrentSetPayload{
 		IDs:            []int64{*trtorrent.ID},
 		TrackerReplace: []any{oldTrackerId, newTrackerUrl},
 	})
@@ -874,7 +1131,7 @@ func (trclient *Client) AddTorrentTrackers(infoHash string, trackers []string,
 		if removeExisting {
 			payload.TrackerRemove = util.Map(trtorrent.Trackers, func(t *transmissionrpc.Tracker) int64 { return t.ID })
 		}
-		return trclient.client.TorrentSet(context.TODO(), payload)
+		return trclient.client.TorrentSet(trclient.ctx, payload)
 	}
 	return nil
 }
@@ -891,7 +1148,7 @@ func (trclient *Client) RemoveTorrentTrackers(infoHash string, trackers []string
 		}
 	}
 	if len(trackerIds) > 0 {
-		return trclient.client.TorrentSet(context.TODO(), transmissionrpc.TorrentSetPayload{
+		return trclient.client.TorrentSet(trclient.ctx, transmissionrpc.TorrentSetPayload{
 			IDs:           []int64{*trtorrent.ID},
 			TrackerRemove: trackerIds,
 		})
@@ -934,20 +1191,28 @@ func NewClient(name string, clientConfig *config.ClientConfigStruct, config *con
 	if (schema != "http" && schema != "https") || hostname == "" || port == 0 {
 		return nil, fmt.Errorf("invalid tr url: %s", clientConfig.Url)
 	}
+	httpClient, err := client.NewHttpClient(clientConfig)
+	if err != nil {
+		return nil, err
+	}
 	client, err := transmissionrpc.New(hostname, clientConfig.Username, clientConfig.Password,
 		&transmissionrpc.AdvancedConfig{
-			HTTPS:  isHttps,
-			Port:   uint16(port),
-			RPCURI: rpcUri,
+			HTTPS:      isHttps,
+			Port:       uint16(port),
+			RPCURI:     rpcUri,
+			HTTPClient: httpClient,
 		})
 	if err != nil {
 		return nil, err
 	}
 	return &Client{
-		Name:         name,
-		ClientConfig: clientConfig,
-		Config:       config,
-		client:       client,
+		clientData: &clientData{
+			Name:         name,
+			ClientConfig: clientConfig,
+			Config:       config,
+			client:       client,
+		},
+		ctx: context.Background(),
 	}, nil
 }
 
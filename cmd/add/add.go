@@ -19,7 +19,7 @@ import (
 )
 
 var command = &cobra.Command{
-	Use:         "add {client} {torrentFilename | torrentId | torrentUrl}...",
+	Use:         "add {client} {torrentFilename | torrentId | torrentUrl | infoHash}...",
 	Aliases:     []string{"addlocal"},
 	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "add"},
 	Short:       "Add torrents to client.",
@@ -32,6 +32,12 @@ download the .torrent file to local and verify it's a valid metainfo file,
 then adding the downloaded .torrent file contents to the BitTorrent client.
 If "--raw" flag is set, it skips above procedures and directly submits the url to the BitTorrent client.
 
+"magnet:" links are always directly submitted to the BitTorrent client.
+A bare info-hash arg (40 or 64 hex chars) is converted to a magnet link,
+using the "publicTrackers" in config file as trackers, and then submitted to the client.
+The category, tags and save path flags are applied to those torrents when they are added,
+and the metadata (.torrent) is resolved by the client itself.
+
 To set the name of added torrent in client, use --rename <name> flag,
 which supports the following variable placeholders:
 * [size] : Torrent size
@@ -118,7 +124,7 @@ func add(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -143,18 +149,33 @@ func add(cmd *cobra.Command, args []string) error {
 
 	for i, torrent := range torrents {
 		fmt.Printf("(%d/%d) ", i+1, len(torrents))
+		option.Name = ""
 		option.Category = ""
 		option.Tags = nil
 		option.SavePath = ""
-		// handle as a special case
+		torrentUrl := ""
 		if util.IsPureTorrentUrl(torrent) || (addRawUrl && util.IsUrl(torrent)) {
+			torrentUrl = torrent
+		} else if !forceLocal && client.IsValidInfoHash(torrent) {
+			torrentUrl = client.GenerateMagnetUrl(torrent, "", config.Get().PublicTrackers)
+		}
+		// handle as a special case
+		if torrentUrl != "" {
 			option.Category = addCategory
-			option.Tags = fixedTags
+			option.Tags = util.CopySlice(fixedTags)
+			if util.IsPureTorrentUrl(torrentUrl) {
+				option.Tags = append(option.Tags, config.PUBLIC_TAG)
+			}
 			option.SavePath = savePath
-			if err = clientInstance.AddTorrent([]byte(torrent), option, nil); err != nil {
+			option.RatioLimit = ratioLimit
+			if ratioLimit == 0 && util.IsPureTorrentUrl(torrentUrl) {
+				option.RatioLimit = config.Get().PublicTorrentRatioLimit
+			}
+			if err = clientInstance.AddTorrentUrl(torrentUrl, option, nil); err != nil {
 				fmt.Printf("✕ %s: failed to add to client: %v\n", torrent, err)
 				errorCnt++
 			} else {
+				cntAdded++
 				fmt.Printf("✓ %s\n", torrent)
 			}
 			continue
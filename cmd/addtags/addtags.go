@@ -48,7 +48,7 @@ func addtags(cmd *cobra.Command, args []string) error {
 			infoHashes = _infoHashes
 		}
 	}
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -77,7 +77,7 @@ func addtrackers(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("the provided tracker %s is not a valid URL", tracker)
 		}
 	}
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
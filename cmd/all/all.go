@@ -6,8 +6,10 @@ import (
 	_ "github.com/sagan/ptool/cmd/addtags"
 	_ "github.com/sagan/ptool/cmd/addtrackers"
 	_ "github.com/sagan/ptool/cmd/alias"
+	_ "github.com/sagan/ptool/cmd/banpeers"
 	_ "github.com/sagan/ptool/cmd/batchdl"
-	_ "github.com/sagan/ptool/cmd/brush"
+	_ "github.com/sagan/ptool/cmd/brush/all"
+	_ "github.com/sagan/ptool/cmd/checkin"
 	_ "github.com/sagan/ptool/cmd/checktag"
 	_ "github.com/sagan/ptool/cmd/clientctl"
 	_ "github.com/sagan/ptool/cmd/configcmd/all"
@@ -27,12 +29,15 @@ import (
 	_ "github.com/sagan/ptool/cmd/gettags"
 	_ "github.com/sagan/ptool/cmd/hardlink/all"
 	_ "github.com/sagan/ptool/cmd/iyuu/all"
+	_ "github.com/sagan/ptool/cmd/login"
 	_ "github.com/sagan/ptool/cmd/maketorrent"
 	_ "github.com/sagan/ptool/cmd/markinvalidtracker"
+	_ "github.com/sagan/ptool/cmd/messages"
 	_ "github.com/sagan/ptool/cmd/movesavepath"
 	_ "github.com/sagan/ptool/cmd/parsetorrent"
 	_ "github.com/sagan/ptool/cmd/partialdownload"
 	_ "github.com/sagan/ptool/cmd/pause"
+	_ "github.com/sagan/ptool/cmd/peers"
 	_ "github.com/sagan/ptool/cmd/publish"
 	_ "github.com/sagan/ptool/cmd/reannounce"
 	_ "github.com/sagan/ptool/cmd/recheck"
@@ -53,6 +58,8 @@ import (
 	_ "github.com/sagan/ptool/cmd/statscmd"
 	_ "github.com/sagan/ptool/cmd/status"
 	_ "github.com/sagan/ptool/cmd/tidyup"
+	_ "github.com/sagan/ptool/cmd/torrentinfo"
+	_ "github.com/sagan/ptool/cmd/torznabserver"
 	_ "github.com/sagan/ptool/cmd/transfertorrent"
 	_ "github.com/sagan/ptool/cmd/verifytorrent"
 	_ "github.com/sagan/ptool/cmd/versioncmd"
@@ -0,0 +1,114 @@
+package banpeers
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/cmd/common"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+	"github.com/sagan/ptool/util/helper"
+)
+
+var command = &cobra.Command{
+	Use: "banpeers {client} {--peer-client clients | --peer-ip ips} " +
+		"[--category category] [--tag tag] [--filter filter] [infoHash]...",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "banpeers"},
+	Short:       "Ban connected peers of torrents of client.",
+	Long: fmt.Sprintf(`Ban connected peers of torrents of client.
+%s.
+
+If both filter flags (--category & --tag & --filter) and args are not set, it will check peers of active torrents.
+If at least one filter flag is set but no arg is provided, the args is assumed to be "_all".
+
+At least one of --peer-client & --peer-ip flags must be set. All matched peers will be banned, e.g.:
+  ptool banpeers local --peer-client "xunlei,xl0012" _all
+
+How peers are banned depends on the client:
+* qBittorrent: the peer ips are added to the banned IPs list.
+* Transmission: the peer ips are written to the "transmissionBlocklistFile" of client config,
+  which is loaded by Transmission as it's blocklist.
+* rTorrent: the connected peers are banned in the torrents and disconnected.
+* Deluge: not supported.`, constants.HELP_INFOHASH_ARGS),
+	Args: cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE: banpeers,
+}
+
+var (
+	dryRun     = false
+	category   = ""
+	tag        = ""
+	filter     = ""
+	peerClient = ""
+	peerIp     = ""
+)
+
+func init() {
+	command.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Dry run. Do NOT actually ban peers")
+	command.Flags().StringVarP(&filter, "filter", "", "", constants.HELP_ARG_FILTER_TORRENT)
+	command.Flags().StringVarP(&category, "category", "", "", constants.HELP_ARG_CATEGORY)
+	command.Flags().StringVarP(&tag, "tag", "", "", constants.HELP_ARG_TAG)
+	command.Flags().StringVarP(&peerClient, "peer-client", "", "",
+		`Comma-separated list. Ban peers which client contains any one in the list (case-insensitive). `+
+			`E.g. "xunlei,xl0012"`)
+	command.Flags().StringVarP(&peerIp, "peer-ip", "", "",
+		`Comma-separated ip or CIDR list. Ban peers which ip matches any one in the list. E.g. "10.0.0.0/8"`)
+	cmd.RootCmd.AddCommand(command)
+}
+
+func banpeers(cmd *cobra.Command, args []string) error {
+	clientName := args[0]
+	infoHashes := args[1:]
+	if peerClient == "" && peerIp == "" {
+		return fmt.Errorf("at least one of --peer-client and --peer-ip flags must be set")
+	}
+	peerFilter, err := common.NewPeerFilter(peerClient, peerIp, false)
+	if err != nil {
+		return err
+	}
+	if category == "" && tag == "" && filter == "" {
+		if len(infoHashes) == 0 {
+			infoHashes = []string{"_active"}
+		} else if _infoHashes, err := helper.ParseInfoHashesFromArgs(infoHashes); err != nil {
+			return err
+		} else {
+			infoHashes = _infoHashes
+		}
+	}
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	infoHashes, err = client.SelectTorrents(clientInstance, category, tag, filter, infoHashes...)
+	if err != nil {
+		return err
+	}
+	torrentsPeers, err := common.GetClientTorrentsPeers(clientInstance, infoHashes, peerFilter)
+	if err != nil {
+		return err
+	}
+	if len(torrentsPeers) == 0 {
+		fmt.Fprintf(os.Stderr, "No matched peers found\n")
+		return nil
+	}
+	client.PrintTorrentPeersHeader(os.Stdout)
+	addresses := []string{}
+	for _, peer := range torrentsPeers {
+		client.PrintTorrentPeers(os.Stdout, peer.InfoHash, []*client.TorrentPeer{peer.TorrentPeer}, false)
+		addresses = append(addresses, peer.Address)
+	}
+	addresses = util.UniqueSlice(addresses)
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "\n// Dry run: %d peers would be banned\n", len(addresses))
+		return nil
+	}
+	if err = clientInstance.BanPeers(addresses); err != nil {
+		return fmt.Errorf("failed to ban peers: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "\n// Banned %d peers\n", len(addresses))
+	return nil
+}
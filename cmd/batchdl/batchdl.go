@@ -299,7 +299,7 @@ func batchdl(command *cobra.Command, args []string) error {
 	var clientAddTorrentOption *client.TorrentOption
 	var clientAddFixedTags []string
 	if addClient != "" {
-		clientInstance, err = client.CreateClient(addClient)
+		clientInstance, err = client.CreateClient(command.Context(), addClient)
 		if err != nil {
 			return fmt.Errorf("failed to create client %s: %w", addClient, err)
 		}
@@ -539,71 +539,91 @@ mainloop:
 			if i > 0 && slowMode {
 				util.Sleep(3)
 			}
-			var torrentContent []byte
-			var _filename string
-			if torrent.DownloadUrl != "" {
-				torrentContent, _filename, _, err = siteInstance.DownloadTorrent(torrent.DownloadUrl)
-			} else {
-				torrentContent, _filename, _, err = siteInstance.DownloadTorrent(torrent.Id)
-			}
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "torrent %s (%s): failed to download: %v\n", torrent.Id, torrent.Name, err)
-				consecutiveFail++
-				if maxConsecutiveFail >= 0 && consecutiveFail > maxConsecutiveFail {
-					log.Errorf("Abort due to too many consecutive fails to download torrent from site")
-					break mainloop
+			if addClient != "" && util.IsPureTorrentUrl(torrent.DownloadUrl) {
+				// magnet link, which is added to client directly and the metadata is resolved by client itself
+				clientAddTorrentOption.Name = ""
+				clientAddTorrentOption.Tags = append(util.CopySlice(clientAddFixedTags), config.PUBLIC_TAG)
+				clientAddTorrentOption.RatioLimit = config.Get().PublicTorrentRatioLimit
+				if addCategoryAuto {
+					clientAddTorrentOption.Category = sitename
+				} else {
+					clientAddTorrentOption.Category = addCategory
+				}
+				err = clientInstance.AddTorrentUrl(torrent.DownloadUrl, clientAddTorrentOption, nil)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "torrent %s (%s): failed to add to client: %v\n", torrent.Id, torrent.Name, err)
+				} else {
+					fmt.Fprintf(os.Stderr, "torrent %s - %s (%s) (seeders=%d, time=%s): added to client (magnet)\n",
+						torrent.Id, torrent.Name, util.BytesSize(float64(torrent.Size)),
+						torrent.Seeders, util.FormatDuration(now-torrent.Time))
 				}
 			} else {
-				consecutiveFail = 0
-				if tinfo, err := torrentutil.ParseTorrent(torrentContent); err != nil {
-					fmt.Fprintf(os.Stderr, "torrent %s (%s): failed to parse: %v\n", torrent.Id, torrent.Name, err)
+				var torrentContent []byte
+				var _filename string
+				if torrent.DownloadUrl != "" {
+					torrentContent, _filename, _, err = siteInstance.DownloadTorrent(torrent.DownloadUrl)
 				} else {
-					if doDownload {
-						if filename == "" {
-							if rename == "" {
-								filename = _filename
+					torrentContent, _filename, _, err = siteInstance.DownloadTorrent(torrent.Id)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "torrent %s (%s): failed to download: %v\n", torrent.Id, torrent.Name, err)
+					consecutiveFail++
+					if maxConsecutiveFail >= 0 && consecutiveFail > maxConsecutiveFail {
+						log.Errorf("Abort due to too many consecutive fails to download torrent from site")
+						break mainloop
+					}
+				} else {
+					consecutiveFail = 0
+					if tinfo, err := torrentutil.ParseTorrent(torrentContent); err != nil {
+						fmt.Fprintf(os.Stderr, "torrent %s (%s): failed to parse: %v\n", torrent.Id, torrent.Name, err)
+					} else {
+						if doDownload {
+							if filename == "" {
+								if rename == "" {
+									filename = _filename
+								} else {
+									filename = torrentutil.RenameTorrent(rename, sitename, torrent.Id, _filename, tinfo)
+								}
+							}
+							err = atomic.WriteFile(filepath.Join(downloadDir, filename), bytes.NewReader(torrentContent))
+							if err != nil {
+								fmt.Fprintf(os.Stderr, "torrent %s: failed to write to %s/file %s: %v\n",
+									torrent.Id, downloadDir, _filename, err)
 							} else {
-								filename = torrentutil.RenameTorrent(rename, sitename, torrent.Id, _filename, tinfo)
+								fmt.Fprintf(os.Stderr, "torrent %s - %s (%s): downloaded to %s/%s\n", torrent.Id, torrent.Name,
+									util.BytesSize(float64(torrent.Size)), downloadDir, filename)
+							}
+						} else if addClient != "" {
+							tags := []string{}
+							tags = append(tags, clientAddFixedTags...)
+							ratioLimit := float64(0)
+							if tinfo.IsPrivate() {
+								tags = append(tags, config.PRIVATE_TAG)
+							} else {
+								tags = append(tags, config.PUBLIC_TAG)
+								ratioLimit = config.Get().PublicTorrentRatioLimit
+							}
+							if torrent.HasHnR || siteInstance.GetSiteConfig().GlobalHnR {
+								tags = append(tags, config.HR_TAG)
+							}
+							clientAddTorrentOption.Tags = tags
+							clientAddTorrentOption.RatioLimit = ratioLimit
+							if addCategoryAuto {
+								clientAddTorrentOption.Category = sitename
+							} else {
+								clientAddTorrentOption.Category = addCategory
+							}
+							if rename != "" {
+								clientAddTorrentOption.Name = torrentutil.RenameTorrent(rename, sitename, torrent.Id, _filename, tinfo)
+							}
+							err = clientInstance.AddTorrent(torrentContent, clientAddTorrentOption, nil)
+							if err != nil {
+								fmt.Fprintf(os.Stderr, "torrent %s (%s): failed to add to client: %v\n", torrent.Id, torrent.Name, err)
+							} else {
+								fmt.Fprintf(os.Stderr, "torrent %s - %s (%s) (seeders=%d, time=%s): added to client\n", torrent.Id,
+									torrent.Name, util.BytesSize(float64(torrent.Size)),
+									torrent.Seeders, util.FormatDuration(now-torrent.Time))
 							}
-						}
-						err = atomic.WriteFile(filepath.Join(downloadDir, filename), bytes.NewReader(torrentContent))
-						if err != nil {
-							fmt.Fprintf(os.Stderr, "torrent %s: failed to write to %s/file %s: %v\n",
-								torrent.Id, downloadDir, _filename, err)
-						} else {
-							fmt.Fprintf(os.Stderr, "torrent %s - %s (%s): downloaded to %s/%s\n", torrent.Id, torrent.Name,
-								util.BytesSize(float64(torrent.Size)), downloadDir, filename)
-						}
-					} else if addClient != "" {
-						tags := []string{}
-						tags = append(tags, clientAddFixedTags...)
-						ratioLimit := float64(0)
-						if tinfo.IsPrivate() {
-							tags = append(tags, config.PRIVATE_TAG)
-						} else {
-							tags = append(tags, config.PUBLIC_TAG)
-							ratioLimit = config.Get().PublicTorrentRatioLimit
-						}
-						if torrent.HasHnR || siteInstance.GetSiteConfig().GlobalHnR {
-							tags = append(tags, config.HR_TAG)
-						}
-						clientAddTorrentOption.Tags = tags
-						clientAddTorrentOption.RatioLimit = ratioLimit
-						if addCategoryAuto {
-							clientAddTorrentOption.Category = sitename
-						} else {
-							clientAddTorrentOption.Category = addCategory
-						}
-						if rename != "" {
-							clientAddTorrentOption.Name = torrentutil.RenameTorrent(rename, sitename, torrent.Id, _filename, tinfo)
-						}
-						err = clientInstance.AddTorrent(torrentContent, clientAddTorrentOption, nil)
-						if err != nil {
-							fmt.Fprintf(os.Stderr, "torrent %s (%s): failed to add to client: %v\n", torrent.Id, torrent.Name, err)
-						} else {
-							fmt.Fprintf(os.Stderr, "torrent %s - %s (%s) (seeders=%d, time=%s): added to client\n", torrent.Id,
-								torrent.Name, util.BytesSize(float64(torrent.Size)),
-								torrent.Seeders, util.FormatDuration(now-torrent.Time))
 						}
 					}
 				}
@@ -0,0 +1,7 @@
+package all
+
+import (
+	_ "github.com/sagan/ptool/cmd/brush"
+	_ "github.com/sagan/ptool/cmd/brush/history"
+	_ "github.com/sagan/ptool/cmd/brush/simulate"
+)
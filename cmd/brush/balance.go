@@ -0,0 +1,104 @@
+package brush
+
+import (
+	"github.com/sagan/ptool/cmd/brush/strategy"
+)
+
+// Brush load of a client, used to distribute new site torrents among clients of a group.
+type clientLoad struct {
+	Name             string
+	FreeSpace        int64 // free disk space above brush min disk space. -1 == unknown
+	UploadSpeed      int64
+	UploadSpeedLimit int64 // <= 0 == unknown
+	// brush max downloading torrents - current downloading torrents
+	DownloadingHeadroom int64
+	// max new torrents of current site that could be added, i.e. number of torrents strategy decided to add
+	Quota int64
+}
+
+// Return current upload speed / limit, in [0, 1].
+func (load *clientLoad) uploadSaturation() float64 {
+	if load.UploadSpeedLimit <= 0 {
+		return 0
+	}
+	return min(float64(load.UploadSpeed)/float64(load.UploadSpeedLimit), 1)
+}
+
+// Return the score of client to accept a new torrent, the higher the better. It's the sum of idle upload bandwidth
+// percent, free disk space and downloading headroom relative to the max ones of all clients, each in [0, 1].
+func (load *clientLoad) score(maxFreeSpace int64, maxDownloadingHeadroom int64) float64 {
+	score := 1 - load.uploadSaturation()
+	if load.FreeSpace == -1 || maxFreeSpace <= 0 {
+		score += 1
+	} else {
+		score += float64(max(load.FreeSpace, 0)) / float64(maxFreeSpace)
+	}
+	if maxDownloadingHeadroom > 0 {
+		score += float64(max(load.DownloadingHeadroom, 0)) / float64(maxDownloadingHeadroom)
+	}
+	return score
+}
+
+// Distribute new site torrents among clients. proposals[i] are the torrents that the strategy decided to add
+// to client i (which already takes client own capacity and site restrictions into account), in preferred order.
+// Each torrent is assigned to at most one client: the one of highest score among clients that proposed it
+// and still have quota. The loads are updated as torrents assigned,
+// so that following torrents go to other clients when one is getting busy.
+// Return the torrents assigned to each client.
+func distributeTorrents(loads []*clientLoad,
+	proposals [][]strategy.AlgorithmAddTorrent) [][]strategy.AlgorithmAddTorrent {
+	assignments := make([][]strategy.AlgorithmAddTorrent, len(loads))
+	// siteId => index of clients that proposed the torrent => the proposed torrent
+	proposers := map[string]map[int]strategy.AlgorithmAddTorrent{}
+	// site ids of all proposed torrents, interleaved by rank so that each client's top choices go first
+	var siteIds []string
+	for rank := 0; ; rank++ {
+		more := false
+		for i := range proposals {
+			if rank >= len(proposals[i]) {
+				continue
+			}
+			more = true
+			torrent := proposals[i][rank]
+			if proposers[torrent.SiteId] == nil {
+				proposers[torrent.SiteId] = map[int]strategy.AlgorithmAddTorrent{}
+				siteIds = append(siteIds, torrent.SiteId)
+			}
+			proposers[torrent.SiteId][i] = torrent
+		}
+		if !more {
+			break
+		}
+	}
+	for _, siteId := range siteIds {
+		maxFreeSpace, maxDownloadingHeadroom := int64(0), int64(0)
+		for _, load := range loads {
+			maxFreeSpace = max(maxFreeSpace, load.FreeSpace)
+			maxDownloadingHeadroom = max(maxDownloadingHeadroom, load.DownloadingHeadroom)
+		}
+		best := -1
+		bestScore := 0.0
+		for i := range loads {
+			if _, ok := proposers[siteId][i]; !ok || loads[i].Quota <= 0 {
+				continue
+			}
+			if score := loads[i].score(maxFreeSpace, maxDownloadingHeadroom); best == -1 || score > bestScore {
+				best = i
+				bestScore = score
+			}
+		}
+		if best == -1 {
+			continue
+		}
+		torrent := proposers[siteId][best]
+		load := loads[best]
+		load.Quota--
+		load.DownloadingHeadroom--
+		if load.FreeSpace != -1 {
+			load.FreeSpace -= torrent.Size
+		}
+		load.UploadSpeed += torrent.PredictionUploadSpeed
+		assignments[best] = append(assignments[best], torrent)
+	}
+	return assignments
+}
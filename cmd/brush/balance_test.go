@@ -0,0 +1,49 @@
+package brush
+
+import (
+	"testing"
+
+	"github.com/sagan/ptool/cmd/brush/strategy"
+)
+
+func TestDistributeTorrents(t *testing.T) {
+	const GiB = int64(1 << 30)
+	torrents := []strategy.AlgorithmAddTorrent{}
+	for _, id := range []string{"s.1", "s.2", "s.3", "s.4"} {
+		torrents = append(torrents, strategy.AlgorithmAddTorrent{SiteId: id, Size: 10 * GiB,
+			PredictionUploadSpeed: 1 << 20})
+	}
+	loads := []*clientLoad{
+		// busy: upload almost full, little free disk
+		{Name: "a", FreeSpace: 20 * GiB, UploadSpeed: 9 << 20, UploadSpeedLimit: 10 << 20,
+			DownloadingHeadroom: 2, Quota: 3},
+		// idle
+		{Name: "b", FreeSpace: 100 * GiB, UploadSpeed: 0, UploadSpeedLimit: 10 << 20,
+			DownloadingHeadroom: 6, Quota: 2},
+	}
+	proposals := [][]strategy.AlgorithmAddTorrent{
+		{torrents[0], torrents[1], torrents[3]},
+		{torrents[0], torrents[1], torrents[2]},
+	}
+	assignments := distributeTorrents(loads, proposals)
+
+	assigned := map[string]string{}
+	for i, load := range loads {
+		for _, torrent := range assignments[i] {
+			if client, ok := assigned[torrent.SiteId]; ok {
+				t.Fatalf("torrent %s assigned to both client %s and %s", torrent.SiteId, client, load.Name)
+			}
+			assigned[torrent.SiteId] = load.Name
+		}
+	}
+	// b is preferred until its quota is used up; s.4 is only proposed to a.
+	want := map[string]string{"s.1": "b", "s.2": "b", "s.3": "", "s.4": "a"}
+	for id, client := range want {
+		if assigned[id] != client {
+			t.Errorf("torrent %s assigned to %q, want %q", id, assigned[id], client)
+		}
+	}
+	if loads[1].Quota != 0 || loads[1].FreeSpace != 80*GiB || loads[1].UploadSpeed != 2<<20 {
+		t.Errorf("client b load not updated: %+v", loads[1])
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"github.com/sagan/ptool/cmd/brush/brush_store"
 	"math/rand"
+	"os"
 	"path/filepath"
+	"slices"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -19,17 +21,43 @@ import (
 	"github.com/sagan/ptool/util/torrentutil"
 )
 
-var command = &cobra.Command{
-	Use:         "brush {client} {site | group}...",
+var Command = &cobra.Command{
+	Use:         "brush {client | group} {site | group}...",
 	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "brush"},
 	Short:       "Brush sites using client.",
-	Long:        `Brush sites using client.`,
-	Args:        cobra.MatchAll(cobra.MinimumNArgs(2), cobra.OnlyValidArgs),
-	RunE:        brush,
+	Long: `Brush sites using client.
+{client | group}: name of a client, or comma-separated list of clients, or a group of clients.
+
+If multiple clients are provided, each site's new torrents are distributed among them:
+the strategy decides torrents to add for each client (which could use the site), then each torrent is
+assigned to only one of them, preferring the client with more idle upload bandwidth, more free disk space and
+more "brushMaxDownloadingTorrents" headroom. Set "brushSites" (list of sites or groups) in client config
+to restrict the sites that could be brushed using the client. After the distribution, the strategy decides again
+for each client with only the torrents assigned to it, so deletions that make room for new torrents only happen
+in the client that adds them. Clients that can not brush any of the sites still get their torrents deleted.
+
+The brush strategy decides which site torrents to add and which client torrents to delete.
+Set "brushStrategy" in client or site config (site config takes precedence) to choose it:
+  default : balanced. Prefer free torrents of high leechers / seeders ratio and small size.
+  conservative : free-only, ratio-first. Only add free torrents that have more leechers than seeders.
+  aggressive : max upload. Prefer torrents of most leechers, rotate slow torrents faster.
+Strategy parameters can be tuned via "brushStrategyParams" of client or site config.
+
+Each run records the speeds, ratio and peers of client brush torrents. The average speeds of torrent
+in "historyWindow" (strategy parameter, default 1h) are used in slow torrents & deletion decisions.
+Use "ptool brush history {client}" to view them.
+
+Use --explain to show why each site torrent is (or is not) added, with it's score components or
+rejection reason, and which rules each client brush torrent matched (with the compared values and thresholds)
+that lead to it being deleted, stalled, resumed, modified or kept. It's useful with --dry-run.`,
+	Args: cobra.MatchAll(cobra.MinimumNArgs(2), cobra.OnlyValidArgs),
+	RunE: brush,
 }
 
 var (
 	dryRun    = false
+	explain   = false
+	showJson  = false
 	addPaused = false
 	ordered   = false
 	force     = false
@@ -37,28 +65,71 @@ var (
 )
 
 func init() {
-	command.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Dry run. Do not actually controlling client")
-	command.Flags().BoolVarP(&addPaused, "add-paused", "", false, "Add torrents to client in paused state")
-	command.Flags().BoolVarP(&ordered, "ordered", "", false, "Brush sites provided in order")
-	command.Flags().BoolVarP(&force, "force", "", false, `Force mode. Ignore "`+config.NOADD_TAG+`" flag tag in client`)
-	command.Flags().Int64VarP(&maxSites, "max-sites", "", -1, "Allowed max succcess sites number, -1 == no limit")
-	cmd.RootCmd.AddCommand(command)
+	Command.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Dry run. Do not actually controlling client")
+	Command.Flags().BoolVarP(&explain, "explain", "", false, "Show explanation of brush decisions")
+	Command.Flags().BoolVarP(&showJson, "json", "", false,
+		"Show explanation of brush decisions in json format (implies --explain)")
+	Command.Flags().BoolVarP(&addPaused, "add-paused", "", false, "Add torrents to client in paused state")
+	Command.Flags().BoolVarP(&ordered, "ordered", "", false, "Brush sites provided in order")
+	Command.Flags().BoolVarP(&force, "force", "", false, `Force mode. Ignore "`+config.NOADD_TAG+`" flag tag in client`)
+	Command.Flags().Int64VarP(&maxSites, "max-sites", "", -1, "Allowed max succcess sites number, -1 == no limit")
+	cmd.RootCmd.AddCommand(Command)
+}
+
+// A client of the brush clients group.
+type brushClient struct {
+	instance client.Client
+	sites    []string // sites allowed to brush using it. nil == no restriction
+	full     bool     // client capacity is full or it's in NoAdd status. Skip it in follow sites
+	decided  bool     // brush decisions of client have been applied on any site
+}
+
+func (bc *brushClient) allowSite(sitename string) bool {
+	return bc.sites == nil || slices.Contains(bc.sites, sitename)
+}
+
+// Brush state of a client on current site.
+type siteClient struct {
+	*brushClient
+	strategy       strategy.Strategy
+	status         *client.Status
+	noadd          bool
+	fetch          bool // client wants new site torrents
+	clientTorrents []*client.Torrent
+	siteTorrents   []*site.Torrent // fetched site torrents provided to strategy
+	siteOption     *strategy.BrushSiteOptionStruct
+	clientOption   *strategy.BrushClientOptionStruct
+	result         *strategy.AlgorithmResult
 }
 
 func brush(cmd *cobra.Command, args []string) (err error) {
-	clientName := args[0]
+	clientNames := config.ParseGroupAndOtherNames(util.SplitCsv(args[0])...)
 	sitenames := config.ParseGroupAndOtherNamesWithoutDeduplicate(args[1:]...)
-	clientInstance, err := client.CreateClient(clientName)
-	if err != nil {
-		return err
-	}
-	lock, err := config.LockConfigDirFile(fmt.Sprintf(config.CLIENT_LOCK_FILE, clientName))
-	if err != nil {
-		return err
+	if len(clientNames) == 0 {
+		return fmt.Errorf("no clients provided")
 	}
-	defer lock.Unlock()
-	if clientInstance.GetClientConfig().Type == "transmission" {
-		log.Warnf("Warning: brush function of transmission client has NOT been tested")
+	var brushClients []*brushClient
+	for _, clientName := range clientNames {
+		if !client.ClientExists(clientName) {
+			return fmt.Errorf("%s is not a client", clientName)
+		}
+		clientInstance, err := client.CreateClient(cmd.Context(), clientName)
+		if err != nil {
+			return err
+		}
+		lock, err := config.LockConfigDirFile(fmt.Sprintf(config.CLIENT_LOCK_FILE, clientName))
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock()
+		if clientInstance.GetClientConfig().Type == "transmission" {
+			log.Warnf("Warning: brush function of transmission client has NOT been tested")
+		}
+		bc := &brushClient{instance: clientInstance}
+		if clientInstance.GetClientConfig().BrushSites != nil {
+			bc.sites = config.ParseGroupAndOtherNames(clientInstance.GetClientConfig().BrushSites...)
+		}
+		brushClients = append(brushClients, bc)
 	}
 	if !ordered {
 		rand.Shuffle(len(sitenames), func(i, j int) { sitenames[i], sitenames[j] = sitenames[j], sitenames[i] })
@@ -68,6 +139,7 @@ func brush(cmd *cobra.Command, args []string) (err error) {
 	cntSkipSite := int64(0)
 	cntAddTorrents := int64(0)
 	cntDeleteTorrents := int64(0)
+	explains := []*siteExplain{}
 	var statDb *stats.StatDb
 	if config.Get().BrushEnableStats {
 		statDb, err = stats.NewDb(filepath.Join(config.ConfigDir, config.STATS_FILENAME))
@@ -78,6 +150,17 @@ func brush(cmd *cobra.Command, args []string) (err error) {
 	// 数据库初始化
 	brush_store.BrushStoreDBManagerGlobal = brush_store.NewBrushStoreDBManager()
 	torrentRecordManager := brush_store.NewTorrentRecordManager(brush_store.BrushStoreDBManagerGlobal.GetDB())
+	torrentSampleManager := brush_store.NewTorrentSampleManager(brush_store.BrushStoreDBManagerGlobal.GetDB())
+	siteTorrentSnapshotManager := brush_store.NewSiteTorrentSnapshotManager(
+		brush_store.BrushStoreDBManagerGlobal.GetDB())
+	for _, bc := range brushClients {
+		clientName := bc.instance.GetName()
+		if clientTorrents, err := bc.instance.GetTorrents("", config.BRUSH_CAT, true); err != nil {
+			log.Warnf("Failed to get client %s torrents, torrents speed history not recorded: %v", clientName, err)
+		} else {
+			torrentSampleManager.AddSamples(getTorrentSamples(clientName, clientTorrents, util.Now()))
+		}
+	}
 
 	for i, sitename := range sitenames {
 		siteInstance, err := site.CreateSite(sitename)
@@ -85,227 +168,216 @@ func brush(cmd *cobra.Command, args []string) (err error) {
 			log.Errorf("Failed to get instance of site %s: %v", sitename, err)
 			continue
 		}
-		log.Printf("Brush client %s site %s", clientInstance.GetName(), sitename)
-		status, err := clientInstance.GetStatus()
-		if err != nil {
-			log.Printf("Failed to get client %s status: %v", clientInstance.GetName(), err)
+		var siteClients []*siteClient
+		fetch := false
+		for _, bc := range brushClients {
+			if bc.full || !bc.allowSite(sitename) {
+				continue
+			}
+			clientInstance := bc.instance
+			brushStrategy, err := strategy.GetStrategy(clientInstance.GetClientConfig(), siteInstance.GetSiteConfig())
+			if err != nil {
+				log.Errorf("Failed to get brush strategy of client %s site %s: %v", clientInstance.GetName(), sitename, err)
+				continue
+			}
+			params := brushStrategy.Params()
+			log.Printf("Brush client %s site %s using strategy %s", clientInstance.GetName(), sitename, brushStrategy.Name())
+			log.Debugf("Brush strategy params: %v", params.Strings())
+			status, err := clientInstance.GetStatus()
+			if err != nil {
+				log.Printf("Failed to get client %s status: %v", clientInstance.GetName(), err)
+				continue
+			}
+			sc := &siteClient{brushClient: bc, strategy: brushStrategy, status: status, noadd: !force && status.NoAdd}
+			if status.UploadSpeedLimit > 0 && (status.UploadSpeedLimit < params.SlowUploadSpeed ||
+				(float64(status.UploadSpeed)/float64(status.UploadSpeedLimit)) >= params.BandwidthFullPercent) {
+				log.Printf(
+					"Client %s upload bandwidth is already full (Up speed/limit: %s/s/%s/s). Do not fetch site new torrents\n",
+					clientInstance.GetName(),
+					util.BytesSize(float64(status.UploadSpeed)),
+					util.BytesSize(float64(status.UploadSpeedLimit)),
+				)
+			} else if sc.noadd {
+				log.Printf("Client %s in NoAdd status. Do not fetch site new torrents", clientInstance.GetName())
+			} else {
+				sc.fetch = true
+				fetch = true
+			}
+			siteClients = append(siteClients, sc)
+		}
+		if len(siteClients) == 0 {
+			log.Printf("No available client to brush site %s. Skip it", sitename)
+			cntSkipSite++
 			continue
 		}
-		noadd := !force && status.NoAdd
 		var siteTorrents []*site.Torrent
-		if status.UploadSpeedLimit > 0 && (status.UploadSpeedLimit < strategy.SLOW_UPLOAD_SPEED ||
-			(float64(status.UploadSpeed)/float64(status.UploadSpeedLimit)) >= strategy.BANDWIDTH_FULL_PERCENT) {
-			log.Printf(
-				"Client %s upload bandwidth is already full (Up speed/limit: %s/s/%s/s). Do not fetch site new torrents\n",
-				clientInstance.GetName(),
-				util.BytesSize(float64(status.UploadSpeed)),
-				util.BytesSize(float64(status.UploadSpeedLimit)),
-			)
+		if !fetch {
+			log.Printf("No client wants new torrents. Do not fetch site %s new torrents", sitename)
 		} else if !siteInstance.GetSiteConfig().BrushAllowHr && siteInstance.GetSiteConfig().GlobalHnR {
 			log.Printf("Site %s enforces global HnR. Do not fetch site new torrents", sitename)
-		} else if noadd {
-			log.Printf("Client %s in NoAdd status. Do not fetch site new torrents", clientInstance.GetName())
 		} else {
 			siteTorrents, err = siteInstance.GetLatestTorrents(true)
 			if err != nil {
 				log.Printf("failed to fetch site %s torrents: %v", sitename, err)
+			} else {
+				siteTorrentSnapshotManager.AddSnapshots(getSiteTorrentSnapshots(sitename, siteTorrents, util.Now()))
 			}
 		}
 
-		clientTorrents, err := clientInstance.GetTorrents("", config.BRUSH_CAT, true)
-		if err != nil {
-			log.Printf("Failed to get client %s torrents: %v ", clientInstance.GetName(), err)
-			continue
-		}
-		brushSiteOption := strategy.GetBrushSiteOptions(siteInstance, util.Now())
-		brushMaxTorrents := clientInstance.GetClientConfig().BrushMaxTorrents
-		if siteInstance.GetSiteConfig().BrushAllowAddTorrentsPercent != 0 {
-			p := float64(siteInstance.GetSiteConfig().BrushAllowAddTorrentsPercent) / 100.0
-			brushMaxTorrents = int64(p * float64(clientInstance.GetClientConfig().BrushMaxTorrents))
-		}
-
-		currentTorrents := len(getTorrentsOfSite(clientTorrents, sitename))
-		brushSiteOption.AllowAddTorrents = brushMaxTorrents - int64(currentTorrents)
-		log.Printf("Site %s already have %d torrents, max %d, allow %d", sitename,
-			len(getTorrentsOfSite(clientTorrents, sitename)), brushMaxTorrents, brushSiteOption.AllowAddTorrents)
-		brushClientOption := strategy.GetBrushClientOptions(clientInstance)
-		log.Printf(
-			"Brush Options: minDiskSpace=%v, slowUploadSpeedTier=%v, torrentUploadSpeedLimit=%v/s,"+
-				" maxDownloadingTorrents=%d, maxTorrents=%d, minRatio=%f",
-			util.BytesSize(float64(brushClientOption.MinDiskSpace)),
-			util.BytesSize(float64(brushClientOption.SlowUploadSpeedTier)),
-			util.BytesSize(float64(brushSiteOption.TorrentUploadSpeedLimit)),
-			brushClientOption.MaxDownloadingTorrents,
-			brushClientOption.MaxTorrents,
-			brushClientOption.MinRatio,
-		)
-		result := strategy.Decide(status, clientTorrents, siteTorrents, brushSiteOption, brushClientOption)
-		log.Printf(
-			"Current client %s torrents: %d; Download speed / limit: %s/s / %s/s; "+
-				"Upload speed / limit: %s/s / %s/s;Free disk space: %s;",
-			clientInstance.GetName(),
-			len(clientTorrents),
-			util.BytesSize(float64(status.DownloadSpeed)),
-			util.BytesSize(float64(status.DownloadSpeedLimit)),
-			util.BytesSize(float64(status.UploadSpeed)),
-			util.BytesSize(float64(status.UploadSpeedLimit)),
-			util.BytesSize(float64(status.FreeSpaceOnDisk)),
-		)
-		log.Printf(
-			"Fetched site %s torrents: %d; Client add / modify / stall / delete torrents: %d / %d / %d / %d. Msg: %s",
-			siteInstance.GetName(),
-			len(siteTorrents),
-			len(result.AddTorrents),
-			len(result.ModifyTorrents),
-			len(result.StallTorrents),
-			len(result.DeleteTorrents),
-			result.Msg,
-		)
-
-		// delete
-		var deleteTorrentStats []*stats.TorrentStat
-		var deleteTorrentInfoHashes []string
-		log.Printf("Delete torrents:")
-		for _, torrent := range result.DeleteTorrents {
-			clientTorrent := *util.FindInSlice(clientTorrents, func(t *client.Torrent) bool {
-				return t.InfoHash == torrent.InfoHash
-			})
-			// double check
-			if clientTorrent == nil || clientTorrent.Category != config.BRUSH_CAT {
-				log.Warnf("Invalid torrent deletion target: %s", torrent.InfoHash)
+		// decide torrents to add per client. It's only a proposal and is not applied
+		var decidedClients []*siteClient
+		for _, sc := range siteClients {
+			clientInstance := sc.instance
+			clientTorrents, err := clientInstance.GetTorrents("", config.BRUSH_CAT, true)
+			if err != nil {
+				log.Printf("Failed to get client %s torrents: %v ", clientInstance.GetName(), err)
 				continue
 			}
-			duration := brushSiteOption.Now - clientTorrent.Atime
-			log.Printf("Torrent %s (%v): %v", torrent.Name, torrent.InfoHash, torrent.Msg)
-			log.Printf("Total Dl / Up: %s / %s; Lifespan: %s; Average lifespan Dl / Up speed: %s/s / %s/s",
-				util.BytesSize(float64(clientTorrent.Downloaded)),
-				util.BytesSize(float64(clientTorrent.Uploaded)),
-				util.GetDurationString(duration),
-				util.BytesSize(float64(clientTorrent.Downloaded)/float64(duration)),
-				util.BytesSize(float64(clientTorrent.Uploaded)/float64(duration)),
-			)
-			deleteTorrentStats = append(deleteTorrentStats, &stats.TorrentStat{
-				Client:     clientInstance.GetName(),
-				Site:       clientTorrent.GetSiteFromTag(),
-				InfoHash:   clientTorrent.InfoHash,
-				Category:   clientTorrent.Category,
-				Name:       clientTorrent.Name,
-				Atime:      clientTorrent.Atime,
-				Size:       clientTorrent.Size,
-				Uploaded:   clientTorrent.Uploaded,
-				Downloaded: clientTorrent.Downloaded,
-				Msg:        torrent.Msg,
-			})
-			deleteTorrentInfoHashes = append(deleteTorrentInfoHashes, clientTorrent.InfoHash)
-		}
-		if !dryRun {
-			err := client.DeleteTorrentsAuto(clientInstance, deleteTorrentInfoHashes)
-			log.Printf("Delete torrents result: error=%v", err)
-			if err == nil {
-				cntDeleteTorrents += int64(len(deleteTorrentInfoHashes))
-				if statDb != nil {
-					statDb.AddTorrentStats(brushSiteOption.Now, 1, deleteTorrentStats)
-				}
+			brushSiteOption := strategy.GetBrushSiteOptions(siteInstance, util.Now())
+			brushMaxTorrents := clientInstance.GetClientConfig().BrushMaxTorrents
+			if siteInstance.GetSiteConfig().BrushAllowAddTorrentsPercent != 0 {
+				p := float64(siteInstance.GetSiteConfig().BrushAllowAddTorrentsPercent) / 100.0
+				brushMaxTorrents = int64(p * float64(clientInstance.GetClientConfig().BrushMaxTorrents))
 			}
-		}
 
-		// stall
-		for _, torrent := range result.StallTorrents {
-			log.Printf("Stall client %s torrent: %v / %v / %v",
-				clientInstance.GetName(), torrent.Name, torrent.InfoHash, torrent.Msg)
-			if dryRun {
-				continue
+			currentTorrents := len(getTorrentsOfSite(clientTorrents, sitename))
+			brushSiteOption.AllowAddTorrents = brushMaxTorrents - int64(currentTorrents)
+			log.Printf("Client %s site %s already have %d torrents, max %d, allow %d", clientInstance.GetName(),
+				sitename, currentTorrents, brushMaxTorrents, brushSiteOption.AllowAddTorrents)
+			brushClientOption := getBrushClientOption(sc, brushSiteOption.Now, torrentSampleManager,
+				torrentRecordManager)
+			log.Printf(
+				"Brush Options: minDiskSpace=%v, slowUploadSpeedTier=%v, torrentUploadSpeedLimit=%v/s,"+
+					" maxDownloadingTorrents=%d, maxTorrents=%d, minRatio=%f",
+				util.BytesSize(float64(brushClientOption.MinDiskSpace)),
+				util.BytesSize(float64(brushClientOption.SlowUploadSpeedTier)),
+				util.BytesSize(float64(brushSiteOption.TorrentUploadSpeedLimit)),
+				brushClientOption.MaxDownloadingTorrents,
+				brushClientOption.MaxTorrents,
+				brushClientOption.MinRatio,
+			)
+			if sc.fetch {
+				sc.siteTorrents = siteTorrents
 			}
-			err := clientInstance.ModifyTorrent(torrent.InfoHash, &client.TorrentOption{
-				DownloadSpeedLimit: strategy.STALL_DOWNLOAD_SPEED,
-			}, torrent.Meta)
-			log.Printf("Stall torrent result: error=%v", err)
+			proposalClientOption := *brushClientOption
+			proposalClientOption.NoRecord = true
+			sc.clientTorrents = clientTorrents
+			sc.siteOption = brushSiteOption
+			sc.clientOption = brushClientOption
+			sc.result = sc.strategy.Decide(sc.status, clientTorrents, sc.siteTorrents, brushSiteOption,
+				&proposalClientOption)
+			log.Printf(
+				"Current client %s torrents: %d; Download speed / limit: %s/s / %s/s; "+
+					"Upload speed / limit: %s/s / %s/s;Free disk space: %s;",
+				clientInstance.GetName(),
+				len(clientTorrents),
+				util.BytesSize(float64(sc.status.DownloadSpeed)),
+				util.BytesSize(float64(sc.status.DownloadSpeedLimit)),
+				util.BytesSize(float64(sc.status.UploadSpeed)),
+				util.BytesSize(float64(sc.status.UploadSpeedLimit)),
+				util.BytesSize(float64(sc.status.FreeSpaceOnDisk)),
+			)
+			decidedClients = append(decidedClients, sc)
 		}
 
-		// resume
-		if len(result.ResumeTorrents) > 0 {
-			for _, torrent := range result.ResumeTorrents {
-				log.Printf("Resume client %s torrent: %v / %v / %v",
-					clientInstance.GetName(), torrent.Name, torrent.InfoHash, torrent.Msg)
-			}
-			if !dryRun {
-				err := clientInstance.ResumeTorrents(util.Map(result.ResumeTorrents,
-					func(t strategy.AlgorithmOperationTorrent) string {
-						return t.InfoHash
-					}))
-				log.Printf("Resume torrents result: error=%v", err)
-			}
+		// distribute new torrents among clients
+		loads := make([]*clientLoad, len(decidedClients))
+		proposals := make([][]strategy.AlgorithmAddTorrent, len(decidedClients))
+		for j, sc := range decidedClients {
+			loads[j] = getClientLoad(sc)
+			proposals[j] = sc.result.AddTorrents
 		}
-
-		// modify
-		for _, torrent := range result.ModifyTorrents {
-			log.Printf("Modify client %s torrent: %v / %v / %v / %v ",
-				clientInstance.GetName(), torrent.Name, torrent.InfoHash, torrent.Msg, torrent.Meta)
-			if dryRun {
-				continue
+		assignments := distributeTorrents(loads, proposals)
+		assignedClients := map[string]string{} // site torrent id => client name
+		for j, sc := range decidedClients {
+			for _, torrent := range assignments[j] {
+				assignedClients[torrent.SiteId] = sc.instance.GetName()
 			}
-			err := clientInstance.ModifyTorrent(torrent.InfoHash, nil, torrent.Meta)
-			log.Printf("Modify torrent result: error=%v", err)
 		}
 
-		// add
-		cndAddTorrents := 0
-		for _, torrent := range result.AddTorrents {
-			log.Printf("Add site %s torrent to client %s: %s / %s / %v",
-				siteInstance.GetName(), clientInstance.GetName(), torrent.Name, torrent.Msg, torrent.Meta)
-			if dryRun {
-				continue
-			}
-			torrentdata, _, _, err := siteInstance.DownloadTorrent(torrent.DownloadUrl)
-			if err != nil {
-				log.Printf("Failed to download: %s. Skip \n", err)
-				continue
-			}
-			tinfo, err := torrentutil.ParseTorrent(torrentdata)
-			if err != nil {
-				continue
-			}
-			pClientTorrent, _ := clientInstance.GetTorrent(tinfo.InfoHash)
-			if pClientTorrent != nil {
-				log.Printf("Already existing in client. skip\n")
-				continue
+		// decide again per client with only the torrents assigned to it, so that deletions made for
+		// adding new torrents only happen in the client which actually adds them. Then apply it
+		cntAssigned := 0
+		for j, sc := range decidedClients {
+			cntAssigned += len(assignments[j])
+			proposal := sc.result
+			var assignedSiteTorrents []*site.Torrent
+			for _, siteTorrent := range sc.siteTorrents {
+				if assignedClients[siteTorrent.IDFull()] == sc.instance.GetName() {
+					assignedSiteTorrents = append(assignedSiteTorrents, siteTorrent)
+				}
 			}
-			if clientInstance.TorrentRootPathExists(tinfo.RootDir) {
-				log.Printf("torrent rootpath %s existing in client. skip\n", tinfo.RootDir)
-				continue
+			result := sc.strategy.Decide(sc.status, sc.clientTorrents, assignedSiteTorrents, sc.siteOption,
+				sc.clientOption)
+			siteTorrentsExplainMap := map[string]*strategy.SiteTorrentExplain{}
+			for _, siteTorrentExplain := range result.SiteTorrentsExplain {
+				siteTorrentsExplainMap[siteTorrentExplain.Id] = siteTorrentExplain
 			}
-			torrentRecordManager.CreateTorrentRecord(torrent.SiteId, tinfo.InfoHash, torrent.Name)
-			log.Printf("torrent info: %s\n", tinfo.InfoHash)
-			cndAddTorrents++
-			tags := []string{client.GenerateTorrentTagFromSite(siteInstance.GetName())}
-			if tinfo.IsPrivate() {
-				tags = append(tags, config.PRIVATE_TAG)
-			} else {
-				tags = append(tags, config.PUBLIC_TAG)
+			for k, siteTorrentExplain := range proposal.SiteTorrentsExplain {
+				if siteTorrentsExplainMap[siteTorrentExplain.Id] != nil {
+					proposal.SiteTorrentsExplain[k] = siteTorrentsExplainMap[siteTorrentExplain.Id]
+				}
 			}
-			torrentOption := &client.TorrentOption{
-				Name:             torrent.Name,
-				Pause:            addPaused,
-				Category:         config.BRUSH_CAT,
-				Tags:             tags,
-				UploadSpeedLimit: siteInstance.GetSiteConfig().TorrentUploadSpeedLimitValue,
+			result.SiteTorrentsExplain = proposal.SiteTorrentsExplain
+			sc.result = result
+			sc.decided = true
+			log.Printf(
+				"Fetched site %s torrents: %d; Client %s add / modify / stall / delete torrents: %d / %d / %d / %d. Msg: %s",
+				siteInstance.GetName(),
+				len(sc.siteTorrents),
+				sc.instance.GetName(),
+				len(result.AddTorrents),
+				len(result.ModifyTorrents),
+				len(result.StallTorrents),
+				len(result.DeleteTorrents),
+				result.Msg,
+			)
+			for _, torrent := range proposal.AddTorrents {
+				clientName := assignedClients[torrent.SiteId]
+				if clientName == sc.instance.GetName() {
+					continue
+				}
+				reason := "other torrents are assigned to this client first"
+				if clientName != "" {
+					reason = fmt.Sprintf("assigned to client %s", clientName)
+				}
+				log.Printf("Site %s torrent %s is not added to client %s: %s",
+					sitename, torrent.Name, sc.instance.GetName(), reason)
+				for _, siteTorrentExplain := range result.SiteTorrentsExplain {
+					if siteTorrentExplain.Id == torrent.SiteId {
+						siteTorrentExplain.Action = "skip"
+						siteTorrentExplain.Reason = reason
+					}
+				}
 			}
-			if !dryRun {
-				err = clientInstance.AddTorrent(torrentdata, torrentOption, torrent.Meta)
-				log.Printf("Add torrent result: error=%v", err)
-				if err == nil {
-					cntAddTorrents++
+			if explain || showJson {
+				explanation := newSiteExplain(sc.instance.GetName(), sitename, sc.strategy, result)
+				if showJson {
+					explains = append(explains, explanation)
+				} else {
+					printSiteExplain(os.Stdout, explanation)
 				}
 			}
+			cntDeleteTorrents += applyResult(sc, statDb)
+			cntAddTorrents += addTorrents(sc, siteInstance, result.AddTorrents, brushClients, torrentRecordManager)
 		}
 
-		if len(result.AddTorrents) > 0 {
+		if cntAssigned > 0 {
 			cntSuccessSite++
 		} else {
 			cntSkipSite++
 		}
-		if noadd {
-			log.Printf("Client in NoAdd status. Skip follow sites.")
+		for _, sc := range decidedClients {
+			if sc.noadd {
+				log.Printf("Client %s in NoAdd status. Skip it in follow sites.", sc.instance.GetName())
+				sc.full = true
+			} else if !sc.result.CanAddMore {
+				log.Printf("Client %s capacity is full. Skip it in follow sites.", sc.instance.GetName())
+				sc.full = true
+			}
+		}
+		if !slices.ContainsFunc(brushClients, func(bc *brushClient) bool { return !bc.full }) {
+			log.Printf("All clients are full or in NoAdd status. Stop brushing.")
 			cntSkipSite += int64(len(sitenames) - 1 - i)
 			break
 		}
@@ -314,26 +386,262 @@ func brush(cmd *cobra.Command, args []string) (err error) {
 			cntSkipSite += int64(len(sitenames) - 1 - i)
 			break
 		}
-		if !result.CanAddMore {
-			log.Printf("Client capacity is full. Stop brushing.")
-			cntSkipSite += int64(len(sitenames) - 1 - i)
-			break
+		if i < len(sitenames)-1 {
+			changed := false
+			for _, sc := range decidedClients {
+				if len(sc.result.AddTorrents) > 0 || len(sc.result.ModifyTorrents) > 0 ||
+					len(sc.result.DeleteTorrents) > 0 || len(sc.result.StallTorrents) > 0 {
+					sc.instance.PurgeCache()
+					changed = true
+				}
+			}
+			if changed {
+				util.Sleep(3)
+			}
+		}
+	}
+
+	// clients that are not used to brush any site still get their torrents deleted, stalled etc. as decided
+	for _, bc := range brushClients {
+		if bc.decided {
+			continue
+		}
+		clientInstance := bc.instance
+		brushStrategy, err := strategy.GetStrategy(clientInstance.GetClientConfig(), nil)
+		if err != nil {
+			log.Errorf("Failed to get brush strategy of client %s: %v", clientInstance.GetName(), err)
+			continue
+		}
+		status, err := clientInstance.GetStatus()
+		if err != nil {
+			log.Printf("Failed to get client %s status: %v", clientInstance.GetName(), err)
+			continue
 		}
-		if i < len(sitenames)-1 && (len(result.AddTorrents) > 0 || len(result.ModifyTorrents) > 0 ||
-			len(result.DeleteTorrents) > 0 || len(result.StallTorrents) > 0) {
-			clientInstance.PurgeCache()
-			util.Sleep(3)
+		clientTorrents, err := clientInstance.GetTorrents("", config.BRUSH_CAT, true)
+		if err != nil {
+			log.Printf("Failed to get client %s torrents: %v ", clientInstance.GetName(), err)
+			continue
+		}
+		sc := &siteClient{brushClient: bc, strategy: brushStrategy, status: status, clientTorrents: clientTorrents,
+			siteOption: &strategy.BrushSiteOptionStruct{Now: util.Now()}}
+		sc.clientOption = getBrushClientOption(sc, sc.siteOption.Now, torrentSampleManager, torrentRecordManager)
+		sc.result = brushStrategy.Decide(status, clientTorrents, nil, sc.siteOption, sc.clientOption)
+		log.Printf("Client %s is not used to brush any site. Modify / stall / delete torrents: %d / %d / %d. Msg: %s",
+			clientInstance.GetName(), len(sc.result.ModifyTorrents), len(sc.result.StallTorrents),
+			len(sc.result.DeleteTorrents), sc.result.Msg)
+		if explain || showJson {
+			explanation := newSiteExplain(clientInstance.GetName(), "", brushStrategy, sc.result)
+			if showJson {
+				explains = append(explains, explanation)
+			} else {
+				printSiteExplain(os.Stdout, explanation)
+			}
 		}
+		cntDeleteTorrents += applyResult(sc, statDb)
 	}
 
-	fmt.Printf("Finish brushing %d sites: successSites=%d, skipSites=%d; Added / Deleted torrents: %d / %d\n",
-		len(sitenames), cntSuccessSite, cntSkipSite, cntAddTorrents, cntDeleteTorrents)
+	if showJson {
+		if err := util.PrintJson(os.Stdout, explains); err != nil {
+			log.Errorf("Failed to print explains: %v", err)
+		}
+		log.Printf("Finish brushing %d sites: successSites=%d, skipSites=%d; Added / Deleted torrents: %d / %d",
+			len(sitenames), cntSuccessSite, cntSkipSite, cntAddTorrents, cntDeleteTorrents)
+	} else {
+		fmt.Printf("Finish brushing %d sites: successSites=%d, skipSites=%d; Added / Deleted torrents: %d / %d\n",
+			len(sitenames), cntSuccessSite, cntSkipSite, cntAddTorrents, cntDeleteTorrents)
+	}
 	if cntSuccessSite == 0 {
 		return fmt.Errorf("no sites successed")
 	}
 	return nil
 }
 
+// Return the brush client options of client for a brush run at now.
+func getBrushClientOption(sc *siteClient, now int64, torrentSampleManager *brush_store.TorrentSampleManager,
+	torrentRecordManager *brush_store.TorrentRecordManager) *strategy.BrushClientOptionStruct {
+	clientOption := strategy.GetBrushClientOptions(sc.instance)
+	clientOption.TorrentsHistory = torrentSampleManager.GetHistories(sc.instance.GetName(), now,
+		sc.strategy.Params().HistoryWindow)
+	clientOption.TorrentRecordManager = torrentRecordManager
+	clientOption.Explain = explain || showJson
+	return clientOption
+}
+
+// Return the brush load of client after the decided deletions.
+func getClientLoad(sc *siteClient) *clientLoad {
+	params := sc.strategy.Params()
+	clientOption := strategy.GetBrushClientOptions(sc.instance)
+	load := &clientLoad{
+		Name:             sc.instance.GetName(),
+		FreeSpace:        -1,
+		UploadSpeed:      sc.status.UploadSpeed,
+		UploadSpeedLimit: sc.status.UploadSpeedLimit,
+		DownloadingHeadroom: clientOption.MaxDownloadingTorrents -
+			strategy.CountDownloadingTorrents(sc.clientTorrents, sc.siteOption.Now, params),
+		Quota: int64(len(sc.result.AddTorrents)),
+	}
+	if load.UploadSpeedLimit <= 0 {
+		load.UploadSpeedLimit = clientOption.DefaultUploadSpeedLimit
+	}
+	if sc.status.FreeSpaceOnDisk >= 0 {
+		load.FreeSpace = max(sc.status.FreeSpaceOnDisk+sc.result.FreeSpaceChange-clientOption.MinDiskSpace, 0)
+	}
+	return load
+}
+
+// Delete, stall, resume and modify client torrents as decided. Return the number of deleted torrents.
+func applyResult(sc *siteClient, statDb *stats.StatDb) (cntDeleteTorrents int64) {
+	clientInstance := sc.instance
+	result := sc.result
+	params := sc.strategy.Params()
+
+	// delete
+	var deleteTorrentStats []*stats.TorrentStat
+	var deleteTorrentInfoHashes []string
+	log.Printf("Delete client %s torrents:", clientInstance.GetName())
+	for _, torrent := range result.DeleteTorrents {
+		clientTorrent := *util.FindInSlice(sc.clientTorrents, func(t *client.Torrent) bool {
+			return t.InfoHash == torrent.InfoHash
+		})
+		// double check
+		if clientTorrent == nil || clientTorrent.Category != config.BRUSH_CAT {
+			log.Warnf("Invalid torrent deletion target: %s", torrent.InfoHash)
+			continue
+		}
+		duration := sc.siteOption.Now - clientTorrent.Atime
+		log.Printf("Torrent %s (%v): %v", torrent.Name, torrent.InfoHash, torrent.Msg)
+		log.Printf("Total Dl / Up: %s / %s; Lifespan: %s; Average lifespan Dl / Up speed: %s/s / %s/s",
+			util.BytesSize(float64(clientTorrent.Downloaded)),
+			util.BytesSize(float64(clientTorrent.Uploaded)),
+			util.GetDurationString(duration),
+			util.BytesSize(float64(clientTorrent.Downloaded)/float64(duration)),
+			util.BytesSize(float64(clientTorrent.Uploaded)/float64(duration)),
+		)
+		deleteTorrentStats = append(deleteTorrentStats, &stats.TorrentStat{
+			Client:     clientInstance.GetName(),
+			Site:       clientTorrent.GetSiteFromTag(),
+			InfoHash:   clientTorrent.InfoHash,
+			Category:   clientTorrent.Category,
+			Name:       clientTorrent.Name,
+			Atime:      clientTorrent.Atime,
+			Size:       clientTorrent.Size,
+			Uploaded:   clientTorrent.Uploaded,
+			Downloaded: clientTorrent.Downloaded,
+			Msg:        torrent.Msg,
+		})
+		deleteTorrentInfoHashes = append(deleteTorrentInfoHashes, clientTorrent.InfoHash)
+	}
+	if !dryRun {
+		err := client.DeleteTorrentsAuto(clientInstance, deleteTorrentInfoHashes)
+		log.Printf("Delete torrents result: error=%v", err)
+		if err == nil {
+			cntDeleteTorrents += int64(len(deleteTorrentInfoHashes))
+			if statDb != nil {
+				statDb.AddTorrentStats(sc.siteOption.Now, 1, deleteTorrentStats)
+			}
+		}
+	}
+
+	// stall
+	for _, torrent := range result.StallTorrents {
+		log.Printf("Stall client %s torrent: %v / %v / %v",
+			clientInstance.GetName(), torrent.Name, torrent.InfoHash, torrent.Msg)
+		if dryRun {
+			continue
+		}
+		err := clientInstance.ModifyTorrent(torrent.InfoHash, &client.TorrentOption{
+			DownloadSpeedLimit: params.StallDownloadSpeed,
+		}, torrent.Meta)
+		log.Printf("Stall torrent result: error=%v", err)
+	}
+
+	// resume
+	if len(result.ResumeTorrents) > 0 {
+		for _, torrent := range result.ResumeTorrents {
+			log.Printf("Resume client %s torrent: %v / %v / %v",
+				clientInstance.GetName(), torrent.Name, torrent.InfoHash, torrent.Msg)
+		}
+		if !dryRun {
+			err := clientInstance.ResumeTorrents(util.Map(result.ResumeTorrents,
+				func(t strategy.AlgorithmOperationTorrent) string {
+					return t.InfoHash
+				}))
+			log.Printf("Resume torrents result: error=%v", err)
+		}
+	}
+
+	// modify
+	for _, torrent := range result.ModifyTorrents {
+		log.Printf("Modify client %s torrent: %v / %v / %v / %v ",
+			clientInstance.GetName(), torrent.Name, torrent.InfoHash, torrent.Msg, torrent.Meta)
+		if dryRun {
+			continue
+		}
+		err := clientInstance.ModifyTorrent(torrent.InfoHash, nil, torrent.Meta)
+		log.Printf("Modify torrent result: error=%v", err)
+	}
+	return
+}
+
+// Add site torrents to client. A torrent already existing in any client of the group is skipped.
+// Return the number of added torrents.
+func addTorrents(sc *siteClient, siteInstance site.Site, torrents []strategy.AlgorithmAddTorrent,
+	brushClients []*brushClient, torrentRecordManager *brush_store.TorrentRecordManager) (cntAddTorrents int64) {
+	clientInstance := sc.instance
+	for _, torrent := range torrents {
+		log.Printf("Add site %s torrent to client %s: %s / %s / %v",
+			siteInstance.GetName(), clientInstance.GetName(), torrent.Name, torrent.Msg, torrent.Meta)
+		if dryRun {
+			continue
+		}
+		torrentdata, _, _, err := siteInstance.DownloadTorrent(torrent.DownloadUrl)
+		if err != nil {
+			log.Printf("Failed to download: %s. Skip \n", err)
+			continue
+		}
+		tinfo, err := torrentutil.ParseTorrent(torrentdata)
+		if err != nil {
+			continue
+		}
+		existing := false
+		for _, bc := range brushClients {
+			if pClientTorrent, _ := bc.instance.GetTorrent(tinfo.InfoHash); pClientTorrent != nil {
+				log.Printf("Already existing in client %s. skip\n", bc.instance.GetName())
+				existing = true
+				break
+			}
+		}
+		if existing {
+			continue
+		}
+		if clientInstance.TorrentRootPathExists(tinfo.RootDir) {
+			log.Printf("torrent rootpath %s existing in client. skip\n", tinfo.RootDir)
+			continue
+		}
+		torrentRecordManager.CreateTorrentRecord(torrent.SiteId, tinfo.InfoHash, torrent.Name, util.Now())
+		log.Printf("torrent info: %s\n", tinfo.InfoHash)
+		tags := []string{client.GenerateTorrentTagFromSite(siteInstance.GetName())}
+		if tinfo.IsPrivate() {
+			tags = append(tags, config.PRIVATE_TAG)
+		} else {
+			tags = append(tags, config.PUBLIC_TAG)
+		}
+		torrentOption := &client.TorrentOption{
+			Name:             torrent.Name,
+			Pause:            addPaused,
+			Category:         config.BRUSH_CAT,
+			Tags:             tags,
+			UploadSpeedLimit: siteInstance.GetSiteConfig().TorrentUploadSpeedLimitValue,
+		}
+		err = clientInstance.AddTorrent(torrentdata, torrentOption, torrent.Meta)
+		log.Printf("Add torrent result: error=%v", err)
+		if err == nil {
+			cntAddTorrents++
+		}
+	}
+	return
+}
+
 func getTorrentsOfSite(torrents []*client.Torrent, siteName string) []*client.Torrent {
 	var ret []*client.Torrent
 	for _, torrent := range torrents {
@@ -343,3 +651,51 @@ func getTorrentsOfSite(torrents []*client.Torrent, siteName string) []*client.To
 	}
 	return ret
 }
+
+func getTorrentSamples(clientName string, torrents []*client.Torrent, now int64) []*brush_store.TorrentSample {
+	var samples []*brush_store.TorrentSample
+	for _, torrent := range torrents {
+		samples = append(samples, &brush_store.TorrentSample{
+			Client:        clientName,
+			Hash:          torrent.InfoHash,
+			Time:          now,
+			UploadSpeed:   torrent.UploadSpeed,
+			DownloadSpeed: torrent.DownloadSpeed,
+			Uploaded:      torrent.Uploaded,
+			Downloaded:    torrent.Downloaded,
+			Ratio:         torrent.Ratio,
+			Seeders:       torrent.Seeders,
+			Leechers:      torrent.Leechers,
+		})
+	}
+	return samples
+}
+
+func getSiteTorrentSnapshots(sitename string, torrents []*site.Torrent,
+	now int64) []*brush_store.SiteTorrentSnapshot {
+	var snapshots []*brush_store.SiteTorrentSnapshot
+	for _, torrent := range torrents {
+		if torrent.Id == "" {
+			continue
+		}
+		snapshots = append(snapshots, &brush_store.SiteTorrentSnapshot{
+			Site:               sitename,
+			Time:               now,
+			TorrentId:          torrent.Id,
+			Name:               torrent.Name,
+			Size:               torrent.Size,
+			Seeders:            torrent.Seeders,
+			Leechers:           torrent.Leechers,
+			Snatched:           torrent.Snatched,
+			DownloadMultiplier: torrent.DownloadMultiplier,
+			UploadMultiplier:   torrent.UploadMultiplier,
+			DiscountEndTime:    torrent.DiscountEndTime,
+			TorrentTime:        torrent.Time,
+			HasHnR:             torrent.HasHnR,
+			Paid:               torrent.Paid,
+			Bought:             torrent.Bought,
+			Neutral:            torrent.Neutral,
+		})
+	}
+	return snapshots
+}
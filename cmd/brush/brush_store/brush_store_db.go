@@ -45,9 +45,22 @@ func (receiver *BrushStoreDBManager) GetDB() *gorm.DB {
 	return receiver.db
 }
 
+// Close 关闭数据库
+func (receiver *BrushStoreDBManager) Close() error {
+	sqlDB, err := receiver.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
 // NewBrushStoreDBManager 初始化数据库管理类
 func NewBrushStoreDBManager() *BrushStoreDBManager {
-	var dbFilePath string = filepath.Join(config.ConfigDir, "brush_store.db")
+	return NewBrushStoreDBManagerWithPath(filepath.Join(config.ConfigDir, "brush_store.db"))
+}
+
+// NewBrushStoreDBManagerWithPath 使用指定的数据库文件初始化数据库管理类
+func NewBrushStoreDBManagerWithPath(dbFilePath string) *BrushStoreDBManager {
 	db, err := gorm.Open(sqlite.Open(dbFilePath), &gorm.Config{})
 	if err != nil {
 		log.Fatalf("failed to connect database: %v", err)
@@ -55,7 +68,7 @@ func NewBrushStoreDBManager() *BrushStoreDBManager {
 	}
 
 	// 自动迁移表结构
-	err = db.AutoMigrate(&TorrentRecord{})
+	err = db.AutoMigrate(&TorrentRecord{}, &TorrentSample{}, &SiteTorrentSnapshot{})
 	if err != nil {
 		panic(err)
 	}
@@ -81,8 +94,11 @@ func (m *TorrentRecordManager) MarkDeleteRecord(hash string) {
 		log.Error(result.Error)
 	}
 }
-func (m *TorrentRecordManager) CreateTorrentRecord(siteId, hash, name string) {
+
+// CreateTorrentRecord 记录添加的种子。now 为添加时间(unix 时间戳)
+func (m *TorrentRecordManager) CreateTorrentRecord(siteId, hash, name string, now int64) {
 	newRecord := TorrentRecord{Hash: hash, Name: name, Category: AddTorrent, ID: siteId}
+	newRecord.CreatedAt = time.Unix(now, 0)
 	result := m.db.Create(&newRecord)
 	if result.Error != nil {
 		log.Error(result.Error)
@@ -132,9 +148,11 @@ func (m *TorrentRecordManager) GetRecords(query map[string]interface{}) (foundRe
 	log.Error(result.Error)
 	return
 }
-func (m *TorrentRecordManager) IsDeletedRecord(Id string) bool {
+
+// IsDeletedRecord 判断种子是否在 now (unix 时间戳) 的一周之前添加过
+func (m *TorrentRecordManager) IsDeletedRecord(Id string, now int64) bool {
 	var foundRecords *[]TorrentRecord
-	oneWeekAgo := time.Now().AddDate(0, 0, -7)
+	oneWeekAgo := time.Unix(now, 0).AddDate(0, 0, -7)
 	result := m.db.Where("created_at <= ? AND id = ?", oneWeekAgo, Id).Find(&foundRecords)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -0,0 +1,146 @@
+package brush_store
+
+import (
+	"math"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// 种子历史采样数据保留时长(秒)
+const SAMPLE_RETENTION = int64(7 * 86400)
+
+// TorrentSample 刷流种子的状态采样。每次运行刷流任务时记录
+type TorrentSample struct {
+	ID            uint    `gorm:"primarykey" json:"-"`
+	Client        string  `gorm:"index:idx_torrent_samples_client_hash" comment:"BT 客户端名称" json:"client"`
+	Hash          string  `gorm:"index:idx_torrent_samples_client_hash" comment:"种子hash" json:"infohash"`
+	Time          int64   `gorm:"index" comment:"采样时间戳" json:"time"`
+	UploadSpeed   int64   `json:"uploadSpeed"`
+	DownloadSpeed int64   `json:"downloadSpeed"`
+	Uploaded      int64   `json:"uploaded"`
+	Downloaded    int64   `json:"downloaded"`
+	Ratio         float64 `json:"ratio"`
+	Seeders       int64   `json:"seeders"`
+	Leechers      int64   `json:"leechers"`
+}
+
+// TorrentHistory 种子在一个时间窗口内的历史统计
+type TorrentHistory struct {
+	Samples              int64   `json:"samples"`              // 窗口内的采样数
+	Duration             int64   `json:"duration"`             // 窗口内第一个与最后一个采样之间的时长(秒)
+	AverageUploadSpeed   int64   `json:"averageUploadSpeed"`   // 平均上传速度。优先使用上传量差值计算
+	AverageDownloadSpeed int64   `json:"averageDownloadSpeed"` // 平均下载速度。优先使用下载量差值计算
+	PeakUploadSpeed      int64   `json:"peakUploadSpeed"`      // 最高上传速度
+	UploadSpeedTrend     float64 `json:"uploadSpeedTrend"`     // 上传速度变化趋势(线性回归斜率)，单位: 字节/秒 每小时。正数表示上升
+	AverageSeeders       float64 `json:"averageSeeders"`
+	AverageLeechers      float64 `json:"averageLeechers"`
+	Ratio                float64 `json:"ratio"` // 最后一个采样的分享率
+}
+
+// TorrentSampleManager TorrentSample 操作类
+type TorrentSampleManager struct {
+	db *gorm.DB
+}
+
+// NewTorrentSampleManager 初始化 TorrentSample 操作类
+func NewTorrentSampleManager(db *gorm.DB) *TorrentSampleManager {
+	return &TorrentSampleManager{db: db}
+}
+
+// AddSamples 记录采样，并清理过期的采样数据
+func (m *TorrentSampleManager) AddSamples(samples []*TorrentSample) {
+	if len(samples) == 0 {
+		return
+	}
+	if result := m.db.CreateInBatches(samples, 100); result.Error != nil {
+		log.Error(result.Error)
+		return
+	}
+	if result := m.db.Where("time < ?", samples[0].Time-SAMPLE_RETENTION).
+		Delete(&TorrentSample{}); result.Error != nil {
+		log.Error(result.Error)
+	}
+}
+
+// GetSamples 查询客户端种子自 since 以来的采样，按时间升序排列。hash 为空时查询所有种子
+func (m *TorrentSampleManager) GetSamples(client string, hash string, since int64) []*TorrentSample {
+	samples := []*TorrentSample{}
+	query := m.db.Where("client = ? AND time >= ?", client, since)
+	if hash != "" {
+		query = query.Where("hash = ?", hash)
+	}
+	if result := query.Order("time, id").Find(&samples); result.Error != nil {
+		log.Error(result.Error)
+		return nil
+	}
+	return samples
+}
+
+// GetHistories 计算客户端所有种子最近 window 秒内的历史统计。返回 hash => 统计
+func (m *TorrentSampleManager) GetHistories(client string, now int64, window int64) map[string]*TorrentHistory {
+	samplesMap := map[string][]*TorrentSample{}
+	for _, sample := range m.GetSamples(client, "", now-window) {
+		samplesMap[sample.Hash] = append(samplesMap[sample.Hash], sample)
+	}
+	histories := map[string]*TorrentHistory{}
+	for hash, samples := range samplesMap {
+		histories[hash] = ComputeHistory(samples)
+	}
+	return histories
+}
+
+// ComputeHistory 根据(按时间升序的)采样计算历史统计
+func ComputeHistory(samples []*TorrentSample) *TorrentHistory {
+	history := &TorrentHistory{Samples: int64(len(samples))}
+	if len(samples) == 0 {
+		return history
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	history.Duration = last.Time - first.Time
+	history.Ratio = last.Ratio
+	var sumUploadSpeed, sumDownloadSpeed int64
+	var sumSeeders, sumLeechers float64
+	for _, sample := range samples {
+		sumUploadSpeed += sample.UploadSpeed
+		sumDownloadSpeed += sample.DownloadSpeed
+		sumSeeders += float64(sample.Seeders)
+		sumLeechers += float64(sample.Leechers)
+		history.PeakUploadSpeed = max(history.PeakUploadSpeed, sample.UploadSpeed)
+	}
+	n := int64(len(samples))
+	history.AverageSeeders = sumSeeders / float64(n)
+	history.AverageLeechers = sumLeechers / float64(n)
+	if history.Duration > 0 && last.Uploaded >= first.Uploaded && last.Downloaded >= first.Downloaded {
+		history.AverageUploadSpeed = (last.Uploaded - first.Uploaded) / history.Duration
+		history.AverageDownloadSpeed = (last.Downloaded - first.Downloaded) / history.Duration
+	} else {
+		history.AverageUploadSpeed = sumUploadSpeed / n
+		history.AverageDownloadSpeed = sumDownloadSpeed / n
+	}
+	history.UploadSpeedTrend = uploadSpeedTrend(samples)
+	return history
+}
+
+// 上传速度的最小二乘线性回归斜率。单位: 字节/秒 每小时
+func uploadSpeedTrend(samples []*TorrentSample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	n := float64(len(samples))
+	t0 := samples[0].Time
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := float64(sample.Time-t0) / 3600
+		y := float64(sample.UploadSpeed)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 || math.IsNaN(denominator) {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
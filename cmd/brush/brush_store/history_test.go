@@ -0,0 +1,57 @@
+package brush_store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestTorrentSampleManager(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "brush_store.db")), &gorm.Config{})
+	if err != nil {
+		t.Skipf("sqlite is not available: %v", err)
+	}
+	if err = db.AutoMigrate(&TorrentSample{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	m := NewTorrentSampleManager(db)
+	// upload speed: 100, 200, 300 KiB/s at 10 minutes interval
+	for i := int64(0); i < 3; i++ {
+		m.AddSamples([]*TorrentSample{
+			{Client: "local", Hash: "a", Time: 10000 + i*600, UploadSpeed: (i + 1) * 100 * 1024,
+				Uploaded: i * 600 * 200 * 1024, Seeders: 2, Leechers: 10 + i},
+			{Client: "local", Hash: "b", Time: 10000 + i*600, UploadSpeed: 1024},
+			{Client: "other", Hash: "a", Time: 10000 + i*600},
+		})
+	}
+	if samples := m.GetSamples("local", "a", 0); len(samples) != 3 {
+		t.Fatalf("expect 3 samples, got %d", len(samples))
+	}
+	// all previous samples are outdated and purged by AddSamples
+	m.AddSamples([]*TorrentSample{{Client: "local", Hash: "a", Time: 11200 + SAMPLE_RETENTION + 1}})
+	if samples := m.GetSamples("local", "a", 0); len(samples) != 1 {
+		t.Errorf("expect old samples purged, got %d", len(samples))
+	}
+}
+
+func TestComputeHistory(t *testing.T) {
+	samples := []*TorrentSample{}
+	for i := int64(0); i < 3; i++ {
+		samples = append(samples, &TorrentSample{Time: 10000 + i*1800, UploadSpeed: (i + 1) * 100 * 1024,
+			Uploaded: i * 1800 * 200 * 1024, Seeders: 2, Leechers: 10 + i, Ratio: float64(i)})
+	}
+	history := ComputeHistory(samples)
+	if history.Samples != 3 || history.Duration != 3600 || history.AverageUploadSpeed != 200*1024 ||
+		history.PeakUploadSpeed != 300*1024 || history.AverageLeechers != 11 || history.Ratio != 2 {
+		t.Errorf("unexpected history: %+v", history)
+	}
+	// +100KiB/s every half hour
+	if history.UploadSpeedTrend != 200*1024 {
+		t.Errorf("unexpected trend: %f", history.UploadSpeedTrend)
+	}
+	if history := ComputeHistory(samples[:1]); history.AverageUploadSpeed != 100*1024 || history.UploadSpeedTrend != 0 {
+		t.Errorf("unexpected single sample history: %+v", history)
+	}
+}
@@ -0,0 +1,63 @@
+package brush_store
+
+import (
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SiteTorrentSnapshot 刷流任务获取的站点最新种子的快照。用于刷流模拟 (brush simulate)
+type SiteTorrentSnapshot struct {
+	ID                 uint    `gorm:"primarykey" json:"-"`
+	Site               string  `gorm:"index:idx_site_torrent_snapshots_site_time" comment:"站点名称" json:"site"`
+	Time               int64   `gorm:"index:idx_site_torrent_snapshots_site_time" comment:"快照时间戳" json:"time"`
+	TorrentId          string  `comment:"种子 id, 格式为 站点名称.id" json:"torrentId"`
+	Name               string  `json:"name"`
+	Size               int64   `json:"size"`
+	Seeders            int64   `json:"seeders"`
+	Leechers           int64   `json:"leechers"`
+	Snatched           int64   `json:"snatched"`
+	DownloadMultiplier float64 `json:"downloadMultiplier"`
+	UploadMultiplier   float64 `json:"uploadMultiplier"`
+	DiscountEndTime    int64   `json:"discountEndTime"`
+	TorrentTime        int64   `comment:"种子发布时间戳" json:"torrentTime"`
+	HasHnR             bool    `json:"hasHnR"`
+	Paid               bool    `json:"paid"`
+	Bought             bool    `json:"bought"`
+	Neutral            bool    `json:"neutral"`
+}
+
+// SiteTorrentSnapshotManager SiteTorrentSnapshot 操作类
+type SiteTorrentSnapshotManager struct {
+	db *gorm.DB
+}
+
+// NewSiteTorrentSnapshotManager 初始化 SiteTorrentSnapshot 操作类
+func NewSiteTorrentSnapshotManager(db *gorm.DB) *SiteTorrentSnapshotManager {
+	return &SiteTorrentSnapshotManager{db: db}
+}
+
+// AddSnapshots 记录快照，并清理过期的快照数据。过期时间与种子采样数据相同
+func (m *SiteTorrentSnapshotManager) AddSnapshots(snapshots []*SiteTorrentSnapshot) {
+	if len(snapshots) == 0 {
+		return
+	}
+	if result := m.db.CreateInBatches(snapshots, 100); result.Error != nil {
+		log.Error(result.Error)
+		return
+	}
+	if result := m.db.Where("time < ?", snapshots[0].Time-SAMPLE_RETENTION).
+		Delete(&SiteTorrentSnapshot{}); result.Error != nil {
+		log.Error(result.Error)
+	}
+}
+
+// GetSnapshots 查询站点自 since 以来的快照，按时间升序排列
+func (m *SiteTorrentSnapshotManager) GetSnapshots(sites []string, since int64) []*SiteTorrentSnapshot {
+	snapshots := []*SiteTorrentSnapshot{}
+	if result := m.db.Where("site IN ? AND time >= ?", sites, since).Order("time, id").
+		Find(&snapshots); result.Error != nil {
+		log.Error(result.Error)
+		return nil
+	}
+	return snapshots
+}
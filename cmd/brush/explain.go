@@ -0,0 +1,70 @@
+package brush
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sagan/ptool/cmd/brush/strategy"
+	"github.com/sagan/ptool/util"
+)
+
+// Explanation of brush decisions on a site.
+type siteExplain struct {
+	Client         string                           `json:"client"`
+	Site           string                           `json:"site"`
+	Strategy       string                           `json:"strategy"`
+	Params         map[string]string                `json:"params"`
+	SiteTorrents   []*strategy.SiteTorrentExplain   `json:"siteTorrents"`
+	ClientTorrents []*strategy.ClientTorrentExplain `json:"clientTorrents"`
+	paramsList     [][2]string
+}
+
+func newSiteExplain(clientName string, sitename string, brushStrategy strategy.Strategy,
+	result *strategy.AlgorithmResult) *siteExplain {
+	paramsList := brushStrategy.Params().Strings()
+	params := map[string]string{}
+	for _, param := range paramsList {
+		params[param[0]] = param[1]
+	}
+	return &siteExplain{
+		Client:         clientName,
+		Site:           sitename,
+		Strategy:       brushStrategy.Name(),
+		Params:         params,
+		SiteTorrents:   result.SiteTorrentsExplain,
+		ClientTorrents: result.ClientTorrentsExplain,
+		paramsList:     paramsList,
+	}
+}
+
+func printSiteExplain(output io.Writer, explain *siteExplain) {
+	if explain.Site == "" {
+		fmt.Fprintf(output, "Brush client %s (no site) explain. Strategy: %s\n", explain.Client, explain.Strategy)
+	} else {
+		fmt.Fprintf(output, "Brush client %s site %s explain. Strategy: %s\n",
+			explain.Client, explain.Site, explain.Strategy)
+	}
+	fmt.Fprintf(output, "Params:")
+	for _, param := range explain.paramsList {
+		fmt.Fprintf(output, " %s=%s", param[0], param[1])
+	}
+	fmt.Fprintf(output, "\n\nSite torrents (%d):\n", len(explain.SiteTorrents))
+	for _, t := range explain.SiteTorrents {
+		fmt.Fprintf(output, "  %-8s  %-8.2f  %-6s  %s (%s)\n", "["+t.Action+"]", t.Score,
+			util.BytesSizeAround(float64(t.Size)), t.Name, t.Id)
+		if t.Note != "" {
+			fmt.Fprintf(output, "            %s\n", t.Note)
+		}
+		if t.Reason != "" {
+			fmt.Fprintf(output, "            not added: %s\n", t.Reason)
+		}
+	}
+	fmt.Fprintf(output, "\nClient torrents (%d):\n", len(explain.ClientTorrents))
+	for _, t := range explain.ClientTorrents {
+		fmt.Fprintf(output, "  %-8s  %s (%s)\n", "["+t.Action+"]", t.Name, t.InfoHash)
+		for _, rule := range t.Rules {
+			fmt.Fprintf(output, "            - %s\n", rule)
+		}
+	}
+	fmt.Fprintf(output, "\n")
+}
@@ -0,0 +1,159 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd/brush"
+	"github.com/sagan/ptool/cmd/brush/brush_store"
+	"github.com/sagan/ptool/cmd/brush/strategy"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/util"
+)
+
+var command = &cobra.Command{
+	Use:         "history {client} [infohash]",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "brush.history"},
+	Short:       "Show speed history of client brush torrents.",
+	Long: `Show speed history of client brush torrents.
+The history is recorded by each run of "brush" command.
+
+If infohash is not provided, show the history summary of each torrent in window:
+average upload / download speed, peak upload speed, upload speed trend (change per hour), etc.
+Otherwise show all samples of the torrent in window.
+
+The default window is the "historyWindow" brush strategy parameter of client (default 1h).`,
+	Args: cobra.MatchAll(cobra.RangeArgs(1, 2), cobra.OnlyValidArgs),
+	RunE: history,
+}
+
+var (
+	showJson = false
+	window   = ""
+)
+
+func init() {
+	command.Flags().BoolVarP(&showJson, "json", "", false, "Show output in json format")
+	command.Flags().StringVarP(&window, "window", "", "", `History window. e.g. "1h", "1d"`)
+	brush.Command.AddCommand(command)
+}
+
+type torrentHistory struct {
+	InfoHash string `json:"infohash"`
+	Name     string `json:"name"`
+	*brush_store.TorrentHistory
+}
+
+func history(cmd *cobra.Command, args []string) error {
+	clientName := args[0]
+	infoHash := ""
+	if len(args) > 1 {
+		infoHash = args[1]
+	}
+	clientConfig := config.GetClientConfig(clientName)
+	if clientConfig == nil {
+		return fmt.Errorf("client %s not found", clientName)
+	}
+	var windowSeconds int64
+	if window != "" {
+		var err error
+		if windowSeconds, err = util.ParseTimeDuration(window); err != nil {
+			return fmt.Errorf("invalid window: %w", err)
+		}
+	} else {
+		brushStrategy, err := strategy.GetStrategy(clientConfig, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get brush strategy of client: %w", err)
+		}
+		windowSeconds = brushStrategy.Params().HistoryWindow
+	}
+	now := util.Now()
+	torrentSampleManager := brush_store.NewTorrentSampleManager(brush_store.NewBrushStoreDBManager().GetDB())
+
+	if infoHash != "" {
+		samples := torrentSampleManager.GetSamples(clientName, infoHash, now-windowSeconds)
+		if showJson {
+			return util.PrintJson(os.Stdout, samples)
+		}
+		if len(samples) == 0 {
+			return fmt.Errorf("no history of torrent %s in window %s", infoHash, util.GetDurationString(windowSeconds))
+		}
+		fmt.Printf("%-19s  %-8s  %-8s  %-10s  %-10s  %-6s  %-5s  %-5s\n",
+			"Time", "↑S(/s)", "↓S(/s)", "Uploaded", "Downloaded", "Ratio", "Seeds", "Peers")
+		for _, sample := range samples {
+			fmt.Printf("%-19s  %-8s  %-8s  %-10s  %-10s  %-6.2f  %-5d  %-5d\n",
+				util.FormatTime(sample.Time),
+				util.BytesSizeAround(float64(sample.UploadSpeed)),
+				util.BytesSizeAround(float64(sample.DownloadSpeed)),
+				util.BytesSizeAround(float64(sample.Uploaded)),
+				util.BytesSizeAround(float64(sample.Downloaded)),
+				sample.Ratio,
+				sample.Seeders,
+				sample.Leechers,
+			)
+		}
+		h := brush_store.ComputeHistory(samples)
+		fmt.Printf("\nSamples: %d in %s; Average ↑S / ↓S: %s/s / %s/s; Peak ↑S: %s/s; ↑S trend: %s/s per hour\n",
+			h.Samples, util.GetDurationString(h.Duration),
+			util.BytesSize(float64(h.AverageUploadSpeed)), util.BytesSize(float64(h.AverageDownloadSpeed)),
+			util.BytesSize(float64(h.PeakUploadSpeed)), formatTrend(h.UploadSpeedTrend))
+		return nil
+	}
+
+	// torrent names are not recorded in history, get them from client
+	names := map[string]string{}
+	if clientInstance, err := client.CreateClient(cmd.Context(), clientName); err != nil {
+		log.Warnf("Failed to create client: %v", err)
+	} else if torrents, err := clientInstance.GetTorrents("", config.BRUSH_CAT, true); err != nil {
+		log.Warnf("Failed to get client torrents: %v", err)
+	} else {
+		for _, torrent := range torrents {
+			names[torrent.InfoHash] = torrent.Name
+		}
+	}
+	histories := []*torrentHistory{}
+	for hash, h := range torrentSampleManager.GetHistories(clientName, now, windowSeconds) {
+		histories = append(histories, &torrentHistory{InfoHash: hash, Name: names[hash], TorrentHistory: h})
+	}
+	sort.Slice(histories, func(i, j int) bool {
+		return histories[i].AverageUploadSpeed > histories[j].AverageUploadSpeed
+	})
+	if showJson {
+		return util.PrintJson(os.Stdout, histories)
+	}
+	fmt.Printf("Client %s brush torrents history in window %s: %d torrents\n",
+		clientName, util.GetDurationString(windowSeconds), len(histories))
+	fmt.Printf("%-30s  %-40s  %-7s  %-8s  %-8s  %-8s  %-9s  %-6s  %-5s  %-5s\n",
+		"Name", "InfoHash", "Samples", "Avg↑S", "Peak↑S", "Avg↓S", "↑S trend", "Ratio", "Seeds", "Peers")
+	for _, h := range histories {
+		name := h.Name
+		if name == "" {
+			name = "-"
+		}
+		util.PrintStringInWidth(os.Stdout, name, 30, true)
+		fmt.Printf("  %-40s  %-7d  %-8s  %-8s  %-8s  %-9s  %-6.2f  %-5.0f  %-5.0f\n",
+			h.InfoHash,
+			h.Samples,
+			util.BytesSizeAround(float64(h.AverageUploadSpeed)),
+			util.BytesSizeAround(float64(h.PeakUploadSpeed)),
+			util.BytesSizeAround(float64(h.AverageDownloadSpeed)),
+			formatTrend(h.UploadSpeedTrend),
+			h.Ratio,
+			h.AverageSeeders,
+			h.AverageLeechers,
+		)
+	}
+	return nil
+}
+
+func formatTrend(trend float64) string {
+	if trend < 0 {
+		return "-" + util.BytesSizeAround(-trend)
+	}
+	return "+" + util.BytesSizeAround(trend)
+}
@@ -0,0 +1,27 @@
+package history
+
+import (
+	"github.com/c-bata/go-prompt"
+
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/cmd/shell/suggest"
+)
+
+func init() {
+	cmd.AddShellCompletion("brush.history", func(document *prompt.Document) []prompt.Suggest {
+		info := suggest.Parse(document)
+		if info.LastArgIndex < 2 {
+			return nil
+		}
+		if info.LastArgIsFlag {
+			return nil
+		}
+		switch info.LastArgIndex {
+		case 2:
+			return suggest.ClientArg(info.MatchingPrefix)
+		case 3:
+			return suggest.InfoHashArg(info.MatchingPrefix, info.Args[2])
+		}
+		return nil
+	})
+}
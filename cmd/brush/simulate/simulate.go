@@ -0,0 +1,319 @@
+package simulate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd/brush"
+	"github.com/sagan/ptool/cmd/brush/brush_store"
+	"github.com/sagan/ptool/cmd/brush/strategy"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/stats"
+	"github.com/sagan/ptool/util"
+)
+
+var command = &cobra.Command{
+	Use:         "simulate {client} {site | group}...",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "brush.simulate"},
+	Short:       "Simulate brushing sites using client with recorded data.",
+	Long: `Simulate brushing sites using client with recorded data.
+It replays the site torrents fetched by previous "brush" runs (recorded in the last 7 days) through
+the brush strategy using a simulated client which starts empty, and reports the expected uploaded / downloaded
+bytes, the peak disk usage and the torrents that would have been added or deleted.
+
+The transfer of a simulated torrent follows the recorded speed history (or the deletion stats) of the real
+torrent if it was actually brushed by client; otherwise it's roughly estimated by the torrent seeders & leechers.
+The total upload speed is limited to the client brush default upload speed limit, use --upload-speed-limit
+to change it.
+
+By default it simulates the current brush strategy config. Use --strategy to simulate other configurations.
+The flag can be set multiple times to compare them, each value is in "name" or "name:param=value,..." format,
+the name part could be empty to use the current strategy. E.g.:
+  ptool brush simulate local mteam --strategy default --strategy "aggressive:uploadSpeedTargetFactor=4"
+  ptool brush simulate local mteam --strategy "" --strategy ":slowTorrentsCheckTimespan=30m"`,
+	Args: cobra.MatchAll(cobra.MinimumNArgs(2), cobra.OnlyValidArgs),
+	RunE: simulate,
+}
+
+var (
+	showJson           = false
+	showEvents         = false
+	strategySpecs      []string
+	since              = ""
+	diskSpace          = ""
+	uploadSpeedLimit   = ""
+	downloadSpeedLimit = ""
+)
+
+func init() {
+	command.Flags().BoolVarP(&showJson, "json", "", false, "Show output in json format")
+	command.Flags().BoolVarP(&showEvents, "show-events", "", false,
+		"Show the torrents that would have been added or deleted")
+	command.Flags().StringArrayVarP(&strategySpecs, "strategy", "", nil,
+		`Brush strategy configuration to simulate, in "name" or "name:param=value,..." format. `+
+			`Can be set multiple times`)
+	command.Flags().StringVarP(&since, "since", "", "7d", `Only replay recorded data of this time. e.g. "1d", "12h"`)
+	command.Flags().StringVarP(&diskSpace, "disk-space", "", "",
+		"Total disk space of client for brush torrents. Default is the current free disk space of client "+
+			"plus the size of it's brush torrents")
+	command.Flags().StringVarP(&uploadSpeedLimit, "upload-speed-limit", "", "",
+		"Client upload speed limit. Default is the brush default upload speed limit of client config")
+	command.Flags().StringVarP(&downloadSpeedLimit, "download-speed-limit", "", "",
+		"Client download speed limit. Default is no limit")
+	brush.Command.AddCommand(command)
+}
+
+type simulateResult struct {
+	Strategy string  `json:"strategy"`
+	Result   *Result `json:"result"`
+}
+
+func simulate(cmd *cobra.Command, args []string) error {
+	clientName := args[0]
+	sitenames := util.UniqueSlice(config.ParseGroupAndOtherNames(args[1:]...))
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
+	if err != nil {
+		return err
+	}
+	sinceSeconds, err := util.ParseTimeDuration(since)
+	if err != nil {
+		return fmt.Errorf("invalid since: %w", err)
+	}
+	options := &Options{
+		SiteOptions:     map[string]*strategy.BrushSiteOptionStruct{},
+		SiteMaxTorrents: map[string]int64{},
+		ClientOption:    strategy.GetBrushClientOptions(clientInstance),
+	}
+	if options.UploadSpeedLimit, err = parseSize(uploadSpeedLimit); err != nil {
+		return fmt.Errorf("invalid upload-speed-limit: %w", err)
+	}
+	if options.DownloadSpeedLimit, err = parseSize(downloadSpeedLimit); err != nil {
+		return fmt.Errorf("invalid download-speed-limit: %w", err)
+	}
+	if diskSpace != "" {
+		if options.DiskSpace, err = util.RAMInBytes(diskSpace); err != nil {
+			return fmt.Errorf("invalid disk-space: %w", err)
+		}
+	} else if options.DiskSpace, err = getClientDiskSpace(clientInstance); err != nil {
+		return fmt.Errorf("failed to get client disk space, please set --disk-space: %w", err)
+	}
+	siteConfigs := map[string]*config.SiteConfigStruct{}
+	for _, sitename := range sitenames {
+		siteInstance, err := site.CreateSite(sitename)
+		if err != nil {
+			return fmt.Errorf("failed to get instance of site %s: %w", sitename, err)
+		}
+		siteConfigs[sitename] = siteInstance.GetSiteConfig()
+		options.SiteOptions[sitename] = strategy.GetBrushSiteOptions(siteInstance, 0)
+		brushMaxTorrents := clientInstance.GetClientConfig().BrushMaxTorrents
+		if siteConfigs[sitename].BrushAllowAddTorrentsPercent != 0 {
+			p := float64(siteConfigs[sitename].BrushAllowAddTorrentsPercent) / 100.0
+			brushMaxTorrents = int64(p * float64(clientInstance.GetClientConfig().BrushMaxTorrents))
+		}
+		options.SiteMaxTorrents[sitename] = brushMaxTorrents
+	}
+
+	data := loadData(clientName, sitenames, util.Now()-sinceSeconds)
+	if len(data.Snapshots) == 0 {
+		return fmt.Errorf("no recorded site torrents of %s. Run \"brush\" command first to record them",
+			strings.Join(sitenames, ", "))
+	}
+
+	if len(strategySpecs) == 0 {
+		strategySpecs = []string{""}
+	}
+	results := []*simulateResult{}
+	for _, spec := range strategySpecs {
+		options.Strategies = map[string]strategy.Strategy{}
+		for _, sitename := range sitenames {
+			if options.Strategies[sitename], err = getStrategy(spec, clientInstance.GetClientConfig(),
+				siteConfigs[sitename]); err != nil {
+				return fmt.Errorf("invalid strategy %q: %w", spec, err)
+			}
+		}
+		result, err := Simulate(data, options)
+		if err != nil {
+			return fmt.Errorf("failed to simulate strategy %q: %w", spec, err)
+		}
+		if spec == "" {
+			spec = "<current>"
+		}
+		results = append(results, &simulateResult{Strategy: spec, Result: result})
+	}
+
+	if showJson {
+		return util.PrintJson(os.Stdout, results)
+	}
+	printResults(clientName, sitenames, data, options, results)
+	return nil
+}
+
+// Load recorded site torrent snapshots of sites and timelines of brushed torrents of client since ts.
+func loadData(clientName string, sitenames []string, ts int64) *Data {
+	dbManager := brush_store.NewBrushStoreDBManager()
+	defer dbManager.Close()
+	data := &Data{
+		Snapshots: brush_store.NewSiteTorrentSnapshotManager(dbManager.GetDB()).GetSnapshots(sitenames, ts),
+		Timelines: map[string]*Timeline{},
+	}
+	ids := []string{}
+	for _, snapshot := range data.Snapshots {
+		ids = append(ids, snapshot.TorrentId)
+	}
+	ids = util.UniqueSlice(ids)
+	var torrentStats map[string]*stats.Stat
+	if statRecords, err := stats.ReadTorrentStats(filepath.Join(config.ConfigDir, config.STATS_FILENAME)); err == nil {
+		torrentStats = map[string]*stats.Stat{}
+		for _, statRecord := range statRecords {
+			if statRecord.Data.Client == clientName {
+				torrentStats[statRecord.Data.InfoHash] = statRecord
+			}
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		log.Warnf("Failed to read stats: %v", err)
+	}
+	torrentRecordManager := brush_store.NewTorrentRecordManager(dbManager.GetDB())
+	torrentSampleManager := brush_store.NewTorrentSampleManager(dbManager.GetDB())
+	samplesMap := map[string][]*brush_store.TorrentSample{} // hash => samples
+	for _, sample := range torrentSampleManager.GetSamples(clientName, "", 0) {
+		samplesMap[sample.Hash] = append(samplesMap[sample.Hash], sample)
+	}
+	// query in batches to avoid too many sql variables
+	for i := 0; i < len(ids); i += 500 {
+		records := torrentRecordManager.GetRecords(map[string]any{"id": ids[i:min(i+500, len(ids))]})
+		if records == nil {
+			continue
+		}
+		for _, record := range *records {
+			timeline := &Timeline{
+				AddTime: record.CreatedAt.Unix(),
+				Samples: samplesMap[record.Hash],
+				Stat:    torrentStats[record.Hash],
+			}
+			if len(timeline.Samples) > 0 || timeline.Stat != nil {
+				data.Timelines[record.ID] = timeline
+			}
+		}
+	}
+	return data
+}
+
+// Return the strategy of spec in "name" or "name:param=value,..." format.
+func getStrategy(spec string, clientConfig *config.ClientConfigStruct,
+	siteConfig *config.SiteConfigStruct) (strategy.Strategy, error) {
+	if spec == "" {
+		return strategy.GetStrategy(clientConfig, siteConfig)
+	}
+	name, paramsStr, _ := strings.Cut(spec, ":")
+	params := map[string]string{}
+	for _, param := range strings.Split(paramsStr, ",") {
+		if param = strings.TrimSpace(param); param == "" {
+			continue
+		}
+		key, value, found := strings.Cut(param, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid param %q", param)
+		}
+		params[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		name = clientConfig.BrushStrategy
+		if siteConfig.BrushStrategy != "" {
+			name = siteConfig.BrushStrategy
+		}
+	}
+	return strategy.Create(name, clientConfig.BrushStrategyParams, siteConfig.BrushStrategyParams, params)
+}
+
+func getClientDiskSpace(clientInstance client.Client) (int64, error) {
+	status, err := clientInstance.GetStatus()
+	if err != nil {
+		return 0, err
+	}
+	if status.FreeSpaceOnDisk < 0 {
+		return 0, fmt.Errorf("client free disk space is unknown")
+	}
+	torrents, err := clientInstance.GetTorrents("", config.BRUSH_CAT, true)
+	if err != nil {
+		return 0, err
+	}
+	space := status.FreeSpaceOnDisk
+	for _, torrent := range torrents {
+		space += torrent.SizeCompleted
+	}
+	return space, nil
+}
+
+func parseSize(str string) (int64, error) {
+	if str == "" {
+		return 0, nil
+	}
+	return util.RAMInBytes(str)
+}
+
+func printResults(clientName string, sitenames []string, data *Data, options *Options,
+	results []*simulateResult) {
+	fmt.Printf("Simulate brushing client %s sites %s: %d recorded site torrent snapshots, "+
+		"%d torrents have recorded transfer data\n",
+		clientName, strings.Join(sitenames, ", "), len(data.Snapshots), len(data.Timelines))
+	uploadSpeedLimit := options.UploadSpeedLimit
+	if uploadSpeedLimit <= 0 {
+		uploadSpeedLimit = options.ClientOption.DefaultUploadSpeedLimit
+	}
+	downloadSpeedLimit := "unlimited"
+	if options.DownloadSpeedLimit > 0 {
+		downloadSpeedLimit = util.BytesSize(float64(options.DownloadSpeedLimit)) + "/s"
+	}
+	fmt.Printf("Time: %s ~ %s; Disk space: %s; Upload / download speed limit: %s/s / %s\n\n",
+		util.FormatTime(results[0].Result.Start), util.FormatTime(results[0].Result.End),
+		util.BytesSize(float64(options.DiskSpace)), util.BytesSize(float64(uploadSpeedLimit)), downloadSpeedLimit)
+	rows := []struct {
+		name  string
+		value func(result *Result) string
+	}{
+		{"Brush runs", func(r *Result) string { return fmt.Sprint(r.Runs) }},
+		{"Uploaded", func(r *Result) string { return util.BytesSize(float64(r.Uploaded)) }},
+		{"Downloaded", func(r *Result) string { return util.BytesSize(float64(r.Downloaded)) }},
+		{"Ratio", func(r *Result) string {
+			if r.Downloaded == 0 {
+				return "-"
+			}
+			return fmt.Sprintf("%.2f", float64(r.Uploaded)/float64(r.Downloaded))
+		}},
+		{"Peak disk usage", func(r *Result) string { return util.BytesSize(float64(r.PeakDiskUsage)) }},
+		{"Added torrents", func(r *Result) string { return fmt.Sprint(r.Added) }},
+		{"Deleted torrents", func(r *Result) string { return fmt.Sprint(r.Deleted) }},
+		{"Remaining torrents", func(r *Result) string { return fmt.Sprint(r.Remaining) }},
+	}
+	fmt.Printf("%-20s", "Strategy")
+	for _, result := range results {
+		fmt.Printf("  %-30s", result.Strategy)
+	}
+	fmt.Printf("\n")
+	for _, row := range rows {
+		fmt.Printf("%-20s", row.name)
+		for _, result := range results {
+			fmt.Printf("  %-30s", row.value(result.Result))
+		}
+		fmt.Printf("\n")
+	}
+	if !showEvents {
+		return
+	}
+	for _, result := range results {
+		fmt.Printf("\nTorrents added / deleted of strategy %s:\n", result.Strategy)
+		for _, event := range result.Result.Events {
+			fmt.Printf("%-19s  %-6s  %-10s  %-8s  %s (%s)\n", util.FormatTime(event.Time), event.Action,
+				event.Site, util.BytesSizeAround(float64(event.Size)), event.Name, event.Msg)
+		}
+	}
+}
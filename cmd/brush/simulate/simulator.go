@@ -0,0 +1,405 @@
+package simulate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd/brush/brush_store"
+	"github.com/sagan/ptool/cmd/brush/strategy"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/stats"
+)
+
+// Estimated speeds of simulated torrents that have no recorded data.
+const (
+	// upload speed contributed by each leecher of torrent
+	PEER_UPLOAD_SPEED = int64(50 * 1024)
+	// download speed contributed by each seeder of torrent
+	PEER_DOWNLOAD_SPEED = int64(1024 * 1024)
+)
+
+// Recorded data that is replayed in simulation.
+type Data struct {
+	// site torrent snapshots of brush runs, ordered by time
+	Snapshots []*brush_store.SiteTorrentSnapshot
+	// recorded timelines of site torrents that were actually brushed by client. site torrent id => timeline
+	Timelines map[string]*Timeline
+}
+
+// Recorded timeline of a site torrent that was actually brushed by client.
+type Timeline struct {
+	AddTime int64                        // time the torrent was added to client
+	Samples []*brush_store.TorrentSample // speed samples, ordered by time
+	Stat    *stats.Stat                  // stats of the torrent when it was deleted from client
+}
+
+type Options struct {
+	Strategies map[string]strategy.Strategy // sitename => strategy
+	// sitename => site options. The Now and AllowAddTorrents fields are set in each brush run
+	SiteOptions map[string]*strategy.BrushSiteOptionStruct
+	// sitename => max allowed torrents of site in client
+	SiteMaxTorrents    map[string]int64
+	ClientOption       *strategy.BrushClientOptionStruct
+	DiskSpace          int64 // total disk space of client for brush torrents
+	UploadSpeedLimit   int64 // <= 0: use DefaultUploadSpeedLimit of ClientOption
+	DownloadSpeedLimit int64 // <= 0: no limit
+}
+
+type Event struct {
+	Time   int64  `json:"time"`
+	Action string `json:"action"` // "add" or "delete"
+	Site   string `json:"site"`
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Msg    string `json:"msg"`
+}
+
+type Result struct {
+	Start         int64    `json:"start"`
+	End           int64    `json:"end"`
+	Runs          int64    `json:"runs"` // count of brush runs (of any site)
+	Uploaded      int64    `json:"uploaded"`
+	Downloaded    int64    `json:"downloaded"`
+	PeakDiskUsage int64    `json:"peakDiskUsage"`
+	Added         int64    `json:"added"`
+	Deleted       int64    `json:"deleted"`
+	Remaining     int64    `json:"remaining"` // torrents remaining in client at the end
+	Events        []*Event `json:"events"`
+}
+
+type simTorrent struct {
+	torrent  *client.Torrent
+	site     string
+	timeline *Timeline
+	samples  []*brush_store.TorrentSample
+}
+
+type simulator struct {
+	data      *Data
+	options   *Options
+	result    *Result
+	now       int64
+	torrents  []*simTorrent
+	added     map[string]bool                             // ids of all site torrents ever added
+	latest    map[string]*brush_store.SiteTorrentSnapshot // id => latest snapshot of site torrent
+	diskUsage int64
+	records   *brush_store.TorrentRecordManager
+}
+
+// Replay the brush runs of recorded site torrent snapshots through strategy.Decide using a simulated client
+// that starts empty. The transfer of a simulated torrent follows the recorded timeline of the real torrent
+// if it was actually brushed, or else is estimated by it's seeders & leechers.
+// The torrent records of simulated client are kept in a temporary brush store db.
+func Simulate(data *Data, options *Options) (*Result, error) {
+	if len(data.Snapshots) == 0 {
+		return nil, fmt.Errorf("no recorded site torrent snapshots")
+	}
+	tmpdir, err := os.MkdirTemp("", "ptool-brush-simulate-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpdir)
+	dbManager := brush_store.NewBrushStoreDBManagerWithPath(filepath.Join(tmpdir, "brush_store.db"))
+	defer dbManager.Close()
+
+	s := &simulator{
+		data:    data,
+		options: options,
+		result:  &Result{Start: data.Snapshots[0].Time},
+		added:   map[string]bool{},
+		latest:  map[string]*brush_store.SiteTorrentSnapshot{},
+		records: brush_store.NewTorrentRecordManager(dbManager.GetDB()),
+		now:     data.Snapshots[0].Time,
+	}
+	snapshots := data.Snapshots
+	for i := 0; i < len(snapshots); {
+		j := i + 1
+		for j < len(snapshots) && snapshots[j].Site == snapshots[i].Site && snapshots[j].Time == snapshots[i].Time {
+			j++
+		}
+		s.advance(snapshots[i].Time)
+		if s.options.Strategies[snapshots[i].Site] != nil {
+			s.run(snapshots[i].Site, snapshots[i:j])
+		}
+		i = j
+	}
+	s.result.End = s.now
+	s.result.Remaining = int64(len(s.torrents))
+	return s.result, nil
+}
+
+func (s *simulator) uploadSpeedLimit() int64 {
+	if s.options.UploadSpeedLimit > 0 {
+		return s.options.UploadSpeedLimit
+	}
+	return s.options.ClientOption.DefaultUploadSpeedLimit
+}
+
+// Advance the simulated client to time t.
+func (s *simulator) advance(t int64) {
+	dt := t - s.now
+	if dt <= 0 {
+		return
+	}
+	uploadeds := make([]int64, len(s.torrents))
+	downloadeds := make([]int64, len(s.torrents))
+	totalUploaded, totalDownloaded := int64(0), int64(0)
+	for i, st := range s.torrents {
+		uploadeds[i], downloadeds[i] = s.transfer(st, s.now, t)
+		totalUploaded += uploadeds[i]
+		totalDownloaded += downloadeds[i]
+	}
+	uploadFactor, downloadFactor := 1.0, 1.0
+	if limit := s.uploadSpeedLimit(); limit > 0 && totalUploaded > limit*dt {
+		uploadFactor = float64(limit*dt) / float64(totalUploaded)
+	}
+	if limit := s.options.DownloadSpeedLimit; limit > 0 && totalDownloaded > limit*dt {
+		downloadFactor = float64(limit*dt) / float64(totalDownloaded)
+	}
+	for i, st := range s.torrents {
+		torrent := st.torrent
+		uploaded := int64(float64(uploadeds[i]) * uploadFactor)
+		downloaded := min(int64(float64(downloadeds[i])*downloadFactor), torrent.Size-torrent.SizeCompleted)
+		torrent.UploadSpeed = uploaded / dt
+		torrent.DownloadSpeed = downloaded / dt
+		torrent.Uploaded += uploaded
+		torrent.Downloaded += downloaded
+		torrent.SizeCompleted += downloaded
+		s.result.Uploaded += uploaded
+		s.result.Downloaded += downloaded
+		s.diskUsage += downloaded
+		if torrent.Downloaded > 0 {
+			torrent.Ratio = float64(torrent.Uploaded) / float64(torrent.Downloaded)
+		}
+		if torrent.IsComplete() && torrent.Ctime <= 0 {
+			torrent.Ctime = t
+			torrent.State = "seeding"
+		}
+		st.samples = append(st.samples, &brush_store.TorrentSample{
+			Hash:          torrent.InfoHash,
+			Time:          t,
+			UploadSpeed:   torrent.UploadSpeed,
+			DownloadSpeed: torrent.DownloadSpeed,
+			Uploaded:      torrent.Uploaded,
+			Downloaded:    torrent.Downloaded,
+			Ratio:         torrent.Ratio,
+			Seeders:       torrent.Seeders,
+			Leechers:      torrent.Leechers,
+		})
+	}
+	s.result.PeakDiskUsage = max(s.result.PeakDiskUsage, s.diskUsage)
+	s.now = t
+}
+
+// Return the (uncapped) uploaded & downloaded bytes of simulated torrent in [t0, t1].
+func (s *simulator) transfer(st *simTorrent, t0 int64, t1 int64) (uploaded int64, downloaded int64) {
+	torrent := st.torrent
+	dt := t1 - t0
+	recorded := false
+	if st.timeline != nil {
+		var u0, d0 int64
+		if u0, d0, recorded = st.timeline.transferred(t0 - torrent.Atime); recorded {
+			u1, d1, _ := st.timeline.transferred(t1 - torrent.Atime)
+			uploaded = max(u1-u0, 0)
+			downloaded = max(d1-d0, 0)
+		}
+	}
+	if !recorded {
+		uploadSpeed := torrent.Leechers * PEER_UPLOAD_SPEED
+		if limit := s.options.SiteOptions[st.site].TorrentUploadSpeedLimit; limit > 0 {
+			uploadSpeed = min(uploadSpeed, limit)
+		}
+		uploaded = uploadSpeed * dt
+		downloaded = torrent.Seeders * PEER_DOWNLOAD_SPEED * dt
+	}
+	if torrent.IsComplete() {
+		downloaded = 0
+	} else if torrent.Meta["stt"] > 0 {
+		downloaded = min(downloaded, s.options.Strategies[st.site].Params().StallDownloadSpeed*dt)
+	}
+	return
+}
+
+// Run brush of site with the snapshots of site torrents at current time.
+func (s *simulator) run(sitename string, snapshots []*brush_store.SiteTorrentSnapshot) {
+	s.result.Runs++
+	brushStrategy := s.options.Strategies[sitename]
+	params := brushStrategy.Params()
+	snapshotsMap := map[string]*brush_store.SiteTorrentSnapshot{}
+	for _, snapshot := range snapshots {
+		snapshotsMap[snapshot.TorrentId] = snapshot
+		s.latest[snapshot.TorrentId] = snapshot
+	}
+	clientTorrents := []*client.Torrent{}
+	cntSiteTorrents := int64(0)
+	status := &client.Status{
+		FreeSpaceOnDisk:    max(s.options.DiskSpace-s.diskUsage, 0),
+		UploadSpeedLimit:   s.uploadSpeedLimit(),
+		DownloadSpeedLimit: s.options.DownloadSpeedLimit,
+	}
+	for _, st := range s.torrents {
+		if snapshot := s.latest[st.torrent.InfoHash]; snapshot != nil {
+			st.torrent.Seeders = snapshot.Seeders
+			st.torrent.Leechers = snapshot.Leechers
+		}
+		if st.site == sitename {
+			cntSiteTorrents++
+		}
+		status.UploadSpeed += st.torrent.UploadSpeed
+		status.DownloadSpeed += st.torrent.DownloadSpeed
+		clientTorrents = append(clientTorrents, st.torrent)
+	}
+	var siteTorrents []*site.Torrent
+	if status.UploadSpeedLimit <= 0 || (status.UploadSpeedLimit >= params.SlowUploadSpeed &&
+		float64(status.UploadSpeed)/float64(status.UploadSpeedLimit) < params.BandwidthFullPercent) {
+		for _, snapshot := range snapshots {
+			siteTorrents = append(siteTorrents, &site.Torrent{
+				Id:                 snapshot.TorrentId,
+				Name:               snapshot.Name,
+				Size:               snapshot.Size,
+				Seeders:            snapshot.Seeders,
+				Leechers:           snapshot.Leechers,
+				Snatched:           snapshot.Snatched,
+				DownloadMultiplier: snapshot.DownloadMultiplier,
+				UploadMultiplier:   snapshot.UploadMultiplier,
+				DiscountEndTime:    snapshot.DiscountEndTime,
+				Time:               snapshot.TorrentTime,
+				HasHnR:             snapshot.HasHnR,
+				Paid:               snapshot.Paid,
+				Bought:             snapshot.Bought,
+				Neutral:            snapshot.Neutral,
+				IsActive:           s.added[snapshot.TorrentId],
+			})
+		}
+	}
+	siteOption := *s.options.SiteOptions[sitename]
+	siteOption.Now = s.now
+	siteOption.AllowAddTorrents = s.options.SiteMaxTorrents[sitename] - cntSiteTorrents
+	clientOption := *s.options.ClientOption
+	clientOption.TorrentRecordManager = s.records
+	clientOption.TorrentsHistory = map[string]*brush_store.TorrentHistory{}
+	for _, st := range s.torrents {
+		var samples []*brush_store.TorrentSample
+		for _, sample := range st.samples {
+			if sample.Time >= s.now-params.HistoryWindow {
+				samples = append(samples, sample)
+			}
+		}
+		if len(samples) > 0 {
+			clientOption.TorrentsHistory[st.torrent.InfoHash] = brush_store.ComputeHistory(samples)
+		}
+	}
+
+	result := brushStrategy.Decide(status, clientTorrents, siteTorrents, &siteOption, &clientOption)
+
+	for _, deleteTorrent := range result.DeleteTorrents {
+		index := s.indexOf(deleteTorrent.InfoHash)
+		if index == -1 {
+			continue
+		}
+		torrent := s.torrents[index].torrent
+		s.diskUsage -= torrent.SizeCompleted
+		s.torrents = append(s.torrents[:index], s.torrents[index+1:]...)
+		s.result.Deleted++
+		s.result.Events = append(s.result.Events, &Event{
+			Time:   s.now,
+			Action: "delete",
+			Site:   torrent.GetSiteFromTag(),
+			Id:     torrent.InfoHash,
+			Name:   torrent.Name,
+			Size:   torrent.Size,
+			Msg:    deleteTorrent.Msg,
+		})
+	}
+	for _, modifyTorrent := range append(result.StallTorrents, result.ModifyTorrents...) {
+		if index := s.indexOf(modifyTorrent.InfoHash); index != -1 {
+			s.torrents[index].torrent.Meta = modifyTorrent.Meta
+		}
+	}
+	for _, addTorrent := range result.AddTorrents {
+		snapshot := snapshotsMap[addTorrent.SiteId]
+		if snapshot == nil || s.added[snapshot.TorrentId] {
+			continue
+		}
+		s.added[snapshot.TorrentId] = true
+		s.records.CreateTorrentRecord(snapshot.TorrentId, snapshot.TorrentId, snapshot.Name, s.now)
+		meta := addTorrent.Meta
+		if meta == nil {
+			meta = map[string]int64{}
+		}
+		s.torrents = append(s.torrents, &simTorrent{
+			torrent: &client.Torrent{
+				InfoHash: snapshot.TorrentId,
+				Name:     snapshot.Name,
+				State:    "downloading",
+				Atime:    s.now,
+				Category: config.BRUSH_CAT,
+				Tags:     []string{client.GenerateTorrentTagFromSite(sitename)},
+				Size:     snapshot.Size,
+				Seeders:  snapshot.Seeders,
+				Leechers: snapshot.Leechers,
+				Meta:     meta,
+			},
+			site:     sitename,
+			timeline: s.data.Timelines[snapshot.TorrentId],
+		})
+		s.result.Added++
+		s.result.Events = append(s.result.Events, &Event{
+			Time:   s.now,
+			Action: "add",
+			Site:   sitename,
+			Id:     snapshot.TorrentId,
+			Name:   snapshot.Name,
+			Size:   snapshot.Size,
+			Msg:    addTorrent.Msg,
+		})
+	}
+}
+
+func (s *simulator) indexOf(infoHash string) int {
+	for i, st := range s.torrents {
+		if st.torrent.InfoHash == infoHash {
+			return i
+		}
+	}
+	return -1
+}
+
+// Return the recorded uploaded & downloaded of torrent at age seconds after it was added to client.
+// Beyond the recorded samples, the last recorded speeds are assumed. If the torrent only has the
+// deletion stats, it's average speeds during it's lifespan are assumed, and no transfer after that.
+func (timeline *Timeline) transferred(age int64) (uploaded int64, downloaded int64, ok bool) {
+	if len(timeline.Samples) > 0 {
+		prevAge, prevUploaded, prevDownloaded := int64(0), int64(0), int64(0)
+		for _, sample := range timeline.Samples {
+			sampleAge := sample.Time - timeline.AddTime
+			if sampleAge < prevAge {
+				continue
+			}
+			if age <= sampleAge {
+				if sampleAge == prevAge {
+					return sample.Uploaded, sample.Downloaded, true
+				}
+				ratio := float64(age-prevAge) / float64(sampleAge-prevAge)
+				return prevUploaded + int64(float64(sample.Uploaded-prevUploaded)*ratio),
+					prevDownloaded + int64(float64(sample.Downloaded-prevDownloaded)*ratio), true
+			}
+			prevAge, prevUploaded, prevDownloaded = sampleAge, sample.Uploaded, sample.Downloaded
+		}
+		last := timeline.Samples[len(timeline.Samples)-1]
+		return prevUploaded + last.UploadSpeed*(age-prevAge), prevDownloaded + last.DownloadSpeed*(age-prevAge), true
+	}
+	if timeline.Stat != nil {
+		lifespan := timeline.Stat.Ts - timeline.Stat.Data.Atime
+		if lifespan <= 0 {
+			return 0, 0, false
+		}
+		age = max(min(age, lifespan), 0)
+		return timeline.Stat.Data.Uploaded * age / lifespan, timeline.Stat.Data.Downloaded * age / lifespan, true
+	}
+	return 0, 0, false
+}
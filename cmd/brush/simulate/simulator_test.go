@@ -0,0 +1,98 @@
+package simulate
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sagan/ptool/cmd/brush/brush_store"
+	"github.com/sagan/ptool/cmd/brush/strategy"
+	"github.com/sagan/ptool/stats"
+)
+
+func TestSimulate(t *testing.T) {
+	start := int64(1000000)
+	data := &Data{Timelines: map[string]*Timeline{}}
+	for i := int64(0); i < 12; i++ {
+		for j := int64(1); j <= 3; j++ {
+			data.Snapshots = append(data.Snapshots, &brush_store.SiteTorrentSnapshot{
+				Site:             "s",
+				Time:             start + i*1800,
+				TorrentId:        fmt.Sprintf("s.%d", j),
+				Name:             fmt.Sprintf("torrent%d", j),
+				Size:             j << 30,
+				Seeders:          2,
+				Leechers:         10 * j,
+				UploadMultiplier: 1,
+			})
+		}
+	}
+	// torrent s.3 was actually brushed, and uploaded nothing
+	data.Timelines["s.3"] = &Timeline{AddTime: start, Samples: []*brush_store.TorrentSample{
+		{Time: start + 3600, Downloaded: 3 << 30, DownloadSpeed: 0},
+	}}
+	defaultStrategy, _ := strategy.Create(strategy.DEFAULT_STRATEGY)
+	options := &Options{
+		Strategies:      map[string]strategy.Strategy{"s": defaultStrategy},
+		SiteOptions:     map[string]*strategy.BrushSiteOptionStruct{"s": {TorrentMaxSizeLimit: 1 << 50}},
+		SiteMaxTorrents: map[string]int64{"s": 10},
+		ClientOption: &strategy.BrushClientOptionStruct{
+			MinDiskSpace:            1 << 30,
+			SlowUploadSpeedTier:     100 << 10,
+			MaxDownloadingTorrents:  5,
+			MaxTorrents:             10,
+			DefaultUploadSpeedLimit: 10 << 20,
+			MaxSlowTorrentCount:     100,
+		},
+		DiskSpace: 100 << 30,
+	}
+	result, err := Simulate(data, options)
+	if err != nil {
+		t.Fatalf("simulate failed: %v", err)
+	}
+	if result.Runs != 12 || result.Start != start || result.End != start+11*1800 {
+		t.Errorf("unexpected runs: %+v", result)
+	}
+	if result.Added != 3 || result.Downloaded != 6<<30 || result.PeakDiskUsage != 6<<30 {
+		t.Errorf("unexpected added / downloaded / disk usage: %+v", result)
+	}
+	// s.1 & s.2 upload at (10 + 20) leechers * PEER_UPLOAD_SPEED for 5.5h, s.3 uploads nothing
+	if expected := 30 * PEER_UPLOAD_SPEED * 11 * 1800; result.Uploaded != expected {
+		t.Errorf("expect uploaded %d, got %d", expected, result.Uploaded)
+	}
+	if len(result.Events) != int(result.Added+result.Deleted) {
+		t.Errorf("unexpected events: %d", len(result.Events))
+	}
+}
+
+func TestTimelineTransferred(t *testing.T) {
+	timeline := &Timeline{AddTime: 1000, Samples: []*brush_store.TorrentSample{
+		{Time: 1100, Uploaded: 1000, Downloaded: 2000},
+		{Time: 1200, Uploaded: 3000, Downloaded: 2000, UploadSpeed: 10},
+	}}
+	for _, c := range []struct {
+		age        int64
+		uploaded   int64
+		downloaded int64
+	}{
+		{0, 0, 0},
+		{50, 500, 1000},
+		{150, 2000, 2000},
+		{300, 4000, 2000},
+	} {
+		if uploaded, downloaded, ok := timeline.transferred(c.age); !ok ||
+			uploaded != c.uploaded || downloaded != c.downloaded {
+			t.Errorf("age %d: expect %d / %d, got %d / %d", c.age, c.uploaded, c.downloaded, uploaded, downloaded)
+		}
+	}
+
+	timeline = &Timeline{Stat: &stats.Stat{Ts: 2000, Data: &stats.TorrentStat{Atime: 1000, Uploaded: 1000}}}
+	if uploaded, _, ok := timeline.transferred(500); !ok || uploaded != 500 {
+		t.Errorf("expect stats average, got %d", uploaded)
+	}
+	if uploaded, _, _ := timeline.transferred(2000); uploaded != 1000 {
+		t.Errorf("expect no transfer after lifespan, got %d", uploaded)
+	}
+	if _, _, ok := (&Timeline{}).transferred(100); ok {
+		t.Errorf("expect no recorded transfer")
+	}
+}
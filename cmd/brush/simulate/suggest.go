@@ -0,0 +1,24 @@
+package simulate
+
+import (
+	"github.com/c-bata/go-prompt"
+
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/cmd/shell/suggest"
+)
+
+func init() {
+	cmd.AddShellCompletion("brush.simulate", func(document *prompt.Document) []prompt.Suggest {
+		info := suggest.Parse(document)
+		if info.LastArgIndex < 2 {
+			return nil
+		}
+		if info.LastArgIsFlag {
+			return nil
+		}
+		if info.LastArgIndex == 2 {
+			return suggest.ClientArg(info.MatchingPrefix)
+		}
+		return suggest.SiteOrGroupArg(info.MatchingPrefix)
+	})
+}
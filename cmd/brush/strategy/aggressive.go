@@ -0,0 +1,61 @@
+package strategy
+
+import (
+	"fmt"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/site"
+)
+
+// The "aggressive" strategy: max upload.
+// Torrents are rated by the number of leechers (multiplied by upload multiplier), torrent size is ignored.
+// Slow torrents are rotated out faster, and more torrents are added until the client upload bandwidth
+// is (over) saturated. Non-free torrents are still only added if site brushAllowNoneFree config is set.
+type aggressiveStrategy struct {
+	defaultStrategy
+}
+
+func init() {
+	Register(&RegInfo{
+		Name:        "aggressive",
+		Description: "Max upload. Prefer torrents of most leechers, rotate slow torrents faster",
+		Params: map[string]string{
+			"newTorrentsTimespan":             "10m",
+			"slowTorrentsCheckTimespan":       "10m",
+			"stallTorrentDeletionTimespan":    "15m",
+			"bandwidthFullPercent":            "0.95",
+			"deleteTorrentsFreeDiskSpaceTier": "5GiB",
+			"uploadSpeedTargetFactor":         "3",
+		},
+		Creator: func(name string, params *Params) Strategy {
+			return &aggressiveStrategy{defaultStrategy{name: name, params: params}}
+		},
+	})
+}
+
+func (s *aggressiveStrategy) Decide(clientStatus *client.Status, clientTorrents []*client.Torrent,
+	siteTorrents []*site.Torrent, siteOption *BrushSiteOptionStruct,
+	clientOption *BrushClientOptionStruct) *AlgorithmResult {
+	return decide(s.params, s.RateSiteTorrent, clientStatus, clientTorrents, siteTorrents, siteOption, clientOption)
+}
+
+func (s *aggressiveStrategy) RateSiteTorrent(siteTorrent *site.Torrent, siteOption *BrushSiteOptionStruct) (
+	score float64, predictionUploadSpeed int64, note string) {
+	defer logRate(siteTorrent, siteOption, &score, &note)
+	if ok, reason := filterSiteTorrent(siteTorrent, siteOption, s.params); !ok {
+		return 0, 0, reason
+	}
+	if siteTorrent.Leechers == 0 {
+		return 0, 0, "no leechers"
+	}
+	ratio := float64(siteTorrent.Leechers) / float64(siteTorrent.Seeders+1)
+	score = (float64(siteTorrent.Leechers) + ratio) * siteTorrent.UploadMultiplier
+	note = fmt.Sprintf("(leechers %d + leechers / (seeders + 1) %.2f) * upload multiplier %g",
+		siteTorrent.Leechers, ratio, siteTorrent.UploadMultiplier)
+	if siteTorrent.DownloadMultiplier != 0 {
+		score *= 0.5
+		note += " * 0.5 (not free)"
+	}
+	predictionUploadSpeed = min(siteTorrent.Leechers*100*1024, siteOption.TorrentUploadSpeedLimit)
+	return
+}
@@ -0,0 +1,60 @@
+package strategy
+
+import (
+	"fmt"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/site"
+)
+
+// The "conservative" strategy: free-only, ratio-first.
+// Only free (download multiplier == 0) torrents that have more leechers than seeders are added,
+// regardless of brushAllowNoneFree site config. Torrents are stalled earlier before discount ends,
+// and the client upload bandwidth is not over-committed.
+type conservativeStrategy struct {
+	defaultStrategy
+}
+
+func init() {
+	Register(&RegInfo{
+		Name:        "conservative",
+		Description: "Free-only, ratio-first. Only add free torrents that have more leechers than seeders",
+		Params: map[string]string{
+			"discountEndTimespan":             "2h",
+			"slowTorrentsCheckTimespan":       "10m",
+			"deleteTorrentsFreeDiskSpaceTier": "20GiB",
+			"uploadSpeedTargetFactor":         "1.5",
+		},
+		Creator: func(name string, params *Params) Strategy {
+			return &conservativeStrategy{defaultStrategy{name: name, params: params}}
+		},
+	})
+}
+
+func (s *conservativeStrategy) Decide(clientStatus *client.Status, clientTorrents []*client.Torrent,
+	siteTorrents []*site.Torrent, siteOption *BrushSiteOptionStruct,
+	clientOption *BrushClientOptionStruct) *AlgorithmResult {
+	return decide(s.params, s.RateSiteTorrent, clientStatus, clientTorrents, siteTorrents, siteOption, clientOption)
+}
+
+func (s *conservativeStrategy) RateSiteTorrent(siteTorrent *site.Torrent, siteOption *BrushSiteOptionStruct) (
+	score float64, predictionUploadSpeed int64, note string) {
+	defer logRate(siteTorrent, siteOption, &score, &note)
+	if ok, reason := filterSiteTorrent(siteTorrent, siteOption, s.params); !ok {
+		return 0, 0, reason
+	}
+	if siteTorrent.DownloadMultiplier != 0 {
+		return 0, 0, "not free"
+	}
+	if siteTorrent.Leechers <= siteTorrent.Seeders {
+		return 0, 0, "leechers <= seeders"
+	}
+	// leechers / seeders ratio, slightly penalize big torrents
+	ratio := float64(siteTorrent.Leechers) / float64(siteTorrent.Seeders+1)
+	sizePenalty := 1 + float64(siteTorrent.Size)/(50*1024*1024*1024)
+	score = ratio * siteTorrent.UploadMultiplier / sizePenalty
+	predictionUploadSpeed = min(siteTorrent.Leechers*50*1024, siteOption.TorrentUploadSpeedLimit)
+	note = fmt.Sprintf("leechers / (seeders + 1) %.2f * upload multiplier %g / size penalty %.2f",
+		ratio, siteTorrent.UploadMultiplier, sizePenalty)
+	return
+}
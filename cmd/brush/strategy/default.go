@@ -0,0 +1,705 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd/brush/brush_store"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+// The default strategy: the original brush algorithm of ptool.
+// Site torrents are rated by leechers / seeders ratio, small torrents are preferred.
+type defaultStrategy struct {
+	name   string
+	params *Params
+}
+
+type rateFunc func(siteTorrent *site.Torrent, siteOption *BrushSiteOptionStruct) (
+	score float64, predictionUploadSpeed int64, note string)
+
+func init() {
+	Register(&RegInfo{
+		Name:        DEFAULT_STRATEGY,
+		Description: "Balanced. Prefer free torrents of high leechers / seeders ratio and small size",
+		Creator: func(name string, params *Params) Strategy {
+			return &defaultStrategy{name: name, params: params}
+		},
+	})
+}
+
+func (s *defaultStrategy) Name() string {
+	return s.name
+}
+
+func (s *defaultStrategy) Params() *Params {
+	return s.params
+}
+
+func (s *defaultStrategy) Decide(clientStatus *client.Status, clientTorrents []*client.Torrent,
+	siteTorrents []*site.Torrent, siteOption *BrushSiteOptionStruct,
+	clientOption *BrushClientOptionStruct) *AlgorithmResult {
+	return decide(s.params, s.RateSiteTorrent, clientStatus, clientTorrents, siteTorrents, siteOption, clientOption)
+}
+
+func (s *defaultStrategy) RateSiteTorrent(siteTorrent *site.Torrent, siteOption *BrushSiteOptionStruct) (
+	score float64, predictionUploadSpeed int64, note string) {
+	defer logRate(siteTorrent, siteOption, &score, &note)
+	if ok, reason := filterSiteTorrent(siteTorrent, siteOption, s.params); !ok {
+		return 0, 0, reason
+	}
+	var (
+		score1 float64
+		score2 float64
+	)
+
+	if siteTorrent.Seeders > 1 {
+		// 下载人数除以做种人数
+		score1 = float64(siteTorrent.Leechers) / float64(siteTorrent.Seeders)
+	}
+	//种子大小 原始单位Bytes
+	score2 = float64(siteTorrent.Size) / (1024 * 1024 * 1024)
+	score = score1 + 0.1/score2
+	note = fmt.Sprintf("leechers / seeders %.2f + 0.1 / size(GiB) %.2f", score1, 0.1/score2)
+	return
+}
+
+// Check whether site torrent can be brushed according to site options.
+func filterSiteTorrent(siteTorrent *site.Torrent, siteOption *BrushSiteOptionStruct, params *Params) (
+	ok bool, note string) {
+	switch {
+	case siteTorrent.IsActive:
+		return false, "already downloading or seeding"
+	case siteTorrent.UploadMultiplier == 0:
+		return false, "upload multiplier is 0"
+	case !siteOption.AllowHr && siteTorrent.HasHnR:
+		return false, "has HnR"
+	case !siteOption.AllowNoneFree && siteTorrent.DownloadMultiplier != 0:
+		return false, "not free"
+	case !siteOption.AllowPaid && siteTorrent.Paid && !siteTorrent.Bought:
+		return false, "paid"
+	case siteTorrent.Size < siteOption.TorrentMinSizeLimit:
+		return false, fmt.Sprintf("size %s < min size limit %s",
+			util.BytesSize(float64(siteTorrent.Size)), util.BytesSize(float64(siteOption.TorrentMinSizeLimit)))
+	case siteTorrent.Size > siteOption.TorrentMaxSizeLimit:
+		return false, fmt.Sprintf("size %s > max size limit %s",
+			util.BytesSize(float64(siteTorrent.Size)), util.BytesSize(float64(siteOption.TorrentMaxSizeLimit)))
+	case siteTorrent.DiscountEndTime > 0 && siteTorrent.DiscountEndTime-siteOption.Now < params.DiscountEndTimespan:
+		return false, fmt.Sprintf("discount ends in %s (< discountEndTimespan %s)",
+			util.GetDurationString(siteTorrent.DiscountEndTime-siteOption.Now),
+			util.GetDurationString(params.DiscountEndTimespan))
+	case !siteOption.AllowZeroSeeders && siteTorrent.Seeders == 0:
+		return false, "no seeders"
+	case siteTorrent.MatchFiltersOr(siteOption.Excludes):
+		return false, "brush excludes matches"
+	}
+	return true, ""
+}
+
+func logRate(siteTorrent *site.Torrent, siteOption *BrushSiteOptionStruct, score *float64, note *string) {
+	if log.GetLevel() < log.TraceLevel {
+		return
+	}
+	log.Tracef("rateSiteTorrent score=%0.0f name=%s, free=%t, rtime=%d, seeders=%d, leechers=%d, note=%s",
+		*score,
+		siteTorrent.Name,
+		siteTorrent.DownloadMultiplier == 0,
+		siteOption.Now-siteTorrent.Time,
+		siteTorrent.Seeders,
+		siteTorrent.Leechers,
+		*note,
+	)
+}
+
+func countAsDownloading(torrent *client.Torrent, now int64, params *Params) bool {
+	return !torrent.IsComplete() && torrent.Meta["stt"] == 0 &&
+		(torrent.DownloadSpeed >= params.StallDownloadSpeed || now-torrent.Atime <= params.NewTorrentsTimespan)
+}
+
+// Return the number of client torrents counted as downloading in brush decisions.
+func CountDownloadingTorrents(torrents []*client.Torrent, now int64, params *Params) (cnt int64) {
+	for _, torrent := range torrents {
+		if countAsDownloading(torrent, now, params) {
+			cnt++
+		}
+	}
+	return
+}
+
+// Return the upload / download speed of client torrent used in decisions:
+// the average speeds in history window if there are enough samples, otherwise the current speeds.
+// The returned history is nil if current speeds are used.
+func torrentSpeeds(torrent *client.Torrent, clientOption *BrushClientOptionStruct, params *Params) (
+	uploadSpeed int64, downloadSpeed int64, history *brush_store.TorrentHistory) {
+	if history = clientOption.TorrentsHistory[torrent.InfoHash]; history != nil &&
+		history.Samples >= params.HistoryMinSamples && history.Duration > 0 {
+		return history.AverageUploadSpeed, history.AverageDownloadSpeed, history
+	}
+	return torrent.UploadSpeed, torrent.DownloadSpeed, nil
+}
+
+// Check whether the upload speed of client torrent is lower than tier. Torrents whose upload speed
+// is rising and once reached the tier in history window are not considered slow.
+func isTorrentSlow(torrent *client.Torrent, uploadSpeed int64, tier int64,
+	clientOption *BrushClientOptionStruct, params *Params) bool {
+	if uploadSpeed >= tier {
+		return false
+	}
+	history := clientOption.TorrentsHistory[torrent.InfoHash]
+	return history == nil || history.Samples < params.HistoryMinSamples ||
+		history.UploadSpeedTrend <= 0 || history.PeakUploadSpeed < tier
+}
+
+func canStallTorrent(torrent *client.Torrent) bool {
+	return torrent.State == "downloading" && torrent.Meta["stt"] == 0
+}
+
+func isTorrentStalled(torrent *client.Torrent) bool {
+	return !torrent.IsComplete() && torrent.Meta["stt"] > 0
+}
+
+/*
+ * @todo : this function requires a major rework. It's a mess right now.
+ *
+ * Strategy (Desired)
+ * Delete a torrent from client when (any of the the follow criterion matches):
+ *   a. Tt's uploading speed become SLOW enough AND free disk space insufficient
+ *   b. It's consuming too much downloading bandwidth and uploading / downloading speed ratio is too low
+ *   c. It's incomplete and been totally stalled (no uploading or downloading activity) for some time
+ *   d. It's incomplete and the free discount expired (or will soon expire)
+ * Stall ALL incomplete torrent of client (limit download speed to 1B/s, so upload only)
+ *   when free disk space insufficient
+ *   * This's somwwhat broken in qBittorrent for now (See https://github.com/qbittorrent/qBittorrent/issues/2185 ).
+ *   * Simply limiting downloading speed (to a very low tier) will also drop uploading speed to the same level
+ *   * Consider removing this behavior
+ * Add new torrents to client when server uploading and downloading bandwidth is somewhat idle AND
+ *   there is SOME free disk space
+ * Also：
+ *   * Use the current seeders / leechers info of torrent when make decisions
+ *   * Use the average speeds of torrent in history window (if available) when make slow / deletion decisions
+ */
+func decide(params *Params, rate rateFunc,
+	clientStatus *client.Status, clientTorrents []*client.Torrent, siteTorrents []*site.Torrent,
+	siteOption *BrushSiteOptionStruct, clientOption *BrushClientOptionStruct) (result *AlgorithmResult) {
+	result = &AlgorithmResult{}
+
+	cntTorrents := int64(len(clientTorrents))
+	cntDownloadingTorrents := int64(0)
+	freespace := clientStatus.FreeSpaceOnDisk
+	freespaceChange := int64(0)
+	freespaceTarget := min(clientOption.MinDiskSpace*2, clientOption.MinDiskSpace+params.DeleteTorrentsFreeDiskSpaceTier)
+	estimateUploadSpeed := clientStatus.UploadSpeed
+
+	var candidateTorrents []candidateTorrentStruct
+	var modifyTorrents []AlgorithmModifyTorrent
+	var stallTorrents []AlgorithmModifyTorrent
+	var resumeTorrents []AlgorithmOperationTorrent
+	var deleteCandidateTorrents []candidateClientTorrentStruct
+	clientTorrentsMap := map[string]*clientTorrentInfoStruct{}
+	siteTorrentsMap := map[string]*site.Torrent{}
+
+	targetUploadSpeed := clientStatus.UploadSpeedLimit
+	if targetUploadSpeed <= 0 {
+		targetUploadSpeed = clientOption.DefaultUploadSpeedLimit
+	}
+
+	for i, torrent := range clientTorrents {
+		clientTorrentsMap[torrent.InfoHash] = &clientTorrentInfoStruct{
+			Torrent: clientTorrents[i],
+		}
+	}
+	explain := func(infoHash string, format string, args ...any) {
+		if !clientOption.Explain {
+			return
+		}
+		clientTorrentsMap[infoHash].Rules = append(clientTorrentsMap[infoHash].Rules, fmt.Sprintf(format, args...))
+	}
+	for i, siteTorrent := range siteTorrents {
+		siteTorrentsMap[siteTorrent.ID()] = siteTorrents[i]
+	}
+
+	for _, siteTorrent := range siteTorrents {
+		score, predictionUploadSpeed, note := rate(siteTorrent, siteOption)
+		siteTorrentExplain := &SiteTorrentExplain{
+			Id:                    siteTorrent.IDFull(),
+			Name:                  siteTorrent.Name,
+			Size:                  siteTorrent.Size,
+			Score:                 score,
+			PredictionUploadSpeed: predictionUploadSpeed,
+			Note:                  note,
+			Action:                "reject",
+		}
+		result.SiteTorrentsExplain = append(result.SiteTorrentsExplain, siteTorrentExplain)
+		if score > 0 {
+			siteTorrentExplain.Action = "skip"
+			candidateTorrent := candidateTorrentStruct{
+				Name:                  siteTorrent.Name,
+				Size:                  siteTorrent.Size,
+				DownloadUrl:           siteTorrent.DownloadUrl,
+				PredictionUploadSpeed: predictionUploadSpeed,
+				Score:                 score,
+				Meta:                  map[string]int64{},
+				ID:                    siteTorrent.IDFull(),
+				Explain:               siteTorrentExplain,
+			}
+			if siteTorrent.DiscountEndTime > 0 {
+				candidateTorrent.Meta["dcet"] = siteTorrent.DiscountEndTime
+			}
+			candidateTorrents = append(candidateTorrents, candidateTorrent)
+		}
+	}
+	sort.SliceStable(candidateTorrents, func(i, j int) bool {
+		return candidateTorrents[i].Score > candidateTorrents[j].Score
+	})
+
+	torrentRecordManager := clientOption.TorrentRecordManager
+	// mark torrents
+	for _, torrent := range clientTorrents {
+		if countAsDownloading(torrent, siteOption.Now, params) {
+			cntDownloadingTorrents++
+		}
+		uploadSpeed, downloadSpeed, history := torrentSpeeds(torrent, clientOption, params)
+		if history != nil {
+			explain(torrent.InfoHash, "upload / download speed: %s/s / %s/s (average of %d samples in %s)",
+				util.BytesSize(float64(uploadSpeed)), util.BytesSize(float64(downloadSpeed)),
+				history.Samples, util.GetDurationString(history.Duration))
+		} else {
+			explain(torrent.InfoHash, "upload / download speed: %s/s / %s/s (current)",
+				util.BytesSize(float64(uploadSpeed)), util.BytesSize(float64(downloadSpeed)))
+		}
+		// 标记慢速种子
+		if isTorrentSlow(torrent, uploadSpeed, params.SlowTorrentUploadSpeed, clientOption, params) {
+			if !clientOption.NoRecord {
+				torrentRecordManager.MarkSlowTorrentRecord(torrent.InfoHash, torrent.Name)
+			}
+			explain(torrent.InfoHash, "upload speed < slowTorrentUploadSpeed %s/s: increase slow count",
+				util.BytesSize(float64(params.SlowTorrentUploadSpeed)))
+		}
+		// mark torrents that discount time ends as stall
+		if torrent.Meta["dcet"] > 0 && torrent.Meta["dcet"]-siteOption.Now <= params.DiscountEndTimespan && torrent.Ctime <= 0 {
+			if canStallTorrent(torrent) {
+				meta := util.CopyMap(torrent.Meta, true)
+				meta["stt"] = siteOption.Now
+				stallTorrents = append(stallTorrents, AlgorithmModifyTorrent{
+					InfoHash: torrent.InfoHash,
+					Name:     torrent.Name,
+					Msg:      "discount time ends",
+					Meta:     meta,
+				})
+				clientTorrentsMap[torrent.InfoHash].StallFlag = true
+				explain(torrent.InfoHash, "incomplete and discount ends in %s (<= discountEndTimespan %s): stall",
+					util.GetDurationString(torrent.Meta["dcet"]-siteOption.Now),
+					util.GetDurationString(params.DiscountEndTimespan))
+			}
+		}
+
+		// skip new added torrents
+		if siteOption.Now-torrent.Atime <= params.NewTorrentsTimespan {
+			explain(torrent.InfoHash, "added %s ago (<= newTorrentsTimespan %s): not examined",
+				util.GetDurationString(siteOption.Now-torrent.Atime), util.GetDurationString(params.NewTorrentsTimespan))
+			continue
+		}
+
+		slow := isTorrentSlow(torrent, uploadSpeed, clientOption.SlowUploadSpeedTier, clientOption, params)
+		if !slow && uploadSpeed < clientOption.SlowUploadSpeedTier {
+			history := clientOption.TorrentsHistory[torrent.InfoHash]
+			explain(torrent.InfoHash, "upload speed < slowUploadSpeedTier %s/s, but rising "+
+				"(trend %s/s per hour, peak %s/s): not slow", util.BytesSize(float64(clientOption.SlowUploadSpeedTier)),
+				util.BytesSize(history.UploadSpeedTrend), util.BytesSize(float64(history.PeakUploadSpeed)))
+		}
+
+		if torrent.State == "error" && (uploadSpeed < clientOption.SlowUploadSpeedTier ||
+			uploadSpeed < clientOption.SlowUploadSpeedTier*2 && freespace == 0) &&
+			len(candidateTorrents) > 0 {
+			deleteCandidateTorrents = append(deleteCandidateTorrents, candidateClientTorrentStruct{
+				InfoHash:    torrent.InfoHash,
+				Score:       DELETE_TORRENT_IMMEDIATELY_SCORE,
+				FutureValue: 0,
+				Msg:         "torrent in error state",
+			})
+			clientTorrentsMap[torrent.InfoHash].DeleteCandidateFlag = true
+			explain(torrent.InfoHash, "error state and upload speed < slowUploadSpeedTier %s/s "+
+				"(or < 2x of it with no free disk space): delete candidate",
+				util.BytesSize(float64(clientOption.SlowUploadSpeedTier)))
+		} else if torrent.DownloadSpeed == 0 && torrent.SizeCompleted == 0 {
+			if siteOption.Now-torrent.Atime > params.NoProcessTorrentDeletionTimespan {
+				deleteCandidateTorrents = append(deleteCandidateTorrents, candidateClientTorrentStruct{
+					InfoHash:    torrent.InfoHash,
+					Score:       DELETE_TORRENT_IMMEDIATELY_SCORE,
+					FutureValue: 0,
+					Msg:         "torrent has no download proccess",
+				})
+				clientTorrentsMap[torrent.InfoHash].DeleteCandidateFlag = true
+				explain(torrent.InfoHash, "no download progress in %s (> noProcessTorrentDeletionTimespan %s): "+
+					"delete candidate", util.GetDurationString(siteOption.Now-torrent.Atime),
+					util.GetDurationString(params.NoProcessTorrentDeletionTimespan))
+			} else {
+				explain(torrent.InfoHash, "no download progress in %s (<= noProcessTorrentDeletionTimespan %s): keep",
+					util.GetDurationString(siteOption.Now-torrent.Atime),
+					util.GetDurationString(params.NoProcessTorrentDeletionTimespan))
+			}
+		} else if slowCount := torrentRecordManager.GetSlowTorrentCountByHash(torrent.InfoHash); float64(slowCount) > (float64(clientOption.MaxSlowTorrentCount) * (torrent.Ratio + 1)) {
+			// 大于指定次数进行删除
+			deleteCandidateTorrents = append(deleteCandidateTorrents, candidateClientTorrentStruct{
+				InfoHash:    torrent.InfoHash,
+				Score:       DELETE_TORRENT_IMMEDIATELY_SCORE,
+				FutureValue: 0,
+				Msg:         "torrent is slow too many times",
+			})
+			explain(torrent.InfoHash, "slow count %d > maxSlowTorrentCount %d * (ratio %.2f + 1): delete candidate",
+				slowCount, clientOption.MaxSlowTorrentCount, torrent.Ratio)
+		} else if slow {
+			// check slow torrents, add it to watch list first time and mark as deleteCandidate second time
+			if torrent.Meta["sct"] > 0 { // second encounter on slow torrent
+				if siteOption.Now-torrent.Meta["sct"] >= params.SlowTorrentsCheckTimespan {
+					averageUploadSpeedSinceSct := (torrent.Uploaded - torrent.Meta["sctu"]) /
+						(siteOption.Now - torrent.Meta["sct"])
+					if averageUploadSpeedSinceSct < clientOption.SlowUploadSpeedTier {
+						if canStallTorrent(torrent) &&
+							downloadSpeed >= params.RatioCheckMinDownloadSpeed &&
+							float64(uploadSpeed)/float64(downloadSpeed) < clientOption.MinRatio &&
+							siteOption.Now-torrent.Atime >= params.NewTorrentsStallExemptionTimespan {
+							meta := util.CopyMap(torrent.Meta, true)
+							meta["stt"] = siteOption.Now
+							stallTorrents = append(stallTorrents, AlgorithmModifyTorrent{
+								InfoHash: torrent.InfoHash,
+								Name:     torrent.Name,
+								Msg:      "low upload / download ratio",
+								Meta:     meta,
+							})
+							clientTorrentsMap[torrent.InfoHash].StallFlag = true
+							explain(torrent.InfoHash, "upload / download speed ratio %.2f < minRatio %.2f "+
+								"(download speed >= ratioCheckMinDownloadSpeed %s/s): stall",
+								float64(uploadSpeed)/float64(downloadSpeed), clientOption.MinRatio,
+								util.BytesSize(float64(params.RatioCheckMinDownloadSpeed)))
+						}
+						score := -float64(uploadSpeed)
+						if torrent.Ctime <= 0 {
+							if torrent.Meta["stt"] > 0 {
+								score += float64(siteOption.Now) - float64(torrent.Meta["stt"])
+							}
+						} else {
+							score += math.Min(float64(siteOption.Now-torrent.Ctime), 86400)
+						}
+						deleteCandidateTorrents = append(deleteCandidateTorrents, candidateClientTorrentStruct{
+							InfoHash:    torrent.InfoHash,
+							Score:       score,
+							FutureValue: uploadSpeed,
+							Msg:         "slow uploading speed",
+						})
+						clientTorrentsMap[torrent.InfoHash].DeleteCandidateFlag = true
+						explain(torrent.InfoHash, "average upload speed since slow check mark %s/s "+
+							"< slowUploadSpeedTier %s/s: delete candidate of score %.0f",
+							util.BytesSize(float64(averageUploadSpeedSinceSct)),
+							util.BytesSize(float64(clientOption.SlowUploadSpeedTier)), score)
+					} else {
+						meta := util.CopyMap(torrent.Meta, true)
+						meta["sct"] = siteOption.Now
+						meta["sctu"] = torrent.Uploaded
+						modifyTorrents = append(modifyTorrents, AlgorithmModifyTorrent{
+							InfoHash: torrent.InfoHash,
+							Name:     torrent.Name,
+							Msg:      "reset slow check time mark",
+							Meta:     meta,
+						})
+						clientTorrentsMap[torrent.InfoHash].ModifyFlag = true
+						explain(torrent.InfoHash, "average upload speed since slow check mark %s/s "+
+							">= slowUploadSpeedTier %s/s: reset slow check mark",
+							util.BytesSize(float64(averageUploadSpeedSinceSct)),
+							util.BytesSize(float64(clientOption.SlowUploadSpeedTier)))
+					}
+				} else {
+					explain(torrent.InfoHash, "slow, slow check mark set %s ago (< slowTorrentsCheckTimespan %s): wait",
+						util.GetDurationString(siteOption.Now-torrent.Meta["sct"]),
+						util.GetDurationString(params.SlowTorrentsCheckTimespan))
+				}
+			} else { // first encounter on slow torrent
+				meta := util.CopyMap(torrent.Meta, true)
+				meta["sct"] = siteOption.Now
+				meta["sctu"] = torrent.Uploaded
+				modifyTorrents = append(modifyTorrents, AlgorithmModifyTorrent{
+					InfoHash: torrent.InfoHash,
+					Name:     torrent.Name,
+					Msg:      "set slow check time mark",
+					Meta:     meta,
+				})
+				clientTorrentsMap[torrent.InfoHash].ModifyFlag = true
+				explain(torrent.InfoHash, "upload speed < slowUploadSpeedTier %s/s: set slow check mark",
+					util.BytesSize(float64(clientOption.SlowUploadSpeedTier)))
+			}
+		} else if torrent.Meta["sct"] > 0 { // remove mark on no-longer slow torrents
+			meta := util.CopyMap(torrent.Meta, true)
+			delete(meta, "sct")
+			delete(meta, "sctu")
+			modifyTorrents = append(modifyTorrents, AlgorithmModifyTorrent{
+				InfoHash: torrent.InfoHash,
+				Name:     torrent.Name,
+				Msg:      "remove slow check time mark",
+				Meta:     meta,
+			})
+			clientTorrentsMap[torrent.InfoHash].ModifyFlag = true
+			explain(torrent.InfoHash, "upload speed >= slowUploadSpeedTier %s/s: remove slow check mark",
+				util.BytesSize(float64(clientOption.SlowUploadSpeedTier)))
+		}
+	}
+	sort.SliceStable(deleteCandidateTorrents, func(i, j int) bool {
+		return deleteCandidateTorrents[i].Score > deleteCandidateTorrents[j].Score
+	})
+
+	// @todo: use Dynamic Programming to better find torrents suitable for delete
+	// delete torrents
+
+	for _, deleteTorrent := range deleteCandidateTorrents {
+		torrent := clientTorrentsMap[deleteTorrent.InfoHash].Torrent
+		shouldDelete := false
+		if deleteTorrent.Score >= DELETE_TORRENT_IMMEDIATELY_SCORE {
+			shouldDelete = true
+			explain(torrent.InfoHash, "delete immediately")
+		} else if freespace >= 0 && freespace <= clientOption.MinDiskSpace &&
+			freespace+freespaceChange <= freespaceTarget {
+			shouldDelete = true
+			explain(torrent.InfoHash, "free disk space %s <= minDiskSpace %s: delete",
+				util.BytesSize(float64(freespace)), util.BytesSize(float64(clientOption.MinDiskSpace)))
+		} else if torrent.Ctime <= 0 &&
+			torrent.Meta["stt"] > 0 &&
+			siteOption.Now-torrent.Meta["stt"] >= params.StallTorrentDeletionTimespan {
+			shouldDelete = true
+			explain(torrent.InfoHash, "incomplete and stalled for %s (>= stallTorrentDeletionTimespan %s): delete",
+				util.GetDurationString(siteOption.Now-torrent.Meta["stt"]),
+				util.GetDurationString(params.StallTorrentDeletionTimespan))
+		}
+
+		if !shouldDelete {
+			explain(torrent.InfoHash, "delete candidate kept: free disk space is sufficient")
+			continue
+		}
+		result.DeleteTorrents = append(result.DeleteTorrents, AlgorithmOperationTorrent{
+			InfoHash: torrent.InfoHash,
+			Name:     torrent.Name,
+			Msg:      deleteTorrent.Msg,
+		})
+		if !clientOption.NoRecord {
+			torrentRecordManager.MarkDeleteRecord(torrent.InfoHash)
+		}
+		freespaceChange += torrent.SizeCompleted
+		estimateUploadSpeed -= torrent.UploadSpeed
+		clientTorrentsMap[torrent.InfoHash].DeleteFlag = true
+		if countAsDownloading(torrent, siteOption.Now, params) {
+			cntDownloadingTorrents--
+		}
+		cntTorrents--
+	}
+
+	// if still not enough free space, delete ALL stalled incomplete torrents
+	if freespace >= 0 && freespace <= clientOption.MinDiskSpace && freespace+freespaceChange <= freespaceTarget {
+		for _, torrent := range clientTorrents {
+			if clientTorrentsMap[torrent.InfoHash].DeleteFlag || !isTorrentStalled(torrent) {
+				continue
+			}
+			result.DeleteTorrents = append(result.DeleteTorrents, AlgorithmOperationTorrent{
+				InfoHash: torrent.InfoHash,
+				Name:     torrent.Name,
+				Msg:      "delete stalled incomplete torrents due to insufficient disk space",
+			})
+			explain(torrent.InfoHash, "stalled incomplete torrent and free disk space %s <= minDiskSpace %s: delete",
+				util.BytesSize(float64(freespace+freespaceChange)), util.BytesSize(float64(clientOption.MinDiskSpace)))
+			freespaceChange += torrent.SizeCompleted
+			estimateUploadSpeed -= torrent.UploadSpeed
+			clientTorrentsMap[torrent.InfoHash].DeleteFlag = true
+			if countAsDownloading(torrent, siteOption.Now, params) {
+				cntDownloadingTorrents--
+			}
+			cntTorrents--
+		}
+	}
+
+	// delete torrents due to max brush torrents limit
+	cntDeleteDueToMaxTorrents := max(cntTorrents-clientOption.MaxTorrents, -siteOption.AllowAddTorrents)
+	if cntDeleteDueToMaxTorrents > 0 && len(candidateTorrents) > 0 {
+		for _, deleteTorrent := range deleteCandidateTorrents {
+			torrent := clientTorrentsMap[deleteTorrent.InfoHash].Torrent
+			if clientTorrentsMap[torrent.InfoHash].DeleteFlag {
+				continue
+			}
+			result.DeleteTorrents = append(result.DeleteTorrents, AlgorithmOperationTorrent{
+				InfoHash: torrent.InfoHash,
+				Name:     torrent.Name,
+				Msg:      deleteTorrent.Msg + " (delete due to max torrents limit)",
+			})
+			explain(torrent.InfoHash, "torrents %d > maxTorrents %d (or site allowed add torrents %d < 0): delete",
+				cntTorrents, clientOption.MaxTorrents, siteOption.AllowAddTorrents)
+			freespaceChange += torrent.SizeCompleted
+			estimateUploadSpeed -= torrent.UploadSpeed
+			clientTorrentsMap[torrent.InfoHash].DeleteFlag = true
+			if countAsDownloading(torrent, siteOption.Now, params) {
+				cntDownloadingTorrents--
+			}
+			cntTorrents--
+			cntDeleteDueToMaxTorrents--
+			if cntDeleteDueToMaxTorrents == 0 {
+				break
+			}
+		}
+	}
+
+	// if still not enough free space, mark ALL torrents as stall
+	if freespace >= 0 && freespace+freespaceChange < clientOption.MinDiskSpace {
+		for _, torrent := range clientTorrents {
+			if clientTorrentsMap[torrent.InfoHash].DeleteFlag || clientTorrentsMap[torrent.InfoHash].StallFlag {
+				continue
+			}
+			if canStallTorrent(torrent) {
+				meta := util.CopyMap(torrent.Meta, true)
+				meta["stt"] = siteOption.Now
+				stallTorrents = append(stallTorrents, AlgorithmModifyTorrent{
+					InfoHash: torrent.InfoHash,
+					Name:     torrent.Name,
+					Msg:      "stall all torrents due to insufficient free disk space",
+					Meta:     meta,
+				})
+				clientTorrentsMap[torrent.InfoHash].StallFlag = true
+				explain(torrent.InfoHash, "free disk space %s < minDiskSpace %s: stall",
+					util.BytesSize(float64(freespace+freespaceChange)), util.BytesSize(float64(clientOption.MinDiskSpace)))
+			}
+		}
+	}
+
+	// mark torrents as resume
+	if freespace+freespaceChange >= max(clientOption.MinDiskSpace, params.ResumeTorrentsFreeDiskSpaceTier) {
+		for _, torrent := range clientTorrents {
+			uploadSpeed, _, _ := torrentSpeeds(torrent, clientOption, params)
+			if torrent.State != "error" || uploadSpeed < clientOption.SlowUploadSpeedTier*4 ||
+				isTorrentStalled(torrent) || clientTorrentsMap[torrent.InfoHash].ResumeFlag {
+				continue
+			}
+			resumeTorrents = append(resumeTorrents, AlgorithmOperationTorrent{
+				InfoHash: torrent.InfoHash,
+				Name:     torrent.Name,
+				Msg:      "resume fast uploading errored torrent",
+			})
+			clientTorrentsMap[torrent.InfoHash].ResumeFlag = true
+			explain(torrent.InfoHash, "error state and upload speed >= 4x slowUploadSpeedTier %s/s: resume",
+				util.BytesSize(float64(clientOption.SlowUploadSpeedTier)))
+		}
+	}
+
+	// stall torrents
+	for _, stallTorrent := range stallTorrents {
+		if clientTorrentsMap[stallTorrent.InfoHash].DeleteFlag {
+			continue
+		}
+		result.StallTorrents = append(result.StallTorrents, stallTorrent)
+		if countAsDownloading(clientTorrentsMap[stallTorrent.InfoHash].Torrent, siteOption.Now, params) {
+			cntDownloadingTorrents--
+		}
+	}
+
+	// resume torrents
+	for _, resumeTorrent := range resumeTorrents {
+		if clientTorrentsMap[resumeTorrent.InfoHash].DeleteFlag ||
+			clientTorrentsMap[resumeTorrent.InfoHash].StallFlag {
+			continue
+		}
+		result.ResumeTorrents = append(result.ResumeTorrents, resumeTorrent)
+		if !countAsDownloading(clientTorrentsMap[resumeTorrent.InfoHash].Torrent, siteOption.Now, params) {
+			cntDownloadingTorrents++
+		}
+	}
+
+	// modify torrents
+	for _, modifyTorrent := range modifyTorrents {
+		if clientTorrentsMap[modifyTorrent.InfoHash].DeleteFlag || clientTorrentsMap[modifyTorrent.InfoHash].StallFlag {
+			continue
+		}
+		result.ModifyTorrents = append(result.ModifyTorrents, modifyTorrent)
+	}
+
+	// add new torrents
+	if (freespace == -1 || freespace+freespaceChange > clientOption.MinDiskSpace) &&
+		cntTorrents <= clientOption.MaxTorrents {
+		var added int64
+		for cntDownloadingTorrents < clientOption.MaxDownloadingTorrents &&
+			float64(estimateUploadSpeed) <= float64(targetUploadSpeed)*params.UploadSpeedTargetFactor && len(candidateTorrents) > 0 &&
+			added < siteOption.AllowAddTorrents {
+			candidateTorrent := candidateTorrents[0]
+			candidateTorrents = candidateTorrents[1:]
+			// 判断该种子是否删除过，删除过的不添加
+			if tmpRecord := torrentRecordManager.IsDeletedRecord(candidateTorrent.ID, siteOption.Now); tmpRecord {
+				candidateTorrent.Explain.Reason = "added to client before"
+				continue
+			}
+			candidateTorrent.Explain.Action = "add"
+			result.AddTorrents = append(result.AddTorrents, AlgorithmAddTorrent{
+				DownloadUrl:           candidateTorrent.DownloadUrl,
+				Name:                  candidateTorrent.Name,
+				Meta:                  candidateTorrent.Meta,
+				Msg:                   fmt.Sprintf("new torrrent of score %.0f", candidateTorrent.Score),
+				SiteId:                candidateTorrent.ID,
+				Size:                  candidateTorrent.Size,
+				PredictionUploadSpeed: candidateTorrent.PredictionUploadSpeed,
+			})
+			added++
+			cntTorrents++
+			cntDownloadingTorrents++
+			estimateUploadSpeed += candidateTorrent.PredictionUploadSpeed
+		}
+	}
+
+	if len(candidateTorrents) > 0 {
+		var reason string
+		if freespace != -1 && freespace+freespaceChange <= clientOption.MinDiskSpace {
+			reason = fmt.Sprintf("free disk space %s <= minDiskSpace %s",
+				util.BytesSize(float64(freespace+freespaceChange)), util.BytesSize(float64(clientOption.MinDiskSpace)))
+		} else if cntTorrents > clientOption.MaxTorrents {
+			reason = fmt.Sprintf("torrents %d > maxTorrents %d", cntTorrents, clientOption.MaxTorrents)
+		} else if cntDownloadingTorrents >= clientOption.MaxDownloadingTorrents {
+			reason = fmt.Sprintf("downloading torrents %d >= maxDownloadingTorrents %d",
+				cntDownloadingTorrents, clientOption.MaxDownloadingTorrents)
+		} else if float64(estimateUploadSpeed) > float64(targetUploadSpeed)*params.UploadSpeedTargetFactor {
+			reason = fmt.Sprintf("estimated upload speed %s/s > upload speed limit %s/s * uploadSpeedTargetFactor %g",
+				util.BytesSize(float64(estimateUploadSpeed)), util.BytesSize(float64(targetUploadSpeed)),
+				params.UploadSpeedTargetFactor)
+		} else {
+			reason = fmt.Sprintf("site allowed add torrents %d reached", siteOption.AllowAddTorrents)
+		}
+		for _, candidateTorrent := range candidateTorrents {
+			candidateTorrent.Explain.Reason = reason
+		}
+	}
+
+	for _, torrent := range clientTorrents {
+		info := clientTorrentsMap[torrent.InfoHash]
+		clientTorrentExplain := &ClientTorrentExplain{
+			InfoHash: torrent.InfoHash,
+			Name:     torrent.Name,
+			Action:   "keep",
+			Rules:    info.Rules,
+		}
+		if info.DeleteFlag {
+			clientTorrentExplain.Action = "delete"
+		} else if info.StallFlag {
+			clientTorrentExplain.Action = "stall"
+		} else if info.ResumeFlag {
+			clientTorrentExplain.Action = "resume"
+		} else if info.ModifyFlag {
+			clientTorrentExplain.Action = "modify"
+		}
+		result.ClientTorrentsExplain = append(result.ClientTorrentsExplain, clientTorrentExplain)
+	}
+
+	result.FreeSpaceChange = freespaceChange
+
+	if cntTorrents <= clientOption.MaxTorrents &&
+		cntDownloadingTorrents < clientOption.MaxDownloadingTorrents &&
+		float64(estimateUploadSpeed) <= float64(targetUploadSpeed)*params.UploadSpeedTargetFactor &&
+		(freespace == -1 || freespace+freespaceChange > clientOption.MinDiskSpace) {
+		result.CanAddMore = true
+	}
+
+	return
+}
@@ -0,0 +1,138 @@
+package strategy
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/sagan/ptool/util"
+)
+
+// Tunable parameters of brush strategy. Set them in "brushStrategyParams" of client or site config,
+// the key is the name in "param" tag, the value format depends on the kind:
+// duration: seconds or duration string, e.g. "900", "15m"; size: e.g. "10KiB"; float: e.g. "0.8"; int: e.g. "3".
+type Params struct {
+	// new torrents timespan during which will NOT be examined at all
+	NewTorrentsTimespan int64 `param:"newTorrentsTimespan,duration"`
+	// new torrents timespan during which will NOT be stalled
+	NewTorrentsStallExemptionTimespan int64 `param:"newTorrentsStallExemptionTimespan,duration"`
+	// torrents that have no download progress will be deleted after this time passed
+	NoProcessTorrentDeletionTimespan int64 `param:"noProcessTorrentDeletionTimespan,duration"`
+	// download speed limit of stalled torrents. Torrents slower than it are not counted as downloading
+	StallDownloadSpeed int64 `param:"stallDownloadSpeed,size"`
+	// do not fetch site new torrents if client (global) upload speed limit is lower than it
+	SlowUploadSpeed int64 `param:"slowUploadSpeed,size"`
+	// torrents uploading slower than it (and not trending up) are marked as slow in every run.
+	// Torrents that are marked slow too many times are deleted
+	SlowTorrentUploadSpeed          int64   `param:"slowTorrentUploadSpeed,size"`
+	RatioCheckMinDownloadSpeed      int64   `param:"ratioCheckMinDownloadSpeed,size"`
+	SlowTorrentsCheckTimespan       int64   `param:"slowTorrentsCheckTimespan,duration"`
+	StallTorrentDeletionTimespan    int64   `param:"stallTorrentDeletionTimespan,duration"`
+	BandwidthFullPercent            float64 `param:"bandwidthFullPercent,float"`
+	ResumeTorrentsFreeDiskSpaceTier int64   `param:"resumeTorrentsFreeDiskSpaceTier,size"`
+	DeleteTorrentsFreeDiskSpaceTier int64   `param:"deleteTorrentsFreeDiskSpaceTier,size"`
+	DiscountEndTimespan             int64   `param:"discountEndTimespan,duration"`
+	UploadSpeedTargetFactor         float64 `param:"uploadSpeedTargetFactor,float"`
+	// window of torrent speed history. The average speeds of it are used in slow torrents & deletion decisions
+	HistoryWindow int64 `param:"historyWindow,duration"`
+	// min samples count in history window required to use history speeds instead of current speeds
+	HistoryMinSamples int64 `param:"historyMinSamples,int"`
+}
+
+func DefaultParams() *Params {
+	return &Params{
+		NewTorrentsTimespan:               NEW_TORRENTS_TIMESPAN,
+		NewTorrentsStallExemptionTimespan: NEW_TORRENTS_STALL_EXEMPTION_TIMESPAN,
+		NoProcessTorrentDeletionTimespan:  NO_PROCESS_TORRENT_DELETEION_TIMESPAN,
+		StallDownloadSpeed:                STALL_DOWNLOAD_SPEED,
+		SlowUploadSpeed:                   SLOW_UPLOAD_SPEED,
+		SlowTorrentUploadSpeed:            SLOW_TORRENT_UPLOAD_SPEED,
+		RatioCheckMinDownloadSpeed:        RATIO_CHECK_MIN_DOWNLOAD_SPEED,
+		SlowTorrentsCheckTimespan:         SLOW_TORRENTS_CHECK_TIMESPAN,
+		StallTorrentDeletionTimespan:      STALL_TORRENT_DELETEION_TIMESPAN,
+		BandwidthFullPercent:              BANDWIDTH_FULL_PERCENT,
+		ResumeTorrentsFreeDiskSpaceTier:   RESUME_TORRENTS_FREE_DISK_SPACE_TIER,
+		DeleteTorrentsFreeDiskSpaceTier:   DELETE_TORRENTS_FREE_DISK_SPACE_TIER,
+		DiscountEndTimespan:               DISCOUNT_END_TIMESPAN,
+		UploadSpeedTargetFactor:           UPLOAD_SPEED_TARGET_FACTOR,
+		HistoryWindow:                     HISTORY_WINDOW,
+		HistoryMinSamples:                 HISTORY_MIN_SAMPLES,
+	}
+}
+
+// Set params. Param names are case-insensitive (viper lowercases config map keys).
+func (params *Params) SetAll(values map[string]string) error {
+	for name, value := range values {
+		if err := params.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set a param by it's name.
+func (params *Params) Set(name string, value string) error {
+	v := reflect.ValueOf(params).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		paramName, kind, _ := strings.Cut(t.Field(i).Tag.Get("param"), ",")
+		if !strings.EqualFold(paramName, name) {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch kind {
+		case "duration":
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				if seconds, err = util.ParseTimeDuration(value); err != nil {
+					return fmt.Errorf("invalid %s duration value %q: %w", paramName, value, err)
+				}
+			}
+			v.Field(i).SetInt(seconds)
+		case "size":
+			size, err := util.RAMInBytes(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s size value %q: %w", paramName, value, err)
+			}
+			v.Field(i).SetInt(size)
+		case "float":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s value %q: %w", paramName, value, err)
+			}
+			v.Field(i).SetFloat(f)
+		case "int":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s value %q: %w", paramName, value, err)
+			}
+			v.Field(i).SetInt(n)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown param %q", name)
+}
+
+// Return params in the format of brushStrategyParams config, in fields order.
+func (params *Params) Strings() [][2]string {
+	v := reflect.ValueOf(params).Elem()
+	t := v.Type()
+	strs := [][2]string{}
+	for i := 0; i < t.NumField(); i++ {
+		paramName, kind, _ := strings.Cut(t.Field(i).Tag.Get("param"), ",")
+		var str string
+		switch kind {
+		case "duration":
+			str = util.GetDurationString(v.Field(i).Int())
+		case "size":
+			str = util.BytesSize(float64(v.Field(i).Int()))
+		case "float":
+			str = strconv.FormatFloat(v.Field(i).Float(), 'f', -1, 64)
+		case "int":
+			str = strconv.FormatInt(v.Field(i).Int(), 10)
+		}
+		strs = append(strs, [2]string{paramName, str})
+	}
+	return strs
+}
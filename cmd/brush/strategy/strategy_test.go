@@ -0,0 +1,176 @@
+package strategy
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd/brush/brush_store"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+)
+
+func TestGetStrategy(t *testing.T) {
+	s, err := GetStrategy(nil, nil)
+	if err != nil || s.Name() != DEFAULT_STRATEGY || *s.Params() != *DefaultParams() {
+		t.Fatalf("unexpected default strategy: %v, %v", s, err)
+	}
+
+	clientConfig := &config.ClientConfigStruct{
+		BrushStrategy: "aggressive",
+		// viper lowercases config map keys
+		BrushStrategyParams: map[string]string{"slowtorrentschecktimespan": "300", "stalldownloadspeed": "1KiB"},
+	}
+	siteConfig := &config.SiteConfigStruct{
+		BrushStrategyParams: map[string]string{"slowTorrentsCheckTimespan": "1h", "bandwidthFullPercent": "0.5"},
+	}
+	s, err = GetStrategy(clientConfig, siteConfig)
+	if err != nil || s.Name() != "aggressive" {
+		t.Fatalf("unexpected strategy: %v, %v", s, err)
+	}
+	params := s.Params()
+	if params.SlowTorrentsCheckTimespan != 3600 || params.StallDownloadSpeed != 1024 ||
+		params.BandwidthFullPercent != 0.5 || params.UploadSpeedTargetFactor != 3 ||
+		params.NewTorrentsTimespan != 600 || params.DiscountEndTimespan != DISCOUNT_END_TIMESPAN {
+		t.Errorf("unexpected params: %+v", params)
+	}
+
+	siteConfig.BrushStrategy = "conservative"
+	if s, err = GetStrategy(clientConfig, siteConfig); err != nil || s.Name() != "conservative" {
+		t.Errorf("site brushStrategy should take precedence: %v, %v", s, err)
+	}
+	if _, err = Create("nonexistent"); err == nil {
+		t.Errorf("expect error for unknown strategy")
+	}
+	if _, err = Create("", map[string]string{"foo": "1"}); err == nil {
+		t.Errorf("expect error for unknown param")
+	}
+	if _, err = Create("", map[string]string{"stallDownloadSpeed": "fast"}); err == nil {
+		t.Errorf("expect error for invalid param value")
+	}
+}
+
+func TestRateSiteTorrent(t *testing.T) {
+	siteOption := &BrushSiteOptionStruct{
+		AllowNoneFree:           true,
+		TorrentMaxSizeLimit:     1 << 50,
+		TorrentUploadSpeedLimit: 10 << 20,
+		Now:                     1000000,
+	}
+	free := &site.Torrent{Name: "free", Size: 1 << 30, Seeders: 2, Leechers: 10, UploadMultiplier: 1}
+	nonFree := &site.Torrent{Name: "nonfree", Size: 1 << 30, Seeders: 2, Leechers: 10, UploadMultiplier: 1,
+		DownloadMultiplier: 1}
+	fewLeechers := &site.Torrent{Name: "few", Size: 1 << 30, Seeders: 5, Leechers: 3, UploadMultiplier: 1}
+
+	defaultStrategy, _ := Create(DEFAULT_STRATEGY)
+	conservative, _ := Create("conservative")
+	aggressive, _ := Create("aggressive")
+	for _, s := range []Strategy{defaultStrategy, conservative, aggressive} {
+		if score, _, _ := s.RateSiteTorrent(free, siteOption); score <= 0 {
+			t.Errorf("%s: expect positive score of free torrent", s.Name())
+		}
+	}
+	if score, _, _ := defaultStrategy.RateSiteTorrent(nonFree, siteOption); score <= 0 {
+		t.Errorf("default: expect positive score of non-free torrent when AllowNoneFree")
+	}
+	if score, _, note := conservative.RateSiteTorrent(nonFree, siteOption); score != 0 || note != "not free" {
+		t.Errorf("conservative: expect non-free torrent excluded, got %f, %s", score, note)
+	}
+	if score, _, _ := conservative.RateSiteTorrent(fewLeechers, siteOption); score != 0 {
+		t.Errorf("conservative: expect torrent of leechers <= seeders excluded")
+	}
+	scoreFree, _, _ := aggressive.RateSiteTorrent(free, siteOption)
+	scoreNonFree, speed, _ := aggressive.RateSiteTorrent(nonFree, siteOption)
+	if scoreNonFree <= 0 || scoreNonFree >= scoreFree || speed != 10*100*1024 {
+		t.Errorf("aggressive: unexpected non-free score %f (free %f), speed %d", scoreNonFree, scoreFree, speed)
+	}
+}
+
+func TestTorrentSpeeds(t *testing.T) {
+	params := DefaultParams()
+	torrent := &client.Torrent{InfoHash: "a", UploadSpeed: 0, DownloadSpeed: 0}
+	clientOption := &BrushClientOptionStruct{}
+	if up, _, _ := torrentSpeeds(torrent, clientOption, params); up != 0 {
+		t.Errorf("expect current speed without history, got %d", up)
+	}
+	if !isTorrentSlow(torrent, 0, 100, clientOption, params) {
+		t.Errorf("expect slow torrent without history")
+	}
+
+	clientOption.TorrentsHistory = map[string]*brush_store.TorrentHistory{
+		"a": {Samples: 3, Duration: 1200, AverageUploadSpeed: 500, AverageDownloadSpeed: 50, PeakUploadSpeed: 800,
+			UploadSpeedTrend: 100},
+	}
+	if up, down, history := torrentSpeeds(torrent, clientOption, params); up != 500 || down != 50 || history == nil {
+		t.Errorf("expect history average speeds, got %d / %d", up, down)
+	}
+	// rising torrent which once reached the tier is not slow
+	if isTorrentSlow(torrent, 500, 600, clientOption, params) {
+		t.Errorf("expect rising torrent not slow")
+	}
+	clientOption.TorrentsHistory["a"].UploadSpeedTrend = -100
+	if !isTorrentSlow(torrent, 500, 600, clientOption, params) {
+		t.Errorf("expect falling torrent slow")
+	}
+	params.HistoryMinSamples = 5
+	if up, _, _ := torrentSpeeds(torrent, clientOption, params); up != 0 {
+		t.Errorf("expect current speed with insufficient samples, got %d", up)
+	}
+}
+
+func TestDecideExplain(t *testing.T) {
+	config.ConfigDir = t.TempDir()
+	dbManager := brush_store.NewBrushStoreDBManager()
+	defer dbManager.Close()
+	now := int64(1000000)
+	clientStatus := &client.Status{FreeSpaceOnDisk: 1 << 40, UploadSpeedLimit: 10 << 20}
+	clientTorrents := []*client.Torrent{
+		{InfoHash: "new", Name: "new", State: "downloading", Atime: now - 60, Size: 1 << 30},
+		{InfoHash: "noprogress", Name: "noprogress", State: "downloading", Atime: now - 7200, Size: 1 << 30},
+	}
+	siteTorrents := []*site.Torrent{
+		{Id: "s.1", Name: "hnr", Size: 1 << 30, Seeders: 2, Leechers: 10, UploadMultiplier: 1, HasHnR: true},
+		{Id: "s.2", Name: "good", Size: 1 << 30, Seeders: 2, Leechers: 10, UploadMultiplier: 1},
+	}
+	siteOption := &BrushSiteOptionStruct{
+		TorrentMaxSizeLimit:     1 << 50,
+		TorrentUploadSpeedLimit: 10 << 20,
+		Now:                     now,
+		AllowAddTorrents:        10,
+	}
+	clientOption := &BrushClientOptionStruct{
+		MinDiskSpace:           5 << 30,
+		SlowUploadSpeedTier:    100 << 10,
+		MaxDownloadingTorrents: 6,
+		MaxTorrents:            100,
+		MaxSlowTorrentCount:    10,
+		Explain:                true,
+		TorrentRecordManager:   brush_store.NewTorrentRecordManager(dbManager.GetDB()),
+	}
+	s, _ := Create(DEFAULT_STRATEGY)
+	result := s.Decide(clientStatus, clientTorrents, siteTorrents, siteOption, clientOption)
+
+	if len(result.SiteTorrentsExplain) != 2 {
+		t.Fatalf("expect 2 site torrents explains, got %d", len(result.SiteTorrentsExplain))
+	}
+	if e := result.SiteTorrentsExplain[0]; e.Action != "reject" || e.Note != "has HnR" {
+		t.Errorf("unexpected hnr torrent explain: %+v", e)
+	}
+	if e := result.SiteTorrentsExplain[1]; e.Action != "add" || e.Score <= 0 || e.Note == "" {
+		t.Errorf("unexpected good torrent explain: %+v", e)
+	}
+	if len(result.ClientTorrentsExplain) != 2 {
+		t.Fatalf("expect 2 client torrents explains, got %d", len(result.ClientTorrentsExplain))
+	}
+	if e := result.ClientTorrentsExplain[0]; e.Action != "keep" ||
+		!strings.Contains(e.Rules[len(e.Rules)-1], "not examined") {
+		t.Errorf("unexpected new torrent explain: %+v", e)
+	}
+	if e := result.ClientTorrentsExplain[1]; e.Action != "delete" ||
+		!slices.ContainsFunc(e.Rules, func(rule string) bool {
+			return strings.Contains(rule, "noProcessTorrentDeletionTimespan 30m")
+		}) {
+		t.Errorf("unexpected no progress torrent explain: %+v", e)
+	}
+}
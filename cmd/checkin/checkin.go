@@ -0,0 +1,191 @@
+package checkin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/natefinch/atomic"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+const (
+	CHECKIN_RECORD_FILE = "checkin.json"
+	CHECKIN_LOCK_FILE   = "checkin.lock"
+)
+
+var command = &cobra.Command{
+	Use:         "checkin [site | group]... [--force]",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "checkin"},
+	Short:       "Daily check in (sign in) sites.",
+	Long: `Daily check in (sign in) sites.
+[site | group]: name of a site or group. Use "_all" to check in all sites. If no args provided, "_all" is assumed.
+
+For nexusphp sites, it requests "attendance.php" page by default.
+For other type sites, "checkInUrl" must be configured in site config, otherwise the site is skipped.
+The check in url & method of a site can be overrided by "checkInUrl" & "checkInMethod" of site config.
+Set "noCheckIn = true" in site config to skip the site.
+
+Sites are checked in concurrently. The last successful check in of each site is recorded in
+"<config_dir>/checkin.json". Sites that have been successfully checked in today are skipped,
+unless --force flag is set.`,
+	Args: cobra.MatchAll(cobra.ArbitraryArgs, cobra.OnlyValidArgs),
+	RunE: checkin,
+}
+
+var (
+	force = false
+)
+
+func init() {
+	command.Flags().BoolVarP(&force, "force", "", false,
+		"Force check in sites even if they have been successfully checked in today")
+	cmd.RootCmd.AddCommand(command)
+}
+
+// Last successful check in of a site.
+type checkInRecord struct {
+	Time   int64  `json:"time"`
+	Reward string `json:"reward,omitempty"`
+}
+
+type checkInResponse struct {
+	name   string
+	result *site.CheckInResult
+	err    error
+}
+
+func checkin(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{"_all"}
+	}
+	sitenames := config.ParseGroupAndOtherNames(args...)
+	if len(sitenames) == 0 {
+		return fmt.Errorf("no sites provided")
+	}
+	lock, err := config.LockConfigDirFile(CHECKIN_LOCK_FILE)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	recordFile := filepath.Join(config.ConfigDir, CHECKIN_RECORD_FILE)
+	records, err := readRecords(recordFile)
+	if err != nil {
+		log.Warnf("Failed to read check in records, ignore it: %v", err)
+		records = map[string]*checkInRecord{}
+	}
+
+	errorCnt := int64(0)
+	now := time.Now()
+	ch := make(chan *checkInResponse, len(sitenames))
+	cnt := 0
+	for _, sitename := range sitenames {
+		siteConfig := config.GetSiteConfig(sitename)
+		if siteConfig == nil {
+			log.Errorf("Error: %s is not a site", sitename)
+			errorCnt++
+			continue
+		}
+		if siteConfig.NoCheckIn {
+			log.Debugf("Skip site %s: noCheckIn is set", sitename)
+			continue
+		}
+		if record := records[sitename]; !force && record != nil && isSameDay(record.Time, now) {
+			fmt.Printf("%-15s  skipped (already checked in today at %s)\n", sitename, util.FormatTime(record.Time))
+			continue
+		}
+		siteInstance, err := site.CreateSite(sitename)
+		if err != nil {
+			log.Errorf("Error: failed to create site %s: %v", sitename, err)
+			errorCnt++
+			continue
+		}
+		go func() {
+			result, err := siteInstance.CheckIn()
+			ch <- &checkInResponse{name: sitename, result: result, err: err}
+		}()
+		cnt++
+	}
+
+	responses := []*checkInResponse{}
+	for i := 0; i < cnt; i++ {
+		responses = append(responses, <-ch)
+	}
+	slices.SortStableFunc(responses, func(a, b *checkInResponse) int {
+		return slices.Index(sitenames, a.name) - slices.Index(sitenames, b.name)
+	})
+	successCnt := 0
+	for _, response := range responses {
+		if response.err != nil {
+			if errors.Is(response.err, site.ErrUnimplemented) {
+				fmt.Printf("%-15s  skipped (check in is not supported or checkInUrl is not configured)\n",
+					response.name)
+				continue
+			}
+			fmt.Printf("%-15s  failed: %v\n", response.name, response.err)
+			errorCnt++
+			continue
+		}
+		successCnt++
+		records[response.name] = &checkInRecord{Time: now.Unix(), Reward: response.result.Reward}
+		if response.result.AlreadyCheckedIn {
+			fmt.Printf("%-15s  already checked in: %s\n", response.name, response.result.Message)
+		} else if response.result.Reward != "" {
+			fmt.Printf("%-15s  success, reward: %s\n", response.name, response.result.Reward)
+		} else {
+			fmt.Printf("%-15s  success: %s\n", response.name, response.result.Message)
+		}
+	}
+	if successCnt > 0 {
+		if err := writeRecords(recordFile, records); err != nil {
+			log.Errorf("Failed to write check in records: %v", err)
+			errorCnt++
+		}
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
+
+func readRecords(file string) (map[string]*checkInRecord, error) {
+	records := map[string]*checkInRecord{}
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return records, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(contents, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func writeRecords(file string, records map[string]*checkInRecord) error {
+	contents, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomic.WriteFile(file, bytes.NewReader(contents))
+}
+
+// Return true if ts and now are in the same local day.
+func isSameDay(ts int64, now time.Time) bool {
+	t := time.Unix(ts, 0).In(now.Location())
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := now.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
@@ -30,7 +30,7 @@ func init() {
 func checktag(cmd *cobra.Command, args []string) error {
 	clientName := args[0]
 	tag := args[1]
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -53,6 +53,12 @@ var (
 		{"tr_*", 0, false, false, "The transmission specific preferences. " +
 			"For full list see https://github.com/transmission/transmission/blob/3.00/extras/rpc-spec.txt#L482 . " +
 			"Convert argument name to snake_case. E.g. tr_config_dir"},
+		{"de_*", 0, false, false, "The deluge specific preferences (core config). " +
+			"For full list see https://github.com/deluge-torrent/deluge/blob/develop/deluge/core/preferencesmanager.py . " +
+			"E.g. de_max_active_seeding"},
+		{"rt_*", 0, false, false, "The rtorrent specific settings (any command that has a \".set\" counterpart). " +
+			"For full list see https://rtorrent-docs.readthedocs.io/en/latest/cmd-ref.html . " +
+			"E.g. rt_throttle.max_uploads.global"},
 	}
 	showRaw        = false
 	showValuesOnly = false
@@ -89,7 +95,7 @@ func clientctl(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("--raw and --show-values-only flags are NOT compatible")
 	}
 	clientName := args[0]
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return err
 	}
@@ -110,7 +116,9 @@ func clientctl(cmd *cobra.Command, args []string) error {
 		value := ""
 		var err error
 		if (clientInstance.GetClientConfig().Type == "qbittorrent" && strings.HasPrefix(variable, "qb_") ||
-			clientInstance.GetClientConfig().Type == "transmission" && strings.HasPrefix(variable, "tr_")) &&
+			clientInstance.GetClientConfig().Type == "transmission" && strings.HasPrefix(variable, "tr_") ||
+			clientInstance.GetClientConfig().Type == "deluge" && strings.HasPrefix(variable, "de_") ||
+			clientInstance.GetClientConfig().Type == "rtorrent" && strings.HasPrefix(variable, "rt_")) &&
 			len(variable) > 3 {
 			if len(s) == 1 {
 				value, err = clientInstance.GetConfig(name)
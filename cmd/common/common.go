@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"net"
 	"path"
 	"slices"
 	"strings"
@@ -179,3 +180,77 @@ func ExportClientTorrent(clientInstance client.Client, torrent *client.Torrent,
 	}
 	return contents, tinfo, nil
 }
+
+// A peer of a torrent in client.
+type ClientTorrentPeer struct {
+	InfoHash string
+	*client.TorrentPeer
+}
+
+type PeerFilter struct {
+	Clients []string // peer client keywords, match if peer client contains any one (case-insensitive)
+	Ips     []string // ip or CIDR list
+	Active  bool     // only match peers with non-zero download or upload speed
+}
+
+func NewPeerFilter(peerClient string, peerIp string, active bool) (*PeerFilter, error) {
+	filter := &PeerFilter{
+		Clients: util.SplitCsv(peerClient),
+		Ips:     util.SplitCsv(peerIp),
+		Active:  active,
+	}
+	for _, ip := range filter.Ips {
+		if _, _, err := net.ParseCIDR(ip); err != nil && net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("invalid ip or CIDR %q", ip)
+		}
+	}
+	return filter, nil
+}
+
+func (filter *PeerFilter) Match(peer *client.TorrentPeer) bool {
+	if len(filter.Clients) > 0 && !peer.MatchClient(filter.Clients) {
+		return false
+	}
+	if filter.Active && peer.DownloadSpeed == 0 && peer.UploadSpeed == 0 {
+		return false
+	}
+	if len(filter.Ips) > 0 {
+		ip := net.ParseIP(peer.Ip)
+		if ip == nil {
+			return false
+		}
+		return slices.ContainsFunc(filter.Ips, func(filterIp string) bool {
+			if _, ipnet, err := net.ParseCIDR(filterIp); err == nil {
+				return ipnet.Contains(ip)
+			}
+			return net.ParseIP(filterIp).Equal(ip)
+		})
+	}
+	return true
+}
+
+// Get peers of torrents of client that match filter. infoHashes: nil means all torrents.
+func GetClientTorrentsPeers(clientInstance client.Client, infoHashes []string,
+	filter *PeerFilter) ([]*ClientTorrentPeer, error) {
+	if infoHashes == nil {
+		torrents, err := clientInstance.GetTorrents("", "", true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get client torrents: %w", err)
+		}
+		infoHashes = util.Map(torrents, func(t *client.Torrent) string { return t.InfoHash })
+	}
+	peers := []*ClientTorrentPeer{}
+	for _, infoHash := range infoHashes {
+		torrentPeers, err := clientInstance.GetTorrentPeers(infoHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get torrent %s peers: %w", infoHash, err)
+		}
+		for _, peer := range torrentPeers {
+			if filter != nil && !filter.Match(peer) {
+				continue
+			}
+			peers = append(peers, &ClientTorrentPeer{InfoHash: infoHash, TorrentPeer: peer})
+		}
+	}
+	return peers, nil
+}
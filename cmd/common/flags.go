@@ -72,6 +72,7 @@ var pureFlags = []string{
 	"append",
 	"backup",
 	"bindable",
+	"body",
 	"break",
 	"check",
 	"check-quick",
@@ -83,6 +84,7 @@ var pureFlags = []string{
 	"delete-fail",
 	"dense",
 	"dry-run",
+	"explain",
 	"force",
 	"force-local",
 	"fork",
@@ -94,6 +96,7 @@ var pureFlags = []string{
 	"largest",
 	"latest",
 	"lock-or-exit",
+	"mark-read",
 	"newest",
 	"no-clean",
 	"no-hr",
@@ -114,6 +117,7 @@ var pureFlags = []string{
 	"original-order",
 	"save-append",
 	"sequential-download",
+	"show-events",
 	"show-files",
 	"show-id-only",
 	"show-info-hash-only",
@@ -114,7 +114,7 @@ func configcmd(cmd *cobra.Command, args []string) error {
 			continue
 		}
 		emptyFlag = false
-		if clientInstance, err := client.CreateClient(clientConfig.Name); err != nil {
+		if clientInstance, err := client.CreateClient(cmd.Context(), clientConfig.Name); err != nil {
 			note = fmt.Sprintf("<error>: %v", err)
 		} else {
 			note = clientInstance.GetClientConfig().Url
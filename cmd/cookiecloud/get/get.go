@@ -141,7 +141,7 @@ func get(cmd *cobra.Command, args []string) error {
 				checkFlag = 1
 				if siteInstance, err := site.CreateSiteInternal(siteOrDomainOrUrl, siteConfig, config.Get()); err != nil {
 					log.Debugf("Failed to create site %s with cookie from %s", siteOrDomainOrUrl, cookiecloudData.Label)
-				} else if sitestatus, err := siteInstance.GetStatus(); err != nil {
+				} else if sitestatus, err := siteInstance.GetStatus(false); err != nil {
 					log.Debugf("Failed to get site %s status with cookie from %s", siteOrDomainOrUrl, cookiecloudData.Label)
 				} else if !sitestatus.IsOk() {
 					log.Debugf("Cookie of site %s from cookiecloud %s is invalid", siteOrDomainOrUrl, cookiecloudData.Label)
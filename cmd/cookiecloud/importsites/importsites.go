@@ -118,7 +118,7 @@ func importsites(cmd *cobra.Command, args []string) error {
 						tplname, cookiecloudData.Label, err)
 					continue
 				}
-				sitestatus, err := siteInstance.GetStatus()
+				sitestatus, err := siteInstance.GetStatus(false)
 				if err != nil {
 					log.Debugf("New Site %s from cookiecloud %s is invalid (status error=%v)",
 						tplname, cookiecloudData.Label, err)
@@ -127,7 +127,7 @@ func sync(cmd *cobra.Command, args []string) error {
 				return
 			}
 			log.Tracef("Checking site %s", sitename)
-			sitestatus, err := siteInstance.GetStatus()
+			sitestatus, err := siteInstance.GetStatus(false)
 			if err != nil {
 				if util.AsNetworkError(err) {
 					ch <- &site_test_result{
@@ -207,7 +207,7 @@ func sync(cmd *cobra.Command, args []string) error {
 					sitename, cookiecloudData.Label, err)
 				continue
 			}
-			sitestatus, err := siteInstance.GetStatus()
+			sitestatus, err := siteInstance.GetStatus(false)
 			if err != nil {
 				log.Debugf("Site %s new cookie from cookiecloud %s is invalid (status error=%v)",
 					sitename, cookiecloudData.Label, err)
@@ -26,7 +26,7 @@ func init() {
 func createtags(cmd *cobra.Command, args []string) error {
 	clientName := args[0]
 	tags := args[1:]
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
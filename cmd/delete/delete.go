@@ -64,7 +64,7 @@ func delete(cmd *cobra.Command, args []string) error {
 	}
 	clientName := args[0]
 	infoHashes := args[1:]
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -24,7 +24,7 @@ func init() {
 }
 
 func deletetags(cmd *cobra.Command, args []string) error {
-	clientInstance, err := client.CreateClient(args[0])
+	clientInstance, err := client.CreateClient(cmd.Context(), args[0])
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
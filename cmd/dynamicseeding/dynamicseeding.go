@@ -53,7 +53,7 @@ func init() {
 func dynamicseeding(cmd *cobra.Command, args []string) (err error) {
 	clientName := args[0]
 	sitename := args[1]
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -81,7 +81,7 @@ func export(cmd *cobra.Command, args []string) error {
 	if skipExisting && rename != config.DEFAULT_EXPORT_TORRENT_RENAME {
 		return fmt.Errorf("--skip-existing and --rename flags are NOT compatible")
 	}
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
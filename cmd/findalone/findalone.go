@@ -88,7 +88,7 @@ func findalone(cmd *cobra.Command, args []string) error {
 	savePathes := util.Map(args[1:], func(p string) string {
 		return path.Clean(util.ToSlash(p))
 	})
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
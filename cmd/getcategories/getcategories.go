@@ -31,7 +31,7 @@ func init() {
 
 func getcategories(cmd *cobra.Command, args []string) error {
 	clientName := args[0]
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -123,7 +123,7 @@ func xseed(cmd *cobra.Command, args []string) error {
 	cntSucccessXseedTorrents := int64(0)
 
 	for _, clientName := range clientNames {
-		clientInstance, err := client.CreateClient(clientName)
+		clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 		if err != nil {
 			return fmt.Errorf("failed to create client: %w", err)
 		}
@@ -0,0 +1,121 @@
+package login
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+)
+
+var command = &cobra.Command{
+	Use:         "login {site | group}... [--force]",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "login"},
+	Short:       "Login sites using username & password and save the new cookies to config file.",
+	Long: `Login sites using username & password and save the new cookies to config file.
+{site | group}: name of a site or group. Use "_all" for all sites.
+
+The "username" & "password" (and optional 2FA "totpSecret") must be configured in site config.
+Currently "nexusphp", "unit3d" and "gazelle" type sites are supported. Sites that require image captcha
+in login form are not supported.
+
+By default, the current cookie of site is tested first, and the site is logined only if it's invalid.
+Use --force to always login.
+
+Note: the whole config file is re-written when saving the new cookies, all existing comments will be LOST.
+Sites are also automatically re-logined (and the new cookies are saved) when their cookies are found expired
+by "status" and other commands, if username & password are configured.`,
+	Args: cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE: login,
+}
+
+var (
+	force = false
+)
+
+func init() {
+	command.Flags().BoolVarP(&force, "force", "", false, "Force login even if the current cookie of site is valid")
+	cmd.RootCmd.AddCommand(command)
+}
+
+type loginResult struct {
+	name    string
+	skipped bool
+	err     error
+}
+
+func login(cmd *cobra.Command, args []string) error {
+	sitenames := config.ParseGroupAndOtherNames(args...)
+	if len(sitenames) == 0 {
+		return fmt.Errorf("no sites provided")
+	}
+	errorCnt := int64(0)
+	ch := make(chan *loginResult, len(sitenames))
+	cnt := 0
+	for _, sitename := range sitenames {
+		siteConfig := config.GetSiteConfig(sitename)
+		if siteConfig == nil {
+			log.Errorf("Error: %s is not a site", sitename)
+			errorCnt++
+			continue
+		}
+		if !site.CanLogin(siteConfig) {
+			log.Debugf("Skip site %s: username or password is not configured", sitename)
+			continue
+		}
+		siteInstance, err := site.CreateSite(sitename)
+		if err != nil {
+			log.Errorf("Error: failed to create site %s: %v", sitename, err)
+			errorCnt++
+			continue
+		}
+		go func() {
+			ch <- loginSite(siteInstance)
+		}()
+		cnt++
+	}
+
+	results := []*loginResult{}
+	for i := 0; i < cnt; i++ {
+		results = append(results, <-ch)
+	}
+	slices.SortStableFunc(results, func(a, b *loginResult) int {
+		return slices.Index(sitenames, a.name) - slices.Index(sitenames, b.name)
+	})
+	for _, result := range results {
+		if result.err != nil {
+			if errors.Is(result.err, site.ErrUnimplemented) {
+				fmt.Printf("%-15s  skipped (login is not supported)\n", result.name)
+				continue
+			}
+			fmt.Printf("%-15s  failed: %v\n", result.name, result.err)
+			errorCnt++
+		} else if result.skipped {
+			fmt.Printf("%-15s  skipped (current cookie is valid)\n", result.name)
+		} else {
+			fmt.Printf("%-15s  success, new cookie saved\n", result.name)
+		}
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
+
+func loginSite(siteInstance site.Site) *loginResult {
+	result := &loginResult{name: siteInstance.GetName()}
+	if cookie := siteInstance.GetSiteConfig().Cookie; !force && cookie != "" {
+		if status, err := siteInstance.GetStatus(false); err == nil && status.IsOk() {
+			// GetStatus may have re-logined site automatically
+			result.skipped = siteInstance.GetSiteConfig().Cookie == cookie
+			return result
+		}
+	}
+	result.err = site.Relogin(siteInstance)
+	return result
+}
@@ -0,0 +1,182 @@
+package messages
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+var command = &cobra.Command{
+	Use:         "messages [site | group]... [--all] [--body] [--mark-read] [--json]",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "messages"},
+	Short:       "Show unread messages (PMs / notices) of sites.",
+	Long: `Show unread messages (PMs / notices) of sites.
+[site | group]: name of a site or group. Use "_all" for all sites. If no args provided, "_all" is assumed.
+
+It lists unread messages of site inbox. Only the first page of inbox is checked.
+Currently only "nexusphp" type sites are supported.
+
+By default messages are not marked as read. Use --body to also fetch message bodies (rendered to plain text);
+Note nexusphp sites mark the message as read when it's body is viewed.
+Use --mark-read to explicitly mark all listed messages as read.`,
+	Args: cobra.MatchAll(cobra.ArbitraryArgs, cobra.OnlyValidArgs),
+	RunE: messages,
+}
+
+var (
+	showAll  = false
+	markRead = false
+	showBody = false
+	showJson = false
+)
+
+func init() {
+	command.Flags().BoolVarP(&showAll, "all", "a", false, "Show all messages of the first page of inbox, "+
+		"including read ones (bodies of read messages are not fetched)")
+	command.Flags().BoolVarP(&markRead, "mark-read", "", false, "Mark listed unread messages as read")
+	command.Flags().BoolVarP(&showBody, "body", "", false,
+		"Fetch and show bodies of unread messages. Note nexusphp sites will mark them as read")
+	command.Flags().BoolVarP(&showJson, "json", "", false, "Show output in json format")
+	cmd.RootCmd.AddCommand(command)
+}
+
+type siteMessages struct {
+	Site     string          `json:"site"`
+	Messages []*site.Message `json:"messages"`
+	Error    string          `json:"error,omitempty"`
+	// error of marking messages as read, the messages are still listed
+	MarkReadError string `json:"markReadError,omitempty"`
+	err           error
+	markReadErr   error
+}
+
+func messages(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{"_all"}
+	}
+	sitenames := config.ParseGroupAndOtherNames(args...)
+	if len(sitenames) == 0 {
+		return fmt.Errorf("no sites provided")
+	}
+	errorCnt := int64(0)
+	ch := make(chan *siteMessages, len(sitenames))
+	cnt := 0
+	for _, sitename := range sitenames {
+		siteInstance, err := site.CreateSite(sitename)
+		if err != nil {
+			log.Errorf("Error: failed to create site %s: %v", sitename, err)
+			errorCnt++
+			continue
+		}
+		go func() {
+			ch <- fetchMessages(siteInstance)
+		}()
+		cnt++
+	}
+	results := []*siteMessages{}
+	for i := 0; i < cnt; i++ {
+		result := <-ch
+		if errors.Is(result.err, site.ErrUnimplemented) {
+			log.Debugf("Skip site %s: messages are not supported", result.Site)
+			continue
+		}
+		if result.err != nil {
+			result.Error = result.err.Error()
+			errorCnt++
+		}
+		if result.markReadErr != nil {
+			result.MarkReadError = result.markReadErr.Error()
+			errorCnt++
+		}
+		results = append(results, result)
+	}
+	slices.SortStableFunc(results, func(a, b *siteMessages) int {
+		return slices.Index(sitenames, a.Site) - slices.Index(sitenames, b.Site)
+	})
+
+	if showJson {
+		if err := util.PrintJson(os.Stdout, results); err != nil {
+			return err
+		}
+	} else {
+		total := 0
+		for _, result := range results {
+			if result.err != nil {
+				fmt.Printf("%s: failed to get messages: %v\n\n", result.Site, result.err)
+				continue
+			}
+			for _, message := range result.Messages {
+				total++
+				printMessage(result.Site, message)
+			}
+			if result.markReadErr != nil {
+				fmt.Printf("%s: failed to mark messages as read: %v\n\n", result.Site, result.markReadErr)
+			}
+		}
+		fmt.Printf("// Total %d messages from %d sites\n", total, len(results))
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
+
+func fetchMessages(siteInstance site.Site) *siteMessages {
+	result := &siteMessages{Site: siteInstance.GetName()}
+	messages, err := siteInstance.GetMessages(!showAll)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	unreadIds := []string{}
+	for _, message := range messages {
+		if !message.Unread {
+			continue
+		}
+		unreadIds = append(unreadIds, message.Id)
+		if !showBody {
+			continue
+		}
+		if fullMessage, err := siteInstance.GetMessage(message.Id); err != nil {
+			log.Errorf("%s: failed to get message %s: %v", result.Site, message.Id, err)
+		} else {
+			message.Body = fullMessage.Body
+		}
+	}
+	if markRead && len(unreadIds) > 0 {
+		result.markReadErr = siteInstance.MarkMessagesRead(unreadIds)
+	}
+	result.Messages = messages
+	return result
+}
+
+func printMessage(sitename string, message *site.Message) {
+	status := ""
+	if message.Unread {
+		status = "[unread] "
+	}
+	sender := message.Sender
+	if sender == "" {
+		sender = "-"
+	}
+	timeStr := "-"
+	if message.Time > 0 {
+		timeStr = util.FormatTime(message.Time)
+	}
+	fmt.Printf("%s%s.%s  %s  From: %s\n", status, sitename, message.Id, timeStr, sender)
+	fmt.Printf("Title: %s\n", message.Title)
+	if message.Body != "" {
+		fmt.Printf("  %s\n", strings.ReplaceAll(message.Body, "\n", "\n  "))
+	}
+	fmt.Printf("\n")
+}
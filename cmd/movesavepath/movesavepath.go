@@ -130,7 +130,7 @@ func movesavepath(cmd *cobra.Command, args []string) (err error) {
 		os.Remove(testfiledst)
 	}
 
-	clientInstance, err := client.CreateClient(clientname)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientname)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -180,7 +180,7 @@ func partialdownload(cmd *cobra.Command, args []string) (err error) {
 	clientName := args[0]
 	infoHash := args[1]
 
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
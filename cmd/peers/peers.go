@@ -0,0 +1,99 @@
+package peers
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/cmd/common"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+	"github.com/sagan/ptool/util/helper"
+)
+
+var command = &cobra.Command{
+	Use:         "peers {client} [--category category] [--tag tag] [--filter filter] [infoHash]...",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "peers"},
+	Short:       "Show connected peers of torrents of client.",
+	Long: fmt.Sprintf(`Show connected peers of torrents of client.
+%s.
+
+If both filter flags (--category & --tag & --filter) and args are not set, it will show peers of active torrents.
+If at least one filter flag is set but no arg is provided, the args is assumed to be "_all".
+
+Use --peer-client & --peer-ip & --active-peers flags to filter peers.
+The "Flags" field displays the client specific flags of the peer connection.
+E.g. in qBittorrent: D = downloading from peer, U = uploading to peer, I = incoming connection, E = encrypted.`,
+		constants.HELP_INFOHASH_ARGS),
+	Args: cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE: peers,
+}
+
+var (
+	activePeers = false
+	showJson    = false
+	showRaw     = false
+	category    = ""
+	tag         = ""
+	filter      = ""
+	peerClient  = ""
+	peerIp      = ""
+)
+
+func init() {
+	command.Flags().BoolVarP(&activePeers, "active-peers", "", false,
+		"Only show peers that are downloading from or uploading to")
+	command.Flags().BoolVarP(&showJson, "json", "", false, "Show output in json format")
+	command.Flags().BoolVarP(&showRaw, "raw", "", false, "Show speed in raw format")
+	command.Flags().StringVarP(&filter, "filter", "", "", constants.HELP_ARG_FILTER_TORRENT)
+	command.Flags().StringVarP(&category, "category", "", "", constants.HELP_ARG_CATEGORY)
+	command.Flags().StringVarP(&tag, "tag", "", "", constants.HELP_ARG_TAG)
+	command.Flags().StringVarP(&peerClient, "peer-client", "", "",
+		`Comma-separated list. Only show peers which client contains any one in the list (case-insensitive). `+
+			`E.g. "xunlei,xl0012"`)
+	command.Flags().StringVarP(&peerIp, "peer-ip", "", "",
+		`Comma-separated ip or CIDR list. Only show peers which ip matches any one in the list. E.g. "10.0.0.0/8"`)
+	cmd.RootCmd.AddCommand(command)
+}
+
+func peers(cmd *cobra.Command, args []string) error {
+	clientName := args[0]
+	infoHashes := args[1:]
+	peerFilter, err := common.NewPeerFilter(peerClient, peerIp, activePeers)
+	if err != nil {
+		return err
+	}
+	if category == "" && tag == "" && filter == "" {
+		if len(infoHashes) == 0 {
+			infoHashes = []string{"_active"}
+		} else if _infoHashes, err := helper.ParseInfoHashesFromArgs(infoHashes); err != nil {
+			return err
+		} else {
+			infoHashes = _infoHashes
+		}
+	}
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	infoHashes, err = client.SelectTorrents(clientInstance, category, tag, filter, infoHashes...)
+	if err != nil {
+		return err
+	}
+	torrentsPeers, err := common.GetClientTorrentsPeers(clientInstance, infoHashes, peerFilter)
+	if err != nil {
+		return err
+	}
+	if showJson {
+		return util.PrintJson(os.Stdout, torrentsPeers)
+	}
+	client.PrintTorrentPeersHeader(os.Stdout)
+	for _, peer := range torrentsPeers {
+		client.PrintTorrentPeers(os.Stdout, peer.InfoHash, []*client.TorrentPeer{peer.TorrentPeer}, showRaw)
+	}
+	fmt.Fprintf(os.Stderr, "\n// Total peers: %d\n", len(torrentsPeers))
+	return nil
+}
@@ -175,12 +175,12 @@ func publish(cmd *cobra.Command, args []string) (err error) {
 			httpClient.SetTimeout(time.Second * 30)
 		}
 	}
-	if _, err := siteInstance.GetStatus(); err != nil {
+	if _, err := siteInstance.GetStatus(false); err != nil {
 		return fmt.Errorf("failed to get site status: %w", err)
 	}
 	var clientInstance client.Client
 	if clientname != "" {
-		clientInstance, err = client.CreateClient(clientname)
+		clientInstance, err = client.CreateClient(cmd.Context(), clientname)
 		if err != nil {
 			return fmt.Errorf("failed to create client: %w", err)
 		}
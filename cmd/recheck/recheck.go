@@ -57,7 +57,7 @@ func recheck(cmd *cobra.Command, args []string) error {
 			}
 		}
 	}
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -33,7 +33,7 @@ func renametag(cmd *cobra.Command, args []string) error {
 	if oldTag == "" || newTag == "" {
 		return fmt.Errorf("old-tag and new-tag can NOT be empty")
 	}
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -41,6 +41,7 @@ Ctrl + L	Clear the screen`,
 const simpleHelp = `Type "<command> -h" to see full help of any command
 Note client data will be cached in shell, run "purge [client]..." to purge cache
 Use "exit" or Ctrl + D (in new line) to exit shell
+Press Ctrl + C to cancel the running command (the shell will not exit)
 To disable suggestions panel, add "shellMaxSuggestions = 0" line to the top of ptool.toml config file`
 
 func init() {
@@ -1,6 +1,7 @@
 package suggest
 
 import (
+	"context"
 	"os"
 	"strings"
 	"unicode"
@@ -86,6 +87,16 @@ func ClientArg(prefix string) []prompt.Suggest {
 	return suggestions
 }
 
+func ClientOrGroupArg(prefix string) []prompt.Suggest {
+	suggestions := ClientArg(prefix)
+	for _, group := range config.Get().Groups {
+		if strings.HasPrefix(group.Name, prefix) {
+			suggestions = append(suggestions, prompt.Suggest{Text: group.Name, Description: "<group>"})
+		}
+	}
+	return suggestions
+}
+
 func SiteArg(prefix string) []prompt.Suggest {
 	suggestions := []prompt.Suggest{}
 	for _, site := range config.Get().SitesEnabled {
@@ -279,7 +290,7 @@ func InfoHashArg(prefix string, clientName string) []prompt.Suggest {
 	if clientName == "" {
 		return nil
 	}
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(context.Background(), clientName)
 	if err != nil {
 		return nil
 	}
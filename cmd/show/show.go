@@ -142,7 +142,7 @@ func show(cmd *cobra.Command, args []string) error {
 	if util.CountNonZeroVariables(savePath, savePathPrefix, contentPath) > 1 {
 		return fmt.Errorf("--save-path, --save-path-prefix and --content-path flags are NOT compatible")
 	}
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -21,6 +21,42 @@ type StatusResponse struct {
 	Error             error
 }
 
+// Json output of a client or site status.
+type jsonStatus struct {
+	Name           string            `json:"name"`
+	Kind           string            `json:"kind"` // client | site
+	ClientStatus   *client.Status    `json:"clientStatus,omitempty"`
+	ClientTorrents []*client.Torrent `json:"clientTorrents,omitempty"`
+	SiteStatus     *site.Status      `json:"siteStatus,omitempty"`
+	SiteTorrents   []*site.Torrent   `json:"siteTorrents,omitempty"`
+	Error          string            `json:"error,omitempty"`
+}
+
+func (response *StatusResponse) toJson() *jsonStatus {
+	status := &jsonStatus{
+		Name:           response.Name,
+		ClientStatus:   response.ClientStatus,
+		ClientTorrents: response.ClientTorrents,
+		SiteStatus:     response.SiteStatus,
+		SiteTorrents:   response.SiteTorrents,
+	}
+	if response.Kind == 1 {
+		status.Kind = "client"
+	} else {
+		status.Kind = "site"
+	}
+	// the site status may be cached and shared, so the computed ratio is filled into a copy
+	if status.SiteStatus != nil && status.SiteStatus.UserRatio == 0 {
+		siteStatus := *status.SiteStatus
+		siteStatus.UserRatio = siteStatus.Ratio()
+		status.SiteStatus = &siteStatus
+	}
+	if response.Error != nil {
+		status.Error = response.Error.Error()
+	}
+	return status
+}
+
 func fetchClientStatus(clientInstance client.Client, showTorrents bool, showAllTorrents bool,
 	category string, ch chan *StatusResponse) {
 	response := &StatusResponse{Name: clientInstance.GetName(), Kind: 1}
@@ -62,7 +98,7 @@ func fetchSiteStatus(siteInstance site.Site, showTorrents bool, full bool, showS
 	// 	ch <- response
 	// 	return
 	// }
-	SiteStatus, err := siteInstance.GetStatus()
+	SiteStatus, err := siteInstance.GetStatus(full)
 	response.SiteStatus = SiteStatus
 	if err != nil {
 		response.Error = fmt.Errorf("cann't get site %s status: error=%w", siteInstance.GetName(), err)
@@ -77,11 +113,15 @@ func fetchSiteStatus(siteInstance site.Site, showTorrents bool, full bool, showS
 		} else {
 			if showScore {
 				brushSiteOption := strategy.GetBrushSiteOptions(siteInstance, util.Now())
-				scores := map[string]float64{}
-				for _, torrent := range siteTorrents {
-					scores[torrent.Id], _, _ = strategy.RateSiteTorrent(torrent, brushSiteOption)
+				if brushStrategy, err := strategy.GetStrategy(nil, siteInstance.GetSiteConfig()); err != nil {
+					response.Error = fmt.Errorf("cann't get site %s brush strategy: %w", siteInstance.GetName(), err)
+				} else {
+					scores := map[string]float64{}
+					for _, torrent := range siteTorrents {
+						scores[torrent.Id], _, _ = brushStrategy.RateSiteTorrent(torrent, brushSiteOption)
+					}
+					response.SiteTorrentScores = scores
 				}
-				response.SiteTorrentScores = scores
 			}
 			response.SiteTorrents = siteTorrents
 		}
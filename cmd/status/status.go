@@ -5,6 +5,7 @@ import (
 	"os"
 	"slices"
 	"sort"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -22,6 +23,7 @@ var (
 	dense          = false
 	showTorrents   = false
 	showFull       = false
+	showJson       = false
 	showAll        = false
 	showAllClients = false
 	showAllSites   = false
@@ -50,6 +52,12 @@ For client, display following status info:
 For site, display following status info:
 - ↑: : Current uploading statistics.
 - ↓: : Current downloading statstics.
+Unread messages and account warnings of site are also displayed if exist.
+If "-f" flag is set, it will also display the full user status of site: user class, share ratio, bonus,
+seeding / leeching count, seeding size, active HnR count, invites, unread messages and warnings
+(some items may be unavailable depending on site type). It may require extra requests to site.
+
+Use "--json" flag to output status in json format, which always includes the full user status of site.
 
 If "-t" flag is set, it will also show the active / latest torrents list of client / site.
 For the list format of client torrents, see help of "ptool show" command.
@@ -68,6 +76,7 @@ func init() {
 		"Show torrents (active torrents for client / latest torrents for site)")
 	command.Flags().BoolVarP(&showFull, "full", "f", false, "Show full info of each client or site")
 	command.Flags().BoolVarP(&showScore, "score", "", false, "Show brush score of site torrents")
+	command.Flags().BoolVarP(&showJson, "json", "", false, "Show output in json format")
 	command.Flags().BoolVarP(&largestFlag, "largest", "l", false, `Sort torrents by size in desc order"`)
 	command.Flags().BoolVarP(&newestFlag, "newest", "n", false, `Sort torrents by time in desc order"`)
 	command.Flags().StringVarP(&filter, "filter", "", "", constants.HELP_ARG_FILTER_TORRENT)
@@ -114,7 +123,7 @@ func status(cmd *cobra.Command, args []string) error {
 		}
 		doneFlag[name] = true
 		if client.ClientExists(name) {
-			clientInstance, err := client.CreateClient(name)
+			clientInstance, err := client.CreateClient(cmd.Context(), name)
 			if err != nil {
 				log.Errorf("Error: failed to create client %s: %v\n", name, err)
 				errorCnt++
@@ -129,7 +138,7 @@ func status(cmd *cobra.Command, args []string) error {
 				errorCnt++
 				continue
 			}
-			go fetchSiteStatus(siteInstance, showTorrents, showFull, showScore, ch)
+			go fetchSiteStatus(siteInstance, showTorrents, showFull || showJson, showScore, ch)
 			cnt++
 		} else {
 			log.Errorf("Error: %s is not a client or site\n", name)
@@ -186,6 +195,23 @@ func status(cmd *cobra.Command, args []string) error {
 		})
 	}
 
+	if showJson {
+		jsonStatuses := []*jsonStatus{}
+		for _, response := range responses {
+			if response.Error != nil {
+				errorCnt++
+			}
+			jsonStatuses = append(jsonStatuses, response.toJson())
+		}
+		if err := util.PrintJson(os.Stdout, jsonStatuses); err != nil {
+			return err
+		}
+		if errorCnt > 0 {
+			return fmt.Errorf("%d errors", errorCnt)
+		}
+		return nil
+	}
+
 	errorsStr := ""
 	for _, response := range responses {
 		if response.Kind == 1 {
@@ -234,7 +260,16 @@ func status(cmd *cobra.Command, args []string) error {
 				if len(response.SiteTorrents) > 0 {
 					additionalInfo += fmt.Sprintf("; Torrents: %d", len(response.SiteTorrents))
 				}
+				if response.SiteStatus.UnreadMessagesCnt > 0 {
+					additionalInfo += fmt.Sprintf("; Msgs: %d", response.SiteStatus.UnreadMessagesCnt)
+				}
+				if len(response.SiteStatus.Warnings) > 0 {
+					additionalInfo += fmt.Sprintf("; Warnings: %s", strings.Join(response.SiteStatus.Warnings, ","))
+				}
 				response.SiteStatus.Print(os.Stdout, response.Name, additionalInfo)
+				if showFull {
+					response.SiteStatus.PrintFull(os.Stdout, "        ")
+				}
 			} else {
 				site.PrintDummyStatus(os.Stdout, response.Name, "<error>")
 			}
@@ -0,0 +1,121 @@
+package torrentinfo
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/site/tpl"
+	"github.com/sagan/ptool/util"
+)
+
+var command = &cobra.Command{
+	Use:         "torrentinfo {torrentId | torrentUrl}... [--site {site}] [--json]",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "torrentinfo"},
+	Short:       "Show details of site torrents.",
+	Long: `Show details of site torrents.
+Args is torrent list that each one could be a site torrent id (e.g. "mteam.488424")
+or url (e.g. "https://kp.m-team.cc/details.php?id=488424").
+
+It fetches the torrent details page of site and displays the full description (text & BBCode),
+file list, MediaInfo, external IDs (IMDb / Douban / TMDB), uploader and upload time of torrent.
+Use --json to output in json format.
+
+Currently only "nexusphp" and "mtorrent" type sites are supported.`,
+	Args: cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE: torrentinfo,
+}
+
+var (
+	defaultSite = ""
+	showJson    = false
+	// details.php?id=123 , /detail/123 , /torrents/123
+	torrentUrlIdRegexp = regexp.MustCompile(`(?:[?&]id=|/details?/|/torrents?/)(?P<id>\d+)`)
+)
+
+func init() {
+	command.Flags().StringVarP(&defaultSite, "site", "", "", "Set default site of torrents")
+	command.Flags().BoolVarP(&showJson, "json", "", false, "Show output in json format")
+	cmd.RootCmd.AddCommand(command)
+}
+
+func torrentinfo(cmd *cobra.Command, args []string) error {
+	errorCnt := int64(0)
+	var detailsList []*site.TorrentDetails
+	for _, torrent := range args {
+		sitename, id, err := parseTorrent(torrent)
+		if err != nil {
+			log.Errorf("%s: %v", torrent, err)
+			errorCnt++
+			continue
+		}
+		siteInstance, err := site.CreateSite(sitename)
+		if err != nil {
+			log.Errorf("%s: failed to create site %s: %v", torrent, sitename, err)
+			errorCnt++
+			continue
+		}
+		details, err := siteInstance.GetTorrentDetails(id)
+		if err != nil {
+			log.Errorf("%s: failed to get torrent details: %v", torrent, err)
+			errorCnt++
+			continue
+		}
+		if showJson {
+			detailsList = append(detailsList, details)
+			continue
+		}
+		details.Print(os.Stdout)
+		fmt.Printf("\n")
+	}
+	if showJson {
+		if len(args) == 1 && len(detailsList) == 1 {
+			if err := util.PrintJson(os.Stdout, detailsList[0]); err != nil {
+				return err
+			}
+		} else if err := util.PrintJson(os.Stdout, detailsList); err != nil {
+			return err
+		}
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
+
+// Parse torrent arg to sitename & id.
+func parseTorrent(torrent string) (sitename string, id string, err error) {
+	sitename = defaultSite
+	if !util.IsUrl(torrent) {
+		if before, after, found := strings.Cut(torrent, "."); found {
+			sitename = before
+			id = after
+		} else {
+			id = torrent
+		}
+	} else {
+		domain := util.GetUrlDomain(torrent)
+		if domain == "" {
+			return "", "", fmt.Errorf("invalid url")
+		}
+		if sitename, err = tpl.GuessSiteByDomain(domain, defaultSite); err != nil {
+			return "", "", fmt.Errorf("no site found: %w", err)
+		}
+		if m := torrentUrlIdRegexp.FindStringSubmatch(torrent); m != nil {
+			id = m[torrentUrlIdRegexp.SubexpIndex("id")]
+		}
+	}
+	if sitename == "" {
+		return "", "", fmt.Errorf("no site specified")
+	}
+	if id == "" {
+		return "", "", fmt.Errorf("no torrent id found")
+	}
+	return sitename, id, nil
+}
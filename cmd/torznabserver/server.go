@@ -0,0 +1,375 @@
+package torznabserver
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+const (
+	DEFAULT_LIMIT     = 100
+	MAX_DOWNLOAD_URLS = 100000
+	OTHER_CATEGORY    = "8000"
+)
+
+// Standard newznab categories. ptool sites do not report torznab categories of torrents,
+// so every result is marked as belonging to all requested categories.
+var categories = [][2]string{
+	{"2000", "Movies"},
+	{"3000", "Audio"},
+	{"5000", "TV"},
+	{"6000", "XXX"},
+	{"7000", "Books"},
+	{OTHER_CATEGORY, "Other"},
+}
+
+type server struct {
+	sites   map[string]site.Site
+	apiKey  string
+	baseUrl string // public base url of server, e.g. "http://127.0.0.1:9118". Empty: use request host
+	// Download urls of torrents in served results, which may contain passkey.
+	// So they are kept here and referred by hash in served download links.
+	downloadUrls      map[string]string
+	downloadUrlsMutex sync.Mutex
+	// Site instances are not safe for concurrent use, so requests to each site are serialized.
+	siteMutexes map[string]*sync.Mutex
+}
+
+func newServer(sites map[string]site.Site, apiKey string, baseUrl string) *server {
+	siteMutexes := map[string]*sync.Mutex{}
+	for name := range sites {
+		siteMutexes[name] = &sync.Mutex{}
+	}
+	return &server{
+		sites:        sites,
+		siteMutexes:  siteMutexes,
+		apiKey:       apiKey,
+		baseUrl:      strings.TrimSuffix(baseUrl, "/"),
+		downloadUrls: map[string]string{},
+	}
+}
+
+// Lock the site of name for exclusive use. Return the unlock func.
+func (s *server) lockSite(name string) func() {
+	mutex := s.siteMutexes[name]
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{site}/api", s.handleApi)
+	mux.HandleFunc("GET /{site}/download", s.handleDownload)
+	return mux
+}
+
+// Handle torznab api requests: /{site}/api?t=caps|search|tvsearch|movie|music|book&q=&cat=&offset=&limit=&apikey=
+func (s *server) handleApi(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !s.checkApiKey(query.Get("apikey")) {
+		writeError(w, 100, "Incorrect user credentials")
+		return
+	}
+	siteInstance := s.sites[r.PathValue("site")]
+	if siteInstance == nil {
+		writeError(w, 201, "Indexer not found")
+		return
+	}
+	switch t := query.Get("t"); t {
+	case "caps":
+		writeXml(w, newCaps(siteInstance.GetName()))
+	case "search", "tvsearch", "tv-search", "movie", "movie-search", "music", "audio", "book":
+		keyword := strings.TrimSpace(query.Get("q"))
+		if t == "tvsearch" || t == "tv-search" {
+			if season := util.ParseInt(query.Get("season")); season > 0 {
+				keyword += fmt.Sprintf(" S%02d", season)
+				if ep := util.ParseInt(query.Get("ep")); ep > 0 {
+					keyword += fmt.Sprintf("E%02d", ep)
+				}
+			}
+		}
+		keyword = strings.TrimSpace(keyword)
+		var torrents []*site.Torrent
+		var err error
+		unlock := s.lockSite(r.PathValue("site"))
+		if keyword == "" {
+			torrents, err = siteInstance.GetLatestTorrents(false)
+		} else {
+			torrents, err = siteInstance.SearchTorrents(keyword, "")
+		}
+		unlock()
+		if err != nil {
+			log.Errorf("torznab-server: site %s failed to search %q: %v", siteInstance.GetName(), keyword, err)
+			writeError(w, 900, fmt.Sprintf("Failed to search site: %v", err))
+			return
+		}
+		offset := max(util.ParseInt(query.Get("offset")), 0)
+		limit := util.ParseInt(query.Get("limit"))
+		if limit <= 0 {
+			limit = DEFAULT_LIMIT
+		}
+		total := int64(len(torrents))
+		torrents = torrents[min(offset, total):min(offset+limit, total)]
+		cats := util.SplitCsv(query.Get("cat"))
+		if len(cats) == 0 {
+			cats = []string{OTHER_CATEGORY}
+		}
+		writeXml(w, s.newRss(r, siteInstance, torrents, cats, offset, total))
+	default:
+		writeError(w, 202, fmt.Sprintf("No such function (%s)", t))
+	}
+}
+
+// Download torrent through site, by saved download url (key=) or site torrent id (id=).
+// Saved download urls are lost when server restarts, in which case the id is used.
+// The id must not be an url, as the site cookie / passkey would be sent to it.
+func (s *server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !s.checkApiKey(query.Get("apikey")) {
+		http.Error(w, "Incorrect user credentials", http.StatusUnauthorized)
+		return
+	}
+	siteInstance := s.sites[r.PathValue("site")]
+	if siteInstance == nil {
+		http.Error(w, "Indexer not found", http.StatusNotFound)
+		return
+	}
+	torrentUrl := ""
+	if key := query.Get("key"); key != "" {
+		s.downloadUrlsMutex.Lock()
+		torrentUrl = s.downloadUrls[key]
+		s.downloadUrlsMutex.Unlock()
+	}
+	if torrentUrl == "" {
+		id := query.Get("id")
+		if util.IsUrl(strings.ToLower(id)) || strings.Contains(id, "/") {
+			http.Error(w, "Invalid torrent id", http.StatusBadRequest)
+			return
+		}
+		torrentUrl = id
+	}
+	if torrentUrl == "" {
+		http.Error(w, "Torrent not found", http.StatusNotFound)
+		return
+	}
+	unlock := s.lockSite(r.PathValue("site"))
+	contents, filename, _, err := siteInstance.DownloadTorrent(torrentUrl)
+	unlock()
+	if err != nil {
+		log.Errorf("torznab-server: site %s failed to download %s: %v", siteInstance.GetName(), torrentUrl, err)
+		http.Error(w, fmt.Sprintf("Failed to download torrent: %v", err), http.StatusBadGateway)
+		return
+	}
+	if filename == "" {
+		filename = "a.torrent"
+	}
+	w.Header().Set("Content-Type", "application/x-bittorrent")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, util.EscapeQuotes(filename)))
+	w.Write(contents)
+}
+
+func (s *server) checkApiKey(apiKey string) bool {
+	return s.apiKey == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(s.apiKey)) == 1
+}
+
+// Return the download link of torrent served by this server.
+func (s *server) downloadLink(r *http.Request, siteInstance site.Site, torrent *site.Torrent) string {
+	baseUrl := s.baseUrl
+	if baseUrl == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		baseUrl = scheme + "://" + r.Host
+	}
+	query := url.Values{}
+	if torrent.Id != "" {
+		query.Set("id", torrent.Id)
+	}
+	if torrent.DownloadUrl != "" {
+		hash := sha1.Sum([]byte(torrent.DownloadUrl))
+		key := hex.EncodeToString(hash[:])
+		s.downloadUrlsMutex.Lock()
+		if len(s.downloadUrls) >= MAX_DOWNLOAD_URLS {
+			s.downloadUrls = map[string]string{}
+		}
+		s.downloadUrls[key] = torrent.DownloadUrl
+		s.downloadUrlsMutex.Unlock()
+		query.Set("key", key)
+	}
+	if s.apiKey != "" {
+		query.Set("apikey", s.apiKey)
+	}
+	return baseUrl + "/" + url.PathEscape(siteInstance.GetName()) + "/download?" + query.Encode()
+}
+
+type caps struct {
+	XMLName xml.Name `xml:"caps"`
+	Server  struct {
+		Title string `xml:"title,attr"`
+	} `xml:"server"`
+	Limits struct {
+		Default int `xml:"default,attr"`
+		Max     int `xml:"max,attr"`
+	} `xml:"limits"`
+	Searching struct {
+		Search      capsSearch `xml:"search"`
+		TvSearch    capsSearch `xml:"tv-search"`
+		MovieSearch capsSearch `xml:"movie-search"`
+		MusicSearch capsSearch `xml:"music-search"`
+		AudioSearch capsSearch `xml:"audio-search"`
+		BookSearch  capsSearch `xml:"book-search"`
+	} `xml:"searching"`
+	Categories []capsCategory `xml:"categories>category"`
+}
+
+type capsSearch struct {
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+type capsCategory struct {
+	Id   string `xml:"id,attr"`
+	Name string `xml:"name,attr"`
+}
+
+func newCaps(sitename string) *caps {
+	c := &caps{}
+	c.Server.Title = "ptool - " + sitename
+	c.Limits.Default = DEFAULT_LIMIT
+	c.Limits.Max = DEFAULT_LIMIT
+	c.Searching.Search = capsSearch{"yes", "q"}
+	c.Searching.TvSearch = capsSearch{"yes", "q,season,ep"}
+	c.Searching.MovieSearch = capsSearch{"yes", "q"}
+	c.Searching.MusicSearch = capsSearch{"yes", "q"}
+	c.Searching.AudioSearch = capsSearch{"yes", "q"}
+	c.Searching.BookSearch = capsSearch{"yes", "q"}
+	for _, category := range categories {
+		c.Categories = append(c.Categories, capsCategory{category[0], category[1]})
+	}
+	return c
+}
+
+type rss struct {
+	XMLName      xml.Name `xml:"rss"`
+	Version      string   `xml:"version,attr"`
+	XmlnsTorznab string   `xml:"xmlns:torznab,attr"`
+	Channel      struct {
+		Title    string `xml:"title"`
+		Response struct {
+			Offset int64 `xml:"offset,attr"`
+			Total  int64 `xml:"total,attr"`
+		} `xml:"torznab:response"`
+		Items []*rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Guid        string   `xml:"guid"`
+	Link        string   `xml:"link"`
+	PubDate     string   `xml:"pubDate"`
+	Size        int64    `xml:"size"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+	Enclosure   struct {
+		Url    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	} `xml:"enclosure"`
+	Attrs []*rssAttr `xml:"torznab:attr"`
+}
+
+type rssAttr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func (s *server) newRss(r *http.Request, siteInstance site.Site, torrents []*site.Torrent,
+	cats []string, offset int64, total int64) *rss {
+	feed := &rss{Version: "2.0", XmlnsTorznab: "http://torznab.com/schemas/2015/feed"}
+	feed.Channel.Title = "ptool - " + siteInstance.GetName()
+	feed.Channel.Response.Offset = offset
+	feed.Channel.Response.Total = total
+	for _, torrent := range torrents {
+		link := s.downloadLink(r, siteInstance, torrent)
+		downloadFactor, uploadFactor := torrent.DownloadMultiplier, torrent.UploadMultiplier
+		if torrent.Neutral {
+			downloadFactor, uploadFactor = 0, 0
+		}
+		guid := torrent.Id
+		if guid == "" {
+			guid = torrent.InfoHash
+		}
+		if guid == "" {
+			guid = link
+		}
+		item := &rssItem{
+			Title:       torrent.Name,
+			Guid:        guid,
+			Link:        link,
+			PubDate:     time.Unix(torrent.Time, 0).Format(time.RFC1123Z),
+			Size:        torrent.Size,
+			Description: torrent.Description,
+			Categories:  cats,
+		}
+		item.Enclosure.Url = link
+		item.Enclosure.Length = torrent.Size
+		item.Enclosure.Type = "application/x-bittorrent"
+		attrs := [][2]string{
+			{"seeders", fmt.Sprint(torrent.Seeders)},
+			{"peers", fmt.Sprint(torrent.Seeders + torrent.Leechers)},
+			{"grabs", fmt.Sprint(torrent.Snatched)},
+			{"downloadvolumefactor", strconv.FormatFloat(downloadFactor, 'f', -1, 64)},
+			{"uploadvolumefactor", strconv.FormatFloat(uploadFactor, 'f', -1, 64)},
+		}
+		for _, cat := range cats {
+			attrs = append(attrs, [2]string{"category", cat})
+		}
+		if torrent.InfoHash != "" {
+			attrs = append(attrs, [2]string{"infohash", torrent.InfoHash})
+		}
+		if downloadFactor == 0 {
+			attrs = append(attrs, [2]string{"tag", "freeleech"})
+		}
+		for _, attr := range attrs {
+			item.Attrs = append(item.Attrs, &rssAttr{attr[0], attr[1]})
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return feed
+}
+
+type errorResponse struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        int      `xml:"code,attr"`
+	Description string   `xml:"description,attr"`
+}
+
+func writeError(w http.ResponseWriter, code int, description string) {
+	writeXml(w, &errorResponse{Code: code, Description: description})
+}
+
+func writeXml(w http.ResponseWriter, v any) {
+	contents, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(contents)
+}
@@ -0,0 +1,117 @@
+package torznabserver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/site/torznab"
+)
+
+type fakeSite struct {
+	site.Site
+	torrents   []*site.Torrent
+	downloaded string
+}
+
+func (f *fakeSite) GetName() string {
+	return "fake"
+}
+
+func (f *fakeSite) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
+	return f.torrents, nil
+}
+
+func (f *fakeSite) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
+	return f.torrents, nil
+}
+
+func (f *fakeSite) DownloadTorrent(torrentUrl string) ([]byte, string, string, error) {
+	f.downloaded = torrentUrl
+	return []byte("d4:infoe"), "fake.1.torrent", "1", nil
+}
+
+func TestServer(t *testing.T) {
+	fake := &fakeSite{torrents: []*site.Torrent{{
+		Name:               "Movie",
+		Id:                 "fake.1",
+		DownloadUrl:        "https://fake.example.com/download.php?id=1&passkey=secret",
+		DownloadMultiplier: 0,
+		UploadMultiplier:   2,
+		Time:               1704164645,
+		Size:               1024,
+		Seeders:            5,
+		Leechers:           3,
+		Snatched:           10,
+	}, {
+		Name:               "Neutral",
+		Id:                 "fake.2",
+		DownloadMultiplier: 1,
+		UploadMultiplier:   1,
+		Neutral:            true,
+	}}}
+	srv := httptest.NewServer(newServer(map[string]site.Site{"fake": fake}, "key", "").handler())
+	defer srv.Close()
+
+	// use the torznab site type as client.
+	client, err := torznab.NewSite("idx", &config.SiteConfigStruct{Url: srv.URL + "/fake/api", ApiToken: "key"},
+		&config.ConfigStruct{})
+	if err != nil {
+		t.Fatalf("NewSite: %v", err)
+	}
+	status, err := client.GetStatus(false)
+	if err != nil || status.UserName != "ptool - fake" {
+		t.Errorf("unexpected caps: %+v, %v", status, err)
+	}
+	torrents, err := client.SearchTorrents("movie", "")
+	if err != nil {
+		t.Fatalf("SearchTorrents: %v", err)
+	}
+	if len(torrents) != 2 {
+		t.Fatalf("expect 2 torrents, got %d", len(torrents))
+	}
+	if tr := torrents[0]; tr.Name != "Movie" || tr.Seeders != 5 || tr.Leechers != 3 || tr.Snatched != 10 ||
+		tr.Size != 1024 || tr.DownloadMultiplier != 0 || tr.UploadMultiplier != 2 || tr.Time != 1704164645 {
+		t.Errorf("unexpected torrent: %+v", tr)
+	}
+	if strings.Contains(torrents[0].DownloadUrl, "secret") || !strings.HasPrefix(torrents[0].DownloadUrl, srv.URL) {
+		t.Errorf("download url should be proxied: %s", torrents[0].DownloadUrl)
+	}
+	if tr := torrents[1]; tr.DownloadMultiplier != 0 || tr.UploadMultiplier != 0 {
+		t.Errorf("neutral torrent should have 0 volume factors: %+v", tr)
+	}
+
+	res, err := http.Get(torrents[0].DownloadUrl)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	contents, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(contents) != "d4:infoe" || fake.downloaded != fake.torrents[0].DownloadUrl {
+		t.Errorf("unexpected download: %q, %s", contents, fake.downloaded)
+	}
+	res, _ = http.Get(srv.URL + "/fake/download?id=fake.2&apikey=key")
+	res.Body.Close()
+	if fake.downloaded != "fake.2" {
+		t.Errorf("should download by id: %s", fake.downloaded)
+	}
+
+	res, _ = http.Get(srv.URL + "/fake/download?id=" + url.QueryEscape("https://evil.example.com/") + "&apikey=key")
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest || fake.downloaded != "fake.2" {
+		t.Errorf("url as id should be rejected: status=%d, downloaded %s", res.StatusCode, fake.downloaded)
+	}
+	res, _ = http.Get(srv.URL + "/fake/download?id=fake.1&apikey=wrong")
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expect 401, got %d", res.StatusCode)
+	}
+	if _, err = client.SearchTorrents("movie", srv.URL+"/none/api?q=%s"); err == nil {
+		t.Errorf("expect error for unknown indexer")
+	}
+}
@@ -0,0 +1,26 @@
+package torznabserver
+
+import (
+	"github.com/c-bata/go-prompt"
+
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/cmd/shell/suggest"
+)
+
+func init() {
+	cmd.AddShellCompletion("torznab-server", func(document *prompt.Document) []prompt.Suggest {
+		info := suggest.Parse(document)
+		if info.LastArgIndex < 1 {
+			return nil
+		}
+		if info.LastArgIsFlag {
+			switch info.LastArgFlag {
+			case "sites":
+				return suggest.SiteOrGroupArg(info.MatchingPrefix)
+			default:
+				return nil
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,98 @@
+package torznabserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+var command = &cobra.Command{
+	Use:         "torznab-server [--sites {siteOrGroups}] [--addr {addr}] [--api-key {key}]",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "torznab-server"},
+	Short:       "Serve a Torznab API of sites for Sonarr / Radarr / Prowlarr.",
+	Long: `Serve a Torznab API of sites for Sonarr / Radarr / Prowlarr.
+It runs a http server that exposes each site as a Torznab indexer, backed by ptool's site search.
+
+Each site is served at it's own indexer path. E.g. for site "mteam" and default addr:
+  Torznab url: http://127.0.0.1:9118/mteam/api
+  (In Sonarr / Radarr, add a "Torznab" indexer with above url and the --api-key as API Key)
+
+Supported Torznab functions: caps, search, tvsearch, movie, music, book (only "q" param, and "season" & "ep"
+params of tvsearch). A search without keyword returns latest torrents of site.
+
+Torrent download links in the results point to this server, which downloads torrents through ptool,
+so the site cookies & passkeys never leave ptool. Free / neutral torrents are mapped to Torznab
+"downloadvolumefactor" & "uploadvolumefactor" attributes.
+
+If --api-key is not set, the server does not require any authentication, use it only in trusted network.
+Press Ctrl + C to stop the server.`,
+	Args: cobra.MatchAll(cobra.ExactArgs(0), cobra.OnlyValidArgs),
+	RunE: torznabServer,
+}
+
+var (
+	addr    = ""
+	apiKey  = ""
+	baseUrl = ""
+	sites   = ""
+)
+
+func init() {
+	command.Flags().StringVarP(&addr, "addr", "", "127.0.0.1:9118", "Listening address of http server")
+	command.Flags().StringVarP(&apiKey, "api-key", "", "", "Torznab API Key required by the server")
+	command.Flags().StringVarP(&baseUrl, "base-url", "", "",
+		`Public base url of the server used in torrent download links, e.g. "http://192.168.1.2:9118". `+
+			`Default: use the host of request`)
+	command.Flags().StringVarP(&sites, "sites", "", "_all",
+		"Comma-separated list of sites or groups to serve. Use \"_all\" to serve all sites")
+	cmd.RootCmd.AddCommand(command)
+}
+
+func torznabServer(cmd *cobra.Command, args []string) error {
+	sitenames := config.ParseGroupAndOtherNames(util.SplitCsv(sites)...)
+	if len(sitenames) == 0 {
+		return fmt.Errorf("no sites to serve")
+	}
+	siteInstances := map[string]site.Site{}
+	for _, sitename := range sitenames {
+		siteInstance, err := site.CreateSite(sitename)
+		if err != nil {
+			return fmt.Errorf("failed to create site %s: %w", sitename, err)
+		}
+		siteInstances[sitename] = siteInstance
+	}
+	if apiKey == "" {
+		log.Warnf("No --api-key is set, the torznab server does not require authentication")
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           newServer(siteInstances, apiKey, baseUrl).handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	fmt.Fprintf(os.Stderr, "Torznab server listening on %s, serving %d sites. Torznab url: http://%s/{site}/api\n",
+		addr, len(siteInstances), addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -80,11 +80,11 @@ func transfertorrent(cmd *cobra.Command, args []string) (err error) {
 			return fmt.Errorf("invalid map-save-path(s): %w", err)
 		}
 	}
-	srcClientInstance, err := client.CreateClient(srcClient)
+	srcClientInstance, err := client.CreateClient(cmd.Context(), srcClient)
 	if err != nil {
 		return fmt.Errorf("failed to create src client: %w", err)
 	}
-	dstClientInstance, err := client.CreateClient(dstClient)
+	dstClientInstance, err := client.CreateClient(cmd.Context(), dstClient)
 	if err != nil {
 		return fmt.Errorf("failed to create dst client: %w", err)
 	}
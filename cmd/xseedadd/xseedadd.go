@@ -79,7 +79,7 @@ func xseedadd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
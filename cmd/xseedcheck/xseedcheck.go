@@ -43,7 +43,7 @@ func xseedcheck(cmd *cobra.Command, args []string) error {
 	clientName := args[0]
 	infoHash := args[1]
 	torrent := args[2]
-	clientInstance, err := client.CreateClient(clientName)
+	clientInstance, err := client.CreateClient(cmd.Context(), clientName)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
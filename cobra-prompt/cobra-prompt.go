@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/csv"
 	"os"
+	"os/signal"
 	"strings"
 
 	"github.com/c-bata/go-prompt"
@@ -72,12 +73,20 @@ func (co CobraPrompt) RunContext(ctx context.Context) {
 	}
 
 	co.prepare()
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
 	p := prompt.New(
 		func(in string) {
 			promptArgs := co.parseArgs(in)
 			os.Args = append([]string{os.Args[0]}, promptArgs...)
-			if err := co.RootCmd.ExecuteContext(ctx); err != nil {
+			// mod by ptool
+			// Interrupt (Ctrl-C) cancels the context of the running command only, instead of exiting the prompt.
+			cmdCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+			err := co.RootCmd.ExecuteContext(cmdCtx)
+			stop()
+			if err != nil {
 				if co.OnErrorFunc != nil {
 					co.OnErrorFunc(err)
 				} else {
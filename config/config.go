@@ -116,9 +116,33 @@ type ClientConfigStruct struct {
 	BrushMinDiskSpaceValue            int64
 	BrushSlowUploadSpeedTierValue     int64
 	BrushDefaultUploadSpeedLimitValue int64
-	QbittorrentNoLogin                bool  `yaml:"qbittorrentNoLogin"`  // if set, will NOT send login request
-	QbittorrentNoLogout               bool  `yaml:"qbittorrentNoLogout"` // if set, will NOT send logout request
-	MaxSlowTorrentCount               int64 `yaml:"maxSlowTorrentCount"`
+	QbittorrentNoLogin                bool   `yaml:"qbittorrentNoLogin"`        // if set, will NOT send login request
+	QbittorrentNoLogout               bool   `yaml:"qbittorrentNoLogout"`       // if set, will NOT send logout request
+	DelugeStateDir                    string `yaml:"delugeStateDir"`            // local deluge "state" dir, used to export torrents
+	RtorrentSessionDir                string `yaml:"rtorrentSessionDir"`        // local rtorrent "session" dir, used to export torrents
+	TransmissionTorrentsDir           string `yaml:"transmissionTorrentsDir"`   // local transmission "torrents" dir
+	TransmissionBlocklistFile         string `yaml:"transmissionBlocklistFile"` // blocklist file used to ban peers
+	MockSnapshot                      string `yaml:"mockSnapshot"`              // mock: initial torrents ("show --json" output)
+	MockDownloadSpeed                 string `yaml:"mockDownloadSpeed"`         // mock: simulated max download speed (/s)
+	MockUploadSpeed                   string `yaml:"mockUploadSpeed"`           // mock: simulated max upload speed (/s)
+	MockDiskSpace                     string `yaml:"mockDiskSpace"`             // mock: simulated disk size
+	MaxSlowTorrentCount               int64  `yaml:"maxSlowTorrentCount"`
+	// 刷流策略: default | conservative | aggressive。站点配置的 brushStrategy 优先
+	BrushStrategy string `yaml:"brushStrategy"`
+	// 刷流策略参数，例如 {slowTorrentsCheckTimespan = "10m"}。见 cmd/brush/strategy/params.go
+	BrushStrategyParams map[string]string `yaml:"brushStrategyParams"`
+	// 允许使用该客户端刷流的站点或分组列表。为空时不限制。多客户端刷流时据此分配站点种子
+	BrushSites []string `yaml:"brushSites"`
+	// http options of accessing BT client. Proxy: "" or "env" - use HTTP(S)_PROXY envs; "none" - no proxy.
+	Proxy             string     `yaml:"proxy"`
+	HttpHeaders       [][]string `yaml:"httpHeaders"`       // extra http request headers, e.g. [["X-Token", "abc"]]
+	BasicAuthUsername string     `yaml:"basicAuthUsername"` // http basic auth, e.g. of a reverse proxy
+	BasicAuthPassword string     `yaml:"basicAuthPassword"`
+	TlsCaCert         string     `yaml:"tlsCaCert"`     // CA certificate (PEM) file to verify server certificate
+	TlsClientCert     string     `yaml:"tlsClientCert"` // client certificate (PEM) file
+	TlsClientKey      string     `yaml:"tlsClientKey"`  // client certificate private key (PEM) file
+	Insecure          bool       `yaml:"insecure"`      // skip TLS certificate verification
+	Timeout           int64      `yaml:"timeout"`       // http request timeout (seconds). 0: default; -1: no timeout
 }
 
 type SiteConfigStruct struct {
@@ -187,7 +211,38 @@ type SiteConfigStruct struct {
 	SelectorUserInfoUserName       string     `yaml:"selectorUserInfoUserName"`
 	SelectorUserInfoUploaded       string     `yaml:"selectorUserInfoUploaded"`
 	SelectorUserInfoDownloaded     string     `yaml:"selectorUserInfoDownloaded"`
-	ImageUploadUrl                 string     `yaml:"imageUploadUrl"`
+	// 使用该站点刷流时的策略及策略参数。覆盖客户端配置
+	BrushStrategy       string            `yaml:"brushStrategy"`
+	BrushStrategyParams map[string]string `yaml:"brushStrategyParams"`
+	// jsonapi 站点类型使用。torrentsUrl / searchUrl / userInfoUrl 及请求 body 均为 jinja 模板，
+	// 可用变量: keyword, page (从 1 开始), sort (ptool 排序字段名), desc (bool), order ("asc" | "desc")
+	UserInfoUrl         string `yaml:"userInfoUrl"`
+	JsonApiMethod       string `yaml:"jsonApiMethod"` // GET (默认) | POST
+	JsonApiTorrentsBody string `yaml:"jsonApiTorrentsBody"`
+	JsonApiSearchBody   string `yaml:"jsonApiSearchBody"` // 默认使用 jsonApiTorrentsBody
+	JsonApiUserInfoBody string `yaml:"jsonApiUserInfoBody"`
+	// jinja 模板，以响应 json 对象为 context。渲染结果非空时视为请求失败，结果为错误信息
+	JsonApiError        string `yaml:"jsonApiError"`
+	JsonApiTorrentsList string `yaml:"jsonApiTorrentsList"` // 响应中种子数组的路径。例如 "data.list"
+	JsonApiTotalPages   string `yaml:"jsonApiTotalPages"`   // 响应中总页数的路径
+	// site.Torrent / site.Status 字段名 => 值的路径(JSONPath 风格，例如 "status.seeders")或 jinja 模板。
+	// 路径相对于种子数组里的单个种子对象或用户信息响应对象
+	JsonApiTorrentFields map[string]string `yaml:"jsonApiTorrentFields"`
+	JsonApiStatusFields  map[string]string `yaml:"jsonApiStatusFields"`
+	// torznab 站点类型使用。搜索的分类 id 列表(csv)，例如 "2000,5000"。默认不限制
+	TorznabCategories string `yaml:"torznabCategories"`
+	// 每日签到。nexusphp 站点默认使用 "attendance.php"。其它类型站点需要手动配置 checkInUrl 才能签到
+	CheckInUrl    string `yaml:"checkInUrl"`
+	CheckInMethod string `yaml:"checkInMethod"` // GET (默认) | POST
+	CheckInBody   string `yaml:"checkInBody"`   // POST 请求 body (application/x-www-form-urlencoded)
+	NoCheckIn     bool   `yaml:"noCheckIn"`     // true: checkin 命令跳过该站点
+	// 用户名/密码登录。支持 nexusphp, unit3d, gazelle 类型站点。用于 login 命令; 设置 autoLogin 后 cookie 失效时会自动登录
+	Username       string `yaml:"username"`
+	Password       string `yaml:"password"`
+	TotpSecret     string `yaml:"totpSecret"` // 两步验证(2FA) TOTP 密钥(base32)。未开启两步验证的账号不需要配置
+	LoginUrl       string `yaml:"loginUrl"`   // 登录页面 url。默认: nexusphp "login.php"; unit3d "login"; gazelle "login.php"
+	AutoLogin      bool   `yaml:"autoLogin"`  // cookie 失效时自动登录并将新 cookie 写回配置文件(会重写整个配置文件，注释会丢失)
+	ImageUploadUrl string `yaml:"imageUploadUrl"`
 	// Additional post payload when uploading image, query string format.
 	// E.g. "foo=a&bar=b".
 	ImageUploadPayload   string     `yaml:"imageUploadPayload"`
@@ -215,6 +270,7 @@ type SiteConfigStruct struct {
 	TorrentDownloadUrl               string `yaml:"torrentDownloadUrl"` // use {id} placeholders in url
 	TorrentDownloadUrlPrefix         string `yaml:"torrentDownloadUrlPrefix"`
 	Passkey                          string `yaml:"passkey"`
+	ApiToken                         string `yaml:"apiToken"`  // UNIT3D / Gazelle / Torznab 等站点 API 使用的 token
 	UseCuhash                        bool   `yaml:"useCuhash"` // hdcity 使用机制。种子下载地址里必须有cuhash参数
 	// ttg 使用机制。种子下载地址末段必须有4位数字校验码或Passkey参数(即使有 Cookie)
 	UseDigitHash                      bool   `yaml:"useDigitHash"`
@@ -260,6 +316,8 @@ type ConfigStruct struct {
 	// 公网 BT 种子的分享率(Up/Dl)限制(到达后停止做种)。"add" 等命令添加公网种子到BT客户端时会自动应用此限制。
 	// 0 : unlimited。仅 qBittorrent 支持此选项。
 	PublicTorrentRatioLimit float64 `yaml:"publicTorrentRatioLimit"`
+	// 公网 BT tracker 列表。"add" 等命令通过 info-hash 添加种子时会使用这些 trackers 生成磁力链接。
+	PublicTrackers []string `yaml:"publicTrackers"`
 
 	ClientsEnabled []*ClientConfigStruct
 	SitesEnabled   []*SiteConfigStruct
@@ -4,10 +4,12 @@ import (
 	_ "github.com/sagan/ptool/site/discuz"
 	_ "github.com/sagan/ptool/site/gazelle"
 	_ "github.com/sagan/ptool/site/gazellepw"
+	_ "github.com/sagan/ptool/site/jsonapi"
 	_ "github.com/sagan/ptool/site/mtorrent"
 	_ "github.com/sagan/ptool/site/nexusphp"
 	_ "github.com/sagan/ptool/site/tnode"
 	_ "github.com/sagan/ptool/site/torrenttrader"
+	_ "github.com/sagan/ptool/site/torznab"
 	_ "github.com/sagan/ptool/site/tpl"
 	_ "github.com/sagan/ptool/site/unit3d"
 )
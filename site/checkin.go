@@ -0,0 +1,131 @@
+package site
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Noooste/azuretls-client"
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/util"
+)
+
+type CheckInResult struct {
+	AlreadyCheckedIn bool   // true if user has already checked in today before this request
+	Reward           string // rewards gained, e.g. "获得 10 个魔力值". Could be empty if unknown
+	Message          string // (short) plain text message of site response
+}
+
+const CHECKIN_MESSAGE_MAX_LENGTH = 200
+
+var (
+	checkInAlreadyRegexp = regexp.MustCompile(`(?i)(已经签到|已經簽到|已签到|已簽到|重复签到|重複簽到|签到过了|簽到過了|` +
+		`already (signed|checked|attended)|already (done|claimed) today)`)
+	checkInSuccessRegexp = regexp.MustCompile(`(?i)(签到成功|簽到成功|check[- ]?in success|successfully (signed|checked))`)
+	checkInRewardRegexp  = regexp.MustCompile(`(?i)(获得|獲得|奖励|獎勵|得到|got|earned|received)\s*[：:]?\s*` +
+		`[\d,.]*\d\s*(个|個|点|點)?\s*(魔力值|魔力|积分|積分|bonus( points)?|karma( points)?|points?|[^\s，,。.!！]{0,8})`)
+)
+
+// Check in (sign in) site using the CheckInUrl of site config.
+// Return ErrUnimplemented if CheckInUrl is not configured.
+// It's used by site types that do not have a native check-in implementation.
+func CheckInByConfig(siteInstance Site, httpClient *azuretls.Session) (*CheckInResult, error) {
+	siteConfig := siteInstance.GetSiteConfig()
+	if siteConfig.CheckInUrl == "" {
+		return nil, ErrUnimplemented
+	}
+	return CheckInByUrl(siteInstance, httpClient, siteConfig.CheckInUrl, siteConfig.CheckInMethod,
+		siteConfig.CheckInBody)
+}
+
+// Check in (sign in) site by requesting checkInUrl, and parse result from the response page.
+// method: GET (default) or POST; body: POST request body (application/x-www-form-urlencoded).
+func CheckInByUrl(siteInstance Site, httpClient *azuretls.Session, checkInUrl string,
+	method string, body string) (*CheckInResult, error) {
+	siteConfig := siteInstance.GetSiteConfig()
+	checkInUrl = siteConfig.ParseSiteUrl(checkInUrl, false)
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	headers := [][]string{}
+	if method == http.MethodPost {
+		headers = append(headers, []string{"Content-Type", "application/x-www-form-urlencoded"})
+	}
+	headers = append(headers, siteInstance.GetDefaultHttpHeaders()...)
+	req := &azuretls.Request{
+		Method:         method,
+		Url:            checkInUrl,
+		NoCookie:       true, // disable azuretls internal cookie jar
+		OrderedHeaders: util.GetHttpReqHeaders(headers, siteConfig.Cookie, GetUa(siteInstance)),
+	}
+	if method == http.MethodPost {
+		req.Body = []byte(body)
+	}
+	util.LogAzureHttpRequest(req)
+	res, err := httpClient.Do(req)
+	util.LogAzureHttpResponse(res, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch url: %w", err)
+	}
+	if res.StatusCode != 200 && !siteConfig.AcceptAnyHttpStatus {
+		return nil, fmt.Errorf("failed to fetch url: status=%d", res.StatusCode)
+	}
+	if res.Request != nil && strings.Contains(res.Request.Url, "login") {
+		return nil, ErrNotLoggedIn
+	}
+	return ParseCheckInResponse(res.Body)
+}
+
+// Parse check-in response (html page or json), detect whether it's success or already checked in.
+func ParseCheckInResponse(body []byte) (*CheckInResult, error) {
+	text := ""
+	var data any
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') &&
+		json.Unmarshal(trimmed, &data) == nil {
+		text = strings.Join(jsonStrings(data), " ")
+	} else if doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body)); err == nil {
+		doc.Find("script,style,head").Remove()
+		text = doc.Text()
+	} else {
+		text = string(body)
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	result := &CheckInResult{}
+	message := ""
+	// check success first, as NexusPHP site header shows "[已签到]" after check in.
+	// The reward text alone is not considered as success, as it may be from other parts of page.
+	if m := checkInSuccessRegexp.FindStringIndex(text); m != nil {
+		message = text[m[0]:]
+		result.Reward = checkInRewardRegexp.FindString(message)
+	} else if m := checkInAlreadyRegexp.FindStringIndex(text); m != nil {
+		result.AlreadyCheckedIn = true
+		message = text[m[0]:]
+	} else {
+		return nil, fmt.Errorf("unrecognized check-in response: %s", util.StringPrefixInBytes(text,
+			CHECKIN_MESSAGE_MAX_LENGTH))
+	}
+	result.Message = util.StringPrefixInBytes(message, CHECKIN_MESSAGE_MAX_LENGTH)
+	return result, nil
+}
+
+// Return all string values in a json value.
+func jsonStrings(data any) (strs []string) {
+	switch v := data.(type) {
+	case string:
+		strs = append(strs, v)
+	case []any:
+		for _, item := range v {
+			strs = append(strs, jsonStrings(item)...)
+		}
+	case map[string]any:
+		for _, item := range v {
+			strs = append(strs, jsonStrings(item)...)
+		}
+	}
+	return
+}
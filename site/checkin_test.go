@@ -0,0 +1,29 @@
+package site
+
+import "testing"
+
+func TestParseCheckInResponse(t *testing.T) {
+	result, err := ParseCheckInResponse([]byte(`<html><body><div id="info_block">[已签到]</div>
+<h2>签到成功</h2><p>这是您的第 <b>5</b> 次签到，已连续签到 <b>3</b> 天，本次签到获得 <b>15</b> 个魔力值。</p>
+</body></html>`))
+	if err != nil || result.AlreadyCheckedIn || result.Reward != "获得 15 个魔力值" {
+		t.Errorf("unexpected result: %+v, %v", result, err)
+	}
+
+	result, err = ParseCheckInResponse([]byte(`<html><body><p>您今天已经签到过了，请勿重复刷新。</p></body></html>`))
+	if err != nil || !result.AlreadyCheckedIn || result.Reward != "" {
+		t.Errorf("unexpected result: %+v, %v", result, err)
+	}
+
+	result, err = ParseCheckInResponse([]byte(`{"code":0,"msg":"签到成功，获得10积分"}`))
+	if err != nil || result.AlreadyCheckedIn || result.Reward != "获得10积分" {
+		t.Errorf("unexpected result: %+v, %v", result, err)
+	}
+
+	if _, err = ParseCheckInResponse([]byte(`<html><body>404 Not Found</body></html>`)); err == nil {
+		t.Errorf("expect error for unrecognized response")
+	}
+	if _, err = ParseCheckInResponse([]byte(`<html><body><p>签到失败</p><p>昨日获得 10 个魔力值</p></body></html>`)); err == nil {
+		t.Errorf("expect error for response without success message")
+	}
+}
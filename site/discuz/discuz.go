@@ -50,7 +50,31 @@ func (dzsite *Site) GetSiteConfig() *config.SiteConfigStruct {
 	return dzsite.SiteConfig
 }
 
-func (dzsite *Site) GetStatus() (*site.Status, error) {
+func (dzsite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (dzsite *Site) CheckIn() (*site.CheckInResult, error) {
+	return site.CheckInByConfig(dzsite, dzsite.HttpClient)
+}
+
+func (dzsite *Site) GetMessages(unreadOnly bool) ([]*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (dzsite *Site) GetMessage(id string) (*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (dzsite *Site) MarkMessagesRead(ids []string) error {
+	return site.ErrUnimplemented
+}
+
+func (dzsite *Site) Login() (cookie string, err error) {
+	return "", site.ErrUnimplemented
+}
+
+func (dzsite *Site) GetStatus(full bool) (*site.Status, error) {
 	doc, _, err := util.GetUrlDocWithAzuretls(dzsite.SiteConfig.Url+"forum.php?mod=torrents", dzsite.HttpClient,
 		dzsite.GetSiteConfig().Cookie, site.GetUa(dzsite), dzsite.GetDefaultHttpHeaders())
 	if err != nil {
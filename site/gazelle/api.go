@@ -0,0 +1,351 @@
+package gazelle
+
+// Gazelle JSON API ( https://github.com/WhatCD/Gazelle/wiki/JSON-API-Documentation ).
+// Also used by GazellePW, which is a fork of Gazelle.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Noooste/azuretls-client"
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+const (
+	AJAX_BROWSE_URL   = "ajax.php?action=browse"
+	UPLOAD_URL        = "upload.php"
+	DEFAULT_LOGIN_URL = "login.php"
+)
+
+var SortFields = map[string]string{
+	"time":     "time",
+	"size":     "size",
+	"seeders":  "seeders",
+	"leechers": "leechers",
+	"snatched": "snatched",
+}
+
+type AjaxResponse struct {
+	Status   string          `json:"status"` // "success" | "failure"
+	Error    string          `json:"error"`
+	Response json.RawMessage `json:"response"`
+}
+
+// A torrent of browse results.
+type BrowseTorrent struct {
+	TorrentId           int64  `json:"torrentId"`
+	Media               string `json:"media"`
+	Format              string `json:"format"`
+	Encoding            string `json:"encoding"`
+	RemasterTitle       string `json:"remasterTitle"`
+	Codec               string `json:"codec"` // GazellePW
+	Source              string `json:"source"`
+	Resolution          string `json:"resolution"`
+	Container           string `json:"container"`
+	Processing          string `json:"processing"`
+	Time                string `json:"time"`
+	Size                int64  `json:"size"`
+	Snatches            int64  `json:"snatches"`
+	Seeders             int64  `json:"seeders"`
+	Leechers            int64  `json:"leechers"`
+	IsFreeleech         bool   `json:"isFreeleech"`
+	IsNeutralLeech      bool   `json:"isNeutralLeech"`
+	IsPersonalFreeleech bool   `json:"isPersonalFreeleech"`
+}
+
+// A torrent group of browse results.
+// Music groups have a "torrents" list; groups of other categories are single torrents themselves.
+type BrowseGroup struct {
+	BrowseTorrent
+	GroupId      int64            `json:"groupId"`
+	GroupName    string           `json:"groupName"`
+	GroupSubName string           `json:"groupSubName"` // GazellePW
+	Artist       string           `json:"artist"`
+	GroupYear    any              `json:"groupYear"`
+	ReleaseType  any              `json:"releaseType"`
+	Category     string           `json:"category"`
+	GroupTime    any              `json:"groupTime"`
+	Tags         []string         `json:"tags"`
+	Torrents     []*BrowseTorrent `json:"torrents"`
+}
+
+// ajax.php?action=browse
+type BrowseResponse struct {
+	CurrentPage int64          `json:"currentPage"`
+	Pages       int64          `json:"pages"`
+	Results     []*BrowseGroup `json:"results"`
+}
+
+// Format name & description of a torrent of browse results.
+type TorrentFormatter func(group *BrowseGroup, torrent *BrowseTorrent) (name string, description string)
+
+// Request an ajax.php api and decode the "response" field of result into v.
+// If site apiToken is configured, it's sent as "Authorization" header.
+func Ajax(siteInstance site.Site, httpClient *azuretls.Session, ajaxUrl string, v any) error {
+	headers := siteInstance.GetDefaultHttpHeaders()
+	if apiToken := siteInstance.GetSiteConfig().ApiToken; apiToken != "" {
+		headers = append(util.CopySlice(headers), []string{"Authorization", apiToken})
+	}
+	var data *AjaxResponse
+	err := util.FetchJsonWithAzuretls(siteInstance.GetSiteConfig().ParseSiteUrl(ajaxUrl, false), &data, httpClient,
+		siteInstance.GetSiteConfig().Cookie, site.GetUa(siteInstance), headers)
+	if err != nil {
+		return err
+	}
+	if data == nil || data.Status != "success" {
+		if data != nil && data.Error != "" {
+			return fmt.Errorf("ajax error: %s", data.Error)
+		}
+		return fmt.Errorf("ajax error: invalid response")
+	}
+	return json.Unmarshal(data.Response, v)
+}
+
+// Browse torrents using ajax.php?action=browse api.
+// baseUrl is optional, e.g. "ajax.php?action=browse&filter_cat[1]=1". query overrides queries in baseUrl.
+func Browse(siteInstance site.Site, httpClient *azuretls.Session, baseUrl string, query url.Values) (
+	*BrowseResponse, error) {
+	if baseUrl == "" {
+		baseUrl = AJAX_BROWSE_URL
+	}
+	urlObj, err := url.Parse(baseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	params := urlObj.Query()
+	for key := range query {
+		params.Set(key, query.Get(key))
+	}
+	if !params.Has("action") {
+		params.Set("action", "browse")
+	}
+	urlObj.RawQuery = params.Encode()
+	var data *BrowseResponse
+	if err = Ajax(siteInstance, httpClient, urlObj.String(), &data); err != nil {
+		return nil, fmt.Errorf("failed to browse torrents: %w", err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("failed to browse torrents: empty response")
+	}
+	return data, nil
+}
+
+func GetAllTorrents(siteInstance site.Site, httpClient *azuretls.Session, location *time.Location,
+	formatter TorrentFormatter, sort string, desc bool, pageMarker string, baseUrl string) (
+	torrents []*site.Torrent, nextPageMarker string, err error) {
+	if sort != "" && sort != constants.NONE && SortFields[sort] == "" {
+		return nil, "", fmt.Errorf("unsupported sort field: %s", sort)
+	}
+	query := url.Values{}
+	if sort != "" && sort != constants.NONE {
+		query.Set("order_by", SortFields[sort])
+		if desc {
+			query.Set("order_way", "desc")
+		} else {
+			query.Set("order_way", "asc")
+		}
+	}
+	var pageNumber int64 = 1
+	if pageMarker != "" {
+		pageNumber = util.ParseInt(pageMarker)
+	}
+	// pageNumber starts from 1, NOT 0
+	if pageNumber < 1 {
+		return nil, "", fmt.Errorf("page number must be greater than 0")
+	}
+	query.Set("page", fmt.Sprint(pageNumber))
+	data, err := Browse(siteInstance, httpClient, baseUrl, query)
+	if err != nil {
+		return nil, "", err
+	}
+	torrents = ParseTorrents(siteInstance, data, location, formatter)
+	if data.Pages > pageNumber {
+		nextPageMarker = fmt.Sprint(pageNumber + 1)
+	}
+	return
+}
+
+func SearchTorrents(siteInstance site.Site, httpClient *azuretls.Session, location *time.Location,
+	formatter TorrentFormatter, keyword string, baseUrl string) ([]*site.Torrent, error) {
+	query := url.Values{}
+	if strings.Contains(baseUrl, "%s") {
+		baseUrl = strings.Replace(baseUrl, "%s", url.QueryEscape(keyword), 1)
+	} else {
+		query.Set("searchstr", keyword)
+	}
+	data, err := Browse(siteInstance, httpClient, baseUrl, query)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTorrents(siteInstance, data, location, formatter), nil
+}
+
+// Flatten grouped torrents of browse results into site torrents.
+func ParseTorrents(siteInstance site.Site, data *BrowseResponse, location *time.Location,
+	formatter TorrentFormatter) []*site.Torrent {
+	siteConfig := siteInstance.GetSiteConfig()
+	var torrents []*site.Torrent
+	for _, group := range data.Results {
+		groupTorrents := group.Torrents
+		if len(groupTorrents) == 0 && group.TorrentId > 0 {
+			groupTorrents = []*BrowseTorrent{&group.BrowseTorrent}
+		}
+		groupTime := parseTime(group.GroupTime, location)
+		for _, torrent := range groupTorrents {
+			name, description := formatter(group, torrent)
+			downloadMultiplier := 1.0
+			uploadMultiplier := 1.0
+			if torrent.IsFreeleech || torrent.IsPersonalFreeleech || torrent.IsNeutralLeech {
+				downloadMultiplier = 0
+			}
+			if torrent.IsNeutralLeech {
+				uploadMultiplier = 0
+			}
+			tm := parseTime(torrent.Time, location)
+			if tm == 0 {
+				tm = groupTime
+			}
+			id := fmt.Sprint(torrent.TorrentId)
+			torrents = append(torrents, &site.Torrent{
+				Name:               name,
+				Description:        description,
+				Id:                 siteInstance.GetName() + "." + id,
+				DownloadUrl:        siteConfig.ParseSiteUrl("torrents.php?action=download&id="+id, false),
+				DownloadMultiplier: downloadMultiplier,
+				UploadMultiplier:   uploadMultiplier,
+				DiscountEndTime:    -1,
+				Time:               tm,
+				Size:               torrent.Size,
+				IsSizeAccurate:     true,
+				Seeders:            torrent.Seeders,
+				Leechers:           torrent.Leechers,
+				Snatched:           torrent.Snatches,
+				HasHnR:             siteConfig.GlobalHnR,
+				Neutral:            torrent.IsNeutralLeech,
+				Tags:               group.Tags,
+			})
+		}
+	}
+	return torrents
+}
+
+// Format a json value of string or number type.
+func FormatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+func JoinNonEmpty(sep string, values ...string) string {
+	return strings.Join(util.Filter(values, func(s string) bool { return s != "" }), sep)
+}
+
+// Gazelle uses both unix timestamp and "2009-06-06 19:04:22" format times.
+func parseTime(value any, location *time.Location) int64 {
+	switch v := value.(type) {
+	case float64:
+		return int64(v)
+	case string:
+		if v == "" {
+			return 0
+		}
+		if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return ts
+		}
+		ts, _ := util.ParseTime(v, location)
+		return ts
+	}
+	return 0
+}
+
+// Return the authkey of current user, which is required by most non-ajax POST forms.
+func GetAuthkey(siteInstance site.Site, httpClient *azuretls.Session) (string, error) {
+	var data *struct {
+		Authkey string `json:"authkey"`
+	}
+	if err := Ajax(siteInstance, httpClient, "ajax.php?action=index", &data); err != nil {
+		return "", fmt.Errorf("failed to get authkey: %w", err)
+	}
+	if data == nil || data.Authkey == "" {
+		return "", fmt.Errorf("failed to get authkey: empty")
+	}
+	return data.Authkey, nil
+}
+
+// Upload torrent to upload.php. The "_authkey" metadata is set for payload template.
+// On success, the site redirects to the torrent group page "torrents.php?id=<groupId>",
+// in which case the largest torrent id of the group (the newly uploaded one) is returned.
+func PublishTorrent(siteInstance site.Site, httpClient *azuretls.Session, contents []byte, metadata url.Values,
+	fallbackPayloadTemplate map[string]string) (id string, err error) {
+	authkey, err := GetAuthkey(siteInstance, httpClient)
+	if err != nil {
+		return "", err
+	}
+	metadata = util.CopyMap(metadata, true)
+	metadata.Set("_authkey", authkey)
+	uploadUrl := siteInstance.GetSiteConfig().ParseSiteUrl(UPLOAD_URL, false)
+	res, err := site.UploadTorrent(siteInstance, httpClient, uploadUrl, contents, metadata, fallbackPayloadTemplate)
+	if res == nil {
+		if err == constants.ErrDryRun {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to upload torrent: %w", err)
+	}
+	urlObj, err := url.Parse(res.Request.Url)
+	if err != nil {
+		return "", fmt.Errorf("response: invalid request url: %w", err)
+	}
+	if strings.Contains(urlObj.Path, UPLOAD_URL) {
+		return "", fmt.Errorf("failed to upload torrent: %s", parseUploadError(res.Body))
+	}
+	if id = urlObj.Query().Get("torrentid"); id != "" {
+		return id, nil
+	}
+	groupId := urlObj.Query().Get("id")
+	if !strings.Contains(urlObj.Path, "torrents.php") || groupId == "" {
+		return "", fmt.Errorf("got no id from uploaded page, url=%s", res.Request.Url)
+	}
+	var group *struct {
+		Torrents []struct {
+			Id int64 `json:"id"`
+		} `json:"torrents"`
+	}
+	if err = Ajax(siteInstance, httpClient, "ajax.php?action=torrentgroup&id="+groupId, &group); err != nil {
+		return "", fmt.Errorf("failed to get uploaded torrent group %s: %w", groupId, err)
+	}
+	var maxId int64
+	for _, torrent := range group.Torrents {
+		maxId = max(maxId, torrent.Id)
+	}
+	if maxId == 0 {
+		return "", fmt.Errorf("got no torrent from uploaded torrent group %s", groupId)
+	}
+	return fmt.Sprint(maxId), nil
+}
+
+// On failure, upload.php displays the upload form again with the error message.
+func parseUploadError(body []byte) string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return "unknown error"
+	}
+	if msg := util.DomSanitizedText(doc.Find(`#content p[style*="color: red"], .alertbar`).First()); msg != "" {
+		return msg
+	}
+	return "unknown error"
+}
@@ -0,0 +1,40 @@
+package gazelle
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sagan/ptool/config"
+)
+
+func TestParseTorrents(t *testing.T) {
+	var data *BrowseResponse
+	err := json.Unmarshal([]byte(`{"currentPage":1,"pages":3,"results":[
+		{"groupId":1,"groupName":"Album","artist":"Artist","groupYear":2009,"releaseType":"Single","groupTime":1339117820,
+		"tags":["rock"],"torrents":[
+			{"torrentId":10,"media":"CD","format":"FLAC","encoding":"Lossless","time":"2009-06-06 19:04:22",
+			"size":243680994,"snatches":10,"seeders":3,"leechers":1,"isFreeleech":true},
+			{"torrentId":11,"media":"WEB","format":"MP3","encoding":"320","size":100,"isNeutralLeech":true}]},
+		{"groupId":2,"groupName":"Book","torrentId":20,"category":"E-Books","groupTime":"1339117820","size":5}]}`), &data)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	gzsite := &Site{Name: "g", SiteConfig: &config.SiteConfigStruct{Url: "https://example.com/"}}
+	torrents := ParseTorrents(gzsite, data, time.UTC, formatTorrent)
+	if len(torrents) != 3 {
+		t.Fatalf("expect 3 torrents, got %d", len(torrents))
+	}
+	if tr := torrents[0]; tr.Id != "g.10" || tr.Name != "Artist - Album [2009] [Single]" ||
+		tr.Description != "FLAC / Lossless / CD" || tr.DownloadMultiplier != 0 || tr.UploadMultiplier != 1 ||
+		tr.Size != 243680994 || tr.Time != 1244315062 || tr.Snatched != 10 ||
+		tr.DownloadUrl != "https://example.com/torrents.php?action=download&id=10" {
+		t.Errorf("unexpected torrent 0: %+v", tr)
+	}
+	if tr := torrents[1]; !tr.Neutral || tr.DownloadMultiplier != 0 || tr.UploadMultiplier != 0 || tr.Time != 1339117820 {
+		t.Errorf("unexpected torrent 1: %+v", tr)
+	}
+	if tr := torrents[2]; tr.Id != "g.20" || tr.Name != "Book" || tr.Description != "E-Books" || tr.Size != 5 {
+		t.Errorf("unexpected torrent 2: %+v", tr)
+	}
+}
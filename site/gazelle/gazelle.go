@@ -29,9 +29,42 @@ type Site struct {
 	HttpHeaders [][]string
 }
 
-// PublishTorrent implements site.Site.
+// Music category upload form of Gazelle. Other categories use different fields,
+// set them in uploadTorrentPayload of site config.
+var defaultUploadTorrentPayload = map[string]string{
+	"submit":       "true",
+	"auth":         `{{_authkey}}`,
+	"type":         `{% if type %}{{type}}{% else %}0{% endif %}`,
+	"artists[]":    `{{author}}`,
+	"importance[]": "1",
+	"title":        `{{title}}`,
+	"year":         `{{year}}`,
+	"releasetype":  `{{releasetype}}`,
+	"format":       `{{format}}`,
+	"bitrate":      `{{bitrate}}`,
+	"media":        `{{media}}`,
+	"tags":         `{% if tags %}{{tags | join(",")}}{% endif %}`,
+	"image":        `{{_cover}}`,
+	"album_desc": `
+{% if _cover %}
+[img]{{_cover}}[/img]
+{% endif %}
+{% if _images %}
+{% for image in _images %}
+[img]{{image}}[/img]
+{% endfor %}
+{% endif %}
+{% if _meta %}
+{{_meta}}
+{% endif %}
+{{_text}}`,
+	"release_desc": `{{comment}}`,
+}
+
+// Upload torrent to Gazelle site.
+// See: https://github.com/WhatCD/Gazelle/blob/master/sections/upload/upload_handle.php .
 func (gzsite *Site) PublishTorrent(contents []byte, metadata url.Values) (id string, err error) {
-	return "", site.ErrUnimplemented
+	return PublishTorrent(gzsite, gzsite.HttpClient, contents, metadata, defaultUploadTorrentPayload)
 }
 
 const (
@@ -55,12 +88,53 @@ func (gzsite *Site) GetSiteConfig() *config.SiteConfigStruct {
 	return gzsite.SiteConfig
 }
 
-func (gzsite *Site) GetStatus() (*site.Status, error) {
-	doc, _, err := util.GetUrlDocWithAzuretls(gzsite.SiteConfig.Url+"torrents.php", gzsite.HttpClient,
+func (gzsite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (gzsite *Site) CheckIn() (*site.CheckInResult, error) {
+	return site.CheckInByConfig(gzsite, gzsite.HttpClient)
+}
+
+func (gzsite *Site) GetMessages(unreadOnly bool) ([]*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (gzsite *Site) GetMessage(id string) (*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (gzsite *Site) MarkMessagesRead(ids []string) error {
+	return site.ErrUnimplemented
+}
+
+// Gazelle login: POST login.php with username, password & keeplogged.
+// Some gazelle forks ask for 2FA code in a separate form after password is accepted.
+func (gzsite *Site) Login() (cookie string, err error) {
+	return site.FormLogin(gzsite, gzsite.HttpClient, &site.LoginOption{
+		LoginUrl:        DEFAULT_LOGIN_URL,
+		Fields:          map[string]string{"keeplogged": "1"},
+		TwoFactorFields: []string{"2fa", "code"},
+	})
+}
+
+func (gzsite *Site) GetStatus(full bool) (*site.Status, error) {
+	status, err := gzsite.getStatus()
+	if site.ReloginIfNeeded(gzsite, err) {
+		status, err = gzsite.getStatus()
+	}
+	return status, err
+}
+
+func (gzsite *Site) getStatus() (*site.Status, error) {
+	doc, res, err := util.GetUrlDocWithAzuretls(gzsite.SiteConfig.Url+"torrents.php", gzsite.HttpClient,
 		gzsite.GetSiteConfig().Cookie, site.GetUa(gzsite), gzsite.GetDefaultHttpHeaders())
 	if err != nil {
 		return nil, err
 	}
+	if strings.Contains(res.Request.Url, "/login.php") {
+		return nil, site.ErrNotLoggedIn
+	}
 	userNameSelector := SELECTOR_USERNAME
 	userUploadedSelector := SELECTOR_USER_UPLOADED
 	userDownloadedSelector := SELECTOR_USER_DOWNLOADED
@@ -87,15 +161,35 @@ func (gzsite *Site) GetStatus() (*site.Status, error) {
 
 func (gzsite *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
 	torrents []*site.Torrent, nextPageMarker string, err error) {
-	return nil, "", site.ErrUnimplemented
+	return GetAllTorrents(gzsite, gzsite.HttpClient, gzsite.Location, formatTorrent, sort, desc, pageMarker, baseUrl)
 }
 
 func (gzsite *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	torrents, _, err := gzsite.GetAllTorrents("time", true, "", "")
+	return torrents, err
 }
 
 func (gzsite *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	return SearchTorrents(gzsite, gzsite.HttpClient, gzsite.Location, formatTorrent, keyword, baseUrl)
+}
+
+// Music: "Artist - Album [2009] [Album]", "FLAC / Lossless / CD".
+func formatTorrent(group *BrowseGroup, torrent *BrowseTorrent) (name string, description string) {
+	if len(group.Torrents) == 0 {
+		return group.GroupName, group.Category
+	}
+	name = group.GroupName
+	if group.Artist != "" {
+		name = group.Artist + " - " + name
+	}
+	if year := FormatValue(group.GroupYear); year != "" && year != "0" {
+		name += " [" + year + "]"
+	}
+	if releaseType := FormatValue(group.ReleaseType); releaseType != "" {
+		name += " [" + releaseType + "]"
+	}
+	description = JoinNonEmpty(" / ", torrent.Format, torrent.Encoding, torrent.Media, torrent.RemasterTitle)
+	return
 }
 
 func (gzsite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
@@ -117,8 +211,8 @@ func (gzsite *Site) DownloadTorrentById(id string) ([]byte, string, error) {
 }
 
 func NewSite(name string, siteConfig *config.SiteConfigStruct, config *config.ConfigStruct) (site.Site, error) {
-	if siteConfig.Cookie == "" {
-		log.Warnf("Site %s has no cookie provided", name)
+	if siteConfig.Cookie == "" && siteConfig.ApiToken == "" {
+		log.Warnf("Site %s has no cookie or apiToken provided", name)
 	}
 	location, err := time.LoadLocation(siteConfig.GetTimezone())
 	if err != nil {
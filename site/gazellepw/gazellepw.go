@@ -17,6 +17,7 @@ import (
 
 	"github.com/sagan/ptool/config"
 	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/site/gazelle"
 	"github.com/sagan/ptool/util"
 )
 
@@ -29,9 +30,42 @@ type Site struct {
 	HttpHeaders [][]string
 }
 
-// PublishTorrent implements site.Site.
+var defaultUploadTorrentPayload = map[string]string{
+	"submit":      "true",
+	"auth":        `{{_authkey}}`,
+	"type":        `{% if type %}{{type}}{% else %}1{% endif %}`,
+	"imdb":        `{{imdb}}`,
+	"name":        `{{title}}`,
+	"subname":     `{{subtitle}}`,
+	"year":        `{{year}}`,
+	"releasetype": `{{releasetype}}`,
+	"source":      `{{source}}`,
+	"codec":       `{{codec}}`,
+	"container":   `{{container}}`,
+	"resolution":  `{{resolution}}`,
+	"processing":  `{{processing}}`,
+	"tags":        `{% if tags %}{{tags | join(",")}}{% endif %}`,
+	"image":       `{{_cover}}`,
+	"mediainfo[]": `{{mediainfo}}`,
+	"desc": `
+{% if _cover %}
+[img]{{_cover}}[/img]
+{% endif %}
+{% if _images %}
+{% for image in _images %}
+[img]{{image}}[/img]
+{% endfor %}
+{% endif %}
+{% if _meta %}
+{{_meta}}
+{% endif %}
+{{_text}}`,
+	"release_desc": `{{comment}}`,
+}
+
+// Upload torrent to GazellePW site.
 func (gpwsite *Site) PublishTorrent(contents []byte, metadata url.Values) (id string, err error) {
-	return "", site.ErrUnimplemented
+	return gazelle.PublishTorrent(gpwsite, gpwsite.HttpClient, contents, metadata, defaultUploadTorrentPayload)
 }
 
 func (gpwsite *Site) GetDefaultHttpHeaders() [][]string {
@@ -49,7 +83,31 @@ func (gpwsite *Site) GetSiteConfig() *config.SiteConfigStruct {
 	return gpwsite.SiteConfig
 }
 
-func (gpwsite *Site) GetStatus() (*site.Status, error) {
+func (gpwsite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (gpwsite *Site) CheckIn() (*site.CheckInResult, error) {
+	return site.CheckInByConfig(gpwsite, gpwsite.HttpClient)
+}
+
+func (gpwsite *Site) GetMessages(unreadOnly bool) ([]*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (gpwsite *Site) GetMessage(id string) (*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (gpwsite *Site) MarkMessagesRead(ids []string) error {
+	return site.ErrUnimplemented
+}
+
+func (gpwsite *Site) Login() (cookie string, err error) {
+	return "", site.ErrUnimplemented
+}
+
+func (gpwsite *Site) GetStatus(full bool) (*site.Status, error) {
 	doc, _, err := util.GetUrlDocWithAzuretls(gpwsite.SiteConfig.Url+"torrents.php", gpwsite.HttpClient,
 		gpwsite.GetSiteConfig().Cookie, site.GetUa(gpwsite), gpwsite.GetDefaultHttpHeaders())
 	if err != nil {
@@ -68,15 +126,28 @@ func (gpwsite *Site) GetStatus() (*site.Status, error) {
 
 func (gpwsite *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
 	torrents []*site.Torrent, nextPageMarker string, err error) {
-	return nil, "", site.ErrUnimplemented
+	return gazelle.GetAllTorrents(gpwsite, gpwsite.HttpClient, gpwsite.Location, formatTorrent,
+		sort, desc, pageMarker, baseUrl)
 }
 
 func (gpwsite *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	torrents, _, err := gpwsite.GetAllTorrents("time", true, "", "")
+	return torrents, err
 }
 
 func (gpwsite *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	return gazelle.SearchTorrents(gpwsite, gpwsite.HttpClient, gpwsite.Location, formatTorrent, keyword, baseUrl)
+}
+
+// "Movie [2009]", "Sub name / x264 / Blu-ray / 1080p / MKV / Encode".
+func formatTorrent(group *gazelle.BrowseGroup, torrent *gazelle.BrowseTorrent) (name string, description string) {
+	name = group.GroupName
+	if year := gazelle.FormatValue(group.GroupYear); year != "" && year != "0" {
+		name += " [" + year + "]"
+	}
+	description = gazelle.JoinNonEmpty(" / ", group.GroupSubName, torrent.Codec, torrent.Source,
+		torrent.Resolution, torrent.Container, torrent.Processing, torrent.RemasterTitle)
+	return
 }
 
 func (gpwsite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
@@ -98,8 +169,8 @@ func (gpwsite *Site) DownloadTorrentById(id string) ([]byte, string, error) {
 }
 
 func NewSite(name string, siteConfig *config.SiteConfigStruct, config *config.ConfigStruct) (site.Site, error) {
-	if siteConfig.Cookie == "" {
-		log.Warnf("Site %s has no cookie provided", name)
+	if siteConfig.Cookie == "" && siteConfig.ApiToken == "" {
+		log.Warnf("Site %s has no cookie or apiToken provided", name)
 	}
 	location, err := time.LoadLocation(siteConfig.GetTimezone())
 	if err != nil {
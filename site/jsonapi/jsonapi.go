@@ -0,0 +1,515 @@
+package jsonapi
+
+// 通用 JSON API 站点。种子列表、搜索、用户信息接口的 url、请求方法、请求 body (jinja 模板)
+// 以及响应 json 到 site.Torrent / site.Status 各字段的映射均在配置文件中定义，
+// 用于无需编写代码即可支持仅提供 JSON API 的(SPA)站点。
+// 种子下载链接：通过 jsonApiTorrentFields 的 downloadUrl 映射，或 torrentDownloadUrl 配置 (使用 {id} 占位符)
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Noooste/azuretls-client"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/jinja"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+type Site struct {
+	Name        string
+	Location    *time.Location
+	SiteConfig  *config.SiteConfigStruct
+	Config      *config.ConfigStruct
+	HttpClient  *azuretls.Session
+	HttpHeaders [][]string
+}
+
+// PublishTorrent implements site.Site.
+func (jsite *Site) PublishTorrent(contents []byte, metadata url.Values) (id string, err error) {
+	return "", site.ErrUnimplemented
+}
+
+func (jsite *Site) GetDefaultHttpHeaders() [][]string {
+	return jsite.HttpHeaders
+}
+
+func (jsite *Site) PurgeCache() {
+}
+
+func (jsite *Site) GetName() string {
+	return jsite.Name
+}
+
+func (jsite *Site) GetSiteConfig() *config.SiteConfigStruct {
+	return jsite.SiteConfig
+}
+
+func (jsite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (jsite *Site) CheckIn() (*site.CheckInResult, error) {
+	return site.CheckInByConfig(jsite, jsite.HttpClient)
+}
+
+func (jsite *Site) GetMessages(unreadOnly bool) ([]*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (jsite *Site) GetMessage(id string) (*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (jsite *Site) MarkMessagesRead(ids []string) error {
+	return site.ErrUnimplemented
+}
+
+func (jsite *Site) Login() (cookie string, err error) {
+	return "", site.ErrUnimplemented
+}
+
+func (jsite *Site) GetStatus(full bool) (*site.Status, error) {
+	if jsite.SiteConfig.UserInfoUrl == "" {
+		return nil, fmt.Errorf("userInfoUrl is not configured")
+	}
+	data, err := jsite.request(jsite.SiteConfig.UserInfoUrl, jsite.SiteConfig.JsonApiUserInfoBody, nil)
+	if err != nil {
+		return nil, err
+	}
+	status := &site.Status{}
+	if err = mapFields(status, data, jsite.SiteConfig.JsonApiStatusFields, jsite.Location); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (jsite *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
+	torrents []*site.Torrent, nextPageMarker string, err error) {
+	if baseUrl == "" {
+		baseUrl = jsite.SiteConfig.TorrentsUrl
+	}
+	var pageNumber int64 = 1
+	if pageMarker != "" {
+		pageNumber = util.ParseInt(pageMarker)
+	}
+	// pageNumber starts from 1, NOT 0
+	if pageNumber < 1 {
+		return nil, "", fmt.Errorf("page number must be greater than 0")
+	}
+	if sort == constants.NONE {
+		sort = ""
+	}
+	data, err := jsite.request(baseUrl, jsite.SiteConfig.JsonApiTorrentsBody, map[string]any{
+		"page": pageNumber,
+		"sort": sort,
+		"desc": desc,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	torrents, err = jsite.parseTorrents(data)
+	if err != nil {
+		return nil, "", err
+	}
+	// Without jsonApiTotalPages, keep fetching next page until got an empty list.
+	if jsite.SiteConfig.JsonApiTotalPages != "" {
+		totalPages, _ := util.GetJsonPath(data, jsite.SiteConfig.JsonApiTotalPages)
+		if toInt(totalPages) > pageNumber {
+			nextPageMarker = fmt.Sprint(pageNumber + 1)
+		}
+	} else if len(torrents) > 0 {
+		nextPageMarker = fmt.Sprint(pageNumber + 1)
+	}
+	return
+}
+
+func (jsite *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
+	torrents, _, err := jsite.GetAllTorrents("time", true, "", "")
+	return torrents, err
+}
+
+func (jsite *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
+	if baseUrl == "" {
+		baseUrl = jsite.SiteConfig.SearchUrl
+		if baseUrl == "" {
+			baseUrl = jsite.SiteConfig.TorrentsUrl
+		}
+	}
+	baseUrl = strings.Replace(baseUrl, "%s", url.QueryEscape(keyword), 1)
+	body := jsite.SiteConfig.JsonApiSearchBody
+	if body == "" {
+		body = jsite.SiteConfig.JsonApiTorrentsBody
+	}
+	data, err := jsite.request(baseUrl, body, map[string]any{
+		"keyword": keyword,
+		"page":    1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jsite.parseTorrents(data)
+}
+
+func (jsite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
+	if !util.IsUrl(torrentUrl) {
+		id = strings.TrimPrefix(torrentUrl, jsite.GetName()+".")
+		content, filename, err = jsite.DownloadTorrentById(id)
+		return
+	}
+	if jsite.SiteConfig.TorrentUrlIdRegexp != "" {
+		if idRegexp, err := regexp.Compile(jsite.SiteConfig.TorrentUrlIdRegexp); err == nil {
+			if m := idRegexp.FindStringSubmatch(torrentUrl); m != nil {
+				if index := idRegexp.SubexpIndex("id"); index != -1 {
+					id = m[index]
+				} else if len(m) > 1 {
+					id = m[1]
+				}
+			}
+		}
+	}
+	content, filename, err = site.DownloadTorrentByUrl(jsite, jsite.HttpClient, torrentUrl, id)
+	return
+}
+
+func (jsite *Site) DownloadTorrentById(id string) ([]byte, string, error) {
+	if jsite.SiteConfig.TorrentDownloadUrl == "" {
+		return nil, "", fmt.Errorf("torrentDownloadUrl is not configured, can not download torrent by id")
+	}
+	torrentUrl := jsite.SiteConfig.ParseSiteUrl(strings.ReplaceAll(jsite.SiteConfig.TorrentDownloadUrl, "{id}", id), false)
+	return site.DownloadTorrentByUrl(jsite, jsite.HttpClient, torrentUrl, id)
+}
+
+// Send a request to a configured api. urlTemplate & bodyTemplate are rendered with vars.
+// Return the decoded response json.
+func (jsite *Site) request(urlTemplate string, bodyTemplate string, vars map[string]any) (any, error) {
+	if urlTemplate == "" {
+		return nil, fmt.Errorf("api url is not configured")
+	}
+	context := map[string]any{
+		"keyword": "",
+		"page":    1,
+		"sort":    "",
+		"desc":    false,
+		"order":   "asc",
+	}
+	for key, value := range vars {
+		context[key] = value
+	}
+	if desc, _ := context["desc"].(bool); desc {
+		context["order"] = "desc"
+	}
+	apiUrl, err := jinja.Render(urlTemplate, context)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api url: %w", err)
+	}
+	apiUrl = jsite.SiteConfig.ParseSiteUrl(apiUrl, false)
+	method := http.MethodGet
+	if jsite.SiteConfig.JsonApiMethod != "" {
+		method = strings.ToUpper(jsite.SiteConfig.JsonApiMethod)
+	}
+	var headers [][]string
+	var body []byte
+	if bodyTemplate != "" {
+		rendered, err := jinja.Render(bodyTemplate, context)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api body: %w", err)
+		}
+		body = []byte(rendered)
+		headers = append(headers, []string{"Content-Type", "application/json"})
+	}
+	headers = append(headers, jsite.GetDefaultHttpHeaders()...)
+	req := &azuretls.Request{
+		Method:         method,
+		Url:            apiUrl,
+		NoCookie:       true, // disable azuretls internal cookie jar
+		OrderedHeaders: util.GetHttpReqHeaders(headers, jsite.SiteConfig.Cookie, site.GetUa(jsite)),
+	}
+	if body != nil {
+		req.Body = body
+	}
+	util.LogAzureHttpRequest(req)
+	res, err := jsite.HttpClient.Do(req)
+	util.LogAzureHttpResponse(res, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch url: %w", err)
+	}
+	if res.StatusCode != 200 && !jsite.SiteConfig.AcceptAnyHttpStatus {
+		return nil, fmt.Errorf("failed to fetch url: status=%d", res.StatusCode)
+	}
+	var data any
+	if err = json.Unmarshal(res.Body, &data); err != nil {
+		return nil, fmt.Errorf("unmarshal response as json error: %w", err)
+	}
+	if jsite.SiteConfig.JsonApiError != "" {
+		msg, err := jinja.Render(jsite.SiteConfig.JsonApiError, toContext(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid jsonApiError: %w", err)
+		}
+		if msg != "" {
+			return nil, fmt.Errorf("api error: %s", msg)
+		}
+	}
+	return data, nil
+}
+
+func (jsite *Site) parseTorrents(data any) ([]*site.Torrent, error) {
+	list, ok := util.GetJsonPath(data, jsite.SiteConfig.JsonApiTorrentsList)
+	if !ok || list == nil {
+		return nil, nil
+	}
+	items, ok := list.([]any)
+	if !ok {
+		return nil, fmt.Errorf("jsonApiTorrentsList %q is not an array", jsite.SiteConfig.JsonApiTorrentsList)
+	}
+	var torrents []*site.Torrent
+	for _, item := range items {
+		torrent := &site.Torrent{
+			DownloadMultiplier: 1,
+			UploadMultiplier:   1,
+			DiscountEndTime:    -1,
+			IsSizeAccurate:     true,
+			HasHnR:             jsite.SiteConfig.GlobalHnR,
+		}
+		if err := mapFields(torrent, item, jsite.SiteConfig.JsonApiTorrentFields, jsite.Location); err != nil {
+			return nil, err
+		}
+		if torrent.Id != "" && !strings.HasPrefix(torrent.Id, jsite.GetName()+".") {
+			torrent.Id = jsite.GetName() + "." + torrent.Id
+		}
+		if torrent.DownloadUrl != "" {
+			torrent.DownloadUrl = jsite.SiteConfig.ParseSiteUrl(torrent.DownloadUrl, false)
+		} else if torrent.Id != "" && jsite.SiteConfig.TorrentDownloadUrl != "" {
+			id := strings.TrimPrefix(torrent.Id, jsite.GetName()+".")
+			torrent.DownloadUrl = jsite.SiteConfig.ParseSiteUrl(
+				strings.ReplaceAll(jsite.SiteConfig.TorrentDownloadUrl, "{id}", id), false)
+		}
+		if torrent.IsCurrentActive {
+			torrent.IsActive = true
+		}
+		torrents = append(torrents, torrent)
+	}
+	return torrents, nil
+}
+
+// Set fields of obj (a struct pointer) from json data according to mappings.
+// mappings: field name (case insensitive) => JSONPath-style path or jinja template.
+func mapFields(obj any, data any, mappings map[string]string, location *time.Location) error {
+	v := reflect.ValueOf(obj).Elem()
+	for name, expr := range mappings {
+		field := v.FieldByNameFunc(func(fieldName string) bool { return strings.EqualFold(fieldName, name) })
+		if !field.IsValid() || !field.CanSet() {
+			return fmt.Errorf("invalid field mapping: unknown field %q", name)
+		}
+		value, err := evaluate(data, expr)
+		if err != nil {
+			return fmt.Errorf("invalid field mapping %q: %w", name, err)
+		}
+		if value == nil {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(toString(value))
+		case reflect.Int64:
+			switch strings.ToLower(name) {
+			case "time", "discountendtime":
+				field.SetInt(toTime(value, location))
+			case "size", "userdownloaded", "useruploaded", "torrentsseedingsize":
+				field.SetInt(toSize(value))
+			default:
+				field.SetInt(toInt(value))
+			}
+		case reflect.Float64:
+			field.SetFloat(toFloat(value))
+		case reflect.Bool:
+			field.SetBool(toBool(value))
+		case reflect.Slice:
+			field.Set(reflect.ValueOf(toStrings(value)))
+		default:
+			return fmt.Errorf("invalid field mapping: unsupported field %q", name)
+		}
+	}
+	return nil
+}
+
+// If expr is a jinja template, render it with data as context; otherwise treat it as a path.
+func evaluate(data any, expr string) (any, error) {
+	if strings.Contains(expr, "{{") || strings.Contains(expr, "{%") {
+		return jinja.Render(expr, toContext(data))
+	}
+	value, _ := util.GetJsonPath(data, expr)
+	return value, nil
+}
+
+// Jinja context must be a map. Non-object data is available as "_".
+func toContext(data any) map[string]any {
+	context := map[string]any{}
+	if obj, ok := data.(map[string]any); ok {
+		for key, value := range obj {
+			context[key] = value
+		}
+	}
+	context["_"] = data
+	return context
+}
+
+func toString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	contents, _ := json.Marshal(value)
+	return string(contents)
+}
+
+func toInt(value any) int64 {
+	switch v := value.(type) {
+	case float64:
+		return int64(v)
+	case string:
+		return util.ParseInt(strings.TrimSpace(v))
+	case bool:
+		if v {
+			return 1
+		}
+	}
+	return 0
+}
+
+func toFloat(value any) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f
+	}
+	return 0
+}
+
+func toBool(value any) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "", "0", "false", "no", "null", "none":
+			return false
+		}
+		return true
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	}
+	return false
+}
+
+// Size can be a number of bytes or a string like "1.5 GiB".
+func toSize(value any) int64 {
+	if str, ok := value.(string); ok {
+		str = strings.TrimSpace(str)
+		if size, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return size
+		}
+		size, _ := util.ExtractSizeStr(str)
+		return size
+	}
+	return toInt(value)
+}
+
+// Time can be a unix timestamp (seconds or milliseconds) or a datetime string.
+func toTime(value any, location *time.Location) int64 {
+	var ts int64
+	switch v := value.(type) {
+	case float64:
+		ts = int64(v)
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return 0
+		}
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			ts = i
+		} else if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t.Unix()
+		} else {
+			ts, _ = util.ParseTime(v, location)
+			return ts
+		}
+	default:
+		return 0
+	}
+	if ts > 1e11 {
+		ts /= 1000
+	}
+	return ts
+}
+
+func toStrings(value any) []string {
+	switch v := value.(type) {
+	case []any:
+		var list []string
+		for _, item := range v {
+			if str := toString(item); str != "" {
+				list = append(list, str)
+			}
+		}
+		return list
+	case string:
+		return util.SplitCsv(v)
+	}
+	return nil
+}
+
+func NewSite(name string, siteConfig *config.SiteConfigStruct, config *config.ConfigStruct) (site.Site, error) {
+	if siteConfig.Url == "" {
+		return nil, fmt.Errorf("url is not configured")
+	}
+	if siteConfig.Cookie == "" && len(siteConfig.HttpHeaders) == 0 {
+		log.Warnf("Site %s has no cookie or httpHeaders provided", name)
+	}
+	location, err := time.LoadLocation(siteConfig.GetTimezone())
+	if err != nil {
+		return nil, fmt.Errorf("invalid site timezone %s: %w", siteConfig.GetTimezone(), err)
+	}
+	httpClient, httpHeaders, err := site.CreateSiteHttpClient(siteConfig, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create site http client: %w", err)
+	}
+	site := &Site{
+		Name:        name,
+		Location:    location,
+		SiteConfig:  siteConfig,
+		Config:      config,
+		HttpClient:  httpClient,
+		HttpHeaders: httpHeaders,
+	}
+	return site, nil
+}
+
+func init() {
+	site.Register(&site.RegInfo{
+		Name:    "jsonapi",
+		Creator: NewSite,
+	})
+}
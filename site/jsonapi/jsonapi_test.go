@@ -0,0 +1,81 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+)
+
+func TestParseTorrents(t *testing.T) {
+	var data any
+	json.Unmarshal([]byte(`{"code":"0","data":{"total":"3","list":[
+		{"id":"101","name":"a","smallDescr":"sub","size":"1073741824","createdDate":"2024-01-02 03:04:05",
+		"labels":["4k","hdr"],"status":{"discount":"FREE","seeders":"5","leechers":2,"timesCompleted":9}},
+		{"id":"102","name":"b","size":"1.5 GiB","createdDate":1704164645000,"status":{"discount":"NORMAL"}}]}}`), &data)
+	jsite := &Site{
+		Name:     "api",
+		Location: time.UTC,
+		SiteConfig: &config.SiteConfigStruct{
+			Url:                 "https://example.com/",
+			TorrentDownloadUrl:  "download/{id}",
+			JsonApiTorrentsList: "$.data.list",
+			// viper lowercases map keys
+			JsonApiTorrentFields: map[string]string{
+				"id":                 "id",
+				"name":               "name",
+				"description":        "smallDescr",
+				"size":               "size",
+				"time":               "createdDate",
+				"tags":               "labels",
+				"seeders":            "status.seeders",
+				"leechers":           "status['leechers']",
+				"snatched":           "status.timesCompleted",
+				"downloadmultiplier": `{% if status.discount == "FREE" %}0{% else %}1{% endif %}`,
+			},
+		},
+	}
+	torrents, err := jsite.parseTorrents(data)
+	if err != nil {
+		t.Fatalf("parseTorrents: %v", err)
+	}
+	if len(torrents) != 2 {
+		t.Fatalf("expect 2 torrents, got %d", len(torrents))
+	}
+	want := &site.Torrent{
+		Name:               "a",
+		Description:        "sub",
+		Id:                 "api.101",
+		DownloadUrl:        "https://example.com/download/101",
+		DownloadMultiplier: 0,
+		UploadMultiplier:   1,
+		DiscountEndTime:    -1,
+		Time:               1704164645,
+		Size:               1073741824,
+		IsSizeAccurate:     true,
+		Seeders:            5,
+		Leechers:           2,
+		Snatched:           9,
+		Tags:               []string{"4k", "hdr"},
+	}
+	if a := torrents[0]; a.Name != want.Name || a.Description != want.Description || a.Id != want.Id ||
+		a.DownloadUrl != want.DownloadUrl || a.DownloadMultiplier != 0 || a.Time != want.Time ||
+		a.Size != want.Size || a.Seeders != 5 || a.Leechers != 2 || a.Snatched != 9 || !slices.Equal(a.Tags, want.Tags) {
+		t.Errorf("unexpected torrent: %+v, want %+v", a, want)
+	}
+	if b := torrents[1]; b.Size != 1610612736 || b.Time != 1704164645 || b.DownloadMultiplier != 1 {
+		t.Errorf("unexpected torrent: %+v", b)
+	}
+
+	status := &site.Status{}
+	err = mapFields(status, data, map[string]string{"username": "data.total", "userdownloaded": "data.total"}, time.UTC)
+	if err != nil || status.UserName != "3" || status.UserDownloaded != 3 {
+		t.Errorf("unexpected status: %+v, %v", status, err)
+	}
+	if err = mapFields(status, data, map[string]string{"foo": "bar"}, time.UTC); err == nil {
+		t.Errorf("expect error for unknown field")
+	}
+}
@@ -0,0 +1,371 @@
+package site
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Noooste/azuretls-client"
+	"github.com/PuerkitoBio/goquery"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/util"
+	"github.com/sagan/ptool/util/crypto"
+)
+
+const (
+	LOGIN_MAX_REDIRECTS            = 10
+	LOGIN_ERROR_MESSAGE_MAX_LENGTH = 200
+)
+
+var (
+	loginMu sync.Mutex
+	// sites that have been automatically re-logined in current process
+	reloginedSites = map[string]bool{}
+)
+
+// Options of a form based (username / password) site login.
+type LoginOption struct {
+	LoginUrl string // login page url, relative to site url. E.g. "login.php"
+	// selector of login form in login page. Default: the first form that has a password input
+	FormSelector  string
+	UsernameField string            // Default: "username"
+	PasswordField string            // Default: "password"
+	Fields        map[string]string // additional form fields. E.g. {"keeplogged": "1"}
+	// field name of TOTP code, if site requires it in the login form (e.g. nexusphp "two_step_code")
+	TotpField string
+	// field names of TOTP code in the form of a separate 2FA challenge page which site redirects to
+	// after the password is accepted (e.g. unit3d "code")
+	TwoFactorFields []string
+	// wait some time before submitting the login form. Some sites reject forms that submitted too fast
+	Delay time.Duration
+}
+
+// A http session of site login, which uses it's own cookie jar
+// and keeps the cookies set by site in every (redirect) response.
+type loginSession struct {
+	siteInstance Site
+	httpClient   *azuretls.Session
+	names        []string
+	cookies      map[string]string
+}
+
+// Return true if username & password of site are configured, so it can be (automatically) logined.
+func CanLogin(siteConfig *config.SiteConfigStruct) bool {
+	return siteConfig.Username != "" && siteConfig.Password != ""
+}
+
+// Return current TOTP code of site config, or empty string if totpSecret is not configured.
+func GetLoginTotp(siteConfig *config.SiteConfigStruct) (string, error) {
+	if siteConfig.TotpSecret == "" {
+		return "", nil
+	}
+	return crypto.Totp(siteConfig.TotpSecret, time.Now())
+}
+
+// Login site using a html login form, return the new cookie.
+// It submits username, password and optional TOTP code of site config to the login form,
+// and handles the optional 2FA challenge page.
+func FormLogin(siteInstance Site, httpClient *azuretls.Session, option *LoginOption) (cookie string, err error) {
+	siteConfig := siteInstance.GetSiteConfig()
+	if !CanLogin(siteConfig) {
+		return "", fmt.Errorf("username or password of site is not configured")
+	}
+	loginUrl := option.LoginUrl
+	if siteConfig.LoginUrl != "" {
+		loginUrl = siteConfig.LoginUrl
+	}
+	loginUrl = siteConfig.ParseSiteUrl(loginUrl, false)
+	ls := &loginSession{siteInstance: siteInstance, httpClient: httpClient, cookies: map[string]string{}}
+	doc, pageUrl, err := ls.getDoc(http.MethodGet, loginUrl, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get login page: %w", err)
+	}
+	var form *goquery.Selection
+	if option.FormSelector != "" {
+		form = doc.Find(option.FormSelector).First()
+	} else {
+		form = doc.Find(`input[type="password"]`).First().Closest("form")
+	}
+	if form.Length() == 0 {
+		return "", fmt.Errorf("login form not found in login page %s", pageUrl)
+	}
+	if form.Find(`input[name*="captcha"],input[name="imagestring"]`).Length() > 0 {
+		return "", fmt.Errorf("login form requires captcha, which is not supported")
+	}
+	values := FormValues(form)
+	usernameField := option.UsernameField
+	if usernameField == "" {
+		usernameField = "username"
+	}
+	passwordField := option.PasswordField
+	if passwordField == "" {
+		passwordField = "password"
+	}
+	values.Set(usernameField, siteConfig.Username)
+	values.Set(passwordField, siteConfig.Password)
+	for name, value := range option.Fields {
+		values.Set(name, value)
+	}
+	if option.TotpField != "" && siteConfig.TotpSecret != "" {
+		code, err := GetLoginTotp(siteConfig)
+		if err != nil {
+			return "", err
+		}
+		values.Set(option.TotpField, code)
+	}
+	if option.Delay > 0 {
+		time.Sleep(option.Delay)
+	}
+	doc, pageUrl, err = ls.getDoc(http.MethodPost, formActionUrl(form, pageUrl), values)
+	if err != nil {
+		return "", fmt.Errorf("failed to submit login form: %w", err)
+	}
+
+	if form, field := findTwoFactorForm(doc, option.TwoFactorFields); form != nil {
+		if siteConfig.TotpSecret == "" {
+			return "", fmt.Errorf("site requires 2FA code, but totpSecret is not configured")
+		}
+		code, err := GetLoginTotp(siteConfig)
+		if err != nil {
+			return "", err
+		}
+		values := FormValues(form)
+		values.Set(field, code)
+		doc, pageUrl, err = ls.getDoc(http.MethodPost, formActionUrl(form, pageUrl), values)
+		if err != nil {
+			return "", fmt.Errorf("failed to submit 2FA form: %w", err)
+		}
+	}
+
+	if isLoginPage(doc, pageUrl) {
+		return "", fmt.Errorf("login failed: %s", loginErrorMessage(doc))
+	}
+	cookie = ls.cookie()
+	if cookie == "" {
+		return "", fmt.Errorf("login failed: site did not set any cookie")
+	}
+	return cookie, nil
+}
+
+// Login site, update the site cookie in memory and save it to config file.
+func Relogin(siteInstance Site) error {
+	cookie, err := siteInstance.Login()
+	if err != nil {
+		return err
+	}
+	siteInstance.GetSiteConfig().Cookie = cookie
+	siteInstance.PurgeCache()
+	return saveCookie(siteInstance.GetName(), cookie)
+}
+
+// If err is (or wraps) ErrNotLoggedIn and autoLogin & username & password of site are configured,
+// re-login site and return true, in which case the caller should retry the failed request.
+// Every site is automatically re-logined at most once in current process.
+func ReloginIfNeeded(siteInstance Site, err error) bool {
+	if !errors.Is(err, ErrNotLoggedIn) || !siteInstance.GetSiteConfig().AutoLogin ||
+		!CanLogin(siteInstance.GetSiteConfig()) {
+		return false
+	}
+	loginMu.Lock()
+	if reloginedSites[siteInstance.GetName()] {
+		loginMu.Unlock()
+		return false
+	}
+	reloginedSites[siteInstance.GetName()] = true
+	loginMu.Unlock()
+	log.Warnf("Site %s is not logined, try to login using username & password", siteInstance.GetName())
+	if err := Relogin(siteInstance); err != nil {
+		log.Errorf("Failed to login site %s: %v", siteInstance.GetName(), err)
+		return false
+	}
+	log.Warnf("Site %s logined successfully, the new cookie is saved to config file (%s). "+
+		"All existing comments in config file are lost", siteInstance.GetName(), config.ConfigFile)
+	return true
+}
+
+// Save the new cookie of site to config file.
+// It does nothing if the site is not defined in config file.
+func saveCookie(sitename string, cookie string) error {
+	loginMu.Lock()
+	defer loginMu.Unlock()
+	siteConfig := config.GetSiteConfig(sitename)
+	if siteConfig == nil {
+		return nil
+	}
+	newsiteconfig := &config.SiteConfigStruct{}
+	util.Assign(newsiteconfig, siteConfig, nil)
+	newsiteconfig.Cookie = cookie
+	newsiteconfig.AutoComment = fmt.Sprintf(`cookie updated by login at %s`, util.FormatTime(util.Now()))
+	config.UpdateSites([]*config.SiteConfigStruct{newsiteconfig})
+	if err := config.Set(); err != nil {
+		return fmt.Errorf("failed to save new cookie to config file: %w", err)
+	}
+	return nil
+}
+
+// Get form fields values that will be submitted by browser, e.g. hidden csrf token.
+func FormValues(form *goquery.Selection) url.Values {
+	values := url.Values{}
+	form.Find("input[name]").Each(func(i int, el *goquery.Selection) {
+		switch strings.ToLower(el.AttrOr("type", "text")) {
+		case "submit", "button", "image", "file", "reset":
+			return
+		case "checkbox", "radio":
+			if _, ok := el.Attr("checked"); !ok {
+				return
+			}
+			values.Add(el.AttrOr("name", ""), el.AttrOr("value", "on"))
+		default:
+			values.Add(el.AttrOr("name", ""), el.AttrOr("value", ""))
+		}
+	})
+	form.Find("textarea[name]").Each(func(i int, el *goquery.Selection) {
+		values.Add(el.AttrOr("name", ""), el.Text())
+	})
+	form.Find("select[name]").Each(func(i int, el *goquery.Selection) {
+		option := el.Find("option[selected]").First()
+		if option.Length() == 0 {
+			option = el.Find("option").First()
+		}
+		if option.Length() > 0 {
+			values.Add(el.AttrOr("name", ""), option.AttrOr("value", option.Text()))
+		}
+	})
+	return values
+}
+
+// Return the absolute submit url of form.
+func formActionUrl(form *goquery.Selection, pageUrl string) string {
+	action := form.AttrOr("action", "")
+	pageUrlObj, err := url.Parse(pageUrl)
+	if err != nil {
+		return action
+	}
+	actionUrlObj, err := url.Parse(action)
+	if err != nil {
+		return pageUrl
+	}
+	return pageUrlObj.ResolveReference(actionUrlObj).String()
+}
+
+// Find the form of 2FA challenge page, which has a TOTP code field but no password field.
+func findTwoFactorForm(doc *goquery.Document, fields []string) (form *goquery.Selection, field string) {
+	if doc.Find(`input[type="password"]`).Length() > 0 {
+		return nil, ""
+	}
+	for _, field := range fields {
+		if input := doc.Find(fmt.Sprintf(`form input[name="%s"]`, field)).First(); input.Length() > 0 {
+			return input.Closest("form"), field
+		}
+	}
+	return nil, ""
+}
+
+// Return true if the page is (still) a login (or login failure) page.
+func isLoginPage(doc *goquery.Document, pageUrl string) bool {
+	if urlObj, err := url.Parse(pageUrl); err == nil && strings.Contains(strings.ToLower(urlObj.Path), "login") {
+		return true
+	}
+	return doc.Find(`input[type="password"]`).Length() > 0
+}
+
+// Extract (short) error message from login failure page.
+func loginErrorMessage(doc *goquery.Document) string {
+	selectors := []string{".alert-danger", ".invalid-feedback", ".error", "td.text", "#loginform .warning",
+		"h2", "body"}
+	for _, selector := range selectors {
+		if text := util.DomSanitizedText(doc.Find(selector).First()); text != "" {
+			return util.StringPrefixInBytes(text, LOGIN_ERROR_MESSAGE_MAX_LENGTH)
+		}
+	}
+	return "unknown error"
+}
+
+// Request url and return the dom and final url. Redirects are followed manually,
+// so cookies set by every response are kept. If values is not nil, send it as form body.
+func (ls *loginSession) getDoc(method string, requestUrl string, values url.Values) (
+	doc *goquery.Document, finalUrl string, err error) {
+	var res *azuretls.Response
+	for i := 0; ; i++ {
+		if i >= LOGIN_MAX_REDIRECTS {
+			return nil, "", fmt.Errorf("too many redirects")
+		}
+		headers := [][]string{}
+		if values != nil {
+			headers = append(headers, []string{"Content-Type", "application/x-www-form-urlencoded"})
+		}
+		headers = append(headers, ls.siteInstance.GetDefaultHttpHeaders()...)
+		req := &azuretls.Request{
+			Method:           method,
+			Url:              requestUrl,
+			NoCookie:         true, // disable azuretls internal cookie jar
+			DisableRedirects: true,
+			OrderedHeaders:   util.GetHttpReqHeaders(headers, ls.cookie(), GetUa(ls.siteInstance)),
+		}
+		if values != nil {
+			req.Body = values.Encode()
+		}
+		util.LogAzureHttpRequest(req)
+		res, err = ls.httpClient.Do(req)
+		util.LogAzureHttpResponse(res, err)
+		if err != nil {
+			return nil, "", err
+		}
+		ls.setCookies(res)
+		location := res.Header.Get("Location")
+		if res.StatusCode < 300 || res.StatusCode >= 400 || location == "" {
+			break
+		}
+		requestUrlObj, err := url.Parse(requestUrl)
+		if err != nil {
+			return nil, "", err
+		}
+		locationUrlObj, err := url.Parse(location)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid redirect location %q: %w", location, err)
+		}
+		requestUrl = requestUrlObj.ResolveReference(locationUrlObj).String()
+		method = http.MethodGet
+		values = nil
+	}
+	if res.StatusCode != 200 && !ls.siteInstance.GetSiteConfig().AcceptAnyHttpStatus {
+		return nil, "", fmt.Errorf("status=%d", res.StatusCode)
+	}
+	doc, err = goquery.NewDocumentFromReader(bytes.NewReader(res.Body))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse response dom: %w", err)
+	}
+	return doc, requestUrl, nil
+}
+
+func (ls *loginSession) setCookies(res *azuretls.Response) {
+	for _, cookie := range azuretls.ReadSetCookies(res.Header) {
+		if _, ok := ls.cookies[cookie.Name]; !ok {
+			ls.names = append(ls.names, cookie.Name)
+		}
+		if cookie.MaxAge < 0 || cookie.Value == "" || cookie.Value == "deleted" ||
+			!cookie.Expires.IsZero() && cookie.Expires.Before(time.Now()) {
+			ls.cookies[cookie.Name] = ""
+		} else {
+			ls.cookies[cookie.Name] = cookie.Value
+		}
+	}
+}
+
+// Return the current cookie header value, e.g. "a=1; b=2".
+func (ls *loginSession) cookie() string {
+	cookies := []string{}
+	for _, name := range ls.names {
+		if value := ls.cookies[name]; value != "" {
+			cookies = append(cookies, name+"="+value)
+		}
+	}
+	return strings.Join(cookies, "; ")
+}
@@ -67,3 +67,35 @@ func (ci *Int64) MarshalJSON() ([]byte, error) {
 func (ci *Int64) Value() int64 {
 	return int64(*ci)
 }
+
+// Float64 accepts both json number and string.
+type Float64 float64
+
+func (cf *Float64) UnmarshalJSON(data []byte) error {
+	var num float64
+	if err := json.Unmarshal(data, &num); err == nil {
+		*cf = Float64(num)
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	if str == "" {
+		*cf = 0
+		return nil
+	}
+
+	num, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return err
+	}
+
+	*cf = Float64(num)
+	return nil
+}
+
+func (cf *Float64) Value() float64 {
+	return float64(*cf)
+}
@@ -19,6 +19,10 @@ const (
 	APIPath_GenerateDownloadToken = "/api/torrent/genDlToken"
 	APIPath_TorrentSearch         = "/api/torrent/search"
 	APIPath_Profile               = "/api/member/profile"
+	APIPath_TorrentDetail         = "/api/torrent/detail"
+	APIPath_TorrentFiles          = "/api/torrent/files"
+	APIPath_PeerStatus            = "/api/tracker/myPeerStatus"
+	APIPath_MessageStatistic      = "/api/msg/notify/statistic"
 )
 
 var (
@@ -36,6 +40,20 @@ var (
 		"PERCENT_70":     0.3,
 	}
 
+	// user role id => class name
+	userClasses = map[int64]string{
+		1:  "User",
+		2:  "Power User",
+		3:  "Elite User",
+		4:  "Crazy User",
+		5:  "Insane User",
+		6:  "Veteran User",
+		7:  "Extreme User",
+		8:  "Ultimate User",
+		9:  "Nexus Master",
+		10: "VIP",
+	}
+
 	uploadMultipliers = map[string]float64{
 		"_2X_FREE":       2,
 		"_2X_PERCENT_50": 2,
@@ -160,19 +178,107 @@ func (m *Site) SearchTorrents(keyword string, baseUrl string) (torrents []*site.
 	return
 }
 
-func (m *Site) GetStatus() (*site.Status, error) {
+func (m *Site) CheckIn() (*site.CheckInResult, error) {
+	return site.CheckInByConfig(m, m.HttpClient)
+}
+
+func (m *Site) GetMessages(unreadOnly bool) ([]*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (m *Site) GetMessage(id string) (*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (m *Site) MarkMessagesRead(ids []string) error {
+	return site.ErrUnimplemented
+}
+
+func (m *Site) Login() (cookie string, err error) {
+	return "", site.ErrUnimplemented
+}
+
+func (m *Site) GetStatus(full bool) (*site.Status, error) {
 	var resp ProfileResponse
 	if err := m.do(APIPath_Profile, nil, nil, &resp); err != nil {
 		return nil, err
+	}
+	status := m.convertStatus(&resp.Data)
+	if !full {
+		return status, nil
+	}
+	// below infos are optional
+	var peerResp PeerStatusResponse
+	if err := m.do(APIPath_PeerStatus, nil, nil, &peerResp); err != nil {
+		log.Debugf("%s error: %v", APIPath_PeerStatus, err)
+	} else {
+		status.TorrentsSeedingCnt = peerResp.Data.Seeder.Value()
+		status.TorrentsLeechingCnt = peerResp.Data.Leecher.Value()
+	}
+	var msgResp MessageStatisticResponse
+	if err := m.do(APIPath_MessageStatistic, nil, nil, &msgResp); err != nil {
+		log.Debugf("%s error: %v", APIPath_MessageStatistic, err)
 	} else {
-		return &site.Status{
-			UserName:            resp.Data.UserName,
-			UserDownloaded:      resp.Data.MemberCount.Downloaded.Value(),
-			UserUploaded:        resp.Data.MemberCount.Uploaded.Value(),
-			TorrentsSeedingCnt:  0,
-			TorrentsLeechingCnt: 0,
-		}, nil
+		status.UnreadMessagesCnt = msgResp.Data.UnMake.Value()
+	}
+	return status, nil
+}
+
+func (m *Site) convertStatus(profile *Profile) *site.Status {
+	status := &site.Status{
+		UserName:       profile.UserName,
+		UserDownloaded: profile.MemberCount.Downloaded.Value(),
+		UserUploaded:   profile.MemberCount.Uploaded.Value(),
+		UserBonus:      profile.MemberCount.Bonus.Value(),
+		UserRatio:      profile.MemberCount.ShareRate.Value(),
+		UserClass:      userClasses[profile.Role.Value()],
+	}
+	if status.UserClass == "" && profile.Role.Value() > 0 {
+		status.UserClass = fmt.Sprintf("role %d", profile.Role.Value())
+	}
+	if profile.MemberStatus.Warned {
+		status.Warnings = append(status.Warnings, "warned")
+	}
+	if profile.MemberStatus.LeechWarn {
+		status.Warnings = append(status.Warnings, "leech warned")
+	}
+	return status
+}
+
+func (m *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	id = strings.TrimPrefix(id, m.GetName()+".")
+	q := make(neturl.Values)
+	q.Add("id", id)
+	var resp TorrentDetailResponse
+	if err := m.do(APIPath_TorrentDetail, q, nil, &resp); err != nil {
+		return nil, fmt.Errorf("%s error: %w", APIPath_TorrentDetail, err)
+	}
+	var filesResp TorrentFilesResponse
+	if err := m.do(APIPath_TorrentFiles, q, nil, &filesResp); err != nil {
+		return nil, fmt.Errorf("%s error: %w", APIPath_TorrentFiles, err)
+	}
+	return m.convertTorrentDetails(&resp.Data, filesResp.Data), nil
+}
+
+func (m *Site) convertTorrentDetails(torrent *TorrentDetail, files []TorrentFile) *site.TorrentDetails {
+	details := &site.TorrentDetails{
+		Id:                fmt.Sprintf("%s.%s", m.Name, torrent.Id),
+		Name:              torrent.Name,
+		Description:       torrent.Description,
+		Size:              torrent.Size.Value(),
+		Time:              torrent.CreateDate.UnixWithDefault(0),
+		DescriptionText:   util.BBCodeToText(torrent.Descr),
+		DescriptionBBCode: torrent.Descr,
+		MediaInfo:         strings.TrimSpace(torrent.MediaInfo),
+	}
+	if !torrent.Anonymous {
+		details.Uploader = torrent.Author
+	}
+	details.ParseExternalIds(torrent.Imdb + "\n" + torrent.Douban + "\n" + torrent.Descr)
+	for _, file := range files {
+		details.Files = append(details.Files, &site.TorrentFile{Path: file.Name, Size: file.Size.Value()})
 	}
+	return details
 }
 
 func (m *Site) PurgeCache() {
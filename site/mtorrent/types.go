@@ -119,17 +119,47 @@ type TorrentSearchResponse struct {
 	Data TorrentList `json:"data"`
 }
 
+type TorrentDetail struct {
+	Torrent
+	Descr     string `json:"descr"` // BBCode
+	MediaInfo string `json:"mediainfo"`
+	Imdb      string `json:"imdb"`   // imdb url
+	Douban    string `json:"douban"` // douban url
+	Author    string `json:"author"` // uploader user id
+	Anonymous bool   `json:"anonymous"`
+}
+
+type TorrentDetailResponse struct {
+	ResponseCode
+	Data TorrentDetail `json:"data"`
+}
+
+type TorrentFile struct {
+	Name string `json:"name"`
+	Size Int64  `json:"size"`
+}
+
+type TorrentFilesResponse struct {
+	ResponseCode
+	Data []TorrentFile `json:"data"`
+}
+
 type Profile struct {
 	Id               string `json:"id"`
 	CreateDate       Time   `json:"createdDate"`
 	LastModifiedDate Time   `json:"lastModifiedDate"`
 	UserName         string `json:"username"`
+	Role             Int64  `json:"role"` // user class id
 	MemberCount      struct {
-		Bonus      float64 `json:"bonus"`
+		Bonus      Float64 `json:"bonus"`
 		Uploaded   Int64   `json:"uploaded"`
 		Downloaded Int64   `json:"downloaded"`
-		ShareRate  float64 `json:"shareRate"`
+		ShareRate  Float64 `json:"shareRate"`
 	} `json:"memberCount"`
+	MemberStatus struct {
+		Warned    bool `json:"warned"`
+		LeechWarn bool `json:"leechWarn"`
+	} `json:"memberStatus"`
 }
 
 type ProfileResponse struct {
@@ -137,6 +167,26 @@ type ProfileResponse struct {
 	Data Profile `json:"data"`
 }
 
+type PeerStatus struct {
+	Seeder  Int64 `json:"seeder"`
+	Leecher Int64 `json:"leecher"`
+}
+
+type PeerStatusResponse struct {
+	ResponseCode
+	Data PeerStatus `json:"data"`
+}
+
+type MessageStatistic struct {
+	Count  Int64 `json:"count"`
+	UnMake Int64 `json:"unMake"` // unread count
+}
+
+type MessageStatisticResponse struct {
+	ResponseCode
+	Data MessageStatistic `json:"data"`
+}
+
 type errorGetter interface {
 	GetError() error
 }
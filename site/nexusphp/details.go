@@ -0,0 +1,98 @@
+package nexusphp
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+var (
+	detailsSubtitleLabels = []string{"副标题", "副標題", "Small Description", "Subtitle"}
+	detailsSizeRegexp     = regexp.MustCompile(`(大小|Size)\s*[：:]\s*(?P<size>[\d.,]+\s*[KMGTPE]i?B)`)
+	// mediainfo text in description, e.g. "Complete name : ..." or "General\nUnique ID : ...".
+	mediaInfoRegexp = regexp.MustCompile(`(?i)(Complete name|Unique ID|Format/Info)\s*:`)
+)
+
+// Parse torrent details page (details.php) and file list (viewfilelist.php) dom of NexusPHP site.
+// filesDoc can be nil.
+func parseTorrentDetails(doc *goquery.Document, filesDoc *goquery.Document, location *time.Location,
+) *site.TorrentDetails {
+	details := &site.TorrentDetails{}
+	details.Name = util.DomSelectorText(doc.Selection, "h1#top@text")
+	if details.Name == "" {
+		details.Name = util.DomSanitizedText(doc.Find("h1#top"))
+	}
+	descriptionEl := doc.Find("#kdescr")
+	doc.Find("td.rowhead").Each(func(i int, el *goquery.Selection) {
+		label := strings.TrimSuffix(util.DomSanitizedText(el), ":")
+		value := el.Next()
+		switch {
+		case details.Description == "" && containsFold(detailsSubtitleLabels, label):
+			details.Description = util.DomSanitizedText(value)
+		case details.Size == 0:
+			if m := detailsSizeRegexp.FindStringSubmatch(value.Text()); m != nil {
+				details.Size, _ = util.RAMInBytes(strings.ReplaceAll(strings.ReplaceAll(
+					m[detailsSizeRegexp.SubexpIndex("size")], " ", ""), ",", ""))
+			}
+		}
+		if details.Uploader == "" {
+			if uploaderEl := value.Find(`a[href*="userdetails.php?id="]`); uploaderEl.Length() > 0 {
+				details.Uploader = util.DomSanitizedText(uploaderEl)
+				if timeEl := value.Find("span[title]"); timeEl.Length() > 0 {
+					details.Time, _ = util.ParseTime(timeEl.First().AttrOr("title", ""), location)
+				}
+			}
+		}
+	})
+	if mediaInfoEl := doc.Find(".nexus-media-info-raw"); mediaInfoEl.Length() > 0 {
+		details.MediaInfo = strings.TrimSpace(mediaInfoEl.First().Text())
+	} else {
+		// mediainfo is usually put into a [quote] block of description
+		descriptionEl.Find("fieldset,blockquote,pre,.codemain").EachWithBreak(func(i int, el *goquery.Selection) bool {
+			text := util.HtmlToBBCode(el)
+			if mediaInfoRegexp.MatchString(text) {
+				details.MediaInfo = util.BBCodeToText(text)
+				return false
+			}
+			return true
+		})
+	}
+	details.DescriptionBBCode = util.HtmlToBBCode(descriptionEl)
+	details.DescriptionText = util.BBCodeToText(details.DescriptionBBCode)
+	// imdb / douban links may also be in separate rows (e.g. "IMDb链接") outside of description,
+	// but still in the details table. Links in other parts of page (e.g. other torrents) are ignored.
+	detailsEl := doc.Find("td.rowhead").First().Closest("table")
+	if detailsEl.Length() == 0 {
+		detailsEl = descriptionEl
+	}
+	if html, err := detailsEl.Html(); err == nil {
+		details.ParseExternalIds(html)
+	}
+	if filesDoc != nil {
+		filesDoc.Find("tr").Each(func(i int, el *goquery.Selection) {
+			tds := el.Children().Filter("td")
+			if tds.Length() != 2 || tds.First().HasClass("colhead") {
+				return
+			}
+			size, err := util.ExtractSizeStr(tds.Eq(1).Text())
+			if err != nil {
+				return
+			}
+			details.Files = append(details.Files, &site.TorrentFile{
+				Path: strings.TrimSpace(tds.Eq(0).Text()),
+				Size: size,
+			})
+		})
+	}
+	if details.Size == 0 {
+		for _, file := range details.Files {
+			details.Size += file.Size
+		}
+	}
+	return details
+}
@@ -0,0 +1,57 @@
+package nexusphp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const detailsPage = `<html><body>
+<div id="nav">推荐: <a href="https://www.imdb.com/title/tt7777777/">Other</a></div>
+<h1 id="top">Movie.2024.1080p.BluRay.x264&nbsp;&nbsp;&nbsp;<b>[<font class="free">免费</font>]</b></h1>
+<table>
+<tr><td class="rowhead">下载</td><td class="rowfollow">
+<a href="download.php?id=123">Movie.2024.torrent</a> 由 <a href="userdetails.php?id=9"><b>uploader1</b></a>
+发布于 <span title="2024-01-02 03:04:05">1年前</span></td></tr>
+<tr><td class="rowhead">副标题</td><td class="rowfollow">电影 2024</td></tr>
+<tr><td class="rowhead">基本信息</td><td class="rowfollow"><b>大小：</b>1.50 GB&nbsp;&nbsp;&nbsp;<b>类型:</b> Movie</td></tr>
+<tr><td class="rowhead">简介</td><td class="rowfollow"><div id="kdescr">
+<img src="https://img.example.com/poster.jpg" /><br />
+<b>Title</b>: Movie<br />
+IMDb: <a href="https://www.imdb.com/title/tt1234567/">link</a><br />
+<a href="https://movie.douban.com/subject/7654321/">douban</a><br />
+<fieldset><legend>引用</legend>General<br />Unique ID : 123<br />Complete name : Movie.mkv</fieldset>
+</div></td></tr>
+</table></body></html>`
+
+const filesPage = `<table><tr><td class="colhead">路径</td><td class="colhead">大小</td></tr>
+<tr><td class="rowfollow">Movie/Movie.mkv</td><td class="rowfollow">1.50 GB</td></tr>
+<tr><td class="rowfollow">Movie/Movie.nfo</td><td class="rowfollow">1.00 KB</td></tr></table>`
+
+func TestParseTorrentDetails(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(detailsPage))
+	filesDoc, _ := goquery.NewDocumentFromReader(strings.NewReader(filesPage))
+	details := parseTorrentDetails(doc, filesDoc, time.UTC)
+	if details.Name != "Movie.2024.1080p.BluRay.x264" || details.Description != "电影 2024" ||
+		details.Size != 1610612736 || details.Uploader != "uploader1" || details.Time != 1704164645 {
+		t.Errorf("unexpected details: %+v", details)
+	}
+	if details.ImdbId != "tt1234567" || details.DoubanId != "7654321" || details.TmdbId != "" {
+		t.Errorf("unexpected external ids: %+v", details)
+	}
+	if !strings.Contains(details.DescriptionBBCode, "[img]https://img.example.com/poster.jpg[/img]") ||
+		!strings.Contains(details.DescriptionBBCode, "[b]Title[/b]: Movie") {
+		t.Errorf("unexpected bbcode: %s", details.DescriptionBBCode)
+	}
+	if strings.Contains(details.DescriptionText, "[") {
+		t.Errorf("unexpected text: %s", details.DescriptionText)
+	}
+	if details.MediaInfo != "General\nUnique ID : 123\nComplete name : Movie.mkv" {
+		t.Errorf("unexpected mediainfo: %q", details.MediaInfo)
+	}
+	if len(details.Files) != 2 || details.Files[0].Path != "Movie/Movie.mkv" || details.Files[1].Size != 1024 {
+		t.Errorf("unexpected files: %v", details.Files)
+	}
+}
@@ -0,0 +1,78 @@
+package nexusphp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/util/crypto"
+)
+
+const totpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// A fake nexusphp login server.
+func newLoginServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login.php", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "PHPSESSID", Value: "sess"})
+		w.Write([]byte(`<html><body><form method="post" action="takelogin.php">
+<input type="hidden" name="returnto" value="index.php" />
+<input type="text" name="username" /><input type="password" name="password" />
+<input type="text" name="two_step_code" /><input type="submit" value="登录" /></form></body></html>`))
+	})
+	mux.HandleFunc("/takelogin.php", func(w http.ResponseWriter, r *http.Request) {
+		code, _ := crypto.Totp(totpSecret, time.Now())
+		if r.Method != http.MethodPost || r.FormValue("returnto") != "index.php" ||
+			r.FormValue("username") != "user" || r.FormValue("password") != "pass" ||
+			r.FormValue("two_step_code") != code {
+			w.Write([]byte(`<html><body><h2>登录失败!</h2><table><tr><td class="text">用户名或密码不正确！</td>` +
+				`</tr></table></body></html>`))
+			return
+		}
+		if cookie, err := r.Cookie("PHPSESSID"); err != nil || cookie.Value != "sess" {
+			t.Errorf("login request does not have session cookie")
+		}
+		http.SetCookie(w, &http.Cookie{Name: "c_secure_uid", Value: "MTIz"})
+		http.SetCookie(w, &http.Cookie{Name: "c_secure_pass", Value: "abc"})
+		http.Redirect(w, r, "/index.php", http.StatusFound)
+	})
+	mux.HandleFunc("/index.php", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("c_secure_pass"); err != nil {
+			http.Redirect(w, r, "/login.php", http.StatusFound)
+			return
+		}
+		w.Write([]byte(`<html><body><a href="userdetails.php?id=123">user</a></body></html>`))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestLogin(t *testing.T) {
+	server := newLoginServer(t)
+	defer server.Close()
+	siteConfig := &config.SiteConfigStruct{
+		Type:       "nexusphp",
+		Url:        server.URL + "/",
+		Username:   "user",
+		Password:   "pass",
+		TotpSecret: totpSecret,
+	}
+	siteInstance, err := NewSite("np", siteConfig, &config.ConfigStruct{})
+	if err != nil {
+		t.Fatalf("failed to create site: %v", err)
+	}
+	cookie, err := siteInstance.Login()
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if cookie != "PHPSESSID=sess; c_secure_uid=MTIz; c_secure_pass=abc" {
+		t.Errorf("unexpected cookie: %s", cookie)
+	}
+
+	siteConfig.Password = "wrong"
+	if _, err = siteInstance.Login(); err == nil || !strings.Contains(err.Error(), "用户名或密码不正确") {
+		t.Errorf("expect login failure, got %v", err)
+	}
+}
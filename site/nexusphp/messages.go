@@ -0,0 +1,76 @@
+package nexusphp
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+const (
+	MESSAGES_INBOX_URL   = "messages.php?action=viewmailbox&box=1"
+	MESSAGES_VIEW_URL    = "messages.php?action=viewmessage&id="
+	MESSAGES_ACTION_URL  = "messages.php"
+	SELECTOR_MESSAGE     = `a[href*="action=viewmessage"]`
+	SELECTOR_UNREAD_ICON = `img.unreadpm,img[alt="Unread"],img[alt="未读"],img[alt="未讀"]`
+)
+
+var messageIdRegexp = regexp.MustCompile(`[?&]id=(?P<id>\d+)`)
+
+// Parse messages list of inbox (messages.php?action=viewmailbox) page.
+func parseMessages(doc *goquery.Document, location *time.Location) []*site.Message {
+	messages := []*site.Message{}
+	doc.Find(SELECTOR_MESSAGE).Each(func(i int, el *goquery.Selection) {
+		m := messageIdRegexp.FindStringSubmatch(el.AttrOr("href", ""))
+		if m == nil {
+			return
+		}
+		message := &site.Message{
+			Id:    m[messageIdRegexp.SubexpIndex("id")],
+			Title: util.DomSanitizedText(el),
+		}
+		row := el.Closest("tr")
+		// cells: status icon, subject, sender, date, checkbox
+		cells := row.Children().Filter("td")
+		message.Unread = row.Find(SELECTOR_UNREAD_ICON).Length() > 0
+		subjectIndex := cells.IndexOfSelection(el.Closest("td"))
+		if subjectIndex >= 0 && cells.Length() > subjectIndex+2 {
+			message.Sender = util.DomSanitizedText(cells.Eq(subjectIndex + 1))
+			timeCell := cells.Eq(subjectIndex + 2)
+			// prefer absolute time in title over relative time text like "1天前"
+			if t, err := util.ParseTime(timeCell.Find("*[title]").AttrOr("title", ""), location); err == nil {
+				message.Time = t
+			} else {
+				message.Time = util.DomTime(timeCell, location)
+			}
+		}
+		messages = append(messages, message)
+	})
+	return messages
+}
+
+// Parse message body from message (messages.php?action=viewmessage) page. Return plain text.
+func parseMessageBody(doc *goquery.Document) string {
+	body := ""
+	doc.Find(`td[colspan="2"]`).EachWithBreak(func(i int, el *goquery.Selection) bool {
+		// the last row contains reply / delete links
+		if el.Find(`a[href*="sendmessage.php"],a[href*="deletemessage"]`).Length() > 0 {
+			return true
+		}
+		if text := util.BBCodeToText(util.HtmlToBBCode(el)); text != "" {
+			body = text
+			return false
+		}
+		return true
+	})
+	return body
+}
+
+// Parse message title from message page.
+func parseMessageTitle(doc *goquery.Document) string {
+	return strings.TrimSpace(util.DomSanitizedText(doc.Find("h1")))
+}
@@ -0,0 +1,53 @@
+package nexusphp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const inboxPage = `<html><body><form action="messages.php" method="post"><table>
+<tr><td class="colhead">状态</td><td class="colhead">主题</td><td class="colhead">发信人</td>
+<td class="colhead">日期</td><td class="colhead">操作</td></tr>
+<tr><td class="rowfollow"><img class="unreadpm" src="pic/trans.gif" alt="未读" /></td>
+<td class="rowfollow"><a href="messages.php?action=viewmessage&amp;id=123">H&amp;R 警告</a></td>
+<td class="rowfollow">系统</td>
+<td class="rowfollow"><span title="2024-01-02 03:04:05">1天前</span></td>
+<td class="rowfollow"><input type="checkbox" name="messages[]" value="123" /></td></tr>
+<tr><td class="rowfollow"><img class="readpm" src="pic/trans.gif" alt="已读" /></td>
+<td class="rowfollow"><a href="messages.php?action=viewmessage&amp;id=100">Hello</a></td>
+<td class="rowfollow"><a href="userdetails.php?id=9">user1</a></td>
+<td class="rowfollow"><span title="2024-01-01 00:00:00">2天前</span></td>
+<td class="rowfollow"><input type="checkbox" name="messages[]" value="100" /></td></tr>
+</table></form></body></html>`
+
+const messagePage = `<html><body><table><tr><td class="embedded"><h1>H&amp;R 警告</h1></td></tr></table>
+<table><tr><td class="rowhead">发信人: 系统</td><td class="rowhead">2024-01-02 03:04:05</td></tr>
+<tr><td colspan="2">您的种子 <b>Movie</b> 未达到做种要求。<br />请尽快做种。</td></tr>
+<tr><td align="right" colspan="2"><a href="sendmessage.php?receiver=0&amp;replyto=123">回复</a>
+<a href="messages.php?action=deletemessage&amp;id=123">删除</a></td></tr></table></body></html>`
+
+func TestParseMessages(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(inboxPage))
+	messages := parseMessages(doc, time.UTC)
+	if len(messages) != 2 {
+		t.Fatalf("expect 2 messages, got %d", len(messages))
+	}
+	if m := messages[0]; m.Id != "123" || m.Title != "H&R 警告" || !m.Unread || m.Sender != "系统" ||
+		m.Time != 1704164645 {
+		t.Errorf("unexpected message: %+v", m)
+	}
+	if m := messages[1]; m.Id != "100" || m.Unread || m.Sender != "user1" {
+		t.Errorf("unexpected message: %+v", m)
+	}
+
+	doc, _ = goquery.NewDocumentFromReader(strings.NewReader(messagePage))
+	if title := parseMessageTitle(doc); title != "H&R 警告" {
+		t.Errorf("unexpected title: %s", title)
+	}
+	if body := parseMessageBody(doc); body != "您的种子 Movie 未达到做种要求。\n请尽快做种。" {
+		t.Errorf("unexpected body: %q", body)
+	}
+}
@@ -2,6 +2,7 @@ package nexusphp
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
@@ -33,6 +34,8 @@ type Site struct {
 	digitHashPasskey     string
 	digitHashErr         error
 	torrentsParserOption *TorrentsParserOption
+	userId               string
+	userDetailsDatatime  int64
 }
 
 // Nexusphp default upload torrent form data:
@@ -104,6 +107,8 @@ func (npclient *Site) PublishTorrent(contents []byte, metadata url.Values) (id s
 
 const (
 	DEFAULT_TORRENTS_URL = "torrents.php"
+	DEFAULT_CHECKIN_URL  = "attendance.php"
+	DEFAULT_LOGIN_URL    = "login.php"
 )
 
 var sortFields = map[string]string{
@@ -125,6 +130,7 @@ func (npclient *Site) PurgeCache() {
 	npclient.extraTorrents = nil
 	npclient.siteStatus = nil
 	npclient.cuhash = ""
+	npclient.userDetailsDatatime = 0
 }
 
 func (npclient *Site) GetName() string {
@@ -161,7 +167,7 @@ func (npclient *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.To
 		return nil, fmt.Errorf("failed to parse site page dom: %w", err)
 	}
 	if strings.Contains(res.Request.Url, "/login.php") {
-		return nil, fmt.Errorf("not logined (cookie may has expired)")
+		return nil, site.ErrNotLoggedIn
 	}
 	return npclient.parseTorrentsFromDoc(doc, util.Now())
 }
@@ -229,6 +235,32 @@ func (npclient *Site) DownloadTorrentById(id string) ([]byte, string, error) {
 	return site.DownloadTorrentByUrl(npclient, npclient.HttpClient, torrentUrl, id)
 }
 
+func (npclient *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	id = strings.TrimPrefix(id, npclient.GetName()+".")
+	detailsUrl := npclient.SiteConfig.ParseSiteUrl("details.php?id="+id+"&hit=1", false)
+	doc, res, err := util.GetUrlDocWithAzuretls(detailsUrl, npclient.HttpClient,
+		npclient.SiteConfig.Cookie, site.GetUa(npclient), npclient.GetDefaultHttpHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get torrent detail page: %w", err)
+	}
+	if strings.Contains(res.Request.Url, "/login.php") {
+		return nil, site.ErrNotLoggedIn
+	}
+	if doc.Find("#kdescr").Length() == 0 && doc.Find("h1#top").Length() == 0 {
+		return nil, fmt.Errorf("torrent not found or invalid detail page")
+	}
+	filesUrl := npclient.SiteConfig.ParseSiteUrl("viewfilelist.php?id="+id, false)
+	filesDoc, _, err := util.GetUrlDocWithAzuretls(filesUrl, npclient.HttpClient,
+		npclient.SiteConfig.Cookie, site.GetUa(npclient), npclient.GetDefaultHttpHeaders())
+	if err != nil {
+		log.Warnf("Failed to get torrent file list: %v", err)
+		filesDoc = nil
+	}
+	details := parseTorrentDetails(doc, filesDoc, npclient.Location)
+	details.Id = npclient.GetName() + "." + id
+	return details, nil
+}
+
 func (npclient *Site) getDigithash(id string) (string, error) {
 	detailsUrl := npclient.SiteConfig.ParseSiteUrl(fmt.Sprintf("t/%s/", id), false)
 	doc, _, err := util.GetUrlDocWithAzuretls(detailsUrl, npclient.HttpClient,
@@ -260,14 +292,141 @@ func (npclient *Site) getDigithash(id string) (string, error) {
 	return passkey, nil
 }
 
-func (npclient *Site) GetStatus() (*site.Status, error) {
+func (npclient *Site) GetMessages(unreadOnly bool) ([]*site.Message, error) {
+	doc, err := npclient.getPageDoc(MESSAGES_INBOX_URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inbox page: %w", err)
+	}
+	messages := parseMessages(doc, npclient.Location)
+	if unreadOnly {
+		messages = util.Filter(messages, func(message *site.Message) bool {
+			return message.Unread
+		})
+	}
+	return messages, nil
+}
+
+func (npclient *Site) GetMessage(id string) (*site.Message, error) {
+	doc, err := npclient.getPageDoc(MESSAGES_VIEW_URL + id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message page: %w", err)
+	}
+	return &site.Message{
+		Id:    id,
+		Title: parseMessageTitle(doc),
+		Body:  parseMessageBody(doc),
+	}, nil
+}
+
+func (npclient *Site) MarkMessagesRead(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	data := url.Values{}
+	data.Set("action", "moveordel")
+	data.Set("box", "1")
+	data.Set("markread", "1")
+	for _, id := range ids {
+		data.Add("messages[]", id)
+	}
+	headers := append([][]string{{"Content-Type", "application/x-www-form-urlencoded"}},
+		npclient.GetDefaultHttpHeaders()...)
+	req := &azuretls.Request{
+		Method:         http.MethodPost,
+		Url:            npclient.SiteConfig.ParseSiteUrl(MESSAGES_ACTION_URL, false),
+		Body:           data.Encode(),
+		NoCookie:       true, // disable azuretls internal cookie jar
+		OrderedHeaders: util.GetHttpReqHeaders(headers, npclient.SiteConfig.Cookie, site.GetUa(npclient)),
+	}
+	util.LogAzureHttpRequest(req)
+	res, err := npclient.HttpClient.Do(req)
+	util.LogAzureHttpResponse(res, err)
+	if err != nil {
+		return fmt.Errorf("failed to mark messages read: %w", err)
+	}
+	if res.StatusCode != 200 && res.StatusCode != 302 {
+		return fmt.Errorf("failed to mark messages read: status=%d", res.StatusCode)
+	}
+	if strings.Contains(res.Request.Url, "/login.php") {
+		return site.ErrNotLoggedIn
+	}
+	return nil
+}
+
+// Get dom of a site page, check login status.
+func (npclient *Site) getPageDoc(pageUrl string) (*goquery.Document, error) {
+	doc, res, err := util.GetUrlDocWithAzuretls(npclient.SiteConfig.ParseSiteUrl(pageUrl, false),
+		npclient.HttpClient, npclient.SiteConfig.Cookie, site.GetUa(npclient), npclient.GetDefaultHttpHeaders())
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(res.Request.Url, "/login.php") {
+		return nil, site.ErrNotLoggedIn
+	}
+	return doc, nil
+}
+
+func (npclient *Site) CheckIn() (*site.CheckInResult, error) {
+	checkInUrl := npclient.SiteConfig.CheckInUrl
+	if checkInUrl == "" {
+		checkInUrl = DEFAULT_CHECKIN_URL
+	}
+	return site.CheckInByUrl(npclient, npclient.HttpClient, checkInUrl, npclient.SiteConfig.CheckInMethod,
+		npclient.SiteConfig.CheckInBody)
+}
+
+// Nexusphp login: POST takelogin.php with username, password and optional 2FA "two_step_code".
+// Sites that require image captcha in login form are not supported.
+// See: https://github.com/xiaomlove/nexusphp/blob/php8/public/takelogin.php .
+func (npclient *Site) Login() (cookie string, err error) {
+	return site.FormLogin(npclient, npclient.HttpClient, &site.LoginOption{
+		LoginUrl:  DEFAULT_LOGIN_URL,
+		TotpField: "two_step_code",
+	})
+}
+
+func (npclient *Site) GetStatus(full bool) (*site.Status, error) {
 	err := npclient.sync()
+	if site.ReloginIfNeeded(npclient, err) {
+		err = npclient.sync()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch site data: %w", err)
 	}
+	if full {
+		npclient.syncUserDetails()
+	}
 	return npclient.siteStatus, nil
 }
 
+// Fetch userdetails.php & mybonus.php pages to get user class, seeding size and bonus.
+// These infos are optional, errors are only logged.
+func (npclient *Site) syncUserDetails() {
+	if npclient.userDetailsDatatime > 0 || npclient.siteStatus == nil {
+		return
+	}
+	npclient.userDetailsDatatime = util.Now()
+	if npclient.userId != "" {
+		doc, _, err := util.GetUrlDocWithAzuretls(
+			npclient.SiteConfig.ParseSiteUrl("userdetails.php?id="+npclient.userId, false), npclient.HttpClient,
+			npclient.SiteConfig.Cookie, site.GetUa(npclient), npclient.GetDefaultHttpHeaders())
+		if err != nil {
+			log.Debugf("failed to get userdetails page: %v", err)
+		} else {
+			parseUserDetails(npclient.siteStatus, doc.Selection)
+		}
+	}
+	if npclient.siteStatus.UserBonus == 0 || npclient.siteStatus.TorrentsSeedingSize == 0 {
+		doc, _, err := util.GetUrlDocWithAzuretls(npclient.SiteConfig.ParseSiteUrl("mybonus.php", false),
+			npclient.HttpClient, npclient.SiteConfig.Cookie, site.GetUa(npclient), npclient.GetDefaultHttpHeaders())
+		if err != nil {
+			log.Debugf("failed to get mybonus page: %v", err)
+		} else {
+			parseMyBonus(npclient.siteStatus, doc.Selection)
+		}
+	}
+}
+
 func (npclient *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
 	latestTorrents := []*site.Torrent{}
 	err := npclient.sync()
@@ -347,7 +506,7 @@ func (npclient *Site) GetAllTorrents(sort string, desc bool, pageMarker string,
 		return
 	}
 	if strings.Contains(res.Request.Url, "/login.php") {
-		return nil, "", fmt.Errorf("not logined (cookie may has expired)")
+		return nil, "", site.ErrNotLoggedIn
 	}
 
 	lastPage := int64(0)
@@ -376,7 +535,7 @@ labelLastPage:
 			return
 		}
 		if strings.Contains(res.Request.Url, "/login.php") {
-			err = fmt.Errorf("not logined (cookie may has expired)")
+			err = site.ErrNotLoggedIn
 			return
 		}
 	}
@@ -432,7 +591,7 @@ func (npclient *Site) sync() error {
 		return fmt.Errorf("failed to get site page dom: %w", err)
 	}
 	if strings.Contains(res.Request.Url, "/login.php") {
-		return fmt.Errorf("not logined (cookie may has expired)")
+		return site.ErrNotLoggedIn
 	}
 	html := doc.Find("html")
 	npclient.datatime = util.Now()
@@ -488,6 +647,8 @@ func (npclient *Site) sync() error {
 		siteStatus.UserName = doc.Find(`*[href*="userdetails.php?"]`).First().Text()
 	}
 	siteStatus.UserName = strings.TrimSpace(siteStatus.UserName)
+	parseUserInfo(siteStatus, infoTr, infoTxt)
+	npclient.userId = parseUserId(infoTr)
 
 	// possibly parsing error or some problem
 	if !siteStatus.IsOk() {
@@ -519,7 +680,7 @@ func (npclient *Site) syncExtra() error {
 			continue
 		}
 		if strings.Contains(res.Request.Url, "/login.php") {
-			return fmt.Errorf("not logined (cookie may has expired)")
+			return site.ErrNotLoggedIn
 		}
 		torrents, err := npclient.parseTorrentsFromDoc(doc, util.Now())
 		if err != nil {
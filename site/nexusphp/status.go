@@ -0,0 +1,134 @@
+package nexusphp
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+var (
+	userInfoRatioRegexp  = regexp.MustCompile(`(?i)(分享率|分享比率|Ratio)\s*[：:]?\s*(?P<s>∞|Inf\.?|[\d,]+(\.\d+)?)`)
+	userInfoBonusRegexp  = regexp.MustCompile(`(?i)(魔力值|魔力|积分|積分|Bonus|Karma)\s*(\[[^\]]*\])?\s*[：:]\s*(?P<s>[\d,]+(\.\d+)?)`)
+	userInfoInviteRegexp = regexp.MustCompile(`(?i)(邀请|邀請|Invites?)\s*(\[[^\]]*\])?\s*[：:]\s*(?P<s>\d+)`)
+	userInfoHnrRegexp    = regexp.MustCompile(`(?i)(H&R|HnR)\s*[：:]?\s*\[?\s*(?P<s>\d+)`)
+	// "15 (2 新)", "15 (2 New)"
+	userInfoMessagesRegexp = regexp.MustCompile(`(?i)\d+\s*\(\s*(?P<s>\d+)\s*(新|New)\s*\)`)
+	// "你有 2 条新短讯！", "You have 2 new messages"
+	userInfoNewMessagesRegexp = regexp.MustCompile(`(?i)(?P<s>\d+)\s*(条新短讯|條新短訊|new messages?)`)
+	userSeedingSizeRegexp     = regexp.MustCompile(
+		`(做种|做種|Seeding)\S*?\s*(体积|體積|大小|Size|总体积|總體積)\s*[：:为為]?\s*(?P<s>[\d,.]+\s*[KMGTPE]i?B)`)
+	userClassLabels       = []string{"等级", "等級", "Class", "用户等级", "用戶等級"}
+	userSeedingSizeLabels = []string{"做种体积", "做種體積", "做种大小", "做種大小", "Seeding Size"}
+	userDetailsLinkRegexp = regexp.MustCompile(`userdetails\.php\?id=(?P<id>\d+)`)
+	// warning => selector of warning icon
+	userWarnings = [][]string{{"warned", "img.warned"}, {"leech warned", "img.leechwarned"}}
+)
+
+// Parse extra user status info (ratio, bonus, invites, HnR, seeding / leeching count,
+// unread messages and warnings) from the user info block of NexusPHP page.
+// infoText is the (single line) text of info block.
+func parseUserInfo(siteStatus *site.Status, infoEl *goquery.Selection, infoText string) {
+	if m := userInfoRatioRegexp.FindStringSubmatch(infoText); m != nil {
+		siteStatus.UserRatio = parseRatio(m[userInfoRatioRegexp.SubexpIndex("s")])
+	}
+	if m := userInfoBonusRegexp.FindStringSubmatch(infoText); m != nil {
+		siteStatus.UserBonus = parseNumber(m[userInfoBonusRegexp.SubexpIndex("s")])
+	}
+	if m := userInfoInviteRegexp.FindStringSubmatch(infoText); m != nil {
+		siteStatus.InviteCnt = util.ParseInt(m[userInfoInviteRegexp.SubexpIndex("s")])
+	}
+	if m := userInfoHnrRegexp.FindStringSubmatch(infoText); m != nil {
+		siteStatus.HnrCnt = util.ParseInt(m[userInfoHnrRegexp.SubexpIndex("s")])
+	}
+	if infoEl.Find("img.arrowup").Length() > 0 {
+		siteStatus.TorrentsSeedingCnt = util.ParseInt(strings.TrimSpace(
+			util.DomSelectorText(infoEl, "img.arrowup@after")))
+	}
+	if infoEl.Find("img.arrowdown").Length() > 0 {
+		siteStatus.TorrentsLeechingCnt = util.ParseInt(strings.TrimSpace(
+			util.DomSelectorText(infoEl, "img.arrowdown@after")))
+	}
+	if m := userInfoMessagesRegexp.FindStringSubmatch(infoText); m != nil {
+		siteStatus.UnreadMessagesCnt = util.ParseInt(m[userInfoMessagesRegexp.SubexpIndex("s")])
+	} else if m := userInfoNewMessagesRegexp.FindStringSubmatch(infoText); m != nil {
+		siteStatus.UnreadMessagesCnt = util.ParseInt(m[userInfoNewMessagesRegexp.SubexpIndex("s")])
+	}
+	for _, warning := range userWarnings {
+		if infoEl.Find(warning[1]).Length() > 0 && !slices.Contains(siteStatus.Warnings, warning[0]) {
+			siteStatus.Warnings = append(siteStatus.Warnings, warning[0])
+		}
+	}
+}
+
+// Parse user class & seeding size from userdetails.php page.
+func parseUserDetails(siteStatus *site.Status, doc *goquery.Selection) {
+	doc.Find("td.rowhead").Each(func(i int, el *goquery.Selection) {
+		label := strings.TrimSuffix(util.DomSanitizedText(el), ":")
+		value := el.Next()
+		if siteStatus.UserClass == "" && containsFold(userClassLabels, label) {
+			if img := value.Find("img[title],img[alt]"); img.Length() > 0 {
+				siteStatus.UserClass = img.First().AttrOr("title", img.First().AttrOr("alt", ""))
+			}
+			if siteStatus.UserClass == "" {
+				siteStatus.UserClass = util.DomSanitizedText(value)
+			}
+		} else if siteStatus.TorrentsSeedingSize == 0 && containsFold(userSeedingSizeLabels, label) {
+			siteStatus.TorrentsSeedingSize, _ = util.ExtractSizeStr(value.Text())
+		}
+	})
+	if siteStatus.TorrentsSeedingSize == 0 {
+		parseSeedingSize(siteStatus, doc.Text())
+	}
+}
+
+// Parse bonus & seeding size from mybonus.php page.
+func parseMyBonus(siteStatus *site.Status, doc *goquery.Selection) {
+	text := strings.Join(strings.Fields(doc.Text()), " ")
+	if siteStatus.UserBonus == 0 {
+		if m := userInfoBonusRegexp.FindStringSubmatch(text); m != nil {
+			siteStatus.UserBonus = parseNumber(m[userInfoBonusRegexp.SubexpIndex("s")])
+		}
+	}
+	if siteStatus.TorrentsSeedingSize == 0 {
+		parseSeedingSize(siteStatus, text)
+	}
+}
+
+func parseSeedingSize(siteStatus *site.Status, text string) {
+	if m := userSeedingSizeRegexp.FindStringSubmatch(text); m != nil {
+		siteStatus.TorrentsSeedingSize, _ = util.ExtractSizeStr(m[userSeedingSizeRegexp.SubexpIndex("s")])
+	}
+}
+
+// Return user id from the user info block.
+func parseUserId(infoEl *goquery.Selection) string {
+	if m := userDetailsLinkRegexp.FindStringSubmatch(
+		infoEl.Find(`a[href*="userdetails.php?id="]`).First().AttrOr("href", "")); m != nil {
+		return m[userDetailsLinkRegexp.SubexpIndex("id")]
+	}
+	return ""
+}
+
+func parseRatio(str string) float64 {
+	if str == "∞" || strings.HasPrefix(strings.ToLower(str), "inf") {
+		return -1
+	}
+	return parseNumber(str)
+}
+
+func parseNumber(str string) float64 {
+	value, _ := strconv.ParseFloat(strings.ReplaceAll(str, ",", ""), 64)
+	return value
+}
+
+func containsFold(list []string, str string) bool {
+	return slices.ContainsFunc(list, func(item string) bool {
+		return strings.EqualFold(item, str)
+	})
+}
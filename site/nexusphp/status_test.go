@@ -0,0 +1,47 @@
+package nexusphp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/site"
+)
+
+const infoBlock = `<html><body><table id="info_block"><tr><td><span class="medium">欢迎回来,
+<a href="userdetails.php?id=123" class="User_Name"><b>user1</b></a><img class="warned" src="pic/trans.gif" alt="Warned" />
+[<a href="logout.php">退出</a>] 魔力值 [<a href="mybonus.php">使用</a>]: 12,345.6
+邀请 [<a href="invite.php?id=123">发送</a>]: 2 H&amp;R: [<a href="myhr.php">1/3</a>]<br/>
+分享率： 3.456 上传量： 1.234 TB 下载量： 356.78 GB 当前活动：
+<img class="arrowup" alt="Torrents seeding" src="pic/trans.gif" />23
+<img class="arrowdown" alt="Torrents leeching" src="pic/trans.gif" />1</span></td>
+<td><a href="messages.php"><img class="inbox" src="pic/trans.gif" alt="inbox" /></a> 15 (2 新)</td>
+</tr></table></body></html>`
+
+const userDetailsPage = `<table>
+<tr><td class="rowhead">等级</td><td class="rowfollow"><img alt="Power User" title="Power User" src="pic/power.gif" /></td></tr>
+<tr><td class="rowhead">做种体积</td><td class="rowfollow">1.50 TB</td></tr>
+</table>`
+
+func TestParseUserInfo(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(infoBlock))
+	infoEl := doc.Find("#info_block")
+	infoText := strings.ReplaceAll(infoEl.Text(), "\n", " ")
+	status := &site.Status{}
+	parseUserInfo(status, infoEl, infoText)
+	if status.UserBonus != 12345.6 || status.UserRatio != 3.456 || status.InviteCnt != 2 || status.HnrCnt != 1 ||
+		status.TorrentsSeedingCnt != 23 || status.TorrentsLeechingCnt != 1 || status.UnreadMessagesCnt != 2 ||
+		len(status.Warnings) != 1 || status.Warnings[0] != "warned" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if id := parseUserId(infoEl); id != "123" {
+		t.Errorf("unexpected user id: %s", id)
+	}
+
+	doc, _ = goquery.NewDocumentFromReader(strings.NewReader(userDetailsPage))
+	parseUserDetails(status, doc.Selection)
+	if status.UserClass != "Power User" || status.TorrentsSeedingSize != 1649267441664 {
+		t.Errorf("unexpected user details: %+v", status)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"mime"
 	"net/url"
 	"os"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -50,12 +51,52 @@ type Torrent struct {
 	Tags               []string // labels, e.g. category and other meta infos.
 }
 
+type TorrentFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// Detailed info of a torrent in site, fetched from torrent details page or api.
+type TorrentDetails struct {
+	Id                string         `json:"id"` // sitename.id
+	Name              string         `json:"name"`
+	Description       string         `json:"description"` // subtitle (short description)
+	Size              int64          `json:"size"`
+	Time              int64          `json:"time"` // upload timestamp
+	Uploader          string         `json:"uploader"`
+	DescriptionText   string         `json:"descriptionText"`   // full description in plain text
+	DescriptionBBCode string         `json:"descriptionBBCode"` // full description in BBCode
+	MediaInfo         string         `json:"mediaInfo"`
+	ImdbId            string         `json:"imdbId"`   // e.g. "tt1234567"
+	DoubanId          string         `json:"doubanId"` // e.g. "1234567"
+	TmdbId            string         `json:"tmdbId"`   // e.g. "movie/1234" or "tv/1234"
+	Files             []*TorrentFile `json:"files"`
+}
+
+// Site message (PM / notice) in user's inbox.
+type Message struct {
+	Id     string `json:"id"`
+	Title  string `json:"title"`
+	Sender string `json:"sender"` // empty or "System" for system notices
+	Time   int64  `json:"time"`
+	Unread bool   `json:"unread"`
+	Body   string `json:"body"` // plain text. Empty if body is not fetched yet
+}
+
 type Status struct {
-	UserName            string
-	UserDownloaded      int64
-	UserUploaded        int64
-	TorrentsSeedingCnt  int64
-	TorrentsLeechingCnt int64
+	UserName            string   `json:"userName"`
+	UserDownloaded      int64    `json:"userDownloaded"`
+	UserUploaded        int64    `json:"userUploaded"`
+	UserBonus           float64  `json:"userBonus"` // 魔力值 / 积分 / bonus points
+	UserRatio           float64  `json:"userRatio"` // share ratio. -1 == infinite (nothing downloaded)
+	UserClass           string   `json:"userClass"` // 用户等级, e.g. "Power User"
+	TorrentsSeedingCnt  int64    `json:"torrentsSeedingCnt"`
+	TorrentsLeechingCnt int64    `json:"torrentsLeechingCnt"`
+	TorrentsSeedingSize int64    `json:"torrentsSeedingSize"`
+	HnrCnt              int64    `json:"hnrCnt"`             // count of active (unresolved) HnR torrents
+	InviteCnt           int64    `json:"inviteCnt"`          // count of available invites
+	UnreadMessagesCnt   int64    `json:"unreadMessagesCnt"`  // count of unread (inbox) messages
+	Warnings            []string `json:"warnings,omitempty"` // account warnings, e.g. "warned", "leech warned"
 }
 
 type Site interface {
@@ -78,7 +119,20 @@ type Site interface {
 	// Some keys in metadata should be handled specially:
 	// If metadata contains "_dryrun", use dry run mode;
 	PublishTorrent(contents []byte, metadata url.Values) (id string, err error)
-	GetStatus() (*Status, error)
+	// full: also fetch the optional user infos (e.g. class, bonus, seeding size) that require extra requests
+	GetStatus(full bool) (*Status, error)
+	// get torrent details by torrent id (e.g. "12345")
+	GetTorrentDetails(id string) (*TorrentDetails, error)
+	// daily check in (sign in, attendance) of site
+	CheckIn() (*CheckInResult, error)
+	// list messages of inbox (the first page, latest first). Message bodies are not fetched
+	GetMessages(unreadOnly bool) ([]*Message, error)
+	// get message (with body) by id. Some sites (e.g. nexusphp) mark the message as read when it's viewed
+	GetMessage(id string) (*Message, error)
+	MarkMessagesRead(ids []string) error
+	// login site using username & password (and optional TOTP secret) of site config, return the new cookie.
+	// It does not update site config
+	Login() (cookie string, err error)
 	PurgeCache()
 }
 
@@ -93,6 +147,14 @@ type SiteCreator func(*RegInfo) (Site, error)
 var (
 	// Error that indicates the feature is not implemented in current site.
 	ErrUnimplemented = fmt.Errorf("not implemented yet")
+	// Error that indicates the site page requires login, e.g. the cookie has expired.
+	ErrNotLoggedIn = fmt.Errorf("not logined (cookie may has expired)")
+)
+
+var (
+	imdbIdRegexp   = regexp.MustCompile(`imdb\.com/title/(tt\d+)`)
+	doubanIdRegexp = regexp.MustCompile(`(?:movie|book|music)\.douban\.com/subject/(\d+)`)
+	tmdbIdRegexp   = regexp.MustCompile(`themoviedb\.org/(movie|tv)/(\d+)`)
 )
 
 var (
@@ -153,11 +215,98 @@ func (torrent *Torrent) MatchFilter(filter string) bool {
 	return false
 }
 
+// Return share ratio of status. If site does not provide it, calculate it from uploaded / downloaded.
+// Return -1 if nothing downloaded (infinite ratio).
+func (status *Status) Ratio() float64 {
+	if status.UserRatio != 0 {
+		return status.UserRatio
+	}
+	if status.UserDownloaded == 0 {
+		if status.UserUploaded > 0 {
+			return -1
+		}
+		return 0
+	}
+	return float64(status.UserUploaded) / float64(status.UserDownloaded)
+}
+
+// Print all available info of status, one item per line.
+func (status *Status) PrintFull(f io.Writer, indent string) {
+	ratio := status.Ratio()
+	ratioStr := fmt.Sprintf("%.3f", ratio)
+	if ratio < 0 {
+		ratioStr = "∞"
+	}
+	fmt.Fprintf(f, "%sUserName: %s\n", indent, status.UserName)
+	if status.UserClass != "" {
+		fmt.Fprintf(f, "%sClass: %s\n", indent, status.UserClass)
+	}
+	fmt.Fprintf(f, "%sUploaded / Downloaded: %s / %s\n", indent, util.BytesSize(float64(status.UserUploaded)),
+		util.BytesSize(float64(status.UserDownloaded)))
+	fmt.Fprintf(f, "%sRatio: %s\n", indent, ratioStr)
+	fmt.Fprintf(f, "%sBonus: %.1f\n", indent, status.UserBonus)
+	fmt.Fprintf(f, "%sSeeding / Leeching: %d / %d\n", indent, status.TorrentsSeedingCnt, status.TorrentsLeechingCnt)
+	if status.TorrentsSeedingSize > 0 {
+		fmt.Fprintf(f, "%sSeeding size: %s\n", indent, util.BytesSize(float64(status.TorrentsSeedingSize)))
+	}
+	fmt.Fprintf(f, "%sHnR: %d\n", indent, status.HnrCnt)
+	fmt.Fprintf(f, "%sInvites: %d\n", indent, status.InviteCnt)
+	fmt.Fprintf(f, "%sUnread messages: %d\n", indent, status.UnreadMessagesCnt)
+	if len(status.Warnings) > 0 {
+		fmt.Fprintf(f, "%sWarnings: %s\n", indent, strings.Join(status.Warnings, ", "))
+	}
+}
+
 // Check if (seems) as a valid site status
 func (status *Status) IsOk() bool {
 	return status.UserName != "" || status.UserDownloaded > 0 || status.UserUploaded > 0
 }
 
+// Find IMDb / Douban / TMDB links in text and fill corresponding ids of details, if they are not set yet.
+func (details *TorrentDetails) ParseExternalIds(text string) {
+	if details.ImdbId == "" {
+		if m := imdbIdRegexp.FindStringSubmatch(text); m != nil {
+			details.ImdbId = m[1]
+		}
+	}
+	if details.DoubanId == "" {
+		if m := doubanIdRegexp.FindStringSubmatch(text); m != nil {
+			details.DoubanId = m[1]
+		}
+	}
+	if details.TmdbId == "" {
+		if m := tmdbIdRegexp.FindStringSubmatch(text); m != nil {
+			details.TmdbId = m[1] + "/" + m[2]
+		}
+	}
+}
+
+func (details *TorrentDetails) Print(output io.Writer) {
+	fmt.Fprintf(output, "Id: %s\n", details.Id)
+	fmt.Fprintf(output, "Name: %s\n", details.Name)
+	fmt.Fprintf(output, "Description: %s\n", details.Description)
+	fmt.Fprintf(output, "Size: %s (%d)\n", util.BytesSize(float64(details.Size)), details.Size)
+	if details.Time > 0 {
+		fmt.Fprintf(output, "Time: %s\n", util.FormatTime(details.Time))
+	} else {
+		fmt.Fprintf(output, "Time: -\n")
+	}
+	fmt.Fprintf(output, "Uploader: %s\n", details.Uploader)
+	fmt.Fprintf(output, "IMDb: %s\n", details.ImdbId)
+	fmt.Fprintf(output, "Douban: %s\n", details.DoubanId)
+	fmt.Fprintf(output, "TMDB: %s\n", details.TmdbId)
+	fmt.Fprintf(output, "Files: %d\n", len(details.Files))
+	for _, file := range details.Files {
+		fmt.Fprintf(output, "  %-10s  %s\n", util.BytesSize(float64(file.Size)), file.Path)
+	}
+	if details.MediaInfo != "" {
+		fmt.Fprintf(output, "\nMediaInfo:\n%s\n", details.MediaInfo)
+	}
+	if details.DescriptionText != "" {
+		fmt.Fprintf(output, "\nDescription text:\n%s\n", details.DescriptionText)
+	}
+}
+
 // Matches if any filter in list matches
 func (torrent *Torrent) MatchFiltersOr(filters []string) bool {
 	return slices.ContainsFunc(filters, func(filter string) bool {
@@ -647,10 +796,17 @@ func UploadTorrent(siteInstance Site, httpClient *azuretls.Session, uploadUrl st
 		return nil, constants.ErrDryRun
 	}
 	headers := util.GetHttpReqHeaders(siteInstance.GetDefaultHttpHeaders(), siteInstance.GetSiteConfig().Cookie, "")
-	if siteInstance.GetSiteConfig().Type == "nexusphp" {
+	fileField := "file"
+	switch siteInstance.GetSiteConfig().Type {
+	case "nexusphp":
+		headers = append(headers, []string{"Referer", siteInstance.GetSiteConfig().ParseSiteUrl("upload.php", false)})
+	case "gazelle", "gazellepw":
 		headers = append(headers, []string{"Referer", siteInstance.GetSiteConfig().ParseSiteUrl("upload.php", false)})
+		fileField = "file_input"
+	case "unit3d":
+		fileField = "torrent"
 	}
-	return util.PostUploadFile(httpClient, uploadUrl, "a.torrent", bytes.NewReader(contents), "file",
+	return util.PostUploadFile(httpClient, uploadUrl, "a.torrent", bytes.NewReader(contents), fileField,
 		payload, headers)
 }
 
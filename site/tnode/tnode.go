@@ -66,7 +66,31 @@ func (tnsite *Site) GetSiteConfig() *config.SiteConfigStruct {
 	return tnsite.SiteConfig
 }
 
-func (tnsite *Site) GetStatus() (*site.Status, error) {
+func (tnsite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (tnsite *Site) CheckIn() (*site.CheckInResult, error) {
+	return site.CheckInByConfig(tnsite, tnsite.HttpClient)
+}
+
+func (tnsite *Site) GetMessages(unreadOnly bool) ([]*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (tnsite *Site) GetMessage(id string) (*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (tnsite *Site) MarkMessagesRead(ids []string) error {
+	return site.ErrUnimplemented
+}
+
+func (tnsite *Site) Login() (cookie string, err error) {
+	return "", site.ErrUnimplemented
+}
+
+func (tnsite *Site) GetStatus(full bool) (*site.Status, error) {
 	err := tnsite.syncCsrfToken()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get csrf token")
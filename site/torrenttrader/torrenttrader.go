@@ -54,7 +54,31 @@ func (usite *Site) GetSiteConfig() *config.SiteConfigStruct {
 	return usite.SiteConfig
 }
 
-func (usite *Site) GetStatus() (*site.Status, error) {
+func (usite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (usite *Site) CheckIn() (*site.CheckInResult, error) {
+	return site.CheckInByConfig(usite, usite.HttpClient)
+}
+
+func (usite *Site) GetMessages(unreadOnly bool) ([]*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (usite *Site) GetMessage(id string) (*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (usite *Site) MarkMessagesRead(ids []string) error {
+	return site.ErrUnimplemented
+}
+
+func (usite *Site) Login() (cookie string, err error) {
+	return "", site.ErrUnimplemented
+}
+
+func (usite *Site) GetStatus(full bool) (*site.Status, error) {
 	doc, _, err := util.GetUrlDocWithAzuretls(usite.SiteConfig.Url, usite.HttpClient,
 		usite.GetSiteConfig().Cookie, site.GetUa(usite), usite.GetDefaultHttpHeaders())
 	if err != nil {
@@ -0,0 +1,305 @@
+package torznab
+
+// Torznab ( https://torznab.github.io/spec-1.3-draft/ ) 索引器，例如 Jackett / Prowlarr 提供的 indexer。
+// 站点 url 为 Torznab API 地址，例如 Prowlarr 的 "http://localhost:9696/1/api"，
+// Jackett 的 "http://localhost:9117/api/v2.0/indexers/<id>/results/torznab/api"。apiToken 为其 API Key。
+// 种子下载链接为索引器提供的(代理)下载地址，不支持通过 id 下载种子。
+
+import (
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Noooste/azuretls-client"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+const PAGE_SIZE = 100
+
+type Site struct {
+	Name        string
+	Location    *time.Location
+	SiteConfig  *config.SiteConfigStruct
+	Config      *config.ConfigStruct
+	HttpClient  *azuretls.Session
+	HttpHeaders [][]string
+}
+
+// PublishTorrent implements site.Site.
+func (tsite *Site) PublishTorrent(contents []byte, metadata url.Values) (id string, err error) {
+	return "", site.ErrUnimplemented
+}
+
+func (tsite *Site) GetDefaultHttpHeaders() [][]string {
+	return tsite.HttpHeaders
+}
+
+func (tsite *Site) PurgeCache() {
+}
+
+func (tsite *Site) GetName() string {
+	return tsite.Name
+}
+
+func (tsite *Site) GetSiteConfig() *config.SiteConfigStruct {
+	return tsite.SiteConfig
+}
+
+func (tsite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (tsite *Site) CheckIn() (*site.CheckInResult, error) {
+	return site.CheckInByConfig(tsite, tsite.HttpClient)
+}
+
+func (tsite *Site) GetMessages(unreadOnly bool) ([]*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (tsite *Site) GetMessage(id string) (*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (tsite *Site) MarkMessagesRead(ids []string) error {
+	return site.ErrUnimplemented
+}
+
+func (tsite *Site) Login() (cookie string, err error) {
+	return "", site.ErrUnimplemented
+}
+
+// Torznab has no user info. It fetches indexer capabilities and uses the server title as user name.
+func (tsite *Site) GetStatus(full bool) (*site.Status, error) {
+	var caps *Caps
+	if err := tsite.request("", url.Values{"t": {"caps"}}, &caps); err != nil {
+		return nil, err
+	}
+	return &site.Status{
+		UserName: caps.Server.Title,
+	}, nil
+}
+
+// Torznab results are always sorted by time desc. pageMarker is the offset of next page.
+func (tsite *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
+	torrents []*site.Torrent, nextPageMarker string, err error) {
+	if sort != "" && sort != constants.NONE && (sort != "time" || !desc) {
+		return nil, "", fmt.Errorf("unsupported sort field: %s (only time desc is supported)", sort)
+	}
+	offset := int64(0)
+	if pageMarker != "" {
+		offset = util.ParseInt(pageMarker)
+	}
+	query := url.Values{
+		"t":      {"search"},
+		"offset": {fmt.Sprint(offset)},
+		"limit":  {fmt.Sprint(PAGE_SIZE)},
+	}
+	var rss *Rss
+	if err = tsite.request(baseUrl, query, &rss); err != nil {
+		return nil, "", err
+	}
+	torrents = tsite.parseTorrents(rss)
+	if len(rss.Channel.Items) > 0 {
+		next := offset + int64(len(rss.Channel.Items))
+		if rss.Channel.Response == nil || rss.Channel.Response.Total <= 0 || next < rss.Channel.Response.Total {
+			nextPageMarker = fmt.Sprint(next)
+		}
+	}
+	return
+}
+
+func (tsite *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
+	var rss *Rss
+	if err := tsite.request("", url.Values{"t": {"search"}}, &rss); err != nil {
+		return nil, err
+	}
+	return tsite.parseTorrents(rss), nil
+}
+
+// baseUrl is an optional torznab api url, in which "%s" is the keyword placeholder.
+func (tsite *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
+	query := url.Values{"t": {"search"}}
+	if strings.Contains(baseUrl, "%s") {
+		baseUrl = strings.Replace(baseUrl, "%s", url.QueryEscape(keyword), 1)
+	} else {
+		query.Set("q", keyword)
+	}
+	var rss *Rss
+	if err := tsite.request(baseUrl, query, &rss); err != nil {
+		return nil, err
+	}
+	return tsite.parseTorrents(rss), nil
+}
+
+func (tsite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
+	if !util.IsUrl(torrentUrl) {
+		id = strings.TrimPrefix(torrentUrl, tsite.GetName()+".")
+		content, filename, err = tsite.DownloadTorrentById(id)
+		return
+	}
+	content, filename, err = site.DownloadTorrentByUrl(tsite, tsite.HttpClient, torrentUrl, "")
+	return
+}
+
+func (tsite *Site) DownloadTorrentById(id string) ([]byte, string, error) {
+	return nil, "", fmt.Errorf("torznab site does not support downloading torrent by id, use download url instead")
+}
+
+// Request torznab api and decode xml response into v. Queries in apiUrl are overrided by query.
+// The api key and configured categories are added automatically.
+func (tsite *Site) request(apiUrl string, query url.Values, v any) error {
+	if apiUrl == "" {
+		apiUrl = tsite.SiteConfig.Url
+	}
+	urlObj, err := url.Parse(tsite.SiteConfig.ParseSiteUrl(apiUrl, false))
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	params := urlObj.Query()
+	for key := range query {
+		params.Set(key, query.Get(key))
+	}
+	if tsite.SiteConfig.ApiToken != "" {
+		params.Set("apikey", tsite.SiteConfig.ApiToken)
+	}
+	if tsite.SiteConfig.TorznabCategories != "" && params.Get("t") != "caps" && !params.Has("cat") {
+		params.Set("cat", strings.Join(util.SplitCsv(tsite.SiteConfig.TorznabCategories), ","))
+	}
+	urlObj.RawQuery = params.Encode()
+	res, _, err := util.FetchUrlWithAzuretls(urlObj.String(), tsite.HttpClient,
+		tsite.SiteConfig.Cookie, site.GetUa(tsite), tsite.GetDefaultHttpHeaders())
+	// Torznab returns errors as <error code="100" description="..."/>, with non-200 status for some indexers.
+	if res != nil {
+		var apiError *Error
+		if xml.Unmarshal(res.Body, &apiError) == nil && apiError != nil && apiError.XMLName.Local == "error" {
+			return fmt.Errorf("torznab error %s: %s", apiError.Code, apiError.Description)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err = xml.Unmarshal(res.Body, v); err != nil {
+		return fmt.Errorf("failed to parse torznab response: %w", err)
+	}
+	return nil
+}
+
+func (tsite *Site) parseTorrents(rss *Rss) []*site.Torrent {
+	var torrents []*site.Torrent
+	for _, item := range rss.Channel.Items {
+		attrs := map[string]string{}
+		var tags []string
+		for _, attr := range item.Attrs {
+			if attr.Name == "tag" {
+				tags = append(tags, attr.Value)
+			} else {
+				attrs[attr.Name] = attr.Value
+			}
+		}
+		seeders := util.ParseInt(attrs["seeders"])
+		leechers := int64(0)
+		if attrs["leechers"] != "" {
+			leechers = util.ParseInt(attrs["leechers"])
+		} else if attrs["peers"] != "" {
+			leechers = max(util.ParseInt(attrs["peers"])-seeders, 0)
+		}
+		size := item.Size
+		if size <= 0 {
+			size = util.ParseInt(attrs["size"])
+		}
+		if size <= 0 && item.Enclosure != nil {
+			size = item.Enclosure.Length
+		}
+		downloadUrl := item.Link
+		if item.Enclosure != nil && item.Enclosure.Url != "" {
+			downloadUrl = item.Enclosure.Url
+		}
+		if downloadUrl == "" {
+			downloadUrl = attrs["magneturl"]
+		}
+		var tm int64
+		for _, layout := range []string{time.RFC1123Z, time.RFC1123} {
+			if t, err := time.Parse(layout, strings.TrimSpace(item.PubDate)); err == nil {
+				tm = t.Unix()
+				break
+			}
+		}
+		infoHash := strings.ToLower(attrs["infohash"])
+		id := infoHash
+		if id == "" && item.Guid != "" {
+			h := fnv.New64a()
+			h.Write([]byte(item.Guid))
+			id = strconv.FormatUint(h.Sum64(), 16)
+		}
+		if id != "" {
+			id = tsite.GetName() + "." + id
+		}
+		hasHnR := tsite.SiteConfig.GlobalHnR ||
+			util.ParseInt(attrs["minimumseedtime"]) > 0 || parseFloat(attrs["minimumratio"], 0) > 0
+		torrents = append(torrents, &site.Torrent{
+			Name:               item.Title,
+			Description:        item.Description,
+			Id:                 id,
+			InfoHash:           infoHash,
+			DownloadUrl:        downloadUrl,
+			DownloadMultiplier: parseFloat(attrs["downloadvolumefactor"], 1),
+			UploadMultiplier:   parseFloat(attrs["uploadvolumefactor"], 1),
+			DiscountEndTime:    -1,
+			Time:               tm,
+			Size:               size,
+			IsSizeAccurate:     size > 0,
+			Seeders:            seeders,
+			Leechers:           leechers,
+			Snatched:           util.ParseInt(attrs["grabs"]),
+			HasHnR:             hasHnR,
+			Tags:               tags,
+		})
+	}
+	return torrents
+}
+
+func parseFloat(str string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func NewSite(name string, siteConfig *config.SiteConfigStruct, config *config.ConfigStruct) (site.Site, error) {
+	if siteConfig.Url == "" {
+		return nil, fmt.Errorf("url (torznab api endpoint) is not configured")
+	}
+	location, err := time.LoadLocation(siteConfig.GetTimezone())
+	if err != nil {
+		return nil, fmt.Errorf("invalid site timezone %s: %w", siteConfig.GetTimezone(), err)
+	}
+	httpClient, httpHeaders, err := site.CreateSiteHttpClient(siteConfig, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create site http client: %w", err)
+	}
+	site := &Site{
+		Name:        name,
+		Location:    location,
+		SiteConfig:  siteConfig,
+		Config:      config,
+		HttpClient:  httpClient,
+		HttpHeaders: httpHeaders,
+	}
+	return site, nil
+}
+
+func init() {
+	site.Register(&site.RegInfo{
+		Name:    "torznab",
+		Creator: NewSite,
+	})
+}
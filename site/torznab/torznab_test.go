@@ -0,0 +1,87 @@
+package torznab
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sagan/ptool/config"
+)
+
+const searchResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed">
+<channel>
+<title>Indexer</title>
+<torznab:response offset="0" total="2"/>
+<item>
+	<title>Movie 2024 1080p</title>
+	<guid>https://indexer.example.com/details/1</guid>
+	<link>%[1]s/download/1</link>
+	<pubDate>Tue, 02 Jan 2024 03:04:05 +0000</pubDate>
+	<size>1073741824</size>
+	<category>2000</category>
+	<enclosure url="%[1]s/download/1" length="1073741824" type="application/x-bittorrent"/>
+	<torznab:attr name="seeders" value="5"/>
+	<torznab:attr name="peers" value="7"/>
+	<torznab:attr name="grabs" value="10"/>
+	<torznab:attr name="infohash" value="0123456789ABCDEF0123456789ABCDEF01234567"/>
+	<torznab:attr name="downloadvolumefactor" value="0"/>
+	<torznab:attr name="uploadvolumefactor" value="2"/>
+	<torznab:attr name="minimumseedtime" value="172800"/>
+	<torznab:attr name="tag" value="freeleech"/>
+</item>
+<item>
+	<title>Other</title>
+	<guid>https://indexer.example.com/details/2</guid>
+	<link>%[1]s/download/2</link>
+	<pubDate>Tue, 02 Jan 2024 03:04:05 +0000</pubDate>
+	<size>100</size>
+</item>
+</channel>
+</rss>`
+
+func TestSearchTorrents(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("apikey") != "key" {
+			fmt.Fprint(w, `<error code="100" description="Incorrect user credentials"/>`)
+			return
+		}
+		query = r.URL.RawQuery
+		fmt.Fprintf(w, searchResponse, "http://"+r.Host)
+	}))
+	defer server.Close()
+	siteConfig := &config.SiteConfigStruct{Url: server.URL + "/api", ApiToken: "key", TorznabCategories: "2000,5000"}
+	siteInstance, err := NewSite("idx", siteConfig, &config.ConfigStruct{})
+	if err != nil {
+		t.Fatalf("NewSite: %v", err)
+	}
+	torrents, err := siteInstance.SearchTorrents("movie", "")
+	if err != nil {
+		t.Fatalf("SearchTorrents: %v", err)
+	}
+	if query != "apikey=key&cat=2000%2C5000&q=movie&t=search" {
+		t.Errorf("unexpected query: %s", query)
+	}
+	if len(torrents) != 2 {
+		t.Fatalf("expect 2 torrents, got %d", len(torrents))
+	}
+	if tr := torrents[0]; tr.Name != "Movie 2024 1080p" || tr.Id != "idx.0123456789abcdef0123456789abcdef01234567" ||
+		tr.Seeders != 5 || tr.Leechers != 2 || tr.Snatched != 10 || tr.Size != 1073741824 ||
+		tr.DownloadMultiplier != 0 || tr.UploadMultiplier != 2 || !tr.HasHnR || tr.Time != 1704164645 ||
+		tr.DownloadUrl != server.URL+"/download/1" || len(tr.Tags) != 1 {
+		t.Errorf("unexpected torrent: %+v", tr)
+	}
+	if tr := torrents[1]; tr.DownloadMultiplier != 1 || tr.UploadMultiplier != 1 || tr.HasHnR || tr.Id == "" {
+		t.Errorf("unexpected torrent: %+v", tr)
+	}
+	if _, next, err := siteInstance.GetAllTorrents("", false, "", ""); err != nil || next != "" {
+		t.Errorf("unexpected next page: %q, %v", next, err)
+	}
+
+	siteConfig.ApiToken = "wrong"
+	if _, err = siteInstance.SearchTorrents("movie", ""); err == nil {
+		t.Errorf("expect torznab error")
+	}
+}
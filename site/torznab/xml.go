@@ -0,0 +1,56 @@
+package torznab
+
+import "encoding/xml"
+
+// t=search response.
+type Rss struct {
+	XMLName xml.Name `xml:"rss"`
+	Channel struct {
+		Title    string `xml:"title"`
+		Response *struct {
+			Offset int64 `xml:"offset,attr"`
+			Total  int64 `xml:"total,attr"`
+		} `xml:"response"` // <torznab:response offset="0" total="1234"/>
+		Items []*Item `xml:"item"`
+	} `xml:"channel"`
+}
+
+type Item struct {
+	Title       string   `xml:"title"`
+	Guid        string   `xml:"guid"`
+	Link        string   `xml:"link"`
+	Comments    string   `xml:"comments"`
+	PubDate     string   `xml:"pubDate"`
+	Size        int64    `xml:"size"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+	Enclosure   *struct {
+		Url    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	} `xml:"enclosure"`
+	Attrs []*Attr `xml:"attr"` // <torznab:attr name="seeders" value="1"/>
+}
+
+type Attr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// t=caps response.
+type Caps struct {
+	XMLName xml.Name `xml:"caps"`
+	Server  struct {
+		Title string `xml:"title,attr"`
+	} `xml:"server"`
+	Categories []struct {
+		Id   string `xml:"id,attr"`
+		Name string `xml:"name,attr"`
+	} `xml:"categories>category"`
+}
+
+type Error struct {
+	XMLName     xml.Name
+	Code        string `xml:"code,attr"`
+	Description string `xml:"description,attr"`
+}
@@ -0,0 +1,89 @@
+package unit3d
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// https://github.com/HDInnovations/UNIT3D-Community-Edition/blob/master/app/Http/Resources/TorrentResource.php .
+// Some fields have different types across UNIT3D versions, so they are decoded as any.
+type apiTorrent struct {
+	Type       string `json:"type"`
+	Id         any    `json:"id"`
+	Attributes struct {
+		Name           string `json:"name"`
+		Category       string `json:"category"`
+		Type           string `json:"type"`
+		Resolution     string `json:"resolution"`
+		InfoHash       string `json:"info_hash"`
+		Size           int64  `json:"size"`
+		Seeders        int64  `json:"seeders"`
+		Leechers       int64  `json:"leechers"`
+		TimesCompleted int64  `json:"times_completed"`
+		Freeleech      any    `json:"freeleech"` // "100%" or 100 (percent)
+		DoubleUpload   any    `json:"double_upload"`
+		CreatedAt      string `json:"created_at"`
+		DownloadLink   string `json:"download_link"`
+		DetailsLink    string `json:"details_link"`
+	} `json:"attributes"`
+}
+
+// api/torrents/filter . Both page and cursor pagination are used by different UNIT3D versions.
+type apiTorrentsResponse struct {
+	Data  []*apiTorrent `json:"data"`
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+	Meta struct {
+		CurrentPage int64 `json:"current_page"`
+		LastPage    int64 `json:"last_page"`
+	} `json:"meta"`
+}
+
+// api/torrents/upload
+type apiUploadResponse struct {
+	Success bool   `json:"success"`
+	Data    any    `json:"data"` // download url of uploaded torrent on success; validation errors on failure
+	Message string `json:"message"`
+}
+
+// Return the freeleech percent (0-100) of torrent.
+func parseFreeleech(value any) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case bool:
+		if v {
+			return 100
+		}
+	case string:
+		percent, _ := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(v, "%")), 64)
+		return percent
+	}
+	return 0
+}
+
+func parseBool(value any) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v == "1" || v == "true"
+	}
+	return false
+}
+
+func parseId(value any) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatInt(int64(v), 10)
+	case string:
+		return v
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
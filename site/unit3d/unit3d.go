@@ -3,18 +3,24 @@ package unit3d
 // UNIT3D ( https://github.com/HDInnovations/UNIT3D-Community-Edition )
 // JptvClub、莫妮卡、普斯特等站使用架构
 // 种子下载链接格式：https://jptv.club/torrents/download/39683
+// 种子列表、搜索、发布使用 UNIT3D REST API ( https://github.com/HDInnovations/UNIT3D-Community-Edition/wiki/Torrent-API-(UNIT3D-v8.x) )，
+// 需要配置站点 apiToken (用户设置 - API Key)
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Noooste/azuretls-client"
+	"github.com/PuerkitoBio/goquery"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
 	"github.com/sagan/ptool/site"
 	"github.com/sagan/ptool/util"
 )
@@ -28,17 +34,102 @@ type Site struct {
 	HttpHeaders [][]string
 }
 
-// PublishTorrent implements site.Site.
-func (usite *Site) PublishTorrent(contents []byte, metadata url.Values) (id string, err error) {
-	return "", site.ErrUnimplemented
-}
-
 const (
 	SELECTOR_USERNAME        = ".top-nav__username"
 	SELECTOR_USER_UPLOADED   = ".ratio-bar__uploaded"
 	SELECTOR_USER_DOWNLOADED = ".ratio-bar__downloaded"
+	SELECTOR_USER_RATIO      = ".ratio-bar__ratio"
+	SELECTOR_USER_SEEDING    = ".ratio-bar__seeding"
+	SELECTOR_USER_LEECHING   = ".ratio-bar__leeching"
+	SELECTOR_USER_BONUS      = ".ratio-bar__points"
+	SELECTOR_USER_INBOX      = `.top-nav__icon-bar a[href*="/conversations"],.top-nav__icon-bar a[href*="/inbox"]`
+)
+
+const (
+	API_TORRENTS_URL  = "api/torrents/filter"
+	API_UPLOAD_URL    = "api/torrents/upload"
+	API_PER_PAGE      = 100 // max allowed by UNIT3D
+	DEFAULT_LOGIN_URL = "login"
+	// UNIT3D login form is protected by a honeypot, which rejects forms submitted too fast
+	LOGIN_FORM_DELAY = 3 * time.Second
 )
 
+var sortFields = map[string]string{
+	"name":     "name",
+	"time":     "created_at",
+	"size":     "size",
+	"seeders":  "seeders",
+	"leechers": "leechers",
+	"snatched": "times_completed",
+}
+
+// UNIT3D requires category_id, type_id and (for movie / tv categories) resolution_id
+// and external ids, which are site specific. Provide them in metadata or uploadTorrentPayload.
+var defaultUploadTorrentPayload = map[string]string{
+	"name": `{% if number %}[{{number}}]{% endif %}{% if author %}[{{author}}]{% endif %}{{title}}`,
+	"description": `
+{% if _cover %}
+[img]{{_cover}}[/img]
+{% endif %}
+{% if _images %}
+{% for image in _images %}
+[img]{{image}}[/img]
+{% endfor %}
+{% endif %}
+{% if _meta %}
+{{_meta}}
+{% endif %}
+{{_text}}{% if comment %}
+
+---
+
+{{comment}}{% endif %}`,
+	"category_id":   `{{category_id}}`,
+	"type_id":       `{{type_id}}`,
+	"resolution_id": `{{resolution_id}}`,
+	"tmdb":          `{% if tmdb %}{{tmdb}}{% else %}0{% endif %}`,
+	"imdb":          `{% if imdb %}{{imdb}}{% else %}0{% endif %}`,
+	"tvdb":          `{% if tvdb %}{{tvdb}}{% else %}0{% endif %}`,
+	"mal":           `{% if mal %}{{mal}}{% else %}0{% endif %}`,
+	"igdb":          `{% if igdb %}{{igdb}}{% else %}0{% endif %}`,
+	"anonymous":     "1",
+	"stream":        "0",
+	"sd":            "0",
+}
+
+// Upload torrent to UNIT3D site via api.
+// See: https://github.com/HDInnovations/UNIT3D-Community-Edition/blob/master/app/Http/Controllers/API/TorrentController.php .
+func (usite *Site) PublishTorrent(contents []byte, metadata url.Values) (id string, err error) {
+	if usite.SiteConfig.ApiToken == "" {
+		return "", fmt.Errorf("apiToken is not configured")
+	}
+	uploadUrl := usite.SiteConfig.ParseSiteUrl(API_UPLOAD_URL, true) + "api_token=" + url.QueryEscape(usite.SiteConfig.ApiToken)
+	res, err := site.UploadTorrent(usite, usite.HttpClient, uploadUrl, contents, metadata, defaultUploadTorrentPayload)
+	if res == nil {
+		if err == constants.ErrDryRun {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to upload torrent: %w", err)
+	}
+	var data *apiUploadResponse
+	if err := json.Unmarshal(res.Body, &data); err != nil || data == nil {
+		return "", fmt.Errorf("failed to upload torrent: invalid response (status=%d): %w", res.StatusCode, err)
+	}
+	if !data.Success {
+		return "", fmt.Errorf("failed to upload torrent: %s (%v)", data.Message, data.Data)
+	}
+	// On success, data is the download url of uploaded torrent: "https://example.com/torrent/download/12345.<rsskey>".
+	downloadUrl, _ := data.Data.(string)
+	idRegexp := regexp.MustCompile(`/download/(?P<id>\d+)\b`)
+	if m := idRegexp.FindStringSubmatch(downloadUrl); m != nil {
+		id = m[idRegexp.SubexpIndex("id")]
+	}
+	if id == "" {
+		return "", fmt.Errorf("got no id from upload response: %s", downloadUrl)
+	}
+	return id, nil
+}
+
 func (usite *Site) GetDefaultHttpHeaders() [][]string {
 	return usite.HttpHeaders
 }
@@ -54,14 +145,53 @@ func (usite *Site) GetSiteConfig() *config.SiteConfigStruct {
 	return usite.SiteConfig
 }
 
-func (usite *Site) GetStatus() (*site.Status, error) {
+func (usite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (usite *Site) CheckIn() (*site.CheckInResult, error) {
+	return site.CheckInByConfig(usite, usite.HttpClient)
+}
+
+func (usite *Site) GetMessages(unreadOnly bool) ([]*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (usite *Site) GetMessage(id string) (*site.Message, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (usite *Site) MarkMessagesRead(ids []string) error {
+	return site.ErrUnimplemented
+}
+
+// UNIT3D login: POST /login with csrf token & honeypot fields of login form.
+// If 2FA is enabled, site redirects to "/two-factor-challenge" page, which requires the TOTP "code".
+func (usite *Site) Login() (cookie string, err error) {
+	return site.FormLogin(usite, usite.HttpClient, &site.LoginOption{
+		LoginUrl:        DEFAULT_LOGIN_URL,
+		Fields:          map[string]string{"remember": "on"},
+		TwoFactorFields: []string{"code"},
+		Delay:           LOGIN_FORM_DELAY,
+	})
+}
+
+func (usite *Site) GetStatus(full bool) (*site.Status, error) {
+	status, err := usite.getStatus()
+	if site.ReloginIfNeeded(usite, err) {
+		status, err = usite.getStatus()
+	}
+	return status, err
+}
+
+func (usite *Site) getStatus() (*site.Status, error) {
 	doc, res, err := util.GetUrlDocWithAzuretls(usite.SiteConfig.Url+"torrents", usite.HttpClient,
 		usite.GetSiteConfig().Cookie, site.GetUa(usite), usite.GetDefaultHttpHeaders())
 	if err != nil {
 		return nil, err
 	}
 	if strings.Contains(res.Request.Url, "/login") {
-		return nil, fmt.Errorf("not logined (cookie may has expired)")
+		return nil, site.ErrNotLoggedIn
 	}
 	userNameSelector := SELECTOR_USERNAME
 	userUploadedSelector := SELECTOR_USER_UPLOADED
@@ -80,24 +210,166 @@ func (usite *Site) GetStatus() (*site.Status, error) {
 	downloadedEl := doc.Find(userDownloadedSelector)
 	userUploaded, _ := util.ExtractSizeStr(util.DomSanitizedText(uploadedEl))
 	userDownloaded, _ := util.ExtractSizeStr(util.DomSanitizedText(downloadedEl))
-	return &site.Status{
+	status := &site.Status{
 		UserName:       util.DomSanitizedText(usernameEl),
 		UserUploaded:   userUploaded,
 		UserDownloaded: userDownloaded,
-	}, nil
+	}
+	parseUserStatus(status, doc.Selection)
+	return status, nil
 }
 
+// pageMarker is the pagination query of next page, e.g. "page=2" or "cursor=xxx".
+// baseUrl is an optional api url with additional filter queries, e.g. "api/torrents/filter?categories[]=1".
 func (usite *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
 	torrents []*site.Torrent, nextPageMarker string, err error) {
-	return nil, "", site.ErrUnimplemented
+	if sort != "" && sort != constants.NONE && sortFields[sort] == "" {
+		return nil, "", fmt.Errorf("unsupported sort field: %s", sort)
+	}
+	query := url.Values{}
+	if sort != "" && sort != constants.NONE {
+		query.Set("sortField", sortFields[sort])
+		if desc {
+			query.Set("sortDirection", "desc")
+		} else {
+			query.Set("sortDirection", "asc")
+		}
+	}
+	if pageMarker != "" {
+		markerQuery, err := url.ParseQuery(pageMarker)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid page marker: %w", err)
+		}
+		for key := range markerQuery {
+			query.Set(key, markerQuery.Get(key))
+		}
+	}
+	data, err := usite.getTorrents(baseUrl, query)
+	if err != nil {
+		return nil, "", err
+	}
+	torrents = usite.convertTorrents(data)
+	nextPageMarker = getNextPageMarker(data)
+	return
 }
 
 func (usite *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	data, err := usite.getTorrents("", url.Values{
+		"sortField":     {"created_at"},
+		"sortDirection": {"desc"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usite.convertTorrents(data), nil
 }
 
 func (usite *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	query := url.Values{}
+	if strings.Contains(baseUrl, "%s") {
+		baseUrl = strings.Replace(baseUrl, "%s", url.QueryEscape(keyword), 1)
+	} else {
+		query.Set("name", keyword)
+	}
+	data, err := usite.getTorrents(baseUrl, query)
+	if err != nil {
+		return nil, err
+	}
+	return usite.convertTorrents(data), nil
+}
+
+// Request api/torrents/filter. Queries in baseUrl are preserved and overrided by query.
+func (usite *Site) getTorrents(baseUrl string, query url.Values) (*apiTorrentsResponse, error) {
+	if usite.SiteConfig.ApiToken == "" {
+		return nil, fmt.Errorf("apiToken is not configured")
+	}
+	if baseUrl == "" {
+		baseUrl = API_TORRENTS_URL
+	}
+	urlObj, err := url.Parse(usite.SiteConfig.ParseSiteUrl(baseUrl, false))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	params := urlObj.Query()
+	for key := range query {
+		params.Set(key, query.Get(key))
+	}
+	if !params.Has("perPage") {
+		params.Set("perPage", fmt.Sprint(API_PER_PAGE))
+	}
+	params.Set("api_token", usite.SiteConfig.ApiToken)
+	urlObj.RawQuery = params.Encode()
+	var data *apiTorrentsResponse
+	err = util.FetchJsonWithAzuretls(urlObj.String(), &data, usite.HttpClient,
+		"", site.GetUa(usite), usite.GetDefaultHttpHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch torrents: %w", err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("failed to fetch torrents: empty response")
+	}
+	return data, nil
+}
+
+func (usite *Site) convertTorrents(data *apiTorrentsResponse) []*site.Torrent {
+	var torrents []*site.Torrent
+	for _, torrent := range data.Data {
+		attributes := &torrent.Attributes
+		id := parseId(torrent.Id)
+		var tm int64
+		if t, err := time.Parse(time.RFC3339Nano, attributes.CreatedAt); err == nil {
+			tm = t.Unix()
+		} else {
+			tm, _ = util.ParseTime(attributes.CreatedAt, usite.Location)
+		}
+		uploadMultiplier := 1.0
+		if parseBool(attributes.DoubleUpload) {
+			uploadMultiplier = 2
+		}
+		downloadUrl := attributes.DownloadLink
+		if downloadUrl == "" {
+			downloadUrl = usite.SiteConfig.Url + "torrents/download/" + id
+		}
+		description := util.Filter([]string{attributes.Category, attributes.Type, attributes.Resolution},
+			func(s string) bool { return s != "" })
+		torrents = append(torrents, &site.Torrent{
+			Name:               attributes.Name,
+			Description:        strings.Join(description, " / "),
+			Id:                 usite.GetName() + "." + id,
+			InfoHash:           strings.ToLower(attributes.InfoHash),
+			DownloadUrl:        downloadUrl,
+			DownloadMultiplier: max(0, 1-parseFreeleech(attributes.Freeleech)/100),
+			UploadMultiplier:   uploadMultiplier,
+			DiscountEndTime:    -1,
+			Time:               tm,
+			Size:               attributes.Size,
+			IsSizeAccurate:     true,
+			Seeders:            attributes.Seeders,
+			Leechers:           attributes.Leechers,
+			Snatched:           attributes.TimesCompleted,
+			// UNIT3D api does not report per-torrent HnR; HnR rules of UNIT3D apply to the whole site.
+			HasHnR: usite.SiteConfig.GlobalHnR,
+		})
+	}
+	return torrents
+}
+
+// Return the pagination query of next page from api response, or empty string if it's the last page.
+func getNextPageMarker(data *apiTorrentsResponse) string {
+	if data.Links.Next != "" {
+		if urlObj, err := url.Parse(data.Links.Next); err == nil {
+			query := urlObj.Query()
+			for _, key := range []string{"cursor", "page"} {
+				if value := query.Get(key); value != "" {
+					return url.Values{key: {value}}.Encode()
+				}
+			}
+		}
+	}
+	if data.Meta.LastPage > 0 && data.Meta.CurrentPage < data.Meta.LastPage {
+		return fmt.Sprintf("page=%d", data.Meta.CurrentPage+1)
+	}
+	return ""
 }
 
 func (usite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
@@ -127,9 +399,28 @@ func (usite *Site) DownloadTorrentById(id string) ([]byte, string, error) {
 	return site.DownloadTorrentByUrl(usite, usite.HttpClient, torrentUrl, id)
 }
 
+var numberRegexp = regexp.MustCompile(`[\d,]+(\.\d+)?`)
+
+// Parse ratio, seeding / leeching count, bonus & unread messages from the top nav ratio bar of UNIT3D page.
+func parseUserStatus(status *site.Status, doc *goquery.Selection) {
+	number := func(selector string) float64 {
+		value, _ := strconv.ParseFloat(strings.ReplaceAll(
+			numberRegexp.FindString(util.DomSanitizedText(doc.Find(selector))), ",", ""), 64)
+		return value
+	}
+	status.UserRatio = number(SELECTOR_USER_RATIO)
+	status.TorrentsSeedingCnt = int64(number(SELECTOR_USER_SEEDING))
+	status.TorrentsLeechingCnt = int64(number(SELECTOR_USER_LEECHING))
+	status.UserBonus = number(SELECTOR_USER_BONUS)
+	status.UnreadMessagesCnt = int64(number(SELECTOR_USER_INBOX))
+	if status.UnreadMessagesCnt == 0 && doc.Find(SELECTOR_USER_INBOX).Find(".point").Length() > 0 {
+		status.UnreadMessagesCnt = 1 // only an indicator of having unread messages
+	}
+}
+
 func NewSite(name string, siteConfig *config.SiteConfigStruct, config *config.ConfigStruct) (site.Site, error) {
-	if siteConfig.Cookie == "" {
-		log.Warnf("Site %s has no cookie provided", name)
+	if siteConfig.Cookie == "" && siteConfig.ApiToken == "" {
+		log.Warnf("Site %s has no cookie or apiToken provided", name)
 	}
 	location, err := time.LoadLocation(siteConfig.GetTimezone())
 	if err != nil {
@@ -0,0 +1,44 @@
+package unit3d
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sagan/ptool/config"
+)
+
+func TestConvertTorrents(t *testing.T) {
+	var data *apiTorrentsResponse
+	err := json.Unmarshal([]byte(`{"data":[
+		{"type":"torrent","id":"123","attributes":{"name":"a","size":1024,"seeders":3,"leechers":1,
+		"times_completed":5,"freeleech":"50%","double_upload":true,"info_hash":"ABC",
+		"created_at":"2024-01-02T03:04:05.000000Z","download_link":"https://example.com/torrent/download/123.key"}},
+		{"type":"torrent","id":124,"attributes":{"name":"b","freeleech":100,"double_upload":0,
+		"created_at":"2024-01-02T03:04:05.000000Z"}}],
+		"links":{"next":"https://example.com/api/torrents/filter?cursor=xyz"},"meta":{}}`), &data)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	usite := &Site{
+		Name:       "u",
+		Location:   time.UTC,
+		SiteConfig: &config.SiteConfigStruct{Url: "https://example.com/", GlobalHnR: true},
+	}
+	torrents := usite.convertTorrents(data)
+	if len(torrents) != 2 {
+		t.Fatalf("expect 2 torrents, got %d", len(torrents))
+	}
+	a, b := torrents[0], torrents[1]
+	if a.Id != "u.123" || a.InfoHash != "abc" || a.DownloadMultiplier != 0.5 || a.UploadMultiplier != 2 ||
+		a.Size != 1024 || a.Snatched != 5 || !a.HasHnR || a.Time != 1704164645 {
+		t.Errorf("unexpected torrent a: %+v", a)
+	}
+	if b.Id != "u.124" || b.DownloadMultiplier != 0 || b.UploadMultiplier != 1 ||
+		b.DownloadUrl != "https://example.com/torrents/download/124" {
+		t.Errorf("unexpected torrent b: %+v", b)
+	}
+	if marker := getNextPageMarker(data); marker != "cursor=xyz" {
+		t.Errorf("unexpected next page marker: %s", marker)
+	}
+}
@@ -278,5 +278,26 @@ func NewDb(statFilename string) (*StatDb, error) {
 	return db, nil
 }
 
+// Read torrent deletion stats (event 1) from stats file.
+func ReadTorrentStats(statFilename string) ([]*Stat, error) {
+	f, err := os.Open(statFilename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stats file %s: %w", statFilename, err)
+	}
+	defer f.Close()
+	statRecords := []*Stat{}
+	fileScanner := bufio.NewScanner(f)
+	fileScanner.Split(bufio.ScanLines)
+	for fileScanner.Scan() {
+		statRecord := &Stat{}
+		err := json.Unmarshal([]byte(fileScanner.Text()), statRecord)
+		if err != nil || statRecord.Event != 1 || statRecord.Data == nil {
+			continue
+		}
+		statRecords = append(statRecords, statRecord)
+	}
+	return statRecords, fileScanner.Err()
+}
+
 func init() {
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/sagan/ptool/config"
 	log "github.com/sirupsen/logrus"
 	"testing"
+	"time"
 )
 
 func TestName(t *testing.T) {
@@ -15,7 +16,7 @@ func TestName(t *testing.T) {
 	log.Info(record)
 	torrentRecordManager.MarkSlowTorrentRecord("f6d0a32103e23e0784cc0cf9b572fe8280399734", "")
 	torrentRecordManager.MarkDeleteRecord("f6d0a32103e23e0784cc0cf9b572fe8280399734")
-	result2 := torrentRecordManager.IsDeletedRecord("mteam.226025")
+	result2 := torrentRecordManager.IsDeletedRecord("mteam.226025", time.Now().Unix())
 	log.Infof("是否删除 %v", result2)
 
 }
@@ -44,6 +44,8 @@ type AdvancedConfig struct {
 	HTTPTimeout time.Duration
 	UserAgent   string
 	Debug       bool
+	// If set, use this http client instead of a new one. HTTPTimeout is applied only if it's Timeout is 0.
+	HTTPClient *http.Client
 }
 
 // New returns an initialized and ready to use Controller
@@ -92,10 +94,15 @@ func New(host, user, password string, conf *AdvancedConfig) (c *Client, err erro
 		password:  password,
 		userAgent: conf.UserAgent,
 		rnd:       rand.New(newLockedRandomSource(time.Now().Unix())),
-		httpC:     cleanhttp.DefaultPooledClient(),
+		httpC:     conf.HTTPClient,
 		debug:     conf.Debug,
 	}
-	c.httpC.Timeout = conf.HTTPTimeout
+	if c.httpC == nil {
+		c.httpC = cleanhttp.DefaultPooledClient()
+	}
+	if c.httpC.Timeout == 0 {
+		c.httpC.Timeout = conf.HTTPTimeout
+	}
 	return
 }
 
@@ -48,7 +48,9 @@ func (c *Client) request(ctx context.Context, method string, arguments interface
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", c.userAgent)
 	req.Header.Set(csrfHeader, c.getSessionID())
-	req.SetBasicAuth(c.user, c.password)
+	if c.user != "" || c.password != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
 	// Prepare the marshalling goroutine
 	var tag int
 	var encErr error
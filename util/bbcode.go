@@ -0,0 +1,135 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+var (
+	bbcodeImgRegexp      = regexp.MustCompile(`(?is)\[img(=[^\]]*)?\].*?\[/img\]`)
+	bbcodeTagRegexp      = regexp.MustCompile(`(?i)\[/?[a-z*]+(=[^\]]*)?\]`)
+	multipleNewsRegexp   = regexp.MustCompile(`\n{3,}`)
+	htmlToBBCodeTagNames = map[string]string{
+		"b":          "b",
+		"strong":     "b",
+		"i":          "i",
+		"em":         "i",
+		"u":          "u",
+		"s":          "s",
+		"del":        "s",
+		"blockquote": "quote",
+		"pre":        "code",
+		"code":       "code",
+		"fieldset":   "quote",
+	}
+)
+
+// Convert BBCode to plain text: images are removed and all other tags are stripped.
+func BBCodeToText(str string) string {
+	str = bbcodeImgRegexp.ReplaceAllString(str, "")
+	str = bbcodeTagRegexp.ReplaceAllString(str, "")
+	str = strings.ReplaceAll(str, "\r\n", "\n")
+	str = multipleNewsRegexp.ReplaceAllString(str, "\n\n")
+	return strings.TrimSpace(str)
+}
+
+// Convert a rendered html (e.g. torrent description in NexusPHP details page) back to BBCode.
+// Only common tags (img, url, b, i, u, s, quote, code, color, size) are supported,
+// other tags are replaced by their contents.
+func HtmlToBBCode(el *goquery.Selection) string {
+	sb := &strings.Builder{}
+	for _, node := range el.Nodes {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			htmlNodeToBBCode(sb, child)
+		}
+	}
+	str := strings.ReplaceAll(sb.String(), "\u00a0", " ")
+	str = multipleNewsRegexp.ReplaceAllString(str, "\n\n")
+	return strings.TrimSpace(str)
+}
+
+func htmlNodeToBBCode(sb *strings.Builder, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		text := node.Data
+		if !isInPre(node) {
+			text = strings.ReplaceAll(text, "\n", "")
+		}
+		sb.WriteString(text)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	getAttr := func(name string) string {
+		for _, attr := range node.Attr {
+			if attr.Key == name {
+				return attr.Val
+			}
+		}
+		return ""
+	}
+	writeChildren := func() {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			htmlNodeToBBCode(sb, child)
+		}
+	}
+	tag := node.Data
+	switch tag {
+	case "script", "style", "legend":
+		return
+	case "br":
+		sb.WriteString("\n")
+	case "img":
+		src := getAttr("data-src")
+		if src == "" {
+			src = getAttr("src")
+		}
+		if src != "" {
+			sb.WriteString("[img]" + src + "[/img]")
+		}
+	case "a":
+		sb.WriteString("[url=" + getAttr("href") + "]")
+		writeChildren()
+		sb.WriteString("[/url]")
+	case "font", "span":
+		color := getAttr("color")
+		size := getAttr("size")
+		if color != "" {
+			sb.WriteString("[color=" + color + "]")
+		}
+		if size != "" {
+			sb.WriteString("[size=" + size + "]")
+		}
+		writeChildren()
+		if size != "" {
+			sb.WriteString("[/size]")
+		}
+		if color != "" {
+			sb.WriteString("[/color]")
+		}
+	case "div", "p", "li", "tr":
+		writeChildren()
+		sb.WriteString("\n")
+	default:
+		if bbtag := htmlToBBCodeTagNames[tag]; bbtag != "" {
+			sb.WriteString("[" + bbtag + "]")
+			writeChildren()
+			sb.WriteString("[/" + bbtag + "]")
+		} else {
+			writeChildren()
+		}
+	}
+}
+
+func isInPre(node *html.Node) bool {
+	for p := node.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && (p.Data == "pre" || p.Data == "textarea") {
+			return true
+		}
+	}
+	return false
+}
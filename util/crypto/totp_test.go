@@ -0,0 +1,31 @@
+package crypto
+
+import (
+	"testing"
+	"time"
+)
+
+// Test vectors of RFC 6238 appendix B (SHA1), secret is "12345678901234567890".
+func TestTotp(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for ts, want := range cases {
+		code, err := Totp(secret, time.Unix(ts, 0))
+		if err != nil || code != want {
+			t.Errorf("Totp(%d) = %q, %v; want %q", ts, code, err, want)
+		}
+	}
+	if code, err := Totp("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", time.Unix(59, 0)); err != nil || code != "287082" {
+		t.Errorf("Totp with lowercase spaced secret = %q, %v", code, err)
+	}
+	if _, err := Totp("not-base32!", time.Unix(59, 0)); err == nil {
+		t.Errorf("expect error for invalid secret")
+	}
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Get the value of a JSONPath-style path from decoded json data (map[string]any / []any).
+// Supported syntaxes: "$.data.list", "data.list[0].name", "data['key.with.dot']", "[1].id".
+// A leading "$" is optional. Empty path (or "$") returns data itself.
+func GetJsonPath(data any, path string) (value any, ok bool) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+	value = data
+	for path != "" {
+		var key string
+		index := -1
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			continue
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end == -1 {
+				return nil, false
+			}
+			segment := strings.TrimSpace(path[1:end])
+			path = path[end+1:]
+			if len(segment) >= 2 && (segment[0] == '\'' || segment[0] == '"') && segment[len(segment)-1] == segment[0] {
+				key = segment[1 : len(segment)-1]
+			} else if i, err := strconv.Atoi(segment); err == nil {
+				index = i
+			} else {
+				return nil, false
+			}
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			key = path[:end]
+			path = path[end:]
+		}
+		if index >= 0 {
+			list, isList := value.([]any)
+			if !isList || index >= len(list) {
+				return nil, false
+			}
+			value = list[index]
+		} else {
+			obj, isObj := value.(map[string]any)
+			if !isObj {
+				return nil, false
+			}
+			if value, ok = obj[key]; !ok {
+				return nil, false
+			}
+		}
+	}
+	return value, true
+}
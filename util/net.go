@@ -2,6 +2,7 @@ package util
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -35,7 +36,11 @@ func HttpRequest(req *http.Request, client *http.Client) (res *http.Response, er
 }
 
 func FetchJson(url string, v any, client *http.Client, header http.Header) error {
-	res, _, err := FetchUrl(url, client, header)
+	return FetchJsonContext(context.Background(), url, v, client, header)
+}
+
+func FetchJsonContext(ctx context.Context, url string, v any, client *http.Client, header http.Header) error {
+	res, _, err := FetchUrlContext(ctx, url, client, header)
 	if err != nil {
 		return err
 	}
@@ -49,10 +54,15 @@ func FetchJson(url string, v any, client *http.Client, header http.Header) error
 }
 
 func FetchUrl(url string, client *http.Client, header http.Header) (*http.Response, http.Header, error) {
+	return FetchUrlContext(context.Background(), url, client, header)
+}
+
+func FetchUrlContext(ctx context.Context, url string, client *http.Client,
+	header http.Header) (*http.Response, http.Header, error) {
 	if client == nil {
 		client = http.DefaultClient
 	}
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if header != nil {
 		req.Header = header
 	}
@@ -144,11 +154,16 @@ func PostUrlForJson(url string, data url.Values, v any, header http.Header, clie
 }
 
 func PostAndFetchJson(url string, reqBody any, resBody any, header http.Header, client *http.Client) (err error) {
+	return PostAndFetchJsonContext(context.Background(), url, reqBody, resBody, header, client)
+}
+
+func PostAndFetchJsonContext(ctx context.Context, url string, reqBody any, resBody any, header http.Header,
+	client *http.Client) (err error) {
 	reqData, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to marshal json: %w", err)
 	}
-	req, err := http.NewRequest("POST", url, bytes.NewReader(reqData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqData))
 	if err != nil {
 		return err
 	}